@@ -5,7 +5,6 @@ package app
 
 import (
 	"context"
-	"errors"
 	"github.com/chain5j/chain5j-pkg/codec"
 	"github.com/chain5j/chain5j-pkg/network/rpc"
 	"github.com/chain5j/chain5j-pkg/types"
@@ -13,6 +12,10 @@ import (
 	"github.com/chain5j/chain5j-protocol/models/accounts"
 	"github.com/chain5j/chain5j-protocol/pkg/database/ethStatedb"
 	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
+	"github.com/chain5j/chain5j-stateApp/interpreter/escrowInterpreter"
+	"github.com/chain5j/chain5j-stateApp/interpreter/scheduleInterpreter"
+	"math/big"
 	"strings"
 )
 
@@ -45,6 +48,42 @@ func (api *API) GetBalance(ctx context.Context, account string, blockNrOrHash rp
 	return nil, nil
 }
 
+// BalanceDetail 账户余额明细
+type BalanceDetail struct {
+	Total      *hexutil.Big `json:"total"`      // 总余额
+	Spendable  *hexutil.Big `json:"spendable"`  // 可花费余额
+	Locked     *hexutil.Big `json:"locked"`     // 锁定余额
+	Releasable *hexutil.Big `json:"releasable"` // 锁定余额中可释放的金额
+}
+
+// GetBalanceDetail GetBalance的明细版本，返回可花费、锁定及总余额
+func (api *API) GetBalanceDetail(ctx context.Context, account string, blockNrOrHash rpc.BlockNumberOrHash) (*BalanceDetail, error) {
+	db, header, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if db == nil || err != nil {
+		return nil, err
+	}
+
+	if api.app.useEthereum {
+		state := db.(*ethStatedb.StateDB)
+		balance := state.GetBalance(types.HexToAddress(account))
+		return &BalanceDetail{
+			Total:      (*hexutil.Big)(balance),
+			Spendable:  (*hexutil.Big)(balance),
+			Locked:     (*hexutil.Big)(new(big.Int)),
+			Releasable: (*hexutil.Big)(new(big.Int)),
+		}, state.Error()
+	}
+
+	state := db.(*statedb.StateDB)
+	detail := accountInterpreter.GetBalanceDetail(state, strings.ToLower(account), header.Height, header.Timestamp)
+	return &BalanceDetail{
+		Total:      (*hexutil.Big)(detail.Total),
+		Spendable:  (*hexutil.Big)(detail.Spendable),
+		Locked:     (*hexutil.Big)(detail.Locked),
+		Releasable: (*hexutil.Big)(detail.Releasable),
+	}, state.Error()
+}
+
 func (api *API) GetTransactionCount(ctx context.Context, account string, blockNrOrHash rpc.BlockNumberOrHash) (uint64, error) {
 	db, _, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if db == nil || err != nil {
@@ -83,6 +122,32 @@ func (api *API) GetTransactionCount(ctx context.Context, account string, blockNr
 	return nonce, nil
 }
 
+// GetEscrow 根据锁定交易哈希获取托管记录
+func (api *API) GetEscrow(ctx context.Context, id types.Hash, blockNrOrHash rpc.BlockNumberOrHash) (*escrowInterpreter.Escrow, error) {
+	if api.app.useEthereum {
+		return nil, nil
+	}
+
+	db, _, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if db == nil || err != nil {
+		return nil, err
+	}
+	return escrowInterpreter.GetEscrow(db.(*statedb.StateDB), id), nil
+}
+
+// GetSchedule 根据内层交易哈希获取定时交易记录
+func (api *API) GetSchedule(ctx context.Context, id types.Hash, blockNrOrHash rpc.BlockNumberOrHash) (*scheduleInterpreter.Schedule, error) {
+	if api.app.useEthereum {
+		return nil, nil
+	}
+
+	db, _, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if db == nil || err != nil {
+		return nil, err
+	}
+	return scheduleInterpreter.GetSchedule(db.(*statedb.StateDB), id), nil
+}
+
 type AccountAPI struct {
 	app     *application
 	backend *ApiBackend
@@ -95,9 +160,13 @@ func (a *application) newAccountAPI() *AccountAPI {
 	}
 }
 
-func (api *AccountAPI) AccountInfo(ctx context.Context, account string, blockNrOrHash rpc.BlockNumberOrHash) (*accounts.AccountStore, error) {
+// AccountStore 账户信息，与accounts.AccountStore一致
+// accounts.AccountStore的MarshalJSON会递归调用自身，rpc返回时使用默认的json编码
+type AccountStore accounts.AccountStore
+
+func (api *AccountAPI) AccountInfo(ctx context.Context, account string, blockNrOrHash rpc.BlockNumberOrHash) (*AccountStore, error) {
 	if api.app.useEthereum {
-		return nil, nil
+		return nil, errAccountAPIUnavailable
 	}
 
 	db, _, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
@@ -106,17 +175,18 @@ func (api *AccountAPI) AccountInfo(ctx context.Context, account string, blockNrO
 	}
 	state := db.(*statedb.StateDB)
 
-	store := state.GetAccount(strings.ToLower(account))
+	account = strings.ToLower(account)
+	store := accountInterpreter.GetAccount(state, account)
 	if store == nil {
-		return nil, errors.New("account not exists")
+		return nil, accountNotFound(account)
 	}
 
-	return store, nil
+	return (*AccountStore)(store), nil
 }
 
 func (api *AccountAPI) Partner(ctx context.Context, account string, blockNrOrHash rpc.BlockNumberOrHash) (*accounts.PartnerData, error) {
 	if api.app.useEthereum {
-		return nil, nil
+		return nil, errAccountAPIUnavailable
 	}
 
 	db, _, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
@@ -125,9 +195,10 @@ func (api *AccountAPI) Partner(ctx context.Context, account string, blockNrOrHas
 	}
 	state := db.(*statedb.StateDB)
 
-	store := state.GetAccount(strings.ToLower(account))
+	account = strings.ToLower(account)
+	store := state.GetAccount(account)
 	if store == nil {
-		return nil, errors.New("account not exists")
+		return nil, accountNotFound(account)
 	}
 
 	pdata, ok := store.XXX[accounts.PartnerKey]
@@ -141,21 +212,219 @@ func (api *AccountAPI) Partner(ctx context.Context, account string, blockNrOrHas
 	return &partner, nil
 }
 
-func (api *AccountAPI) DomainInfo(ctx context.Context, domain string, blockNrOrHash rpc.BlockNumberOrHash) (*accounts.DomainStore, error) {
+// DomainInfo 域信息
+type DomainInfo struct {
+	*accounts.DomainStore
+	PendingAdmin string   `json:"pending_admin,omitempty"` // 待接受转移的管理员
+	FormerAdmins []string `json:"former_admins,omitempty"` // 已移交管理权的管理员
+	ExpiryHeight uint64   `json:"expiry_height,omitempty"` // 过期高度
+	GraceEnd     uint64   `json:"grace_end,omitempty"`     // 宽限期结束高度
+	Status       string   `json:"status"`                  // 域状态
+}
+
+func (api *AccountAPI) DomainInfo(ctx context.Context, domain string, blockNrOrHash rpc.BlockNumberOrHash) (*DomainInfo, error) {
 	if api.app.useEthereum {
-		return nil, nil
+		return nil, errAccountAPIUnavailable
 	}
 
-	db, _, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	db, header, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if db == nil || err != nil {
+		return nil, err
+	}
+	domain = strings.ToLower(domain)
+	info := domainInfo(db.(*statedb.StateDB), domain, header.Height)
+	if info == nil {
+		return nil, domainNotFound(domain)
+	}
+	return info, nil
+}
+
+// domainInfo 域在指定高度的信息，域不存在时返回nil
+func domainInfo(state *statedb.StateDB, domain string, height uint64) *DomainInfo {
+	store := state.GetDomain(domain)
+	if store == nil {
+		return nil
+	}
+
+	info := &DomainInfo{
+		DomainStore: store,
+		Status:      accountInterpreter.DomainStatusActive,
+	}
+	if meta := accountInterpreter.GetDomainMeta(state, domain); meta != nil {
+		if meta.Admin != "" {
+			store.Admin = meta.Admin
+		}
+		info.PendingAdmin = meta.PendingAdmin
+		info.FormerAdmins = meta.FormerAdmins
+		info.ExpiryHeight = meta.ExpiryHeight
+		if meta.ExpiryHeight > 0 {
+			info.GraceEnd = meta.ExpiryHeight + accountInterpreter.DomainGracePeriod
+		}
+		info.Status = accountInterpreter.DomainStatus(meta, height)
+	}
+	return info
+}
+
+// FreezeInfo 账户冻结信息
+type FreezeInfo struct {
+	Frozen       bool                             `json:"frozen"`            // 账户在该高度是否被冻结
+	DomainFrozen bool                             `json:"domain_frozen"`     // 所在域是否被冻结
+	Record       *accountInterpreter.FreezeRecord `json:"record,omitempty"`  // 当前冻结信息
+	History      []accountInterpreter.FreezeEvent `json:"history,omitempty"` // 账户及所在域最近的冻结历史，完整历史通过FreezeHistory分页获取
+}
+
+func (api *AccountAPI) FreezeInfo(ctx context.Context, account string, blockNrOrHash rpc.BlockNumberOrHash) (*FreezeInfo, error) {
+	if api.app.useEthereum {
+		return nil, errAccountAPIUnavailable
+	}
+
+	db, header, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if db == nil || err != nil {
 		return nil, err
 	}
 	state := db.(*statedb.StateDB)
 
-	store := state.GetDomain(strings.ToLower(domain))
+	account = strings.ToLower(account)
+	store := accountInterpreter.GetAccount(state, account)
 	if store == nil {
-		return nil, errors.New("domain not exists")
+		return nil, accountNotFound(account)
 	}
 
-	return store, nil
+	info := &FreezeInfo{
+		Frozen:       accountInterpreter.IsAccountFrozen(state, store, header.Height),
+		DomainFrozen: accountInterpreter.IsDomainFrozen(state, store.Domain, header.Height),
+		History:      accountInterpreter.RecentFreezeHistory(state, account, defaultAccountPageSize),
+	}
+	if info.Frozen {
+		info.Record = accountInterpreter.GetAccountFreeze(state, account)
+	}
+	return info, nil
+}
+
+// FreezeHistoryPage 冻结历史的分页
+type FreezeHistoryPage struct {
+	Total  uint64                           `json:"total"`
+	Events []accountInterpreter.FreezeEvent `json:"events"`
+}
+
+// FreezeHistory 分页获取账户或域的冻结历史，按发生顺序排列，limit为0时取默认值
+func (api *AccountAPI) FreezeHistory(ctx context.Context, target string, offset, limit uint64, blockNrOrHash rpc.BlockNumberOrHash) (*FreezeHistoryPage, error) {
+	if api.app.useEthereum {
+		return nil, errAccountAPIUnavailable
+	}
+
+	db, _, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if db == nil || err != nil {
+		return nil, err
+	}
+	state := db.(*statedb.StateDB)
+
+	if limit == 0 {
+		limit = defaultAccountPageSize
+	}
+	if limit > maxAccountPageSize {
+		limit = maxAccountPageSize
+	}
+
+	target = strings.ToLower(target)
+	return &FreezeHistoryPage{
+		Total:  accountInterpreter.FreezeHistoryCount(state, target),
+		Events: accountInterpreter.FreezeHistory(state, target, offset, limit),
+	}, nil
+}
+
+// DelegationInfo 账户的委托信息
+type DelegationInfo struct {
+	Received []accountInterpreter.Delegation `json:"received"` // 接受的有效委托
+	Granted  []accountInterpreter.Delegation `json:"granted"`  // 授予的有效委托
+}
+
+func (api *AccountAPI) Delegations(ctx context.Context, account string, blockNrOrHash rpc.BlockNumberOrHash) (*DelegationInfo, error) {
+	if api.app.useEthereum {
+		return nil, errAccountAPIUnavailable
+	}
+
+	db, header, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if db == nil || err != nil {
+		return nil, err
+	}
+	state := db.(*statedb.StateDB)
+
+	account = strings.ToLower(account)
+	if !state.Exist(account) {
+		return nil, accountNotFound(account)
+	}
+
+	info := &DelegationInfo{
+		Received: make([]accountInterpreter.Delegation, 0),
+		Granted:  make([]accountInterpreter.Delegation, 0),
+	}
+	for _, d := range accountInterpreter.DelegationsTo(state, account) {
+		if d.Active(header.Height) {
+			info.Received = append(info.Received, d)
+		}
+	}
+	for _, d := range accountInterpreter.DelegationsFrom(state, account) {
+		if d.Active(header.Height) {
+			info.Granted = append(info.Granted, d)
+		}
+	}
+	return info, nil
+}
+
+func (api *AccountAPI) SessionKeys(ctx context.Context, account string, blockNrOrHash rpc.BlockNumberOrHash) ([]accountInterpreter.SessionKey, error) {
+	if api.app.useEthereum {
+		return nil, errAccountAPIUnavailable
+	}
+
+	db, header, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if db == nil || err != nil {
+		return nil, err
+	}
+	state := db.(*statedb.StateDB)
+
+	account = strings.ToLower(account)
+	if !state.Exist(account) {
+		return nil, accountNotFound(account)
+	}
+
+	keys := make([]accountInterpreter.SessionKey, 0)
+	for _, k := range accountInterpreter.SessionKeys(state, account) {
+		if k.Active(header.Height) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+// SpendingAllowance 账户额度及剩余额度
+type SpendingAllowance struct {
+	Limit     *accountInterpreter.SpendingLimit `json:"limit"`
+	Remaining *accountInterpreter.Allowance     `json:"remaining"`
+}
+
+func (api *AccountAPI) Allowance(ctx context.Context, account string, blockNrOrHash rpc.BlockNumberOrHash) (*SpendingAllowance, error) {
+	if api.app.useEthereum {
+		return nil, errAccountAPIUnavailable
+	}
+
+	db, header, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if db == nil || err != nil {
+		return nil, err
+	}
+	state := db.(*statedb.StateDB)
+
+	account = strings.ToLower(account)
+	if !state.Exist(account) {
+		return nil, accountNotFound(account)
+	}
+
+	limit := accountInterpreter.GetSpendingLimit(state, account)
+	if limit == nil {
+		return nil, nil
+	}
+	return &SpendingAllowance{
+		Limit:     limit,
+		Remaining: accountInterpreter.AccountAllowance(state, account, header.Timestamp),
+	}, nil
 }
@@ -0,0 +1,339 @@
+// Package app
+//
+// @author: xwc1125
+package app
+
+import (
+	"context"
+	"github.com/chain5j/chain5j-pkg/codec"
+	"github.com/chain5j/chain5j-pkg/network/rpc"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-pkg/util/dateutil"
+	"github.com/chain5j/chain5j-pkg/util/hexutil"
+	"github.com/chain5j/chain5j-protocol/models"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"github.com/chain5j/chain5j-protocol/pkg/database/basedb"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
+	"github.com/chain5j/chain5j-stateApp/statediff"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultAccountPageSize  = 20
+	maxAccountPageSize      = 100
+	defaultAccountScanLimit = 100000
+)
+
+// AccountSummary 账户列表中的账户概要
+type AccountSummary struct {
+	Name     string         `json:"name"`
+	Balance  *hexutil.Big   `json:"balance"`
+	Nonce    hexutil.Uint64 `json:"nonce"`
+	IsAdmin  bool           `json:"is_admin"`
+	IsFrozen bool           `json:"is_frozen"` // 账户在该高度是否被冻结
+}
+
+// AccountList 域下账户的分页列表
+type AccountList struct {
+	Total    uint64            `json:"total"`
+	Accounts []*AccountSummary `json:"accounts"`
+}
+
+// Accounts 分页获取域下的账户，不包含子域的账户，limit为0时取默认值
+// 通过注册创建的域按账户索引分页，账户按注册顺序排列；没有索引的域（如创世时写入的域）
+// 需要遍历整个账户树，账户按名称排序，超过accountScanLimit时返回错误
+func (api *AccountAPI) Accounts(ctx context.Context, domain string, offset, limit uint64, blockNrOrHash rpc.BlockNumberOrHash) (*AccountList, error) {
+	state, header, roots, err := api.stateTrees(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	domain = strings.ToLower(domain)
+	if state.GetDomain(domain) == nil {
+		return nil, domainNotFound(domain)
+	}
+	if limit == 0 {
+		limit = defaultAccountPageSize
+	}
+	if limit > maxAccountPageSize {
+		limit = maxAccountPageSize
+	}
+
+	var (
+		total  uint64
+		stores []*accounts.AccountStore
+	)
+	if count, ok := accountInterpreter.DomainAccountCount(state, domain); ok {
+		total = count
+		for _, name := range accountInterpreter.DomainAccounts(state, domain, offset, limit) {
+			if store := accountInterpreter.GetAccount(state, name); store != nil {
+				stores = append(stores, store)
+			}
+		}
+	} else {
+		all, err := api.scanAccounts(state, roots.AccountRoot, domain)
+		if err != nil {
+			return nil, err
+		}
+		total = uint64(len(all))
+		for i := offset; i < total && i < offset+limit; i++ {
+			stores = append(stores, all[i])
+		}
+	}
+
+	list := &AccountList{
+		Total:    total,
+		Accounts: make([]*AccountSummary, 0, len(stores)),
+	}
+	for _, store := range stores {
+		list.Accounts = append(list.Accounts, &AccountSummary{
+			Name:     store.AccountName(),
+			Balance:  (*hexutil.Big)(store.Balance),
+			Nonce:    hexutil.Uint64(store.Nonce),
+			IsAdmin:  store.IsAdmin,
+			IsFrozen: accountInterpreter.IsAccountFrozen(state, store, header.Height),
+		})
+	}
+	return list, nil
+}
+
+// scanAccounts 遍历账户树获取域下的全部账户，按账户名排序
+func (api *AccountAPI) scanAccounts(state *statedb.StateDB, accountRoot types.Hash, domain string) ([]*accounts.AccountStore, error) {
+	var (
+		stores  []*accounts.AccountStore
+		scanner = api.newScanner()
+	)
+	if err := statediff.ForEachAccount(api.treeDB(), accountRoot, func(store *accounts.AccountStore) bool {
+		if !scanner.next() {
+			return false
+		}
+		if store.Domain == domain {
+			stores = append(stores, accountInterpreter.ApplyDomainAdmin(state, store))
+		}
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	if scanner.err != nil {
+		return nil, scanner.err
+	}
+	sort.Slice(stores, func(i, j int) bool {
+		return stores[i].AccountName() < stores[j].AccountName()
+	})
+	return stores, nil
+}
+
+// DomainTree 域及其子域构成的树
+type DomainTree struct {
+	Domain   string        `json:"domain"`
+	Admin    string        `json:"admin,omitempty"` // 当前管理员名称，不包含域
+	Status   string        `json:"status,omitempty"`
+	Children []*DomainTree `json:"children"`
+}
+
+// SubDomains 获取域及其全部子域构成的树，domain为空时返回全部顶级域
+// 每次调用需要遍历全部域记录，超过accountScanLimit时返回错误
+func (api *AccountAPI) SubDomains(ctx context.Context, domain string, blockNrOrHash rpc.BlockNumberOrHash) (*DomainTree, error) {
+	state, header, roots, err := api.stateTrees(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	domain = strings.ToLower(domain)
+	if domain != "" && state.GetDomain(domain) == nil {
+		return nil, domainNotFound(domain)
+	}
+
+	var (
+		domains []string
+		scanner = api.newScanner()
+	)
+	if err := statediff.ForEachDomain(api.treeDB(), roots.XRoot, func(name string, store *accounts.DomainStore) bool {
+		if !scanner.next() {
+			return false
+		}
+		if domain == "" || strings.HasSuffix(name, "."+domain) {
+			domains = append(domains, name)
+		}
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	if scanner.err != nil {
+		return nil, scanner.err
+	}
+	// 父域先于子域加入树中
+	sort.Slice(domains, func(i, j int) bool {
+		if ni, nj := strings.Count(domains[i], "."), strings.Count(domains[j], "."); ni != nj {
+			return ni < nj
+		}
+		return domains[i] < domains[j]
+	})
+
+	root := newDomainTree(state, domain, header.Height)
+	nodes := map[string]*DomainTree{domain: root}
+	for _, name := range domains {
+		parent := ""
+		if i := strings.Index(name, "."); i >= 0 {
+			parent = name[i+1:]
+		}
+		// 中间层级的域未注册时挂在最近的已注册的父域下
+		for nodes[parent] == nil && parent != domain {
+			if i := strings.Index(parent, "."); i >= 0 {
+				parent = parent[i+1:]
+			} else {
+				parent = ""
+			}
+		}
+		node := newDomainTree(state, name, header.Height)
+		nodes[parent].Children = append(nodes[parent].Children, node)
+		nodes[name] = node
+	}
+	return root, nil
+}
+
+func newDomainTree(state *statedb.StateDB, domain string, height uint64) *DomainTree {
+	node := &DomainTree{
+		Domain:   domain,
+		Children: make([]*DomainTree, 0),
+	}
+	if info := domainInfo(state, domain, height); info != nil {
+		node.Admin = info.Admin
+		node.Status = info.Status
+	}
+	return node
+}
+
+// LostStatus 账户密钥丢失找回的状态
+type LostStatus struct {
+	Lost          bool           `json:"lost"`                     // 是否存在找回请求
+	Partner       string         `json:"partner,omitempty"`        // 可发起找回请求的合作账户
+	RecoverAddr   *types.Address `json:"recover_addr,omitempty"`   // 找回后绑定的地址
+	RecoverableAt uint64         `json:"recoverable_at,omitempty"` // 可完成找回的时间
+	Recoverable   bool           `json:"recoverable"`              // 当前是否可完成找回
+}
+
+func (api *AccountAPI) LostStatus(ctx context.Context, account string, blockNrOrHash rpc.BlockNumberOrHash) (*LostStatus, error) {
+	if api.app.useEthereum {
+		return nil, errAccountAPIUnavailable
+	}
+
+	db, _, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if db == nil || err != nil {
+		return nil, err
+	}
+	state := db.(*statedb.StateDB)
+
+	account = strings.ToLower(account)
+	store := state.GetAccount(account)
+	if store == nil {
+		return nil, accountNotFound(account)
+	}
+
+	status := &LostStatus{Partner: store.Partner()}
+	data, ok := store.XXX[accounts.LostKey]
+	if !ok || len(data) == 0 {
+		return status, nil
+	}
+	var lost accounts.LostStore
+	if err := codec.Coder().Decode(data, &lost); err != nil || lost.LostRequest == nil {
+		return status, nil
+	}
+	status.Lost = true
+	status.RecoverAddr = &lost.RecoverAddr
+	status.RecoverableAt = lost.TimeStamp
+	// 与找回交易的校验一致，以当前时间判断
+	status.Recoverable = uint64(dateutil.CurrentTime()) >= lost.TimeStamp
+	return status, nil
+}
+
+// stateTrees 获取账户体系下的状态、区块头及各树的根
+func (api *AccountAPI) stateTrees(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*statedb.StateDB, *models.Header, *statediff.StateRoots, error) {
+	if api.app.useEthereum {
+		return nil, nil, nil, errAccountAPIUnavailable
+	}
+	db, header, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if db == nil || err != nil {
+		return nil, nil, nil, err
+	}
+	stateRoots := statetype.NewRoots()
+	if err := codec.Coder().Decode(header.StateRoots, stateRoots); err != nil {
+		return nil, nil, nil, err
+	}
+	roots, err := statediff.ReadRoots(api.app.kvDB, stateRoots.GetObj("STATE"))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return db.(*statedb.StateDB), header, roots, nil
+}
+
+func (api *AccountAPI) treeDB() basedb.Database {
+	return basedb.NewDatabase(api.app.kvDB)
+}
+
+// treeScanner 限制单次调用遍历的树记录数
+type treeScanner struct {
+	limit   uint64
+	scanned uint64
+	err     error
+}
+
+func (api *AccountAPI) newScanner() *treeScanner {
+	return &treeScanner{limit: api.app.accountScanLimit}
+}
+
+// next 记录一条遍历的记录，超过限制时返回false并记录错误
+func (s *treeScanner) next() bool {
+	s.scanned++
+	if s.limit > 0 && s.scanned > s.limit {
+		s.err = errScanLimitExceeded
+		return false
+	}
+	return true
+}
+
+// ByAddress 地址所属的账户名，合约地址返回合约账户名，会话密钥地址返回添加该密钥的账户名
+func (api *AccountAPI) ByAddress(ctx context.Context, address types.Address, blockNrOrHash rpc.BlockNumberOrHash) (string, error) {
+	if api.app.useEthereum {
+		return "", errAccountAPIUnavailable
+	}
+
+	db, _, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if db == nil || err != nil {
+		return "", err
+	}
+	owner := accountInterpreter.AddressOwner(db.(*statedb.StateDB), address)
+	if owner == "" {
+		return "", accountNotFound(address.Hex())
+	}
+	return owner, nil
+}
+
+// accountNames 解析地址所属的账户名，未被账户持有的地址不包含在结果中
+func accountNames(state *statedb.StateDB, addrs []types.Address) map[types.Address]string {
+	names := make(map[types.Address]string)
+	for _, addr := range addrs {
+		if _, ok := names[addr]; ok {
+			continue
+		}
+		if owner := accountInterpreter.AddressOwner(state, addr); owner != "" {
+			names[addr] = owner
+		}
+	}
+	return names
+}
+
+// resolveAccountNames 开启解析时以最新状态解析地址所属的账户名，未开启或以太坊模式下返回nil
+// 地址与账户的映射只会新增，以最新状态解析即可
+func (a *application) resolveAccountNames(addrs []types.Address) map[types.Address]string {
+	if !a.resolveNames || a.useEthereum || len(addrs) == 0 {
+		return nil
+	}
+	backend := newApiBackend(a.config, a.blockRW, a.kvDB, a.useEthereum)
+	db, _, err := backend.StateAndHeaderByNumber(context.Background(), rpc.LatestBlockNumber)
+	if err != nil {
+		return nil
+	}
+	return accountNames(db.(*statedb.StateDB), addrs)
+}
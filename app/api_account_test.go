@@ -0,0 +1,255 @@
+// Package app
+//
+// @author: xwc1125
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/chain5j/chain5j-pkg/network/rpc"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp/internal/testutil"
+	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+var lostRecoverAddr = types.HexToAddress("0x00000000000000000000000000000000000000cc")
+
+// newAccountTestApp 域bank及其子域east.bank、x.east.bank、west.bank和顶级域shop
+// bank下有账户alice、bob、carol，east.bank下有账户dave，alice以bob为合作账户且已发起找回
+// 顶级域fund通过注册创建，依次注册了管理员admin及用户zed、amy
+func newAccountTestApp(t *testing.T) *application {
+	return newTestApp(t, false, 1, func(db interface{}) {
+		state := db.(*statedb.StateDB)
+		for _, domain := range []string{"bank", "east.bank", "x.east.bank", "west.bank", "shop"} {
+			state.AddDomain(domain, accounts.DomainStore{Admin: "root"})
+		}
+		for _, name := range []string{"carol@bank", "alice@bank", "bob@bank", "dave@east.bank"} {
+			parts := strings.Split(name, "@")
+			store := accounts.NewAccountStore(parts[0], parts[1])
+			store.Balance = big.NewInt(100)
+			state.CreateAccount(store)
+		}
+		state.SetPartner("alice@bank", accounts.PartnerData{CN: "bob", Domain: "bank"})
+		state.SetLost("alice@bank", &accounts.LostStore{
+			LostRequest: &accounts.LostRequest{CN: "alice", Domain: "bank", RecoverAddr: lostRecoverAddr},
+			TimeStamp:   1,
+		})
+
+		admin := accounts.NewAccountStore("admin", "fund")
+		admin.Balance = big.NewInt(100)
+		accountInterpreter.RegisterDomain(state, admin, 1)
+		for _, cn := range []string{"zed", "amy"} {
+			store := accounts.NewAccountStore(cn, "fund")
+			store.Balance = big.NewInt(100)
+			accountInterpreter.RegisterUser(state, store)
+		}
+	})
+}
+
+// errorCode rpc返回的错误码，未定义错误码的错误返回0
+func errorCode(err error) int {
+	if e, ok := err.(rpc.Error); ok {
+		return e.ErrorCode()
+	}
+	return 0
+}
+
+func TestAccountAPI_Accounts(t *testing.T) {
+	a := newAccountTestApp(t)
+	tests := []struct {
+		name      string
+		domain    string
+		offset    uint64
+		limit     uint64
+		scanLimit uint64
+		accounts  []string
+		total     uint64
+		err       error
+	}{
+		{"all", "bank", 0, 0, 0, []string{"alice@bank", "bob@bank", "carol@bank"}, 3, nil},
+		{"upper case", "BANK", 0, 0, 0, []string{"alice@bank", "bob@bank", "carol@bank"}, 3, nil},
+		{"page", "bank", 1, 1, 0, []string{"bob@bank"}, 3, nil},
+		{"past end", "bank", 5, 1, 0, []string{}, 3, nil},
+		{"subdomain", "east.bank", 0, 0, 0, []string{"dave@east.bank"}, 1, nil},
+		{"within scan limit", "bank", 0, 0, 8, []string{"alice@bank", "bob@bank", "carol@bank"}, 3, nil},
+		{"scan limit", "bank", 0, 0, 7, nil, 0, errScanLimitExceeded},
+		// 有索引的域按注册顺序分页，不遍历账户树
+		{"indexed", "fund", 0, 0, 1, []string{"admin@fund", "zed@fund", "amy@fund"}, 3, nil},
+		{"indexed page", "fund", 1, 1, 1, []string{"zed@fund"}, 3, nil},
+		{"indexed past end", "fund", 3, 1, 1, []string{}, 3, nil},
+		{"missing domain", "nobank", 0, 0, 0, nil, 0, errDomainNotFound},
+	}
+	for _, tt := range tests {
+		a.accountScanLimit = tt.scanLimit
+		list, err := a.newAccountAPI().Accounts(context.Background(), tt.domain, tt.offset, tt.limit, latestBlock(nil))
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+		if err != nil {
+			continue
+		}
+		if list.Total != tt.total || len(list.Accounts) != len(tt.accounts) {
+			t.Fatalf("%s: total %d, %d accounts", tt.name, list.Total, len(list.Accounts))
+		}
+		for i, name := range tt.accounts {
+			if list.Accounts[i].Name != name || list.Accounts[i].Balance.ToInt().Int64() != 100 {
+				t.Fatalf("%s: account %d %+v, want %s", tt.name, i, list.Accounts[i], name)
+			}
+		}
+	}
+}
+
+// flattenTree 以"父域>子域"的形式列出树中的边
+func flattenTree(node *DomainTree) []string {
+	var edges []string
+	for _, child := range node.Children {
+		edges = append(edges, node.Domain+">"+child.Domain)
+		edges = append(edges, flattenTree(child)...)
+	}
+	return edges
+}
+
+func TestAccountAPI_SubDomains(t *testing.T) {
+	a := newAccountTestApp(t)
+	tests := []struct {
+		name      string
+		domain    string
+		scanLimit uint64
+		edges     []string
+		err       error
+	}{
+		{"domain", "bank", 0, []string{"bank>east.bank", "east.bank>x.east.bank", "bank>west.bank"}, nil},
+		{"leaf", "x.east.bank", 0, nil, nil},
+		{"top level", "", 0, []string{">bank", "bank>east.bank", "east.bank>x.east.bank", "bank>west.bank", ">fund", ">shop"}, nil},
+		{"scan limit", "bank", 4, nil, errScanLimitExceeded},
+		{"missing domain", "nobank", 0, nil, errDomainNotFound},
+	}
+	for _, tt := range tests {
+		a.accountScanLimit = tt.scanLimit
+		tree, err := a.newAccountAPI().SubDomains(context.Background(), tt.domain, latestBlock(nil))
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+		if err != nil {
+			continue
+		}
+		edges := flattenTree(tree)
+		if strings.Join(edges, ",") != strings.Join(tt.edges, ",") {
+			t.Fatalf("%s: %v, want %v", tt.name, edges, tt.edges)
+		}
+		if tt.domain != "" && tree.Admin != "root" {
+			t.Fatalf("%s: admin %q", tt.name, tree.Admin)
+		}
+	}
+}
+
+func TestAccountAPI_LostStatus(t *testing.T) {
+	api := newAccountTestApp(t).newAccountAPI()
+	tests := []struct {
+		account string
+		want    LostStatus
+		err     error
+	}{
+		{"alice@bank", LostStatus{Lost: true, Partner: "bob@bank", RecoverAddr: &lostRecoverAddr, RecoverableAt: 1, Recoverable: true}, nil},
+		{"bob@bank", LostStatus{}, nil},
+		{"eve@bank", LostStatus{}, errAccountNotFound},
+	}
+	for _, tt := range tests {
+		status, err := api.LostStatus(context.Background(), tt.account, latestBlock(nil))
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("%s: %v, want %v", tt.account, err, tt.err)
+		}
+		if err != nil {
+			continue
+		}
+		if status.Lost != tt.want.Lost || status.Partner != tt.want.Partner || status.RecoverableAt != tt.want.RecoverableAt ||
+			status.Recoverable != tt.want.Recoverable || (status.RecoverAddr == nil) != (tt.want.RecoverAddr == nil) ||
+			(status.RecoverAddr != nil && *status.RecoverAddr != *tt.want.RecoverAddr) {
+			t.Fatalf("%s: %+v, want %+v", tt.account, status, tt.want)
+		}
+	}
+}
+
+// 不存在的账户及域返回固定的错误码
+func TestAccountAPI_NotFound(t *testing.T) {
+	api := newAccountTestApp(t).newAccountAPI()
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		call func() error
+		code int
+	}{
+		{"account info", func() error { _, err := api.AccountInfo(ctx, "eve@bank", latestBlock(nil)); return err }, AccountNotFoundCode},
+		{"partner", func() error { _, err := api.Partner(ctx, "eve@bank", latestBlock(nil)); return err }, AccountNotFoundCode},
+		{"freeze info", func() error { _, err := api.FreezeInfo(ctx, "eve@bank", latestBlock(nil)); return err }, AccountNotFoundCode},
+		{"by address", func() error { _, err := api.ByAddress(ctx, lostRecoverAddr, latestBlock(nil)); return err }, AccountNotFoundCode},
+		{"domain info", func() error { _, err := api.DomainInfo(ctx, "nobank", latestBlock(nil)); return err }, DomainNotFoundCode},
+		{"accounts", func() error { _, err := api.Accounts(ctx, "nobank", 0, 0, latestBlock(nil)); return err }, DomainNotFoundCode},
+		{"sub domains", func() error { _, err := api.SubDomains(ctx, "nobank", latestBlock(nil)); return err }, DomainNotFoundCode},
+	}
+	for _, tt := range tests {
+		if code := errorCode(tt.call()); code != tt.code {
+			t.Fatalf("%s: code %d, want %d", tt.name, code, tt.code)
+		}
+	}
+}
+
+// 以太坊模式下账户接口不可用
+func TestAccountAPI_Ethereum(t *testing.T) {
+	api := newTestApp(t, true, 1, func(interface{}) {}).newAccountAPI()
+	ctx := context.Background()
+	calls := map[string]func() error{
+		"account info": func() error { _, err := api.AccountInfo(ctx, "alice@bank", latestBlock(nil)); return err },
+		"domain info":  func() error { _, err := api.DomainInfo(ctx, "bank", latestBlock(nil)); return err },
+		"accounts":     func() error { _, err := api.Accounts(ctx, "bank", 0, 0, latestBlock(nil)); return err },
+		"sub domains":  func() error { _, err := api.SubDomains(ctx, "bank", latestBlock(nil)); return err },
+		"lost status":  func() error { _, err := api.LostStatus(ctx, "alice@bank", latestBlock(nil)); return err },
+	}
+	for name, call := range calls {
+		if err := call(); err != errAccountAPIUnavailable {
+			t.Fatalf("%s: %v, want %v", name, err, errAccountAPIUnavailable)
+		}
+	}
+}
+
+func TestAccountAPI_ByAddress(t *testing.T) {
+	var (
+		aliceAddr    = types.HexToAddress("0x00000000000000000000000000000000000000a1")
+		sessionAddr  = types.HexToAddress("0x00000000000000000000000000000000000000a2")
+		contractAddr = types.HexToAddress("0x00000000000000000000000000000000000000a3")
+		contract     string
+	)
+	api := newTestApp(t, false, 1, func(db interface{}) {
+		state := db.(*statedb.StateDB)
+		store := accounts.NewAccountStore("alice", "bank")
+		store.SetAddress(aliceAddr, nil)
+		state.CreateAccount(store)
+		input := testutil.Encode(t, &accountInterpreter.AddSessionKeyData{Address: sessionAddr, ExpiryHeight: 100})
+		accountInterpreter.AddSessionKey(state, "alice@bank", input, 1)
+		statedb.NewEvmStateDB(state).CreateAccount(contractAddr)
+		contract = state.GetOwner(contractAddr)
+	}).newAccountAPI()
+
+	tests := []struct {
+		name  string
+		addr  types.Address
+		owner string
+		err   error
+	}{
+		{"account", aliceAddr, "alice@bank", nil},
+		{"session key", sessionAddr, "alice@bank", nil},
+		{"contract", contractAddr, contract, nil},
+		{"unknown", lostRecoverAddr, "", errAccountNotFound},
+	}
+	for _, tt := range tests {
+		owner, err := api.ByAddress(context.Background(), tt.addr, latestBlock(nil))
+		if !errors.Is(err, tt.err) || owner != tt.owner {
+			t.Fatalf("%s: %q %v, want %q %v", tt.name, owner, err, tt.owner, tt.err)
+		}
+	}
+}
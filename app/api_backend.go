@@ -9,7 +9,6 @@ import (
 	evm "github.com/chain5j/chain5j-evm"
 	"github.com/chain5j/chain5j-pkg/codec"
 	"github.com/chain5j/chain5j-pkg/database/kvstore"
-	"github.com/chain5j/chain5j-pkg/math"
 	"github.com/chain5j/chain5j-pkg/network/rpc"
 	"github.com/chain5j/chain5j-pkg/types"
 	"github.com/chain5j/chain5j-protocol/models"
@@ -17,7 +16,9 @@ import (
 	"github.com/chain5j/chain5j-protocol/pkg/database/ethStatedb"
 	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
 	"github.com/chain5j/chain5j-protocol/protocol"
+	"github.com/chain5j/chain5j-stateApp/interpreter/ethereumInterpreter"
 	"github.com/chain5j/chain5j-stateApp/interpreter/evmInterpreter"
+	"github.com/chain5j/chain5j-stateApp/tracers"
 )
 
 type ApiBackend struct {
@@ -84,6 +85,33 @@ func (b *ApiBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOr
 	return nil, nil, errors.New("invalid arguments; neither block nor hash specified")
 }
 
+// HeaderByNumberOrHash 获取区块头，latest及pending为当前区块
+func (b *ApiBackend) HeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*models.Header, error) {
+	if blockNr, ok := blockNrOrHash.Number(); ok {
+		var header *models.Header
+		if blockNr == rpc.LatestBlockNumber || blockNr == rpc.PendingBlockNumber {
+			header = b.blockchain.CurrentBlock().Header()
+		} else {
+			header = b.blockchain.GetHeaderByNumber(uint64(blockNr))
+		}
+		if header == nil {
+			return nil, errors.New("header not found")
+		}
+		return header, nil
+	}
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		header := b.blockchain.GetHeaderByHash(hash)
+		if header == nil {
+			return nil, errors.New("header for hash not found")
+		}
+		if blockNrOrHash.RequireCanonical && b.blockchain.GetHeaderByNumber(header.Height).Hash() != hash {
+			return nil, errors.New("hash is not currently canonical")
+		}
+		return header, nil
+	}
+	return nil, errors.New("invalid arguments; neither block nor hash specified")
+}
+
 func (b *ApiBackend) RootsAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*statetype.StateRoots, error) {
 	// Otherwise resolve and return the block
 	var header *models.Header
@@ -123,11 +151,36 @@ func (b *ApiBackend) RootsAndHeaderByNumberOrHash(ctx context.Context, blockNrOr
 	return nil, errors.New("invalid arguments; neither block nor hash specified")
 }
 
-func (b *ApiBackend) GetEVM(ctx context.Context, msg models.VmMessage, state *statedb.StateDB, header *models.Header) (protocol.VM, func() error, error) {
-	state.SetBalance(msg.From(), math.MaxBig256)
+// GetEVM 账户体系下创建evm，不修改调用方的余额
+func (b *ApiBackend) GetEVM(ctx context.Context, msg models.VmMessage, state *statedb.StateDB, header *models.Header, vmConfig evm.Config) (protocol.VM, func() error, error) {
+	evmdb := evmInterpreter.NewStateDB(state, header.Height)
+	if vmConfig.Debug {
+		var to *types.Address
+		if msg.To() != "" {
+			addr := types.DomainToAddress(msg.To())
+			to = &addr
+		}
+		tracers.CaptureTxStart(vmConfig.Tracer, evmdb, types.DomainToAddress(msg.From()), to)
+	}
 	vmError := func() error { return nil }
-	evmdb := statedb.NewEvmStateDB(state)
 
 	context := evmInterpreter.NewEVMContext(msg, header, b.blockchain, nil)
-	return evm.NewEVM(context, evmdb, nil, evm.Config{}), vmError, nil
+	return evm.NewEVM(context, evmdb, nil, vmConfig), vmError, nil
+}
+
+// GetEthEVM 以太坊模式下创建evm，不修改调用方的余额
+func (b *ApiBackend) GetEthEVM(ctx context.Context, msg models.VmMessage, state *ethStatedb.StateDB, header *models.Header, vmConfig evm.Config) (*evm.EVM, func() error, error) {
+	if vmConfig.Debug {
+		var to *types.Address
+		if msg.To() != "" {
+			addr := types.HexToAddress(msg.To())
+			to = &addr
+		}
+		tracers.CaptureTxStart(vmConfig.Tracer, state, types.HexToAddress(msg.From()), to)
+	}
+	vmError := func() error { return state.Error() }
+
+	chainConfig := b.config.ChainConfig()
+	context := ethereumInterpreter.NewEVMContext(msg, header, b.blockchain, nil)
+	return evm.NewEVM(context, state, &chainConfig, vmConfig), vmError, nil
 }
@@ -0,0 +1,101 @@
+// Package app
+//
+// @author: xwc1125
+package app
+
+import (
+	"github.com/chain5j/chain5j-pkg/codec"
+	"github.com/chain5j/chain5j-pkg/crypto/signature"
+	"github.com/chain5j/chain5j-pkg/database/kvstore/memorydb"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"github.com/chain5j/chain5j-protocol/pkg/database/ethStatedb"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-protocol/protocol"
+	"testing"
+)
+
+// testChain 只有当前区块的链，未实现的方法调用时panic
+type testChain struct {
+	protocol.BlockReadWriter
+	header *models.Header
+	txs    models.Transactions // 当前区块中的交易
+}
+
+func (c *testChain) CurrentBlock() *models.Block {
+	return models.NewBlock(c.header, c.txs, nil)
+}
+
+func (c *testChain) GetBlock(hash types.Hash, number uint64) *models.Block {
+	if number != c.header.Height {
+		return nil
+	}
+	return c.CurrentBlock()
+}
+
+func (c *testChain) CurrentHeader() *models.Header {
+	return c.header
+}
+
+func (c *testChain) GetHeaderByNumber(number uint64) *models.Header {
+	if number != c.header.Height {
+		return nil
+	}
+	return c.header
+}
+
+func (c *testChain) GetHeaderByHash(hash types.Hash) *models.Header {
+	if hash != c.header.Hash() {
+		return nil
+	}
+	return c.header
+}
+
+// newTestApp 以setup构造状态并提交，返回当前区块为该状态的应用
+// 账户体系下setup的参数为*statedb.StateDB，以太坊模式下为*ethStatedb.StateDB
+func newTestApp(t *testing.T, useEthereum bool, height uint64, setup func(state interface{})) *application {
+	db := memorydb.New()
+	var (
+		root types.Hash
+		err  error
+	)
+	if useEthereum {
+		state, err := ethStatedb.New(types.Hash{}, ethStatedb.NewDatabase(db))
+		if err != nil {
+			t.Fatal(err)
+		}
+		setup(state)
+		if root, err = state.Commit(false); err != nil {
+			t.Fatal(err)
+		}
+		if err := state.Database().TreeDB().Commit(root, true); err != nil {
+			t.Fatal(err)
+		}
+	} else {
+		state, err := statedb.New(types.Hash{}, db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		setup(state)
+		// 与区块处理一致，先计算中间根使域等数据写入树中
+		state.IntermediateRoot(false)
+		if root, err = state.Commit(false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	roots := statetype.NewRoots()
+	roots.Put("STATE", root)
+	stateRoots, err := codec.Coder().Encode(roots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := &models.Header{Height: height, StateRoots: stateRoots, Signature: &signature.SignResult{}}
+	return &application{
+		kvDB:             db,
+		blockRW:          &testChain{header: header},
+		useEthereum:      useEthereum,
+		accountScanLimit: defaultAccountScanLimit,
+	}
+}
@@ -0,0 +1,162 @@
+// Package app
+//
+// @author: xwc1125
+package app
+
+import (
+	"context"
+	evm "github.com/chain5j/chain5j-evm"
+	"github.com/chain5j/chain5j-pkg/codec"
+	"github.com/chain5j/chain5j-pkg/network/rpc"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"github.com/chain5j/chain5j-protocol/pkg/database/ethStatedb"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/tracers"
+	"time"
+)
+
+const traceCallTimeout = 5 * time.Second
+
+// TraceConfig 跟踪配置，Tracer为空时逐条指令跟踪
+type TraceConfig struct {
+	*evm.LogConfig
+	Tracer string `json:"tracer"`
+}
+
+// TraceCallConfig 合约调用的跟踪配置，可在执行前覆盖状态
+type TraceCallConfig struct {
+	TraceConfig
+	StateOverrides *StateOverride `json:"state_overrides"`
+}
+
+// DebugAPI 调试接口，默认不对外开放
+type DebugAPI struct {
+	app     *application
+	backend *ApiBackend
+	apps    *API
+}
+
+func (a *application) newDebugAPI() *DebugAPI {
+	backend := newApiBackend(a.config, a.blockRW, a.kvDB, a.useEthereum)
+	return &DebugAPI{
+		app:     a,
+		backend: backend,
+		apps:    &API{app: a, backend: backend},
+	}
+}
+
+func newTracer(config *TraceConfig) (tracers.Tracer, error) {
+	if config == nil {
+		return tracers.New(tracers.StructLoggerName, nil)
+	}
+	return tracers.New(config.Tracer, config.LogConfig)
+}
+
+// TraceTransaction 跟踪已上链交易的执行
+// 以父区块的状态为起点，依次执行区块开始的处理及该交易之前的交易，再跟踪执行该交易
+func (api *DebugAPI) TraceTransaction(ctx context.Context, hash types.Hash, config *TraceConfig) (interface{}, error) {
+	tracer, err := newTracer(config)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, lookup := api.app.getTransaction(hash)
+	if tx == nil {
+		return nil, errTxNotFound
+	}
+	block := api.app.blockRW.GetBlock(lookup.BlockHash, lookup.Height)
+	if block == nil {
+		return nil, errBlockNotFound
+	}
+	parent := api.app.blockRW.GetHeaderByHash(block.ParentHash())
+	if parent == nil {
+		return nil, errBlockNotFound
+	}
+
+	roots := statetype.NewRoots()
+	if err := codec.Coder().Decode(parent.StateRoots, roots); err != nil {
+		return nil, err
+	}
+	root := roots.GetObj("STATE")
+	db, err := api.backend.StateAt(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		stateDB *statedb.StateDB
+		ethDB   *ethStatedb.StateDB
+	)
+	if api.app.useEthereum {
+		ethDB = db.(*ethStatedb.StateDB)
+	} else {
+		stateDB = db.(*statedb.StateDB)
+	}
+
+	header := block.Header()
+	interpreterCtx, err := stateApp.NewInterpreterCtx(stateDB, ethDB, root, header, api.app.blockRW, header.GasLimit, api.app.config)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		tcount  int
+		usedGas = new(uint64)
+	)
+	api.app.runBlockHooks(interpreterCtx, header, true, usedGas)
+
+	for _, txs := range block.Transactions() {
+		for _, t := range txs {
+			tx, ok := t.(*stateApp.Transaction)
+			if !ok {
+				continue
+			}
+			interpreter, ok := interpreters[tx.Interpreter()]
+			if !ok || interpreter.VerifyTx(interpreterCtx, tx) != nil {
+				continue
+			}
+
+			interpreterCtx.Prepare(tx.Hash(), block.Hash(), tcount)
+			if tx.Hash() != hash {
+				if _, err := interpreter.ApplyTransaction(interpreterCtx, tx, usedGas); err == nil {
+					tcount++
+				}
+				continue
+			}
+
+			traceable, ok := interpreter.(stateApp.TraceableInterpreter)
+			if !ok {
+				return nil, errNotTraceable
+			}
+			receipt, err := traceable.TraceTransaction(interpreterCtx, tx, usedGas, tracer)
+			if err != nil {
+				return nil, err
+			}
+			return tracer.Result(receipt.GasUsed, receipt.Status == statetype.ReceiptStatusFailed)
+		}
+	}
+	return nil, errTxNotFound
+}
+
+// TraceCall 在指定区块的状态上跟踪合约调用，不会上链
+func (api *DebugAPI) TraceCall(ctx context.Context, args CallMsg, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) (interface{}, error) {
+	var (
+		traceConfig *TraceConfig
+		overrides   *StateOverride
+	)
+	if config != nil {
+		traceConfig, overrides = &config.TraceConfig, config.StateOverrides
+	}
+	tracer, err := newTracer(traceConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	_, gas, failed, err := api.apps.doCall(ctx, args, blockNrOrHash, overrides, traceCallTimeout, evm.Config{Debug: true, Tracer: tracer})
+	if err != nil {
+		return nil, err
+	}
+	return tracer.Result(gas, failed)
+}
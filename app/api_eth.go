@@ -0,0 +1,457 @@
+// Package app
+//
+// @author: xwc1125
+package app
+
+import (
+	"context"
+	"fmt"
+	evm "github.com/chain5j/chain5j-evm"
+	"github.com/chain5j/chain5j-pkg/codec"
+	"github.com/chain5j/chain5j-pkg/math"
+	"github.com/chain5j/chain5j-pkg/network/rpc"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-pkg/util/hexutil"
+	"github.com/chain5j/chain5j-protocol/models"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"github.com/chain5j/chain5j-protocol/models/vm"
+	"github.com/chain5j/chain5j-protocol/pkg/database/ethStatedb"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/interpreter/ethereumInterpreter"
+	"github.com/chain5j/logger"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const ethCallTimeout = 5 * time.Second
+
+// EthAPI 以太坊兼容接口，仅在以太坊模式下注册，供钱包及开发工具使用
+type EthAPI struct {
+	app     *application
+	backend *ApiBackend
+	apps    *API
+}
+
+func (a *application) newEthAPI() *EthAPI {
+	backend := newApiBackend(a.config, a.blockRW, a.kvDB, a.useEthereum)
+	return &EthAPI{
+		app:     a,
+		backend: backend,
+		apps:    &API{app: a, backend: backend},
+	}
+}
+
+// CallArgs eth_call及eth_estimateGas的参数
+type CallArgs struct {
+	From     *types.Address  `json:"from"`
+	To       *types.Address  `json:"to"`
+	Gas      *hexutil.Uint64 `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Value    *hexutil.Big    `json:"value"`
+	Data     *hexutil.Bytes  `json:"data"`
+	Input    *hexutil.Bytes  `json:"input"`
+}
+
+// toMessage 转换为evm消息，gas为0时不限制
+func (args *CallArgs) toMessage() models.VmMessage {
+	var from types.Address
+	if args.From != nil {
+		from = *args.From
+	}
+	gas := uint64(math.MaxUint64 / 2)
+	if args.Gas != nil && *args.Gas != 0 {
+		gas = uint64(*args.Gas)
+	}
+	gasPrice := new(big.Int)
+	if args.GasPrice != nil {
+		gasPrice = args.GasPrice.ToInt()
+	}
+	value := new(big.Int)
+	if args.Value != nil {
+		value = args.Value.ToInt()
+	}
+	var data []byte
+	if args.Input != nil {
+		data = *args.Input
+	} else if args.Data != nil {
+		data = *args.Data
+	}
+	return models.NewEvmMessage(from, args.To, 0, value, gas, gasPrice, data, false)
+}
+
+// RPCTransaction 以太坊格式的交易，待打包的交易区块信息为空
+type RPCTransaction struct {
+	BlockHash        *types.Hash     `json:"blockHash"`
+	BlockNumber      *hexutil.Big    `json:"blockNumber"`
+	From             types.Address   `json:"from"`
+	Gas              hexutil.Uint64  `json:"gas"`
+	GasPrice         *hexutil.Big    `json:"gasPrice"`
+	Hash             types.Hash      `json:"hash"`
+	Input            hexutil.Bytes   `json:"input"`
+	Nonce            hexutil.Uint64  `json:"nonce"`
+	To               *types.Address  `json:"to"`
+	TransactionIndex *hexutil.Uint64 `json:"transactionIndex"`
+	Value            *hexutil.Big    `json:"value"`
+	Type             hexutil.Uint64  `json:"type"`
+	ChainID          *hexutil.Big    `json:"chainId,omitempty"`
+	V                *hexutil.Big    `json:"v"`
+	R                *hexutil.Big    `json:"r"`
+	S                *hexutil.Big    `json:"s"`
+}
+
+func newRPCTransaction(tx *stateApp.Transaction, blockHash types.Hash, height uint64, index uint64) *RPCTransaction {
+	result := &RPCTransaction{
+		From:     types.HexToAddress(tx.From()),
+		Gas:      hexutil.Uint64(tx.GasLimit()),
+		GasPrice: (*hexutil.Big)(new(big.Int).SetUint64(tx.GasPrice())),
+		Hash:     tx.Hash(),
+		Input:    hexutil.Bytes(tx.Input()),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		Value:    (*hexutil.Big)(tx.Value()),
+	}
+	if tx.To() != "" {
+		to := types.HexToAddress(tx.To())
+		result.To = &to
+	}
+	if v, r, s, err := tx.EthSignatureValues(); err == nil {
+		result.ChainID = (*hexutil.Big)(tx.EthChainId())
+		result.V, result.R, result.S = (*hexutil.Big)(v), (*hexutil.Big)(r), (*hexutil.Big)(s)
+	}
+	if blockHash != (types.Hash{}) {
+		result.BlockHash = &blockHash
+		result.BlockNumber = (*hexutil.Big)(new(big.Int).SetUint64(height))
+		result.TransactionIndex = (*hexutil.Uint64)(&index)
+	}
+	return result
+}
+
+// RPCLog 以太坊格式的日志
+type RPCLog struct {
+	Address          types.Address  `json:"address"`
+	Topics           []types.Hash   `json:"topics"`
+	Data             hexutil.Bytes  `json:"data"`
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	TransactionHash  types.Hash     `json:"transactionHash"`
+	TransactionIndex hexutil.Uint   `json:"transactionIndex"`
+	BlockHash        types.Hash     `json:"blockHash"`
+	LogIndex         hexutil.Uint   `json:"logIndex"`
+	Removed          bool           `json:"removed"`
+	Account          string         `json:"account,omitempty"` // 合约地址所属的账户名，仅账户体系下开启解析时返回
+}
+
+func newRPCLogs(logs []*statetype.Log) []*RPCLog {
+	result := make([]*RPCLog, 0, len(logs))
+	for _, l := range logs {
+		topics := l.Topics
+		if topics == nil {
+			topics = []types.Hash{}
+		}
+		result = append(result, &RPCLog{
+			Address:          l.Address,
+			Topics:           topics,
+			Data:             l.Data,
+			BlockNumber:      hexutil.Uint64(l.BlockHeight),
+			TransactionHash:  l.TransactionHash,
+			TransactionIndex: hexutil.Uint(l.TxIndex),
+			BlockHash:        l.BlockHash,
+			LogIndex:         hexutil.Uint(l.Index),
+			Removed:          l.Removed,
+		})
+	}
+	return result
+}
+
+func latestBlock(blockNrOrHash *rpc.BlockNumberOrHash) rpc.BlockNumberOrHash {
+	if blockNrOrHash == nil {
+		return rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	}
+	return *blockNrOrHash
+}
+
+func (api *EthAPI) state(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*ethStatedb.StateDB, *models.Header, error) {
+	db, header, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	state, ok := db.(*ethStatedb.StateDB)
+	if !ok || state == nil {
+		return nil, nil, errInvalidState
+	}
+	return state, header, nil
+}
+
+// ChainId 链ID，用于EIP-155签名
+func (api *EthAPI) ChainId() hexutil.Uint64 {
+	return hexutil.Uint64(api.app.config.ChainConfig().ChainID)
+}
+
+// BlockNumber 当前区块高度
+func (api *EthAPI) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(api.app.blockRW.CurrentBlock().Height())
+}
+
+// GasPrice 链上不限制最低的gas价格
+func (api *EthAPI) GasPrice() *hexutil.Big {
+	return (*hexutil.Big)(new(big.Int))
+}
+
+func (api *EthAPI) GetBalance(ctx context.Context, address types.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	state, _, err := api.state(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(state.GetBalance(address)), state.Error()
+}
+
+// GetTransactionCount pending时包含交易池中的交易
+func (api *EthAPI) GetTransactionCount(ctx context.Context, address types.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Uint64, error) {
+	nonce, err := api.apps.GetTransactionCount(ctx, strings.ToLower(address.Hex()), blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Uint64)(&nonce), nil
+}
+
+func (api *EthAPI) GetCode(ctx context.Context, address types.Address, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	state, _, err := api.state(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	return state.GetCode(address), state.Error()
+}
+
+func (api *EthAPI) GetStorageAt(ctx context.Context, address types.Address, key string, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	state, _, err := api.state(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	res := state.GetState(address, types.HexToHash(key))
+	return res[:], state.Error()
+}
+
+// Call 在指定区块的状态上执行合约调用，不会上链
+func (api *EthAPI) Call(ctx context.Context, args CallArgs, blockNrOrHash *rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	result, _, failed, err := api.doCall(ctx, args.toMessage(), latestBlock(blockNrOrHash), ethCallTimeout, evm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	if failed {
+		return nil, newRevertError(result)
+	}
+	return result, nil
+}
+
+// EstimateGas 二分查找交易可执行的最小gas
+func (api *EthAPI) EstimateGas(ctx context.Context, args CallArgs, blockNrOrHash *rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
+	bn := latestBlock(blockNrOrHash)
+	_, header, err := api.state(ctx, bn)
+	if err != nil {
+		return 0, err
+	}
+
+	var gasLimit uint64
+	if args.Gas != nil {
+		gasLimit = uint64(*args.Gas)
+	}
+	executable := func(gas uint64) (bool, error) {
+		args.Gas = (*hexutil.Uint64)(&gas)
+		_, _, failed, err := api.doCall(ctx, args.toMessage(), bn, 0, evm.Config{})
+		if err != nil {
+			return false, err
+		}
+		return !failed, nil
+	}
+	return searchGas(gasLimit, header.GasLimit, executable)
+}
+
+func (api *EthAPI) doCall(ctx context.Context, msg models.VmMessage, blockNrOrHash rpc.BlockNumberOrHash, timeout time.Duration, vmConfig evm.Config) ([]byte, uint64, bool, error) {
+	defer func(start time.Time) { logger.Trace("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
+
+	state, header, err := api.state(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	vmenv, vmError, err := api.backend.GetEthEVM(ctx, msg, state, header, vmConfig)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	go func() {
+		<-ctx.Done()
+		vmenv.Cancel()
+	}()
+
+	gp := new(vm.GasPool).AddGas(math.MaxUint64)
+	res, gas, failed, err := ethereumInterpreter.ApplyMessage(vmenv, msg, gp)
+	if err := vmError(); err != nil {
+		return nil, 0, false, err
+	}
+	return res, gas, failed, err
+}
+
+// SendRawTransaction 提交以太坊签名的原始交易，返回交易hash
+func (api *EthAPI) SendRawTransaction(ctx context.Context, input hexutil.Bytes) (types.Hash, error) {
+	tx, err := stateApp.DecodeEthTransaction(input)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	if api.app.txPool == nil {
+		return types.Hash{}, errTxPoolUnavailable
+	}
+	if err := api.app.txPool.Add(nil, tx); err != nil {
+		return types.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+// GetTransactionByHash 查询交易，交易池中待打包的交易区块信息为空
+func (api *EthAPI) GetTransactionByHash(ctx context.Context, hash types.Hash) (*RPCTransaction, error) {
+	if tx, lookup := api.app.getTransaction(hash); tx != nil {
+		return newRPCTransaction(tx, lookup.BlockHash, lookup.Height, lookup.Index), nil
+	}
+	if tx := api.app.pendingTransaction(hash); tx != nil {
+		return newRPCTransaction(tx, types.Hash{}, 0, 0), nil
+	}
+	return nil, nil
+}
+
+// GetTransactionReceipt 查询已上链交易的收据
+func (api *EthAPI) GetTransactionReceipt(ctx context.Context, hash types.Hash) (map[string]interface{}, error) {
+	tx, lookup := api.app.getTransaction(hash)
+	if tx == nil {
+		return nil, nil
+	}
+	receipt := api.app.getReceipt(hash, lookup)
+	if receipt == nil {
+		return nil, nil
+	}
+
+	fields := map[string]interface{}{
+		"blockHash":         lookup.BlockHash,
+		"blockNumber":       hexutil.Uint64(lookup.Height),
+		"transactionHash":   hash,
+		"transactionIndex":  hexutil.Uint64(lookup.Index),
+		"from":              types.HexToAddress(tx.From()),
+		"to":                nil,
+		"gasUsed":           hexutil.Uint64(receipt.GasUsed),
+		"cumulativeGasUsed": hexutil.Uint64(receipt.CumulativeGasUsed),
+		"effectiveGasPrice": (*hexutil.Big)(new(big.Int).SetUint64(tx.GasPrice())),
+		"contractAddress":   nil,
+		"logs":              newRPCLogs(receipt.Logs),
+		"logsBloom":         receipt.LogsBloom,
+		"status":            hexutil.Uint64(receipt.Status),
+		"type":              hexutil.Uint64(0),
+	}
+	if tx.To() != "" {
+		fields["to"] = types.HexToAddress(tx.To())
+	}
+	if receipt.ContractAddress != (types.Address{}) {
+		fields["contractAddress"] = receipt.ContractAddress
+	}
+	return fields, nil
+}
+
+func (api *EthAPI) GetBlockByNumber(ctx context.Context, number rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
+	var block *models.Block
+	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+		block = api.app.blockRW.CurrentBlock()
+	} else {
+		block = api.app.blockRW.GetBlockByNumber(uint64(number))
+	}
+	if block == nil {
+		return nil, nil
+	}
+	return api.rpcMarshalBlock(block, fullTx)
+}
+
+func (api *EthAPI) GetBlockByHash(ctx context.Context, hash types.Hash, fullTx bool) (map[string]interface{}, error) {
+	block := api.app.blockRW.GetBlockByHash(hash)
+	if block == nil {
+		return nil, nil
+	}
+	return api.rpcMarshalBlock(block, fullTx)
+}
+
+// rpcMarshalBlock 以太坊格式的区块，区块时间戳转换为秒
+func (api *EthAPI) rpcMarshalBlock(block *models.Block, fullTx bool) (map[string]interface{}, error) {
+	header := block.Header()
+	roots := statetype.NewRoots()
+	if err := codec.Coder().Decode(header.StateRoots, roots); err != nil {
+		return nil, err
+	}
+	var bloom statetype.Bloom
+	if header.LogsBloom != nil {
+		bloom = *header.LogsBloom
+	}
+
+	txs := make([]interface{}, 0, header.TxsCount)
+	var index uint64
+	for _, list := range block.Transactions() {
+		for _, txI := range list {
+			tx, ok := txI.(*stateApp.Transaction)
+			if fullTx && ok {
+				txs = append(txs, newRPCTransaction(tx, block.Hash(), block.Height(), index))
+			} else {
+				txs = append(txs, txI.Hash())
+			}
+			index++
+		}
+	}
+
+	return map[string]interface{}{
+		"number":           hexutil.Uint64(header.Height),
+		"hash":             block.Hash(),
+		"parentHash":       header.ParentHash,
+		"nonce":            hexutil.Bytes(make([]byte, 8)),
+		"sha3Uncles":       types.Hash{},
+		"logsBloom":        bloom,
+		"stateRoot":        roots.GetObj("STATE"),
+		"miner":            types.Address{},
+		"difficulty":       (*hexutil.Big)(new(big.Int)),
+		"extraData":        hexutil.Bytes(header.Extra),
+		"size":             hexutil.Uint64(block.Size()),
+		"gasLimit":         hexutil.Uint64(header.GasLimit),
+		"gasUsed":          hexutil.Uint64(header.GasUsed),
+		"timestamp":        hexutil.Uint64(header.Timestamp / 1000),
+		"transactions":     txs,
+		"transactionsRoot": types.Hash{},
+		"receiptsRoot":     types.Hash{},
+		"uncles":           []types.Hash{},
+	}, nil
+}
+
+// newRevertError 合约执行失败的错误，返回数据为Error(string)时附带原因
+func newRevertError(result []byte) error {
+	if reason, ok := unpackRevert(result); ok {
+		return fmt.Errorf("%v: %s", errExecutionReverted, reason)
+	}
+	return errExecutionReverted
+}
+
+// unpackRevert 解析Error(string)编码的revert原因
+func unpackRevert(data []byte) (string, bool) {
+	// selector(4) + offset(32) + length(32)
+	if len(data) < 68 || !strings.EqualFold(hexutil.Encode(data[:4]), "0x08c379a0") {
+		return "", false
+	}
+	offset := new(big.Int).SetBytes(data[4:36])
+	// 先比较再相加，避免超大的偏移及长度溢出
+	if !offset.IsUint64() || offset.Uint64() > uint64(len(data)-36) {
+		return "", false
+	}
+	start := 4 + offset.Uint64()
+	length := new(big.Int).SetBytes(data[start : start+32])
+	if !length.IsUint64() || length.Uint64() > uint64(len(data))-start-32 {
+		return "", false
+	}
+	return string(data[start+32 : start+32+length.Uint64()]), true
+}
@@ -0,0 +1,192 @@
+// Package app
+//
+// @author: xwc1125
+package app
+
+import (
+	"context"
+	"github.com/chain5j/chain5j-pkg/network/rpc"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"github.com/chain5j/chain5j-protocol/protocol"
+	"github.com/chain5j/chain5j-stateApp/filters"
+	"sync"
+	"time"
+)
+
+// filterTimeout 轮询过滤器超过该时间未被访问时自动卸载
+const filterTimeout = 5 * time.Minute
+
+// filterBackend 为日志查询提供区块及收据
+type filterBackend struct {
+	protocol.BlockReader
+	db protocol.Database
+}
+
+func (b *filterBackend) GetReceipts(hash types.Hash, height uint64) (statetype.Receipts, error) {
+	return b.db.GetReceipts(hash, height)
+}
+
+// pollFilter 轮询过滤器，缓存自上次查询以来新提交区块中的日志
+type pollFilter struct {
+	sub      *filters.Subscription
+	crit     filters.FilterCriteria
+	logs     []*statetype.Log
+	lastUsed time.Time
+}
+
+// FilterAPI 日志查询、轮询过滤器及日志订阅
+// 以太坊模式下位于eth命名空间，否则位于apps命名空间
+type FilterAPI struct {
+	app     *application
+	backend filters.Backend
+
+	mu      sync.Mutex
+	filters map[rpc.ID]*pollFilter
+}
+
+func (a *application) newFilterAPI() *FilterAPI {
+	api := &FilterAPI{
+		app:     a,
+		backend: &filterBackend{BlockReader: a.blockRW, db: a.db},
+		filters: make(map[rpc.ID]*pollFilter),
+	}
+	go api.timeoutLoop()
+	return api
+}
+
+// timeoutLoop 卸载超时未访问的轮询过滤器
+func (api *FilterAPI) timeoutLoop() {
+	ticker := time.NewTicker(filterTimeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			api.mu.Lock()
+			for id, f := range api.filters {
+				if time.Since(f.lastUsed) >= filterTimeout {
+					delete(api.filters, id)
+					f.sub.Unsubscribe()
+				}
+			}
+			api.mu.Unlock()
+		case <-api.app.rootCtx.Done():
+			return
+		}
+	}
+}
+
+// GetLogs 按区块范围或区块hash、合约地址及topic查询日志
+func (api *FilterAPI) GetLogs(ctx context.Context, crit filters.FilterCriteria) ([]*RPCLog, error) {
+	logs, err := filters.NewFilter(api.backend, api.app.bloomIndexer, crit, api.app.logLimits).Logs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return api.rpcLogs(logs), nil
+}
+
+// NewFilter 创建轮询过滤器，通过GetFilterChanges获取此后新提交区块中满足条件的日志
+func (api *FilterAPI) NewFilter(crit filters.FilterCriteria) (rpc.ID, error) {
+	sub := api.app.events.SubscribeLogs(crit)
+
+	api.mu.Lock()
+	api.filters[sub.ID] = &pollFilter{sub: sub, crit: crit, lastUsed: time.Now()}
+	api.mu.Unlock()
+
+	go func() {
+		for logs := range sub.Logs() {
+			api.mu.Lock()
+			if f, ok := api.filters[sub.ID]; ok {
+				f.logs = append(f.logs, logs...)
+			}
+			api.mu.Unlock()
+		}
+	}()
+	return sub.ID, nil
+}
+
+// GetFilterChanges 返回自上次查询以来的日志
+func (api *FilterAPI) GetFilterChanges(id rpc.ID) ([]*RPCLog, error) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	f, ok := api.filters[id]
+	if !ok {
+		return nil, errFilterNotFound
+	}
+	logs := f.logs
+	f.logs = nil
+	f.lastUsed = time.Now()
+	return api.rpcLogs(logs), nil
+}
+
+// GetFilterLogs 按过滤器的条件查询已上链的日志
+func (api *FilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) ([]*RPCLog, error) {
+	api.mu.Lock()
+	f, ok := api.filters[id]
+	if ok {
+		f.lastUsed = time.Now()
+	}
+	api.mu.Unlock()
+	if !ok {
+		return nil, errFilterNotFound
+	}
+	return api.GetLogs(ctx, f.crit)
+}
+
+// UninstallFilter 卸载轮询过滤器
+func (api *FilterAPI) UninstallFilter(id rpc.ID) bool {
+	api.mu.Lock()
+	f, ok := api.filters[id]
+	delete(api.filters, id)
+	api.mu.Unlock()
+	if ok {
+		f.sub.Unsubscribe()
+	}
+	return ok
+}
+
+// Logs 订阅新提交区块中满足条件的日志，逐条推送
+func (api *FilterAPI) Logs(ctx context.Context, crit filters.FilterCriteria) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+	sub := api.app.events.SubscribeLogs(crit)
+	go func() {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case logs, ok := <-sub.Logs():
+				if !ok {
+					return
+				}
+				for _, l := range api.rpcLogs(logs) {
+					notifier.Notify(rpcSub.ID, l)
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// rpcLogs 转换为rpc格式的日志，账户体系下开启解析时附加合约地址所属的账户名
+func (api *FilterAPI) rpcLogs(logs []*statetype.Log) []*RPCLog {
+	result := newRPCLogs(logs)
+	addrs := make([]types.Address, 0, len(result))
+	for _, l := range result {
+		addrs = append(addrs, l.Address)
+	}
+	if names := api.app.resolveAccountNames(addrs); names != nil {
+		for _, l := range result {
+			l.Account = names[l.Address]
+		}
+	}
+	return result
+}
@@ -0,0 +1,175 @@
+// Package app
+//
+// @author: xwc1125
+package app
+
+import (
+	"context"
+	"fmt"
+	evm "github.com/chain5j/chain5j-evm"
+	"github.com/chain5j/chain5j-pkg/network/rpc"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-pkg/util/hexutil"
+	"github.com/chain5j/chain5j-protocol/models/vm"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
+)
+
+// EstimateGas 估算交易的gas
+// evm解析器在[TxGas, gasLimit]之间二分查找可执行的最小gas，gasLimit为0时以区块的gasLimit为上限
+// 原生解析器的gas固定，按其gas计费返回，但需在指定区块的状态上试执行交易，因此交易需签名
+func (api *API) EstimateGas(ctx context.Context, tx *stateApp.Transaction, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
+	interpreter, ok := interpreters[tx.Interpreter()]
+	if !ok || api.app.checkInterpreters(tx.Interpreter()) != nil {
+		return 0, errInvalidInterpreter
+	}
+
+	switch tx.Interpreter() {
+	case stateApp.EvmInterpreter, stateApp.EthereumInterpreter:
+		return api.estimateEvmGas(ctx, tx, blockNrOrHash)
+	}
+
+	schedule, ok := interpreter.(stateApp.GasSchedule)
+	if !ok {
+		return 0, errNotEstimable
+	}
+	db, header, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return 0, err
+	}
+	stateDB, ok := db.(*statedb.StateDB)
+	if !ok || stateDB == nil {
+		return 0, errInvalidState
+	}
+
+	// 状态为临时加载，执行结果不会保存
+	interpreterCtx, err := stateApp.NewInterpreterCtx(stateDB, nil, types.Hash{}, header, api.app.blockRW, header.GasLimit, api.app.config)
+	if err != nil {
+		return 0, err
+	}
+	if err := interpreter.VerifyTx(interpreterCtx, tx); err != nil {
+		return 0, fmt.Errorf("%v: %w", errTxAlwaysFailing, err)
+	}
+	if _, err := interpreter.ApplyTransaction(interpreterCtx, tx, new(uint64)); err != nil {
+		return 0, fmt.Errorf("%v: %w", errTxAlwaysFailing, err)
+	}
+	return hexutil.Uint64(schedule.Gas(tx)), nil
+}
+
+func (api *API) estimateEvmGas(ctx context.Context, tx *stateApp.Transaction, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
+	db, header, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return 0, err
+	}
+	if _, _, err := api.resolveCall(db, tx.From(), tx.To()); err != nil {
+		return 0, err
+	}
+
+	executable := func(gas uint64) (bool, error) {
+		args := CallMsg{From: tx.From(), To: tx.To(), Amount: tx.Value(), GasLimit: gas, Data: tx.Input()}
+		_, _, failed, err := api.doCall(ctx, args, blockNrOrHash, nil, 0, evm.Config{})
+		// 取消执行时evm以失败返回，需区分于gas不足
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return false, ctxErr
+		}
+		if err == evm.ErrOutOfGas || err == vm.ErrOutOfGas {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return !failed, nil
+	}
+	return searchGas(tx.GasLimit(), header.GasLimit, executable)
+}
+
+// searchGas 在[TxGas, gasLimit]之间二分查找可执行的最小gas，gasLimit未设置时以区块的gasLimit为上限
+// executable返回的错误与gas无关（如请求取消、状态读取失败），出现时直接返回
+func searchGas(gasLimit, blockGasLimit uint64, executable func(gas uint64) (bool, error)) (hexutil.Uint64, error) {
+	hi := gasLimit
+	if hi < stateApp.TxGas {
+		hi = blockGasLimit
+	}
+	var (
+		lo        = stateApp.TxGas - 1
+		allowance = hi
+	)
+
+	for lo+1 < hi {
+		mid := (hi + lo) / 2
+		ok, err := executable(mid)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	if hi == allowance {
+		ok, err := executable(hi)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			return 0, fmt.Errorf("%v: gas required exceeds allowance (%d)", errTxAlwaysFailing, allowance)
+		}
+	}
+	return hexutil.Uint64(hi), nil
+}
+
+// resolveCall 将调用的发送方及接收方转换为evm地址
+// 账户体系下，发送方为账户的主地址，接收方需为合约账户，与交易执行时的解析一致
+// 账户不存在时兼容直接传入的十六进制地址，发送方为空时为零地址
+func (api *API) resolveCall(db interface{}, from, to string) (types.Address, *types.Address, error) {
+	stateDB, ok := db.(*statedb.StateDB)
+	if !ok {
+		if from != "" && !types.IsHexAddress(from) {
+			return types.Address{}, nil, stateApp.ErrFromAccountNotFound
+		}
+		if to == "" {
+			return hexToAddress(from), nil, nil
+		}
+		if !types.IsHexAddress(to) {
+			return types.Address{}, nil, stateApp.ErrToAccountNotFound
+		}
+		toAddr := types.HexToAddress(to)
+		return hexToAddress(from), &toAddr, nil
+	}
+
+	var fromAddr types.Address
+	if accountFrom := stateDB.GetAccount(from); accountFrom != nil {
+		fromAddr = accountInterpreter.PrimaryAddress(accountFrom)
+	} else if from == "" || types.IsHexAddress(from) {
+		fromAddr = hexToAddress(from)
+	} else {
+		return types.Address{}, nil, stateApp.ErrFromAccountNotFound
+	}
+	if to == "" {
+		return fromAddr, nil, nil
+	}
+
+	accountTo := stateDB.GetAccount(to)
+	if accountTo == nil {
+		if !types.IsHexAddress(to) {
+			return types.Address{}, nil, stateApp.ErrToAccountNotFound
+		}
+		toAddr := types.HexToAddress(to)
+		return fromAddr, &toAddr, nil
+	}
+	if !accountTo.IsContract() {
+		return types.Address{}, nil, errNotContract
+	}
+	toAddr := types.HexToAddress(accountTo.CN)
+	return fromAddr, &toAddr, nil
+}
+
+// hexToAddress 空字符串为零地址
+func hexToAddress(s string) types.Address {
+	if s == "" {
+		return types.Address{}
+	}
+	return types.HexToAddress(s)
+}
@@ -0,0 +1,98 @@
+// Package app
+//
+// @author: xwc1125
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-stateApp"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestSearchGas(t *testing.T) {
+	errState := errors.New("state unavailable")
+	// atLeast 所需gas不少于need时可执行
+	atLeast := func(need uint64) func(uint64) (bool, error) {
+		return func(gas uint64) (bool, error) { return gas >= need, nil }
+	}
+	tests := []struct {
+		name          string
+		gasLimit      uint64
+		blockGasLimit uint64
+		executable    func(uint64) (bool, error)
+		want          uint64
+		err           error
+	}{
+		{"transfer", 100000, 1000000, atLeast(stateApp.TxGas), stateApp.TxGas, nil},
+		{"contract", 100000, 1000000, atLeast(53123), 53123, nil},
+		{"exact limit", 53123, 1000000, atLeast(53123), 53123, nil},
+		{"block limit", 0, 1000000, atLeast(700001), 700001, nil},
+		{"exceeds allowance", 50000, 1000000, atLeast(53123), 0, errTxAlwaysFailing},
+		{"state error", 100000, 1000000, func(uint64) (bool, error) { return false, errState }, 0, errState},
+		{"cancelled", 100000, 1000000, func(uint64) (bool, error) { return false, context.Canceled }, 0, context.Canceled},
+	}
+	for _, tt := range tests {
+		calls := 0
+		executable := func(gas uint64) (bool, error) {
+			calls++
+			return tt.executable(gas)
+		}
+		gas, err := searchGas(tt.gasLimit, tt.blockGasLimit, executable)
+		if tt.err != nil {
+			// gas不足的错误以errTxAlwaysFailing开头，其他错误原样返回
+			if err == nil || !(errors.Is(err, tt.err) || strings.HasPrefix(err.Error(), tt.err.Error())) {
+				t.Fatalf("%s: %v, want %v", tt.name, err, tt.err)
+			}
+			if tt.err != errTxAlwaysFailing && calls != 1 {
+				t.Fatalf("%s: executed %d times after error", tt.name, calls)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if uint64(gas) != tt.want {
+			t.Fatalf("%s: gas %d, want %d", tt.name, gas, tt.want)
+		}
+	}
+}
+
+func TestUnpackRevert(t *testing.T) {
+	word := func(n uint64) []byte {
+		return types.BigToHash(new(big.Int).SetUint64(n)).Bytes()
+	}
+	revert := func(words ...[]byte) []byte {
+		data := []byte{0x08, 0xc3, 0x79, 0xa0}
+		for _, w := range words {
+			data = append(data, w...)
+		}
+		return data
+	}
+	reason := append([]byte("denied"), make([]byte, 26)...)
+	tests := []struct {
+		name   string
+		data   []byte
+		reason string
+		ok     bool
+	}{
+		{"reason", revert(word(32), word(6), reason), "denied", true},
+		{"short", revert(word(32)), "", false},
+		{"selector", append([]byte{0, 0, 0, 0}, revert(word(32), word(6), reason)[4:]...), "", false},
+		{"offset out of range", revert(word(64), word(6), reason), "", false},
+		{"length out of range", revert(word(32), word(33), reason), "", false},
+		// 相加溢出的偏移及长度不能越界
+		{"huge offset", revert(word(math.MaxUint64-20), word(6), reason), "", false},
+		{"huge length", revert(word(32), word(math.MaxUint64-40), reason), "", false},
+	}
+	for _, tt := range tests {
+		reason, ok := unpackRevert(tt.data)
+		if reason != tt.reason || ok != tt.ok {
+			t.Fatalf("%s: %q %v", tt.name, reason, ok)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+// Package app
+//
+// @author: xwc1125
+package app
+
+import (
+	"context"
+	"github.com/chain5j/chain5j-pkg/codec"
+	"github.com/chain5j/chain5j-pkg/network/rpc"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-pkg/util/hexutil"
+	"github.com/chain5j/chain5j-protocol/models"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
+	"github.com/chain5j/chain5j-stateApp/txindex"
+	"strings"
+)
+
+const (
+	defaultHistoryPageSize = 20
+	maxHistoryPageSize     = 100
+)
+
+// HistoryQuery 交易历史的查询条件，区块范围为闭区间
+type HistoryQuery struct {
+	Interpreter string          `json:"interpreter"`
+	FromBlock   *hexutil.Uint64 `json:"from_block"`
+	ToBlock     *hexutil.Uint64 `json:"to_block"`
+	Cursor      hexutil.Bytes   `json:"cursor"` // 上一页返回的next
+	Limit       uint64          `json:"limit"`
+}
+
+// TxHistory 账户的一条交易历史
+type TxHistory struct {
+	BlockNumber      hexutil.Uint64 `json:"block_number"`
+	TransactionIndex hexutil.Uint64 `json:"transaction_index"`
+	TransactionHash  types.Hash     `json:"transaction_hash"`
+	Direction        string         `json:"direction"` // out、in或self
+	Interpreter      string         `json:"interpreter"`
+}
+
+// TxHistoryPage 按区块倒序的一页交易历史，Next为空时没有更多记录
+// 单次查询遍历的记录数有上限，按解析器过滤时一页可能少于limit条甚至为空，Next不为空时应继续查询
+type TxHistoryPage struct {
+	Account      string        `json:"account"`
+	Transactions []*TxHistory  `json:"transactions"`
+	Next         hexutil.Bytes `json:"next,omitempty"`
+}
+
+// GetTransactionHistory 查询账户发送或接收的交易，需开启交易历史索引
+// 账户体系下account为账户名，也可为账户持有的地址；以太坊模式下为地址
+func (api *API) GetTransactionHistory(ctx context.Context, account string, query HistoryQuery) (*TxHistoryPage, error) {
+	if !api.app.txHistory {
+		return nil, errTxHistoryDisabled
+	}
+	account = strings.ToLower(account)
+	if !api.app.useEthereum && types.IsHexAddress(account) {
+		db, _, err := api.backend.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+		if err != nil {
+			return nil, err
+		}
+		if owner := accountInterpreter.AddressOwner(db.(*statedb.StateDB), types.HexToAddress(account)); owner != "" {
+			account = owner
+		}
+	}
+
+	var filter txindex.HistoryFilter
+	filter.Interpreter = query.Interpreter
+	if query.FromBlock != nil {
+		filter.FromBlock = uint64(*query.FromBlock)
+	}
+	if query.ToBlock != nil {
+		to := uint64(*query.ToBlock)
+		filter.ToBlock = &to
+		if to < filter.FromBlock {
+			return nil, errInvalidBlockRange
+		}
+	}
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaultHistoryPageSize
+	}
+	if limit > maxHistoryPageSize {
+		limit = maxHistoryPageSize
+	}
+
+	histories, next, err := api.app.txIndexer.History(account, filter, query.Cursor, int(limit))
+	if err != nil {
+		return nil, err
+	}
+	page := &TxHistoryPage{
+		Account:      account,
+		Transactions: make([]*TxHistory, 0, len(histories)),
+		Next:         next,
+	}
+	for _, h := range histories {
+		page.Transactions = append(page.Transactions, &TxHistory{
+			BlockNumber:      hexutil.Uint64(h.Height),
+			TransactionIndex: hexutil.Uint64(h.TxIndex),
+			TransactionHash:  h.Hash,
+			Direction:        h.Direction,
+			Interpreter:      h.Interpreter,
+		})
+	}
+	return page, nil
+}
+
+// RebuildTxHistory 从已有的区块及收据重建交易历史索引，to为0时至当前区块，返回写入的记录数
+func (api *DebugAPI) RebuildTxHistory(ctx context.Context, from, to hexutil.Uint64) (hexutil.Uint64, error) {
+	if !api.app.txHistory {
+		return 0, errTxHistoryDisabled
+	}
+	if to == 0 {
+		to = hexutil.Uint64(api.app.blockRW.CurrentBlock().Height())
+	}
+	if from > to {
+		return 0, errInvalidBlockRange
+	}
+
+	var count uint64
+	for height := uint64(from); height <= uint64(to); height++ {
+		if err := ctx.Err(); err != nil {
+			return hexutil.Uint64(count), err
+		}
+		block := api.app.blockRW.GetBlockByNumber(height)
+		if block == nil {
+			return hexutil.Uint64(count), errBlockNotFound
+		}
+		receipts, err := api.app.db.GetReceipts(block.Hash(), height)
+		if err != nil {
+			return hexutil.Uint64(count), err
+		}
+		blockTxs := blockTxList(block)
+		txs := transactionsByHash(blockTxs)
+		for _, receipt := range receipts {
+			if txs[receipt.TransactionHash] == nil {
+				if tx := api.app.hookTransaction(receipt.TransactionHash); tx != nil {
+					txs[tx.Hash()] = tx
+				}
+			}
+		}
+
+		resolve, err := api.app.historyResolver(block.Header())
+		if err != nil {
+			return hexutil.Uint64(count), err
+		}
+		histories := txHistories(height, txOrder(blockTxs, receipts), txs, receipts, resolve)
+		if err := api.app.txIndexer.AddHistory(histories); err != nil {
+			return hexutil.Uint64(count), err
+		}
+		count += uint64(len(histories))
+	}
+	return hexutil.Uint64(count), nil
+}
+
+// historyResolver 合约创建交易的接收方，账户体系下为合约地址所属的账户名
+func (a *application) historyResolver(header *models.Header) (func(types.Address) string, error) {
+	if a.useEthereum {
+		return addressKey, nil
+	}
+	roots := statetype.NewRoots()
+	if err := codec.Coder().Decode(header.StateRoots, roots); err != nil {
+		return nil, err
+	}
+	state, err := statedb.New(roots.GetObj("STATE"), a.kvDB)
+	if err != nil {
+		return nil, err
+	}
+	return stateResolver(state), nil
+}
+
+func stateResolver(state *statedb.StateDB) func(types.Address) string {
+	return func(addr types.Address) string {
+		if owner := accountInterpreter.AddressOwner(state, addr); owner != "" {
+			return owner
+		}
+		return addressKey(addr)
+	}
+}
+
+func addressKey(addr types.Address) string {
+	return strings.ToLower(addr.Hex())
+}
+
+// txHistories 由区块执行的交易及收据生成交易历史，order为按位置排列的交易hash，见txOrder，没有对应交易的系统收据不记录
+// 除交易的发送方及接收方外，收据中转账日志的接收方同样记为接收
+func txHistories(height uint64, order []types.Hash, txs map[types.Hash]*stateApp.Transaction, receipts []*statetype.Receipt, resolve func(types.Address) string) []*txindex.History {
+	byHash := make(map[types.Hash]*statetype.Receipt, len(receipts))
+	for _, receipt := range receipts {
+		byHash[receipt.TransactionHash] = receipt
+	}
+
+	var histories []*txindex.History
+	for i, hash := range order {
+		tx, receipt := txs[hash], byHash[hash]
+		if tx == nil || receipt == nil {
+			continue
+		}
+		from, to := tx.From(), tx.To()
+		if to == "" && receipt.ContractAddress != (types.Address{}) {
+			to = resolve(receipt.ContractAddress)
+		}
+		add := func(account, direction string) {
+			histories = append(histories, &txindex.History{
+				Account:     account,
+				Height:      height,
+				TxIndex:     uint64(i),
+				Hash:        receipt.TransactionHash,
+				Direction:   direction,
+				Interpreter: tx.Interpreter(),
+			})
+		}
+		added := make(map[string]bool)
+		if from == to {
+			add(from, txindex.DirectionSelf)
+			added[from] = true
+		} else {
+			if from != "" {
+				add(from, txindex.DirectionOut)
+				added[from] = true
+			}
+			if to != "" {
+				add(to, txindex.DirectionIn)
+				added[to] = true
+			}
+		}
+		// 托管释放、归属计划及合约内部调用等转账的接收方，已记录的账户不重复记录
+		for _, l := range receipt.Logs {
+			transfer, ok := stateApp.DecodeTransferLog(l)
+			if !ok {
+				continue
+			}
+			account := transfer.To
+			if types.IsHexAddress(account) {
+				account = resolve(types.HexToAddress(account))
+			}
+			if account != "" && !added[account] {
+				add(account, txindex.DirectionIn)
+				added[account] = true
+			}
+		}
+	}
+	return histories
+}
@@ -0,0 +1,158 @@
+// Package app
+//
+// @author: xwc1125
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-pkg/util/hexutil"
+	"github.com/chain5j/chain5j-protocol/models"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/txindex"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+var (
+	historyAlice    = types.HexToAddress("0x00000000000000000000000000000000000000e1")
+	historyContract = types.HexToAddress("0x00000000000000000000000000000000000000e2")
+	historyUnknown  = types.HexToAddress("0x00000000000000000000000000000000000000e3")
+)
+
+func TestTxHistories(t *testing.T) {
+	resolve := func(addr types.Address) string {
+		if addr == historyContract {
+			return "contract@bank"
+		}
+		return addressKey(addr)
+	}
+	newTx := func(from, to string, nonce uint64) *stateApp.Transaction {
+		return stateApp.NewTransaction(from, to, "", nonce, 0, stateApp.TxGas, big.NewInt(1), nil, 0, nil)
+	}
+	var (
+		transfer = newTx("alice@bank", "bob@bank", 0)
+		self     = newTx("alice@bank", "alice@bank", 1)
+		deploy   = newTx("alice@bank", "", 2)
+		resolved = newTx("carol@bank", "carol@bank", 0)
+		internal = newTx("alice@bank", "contract@bank", 3)
+	)
+	receipts := []*statetype.Receipt{
+		{TransactionHash: types.Hash{0xff}}, // 没有对应交易的系统收据
+		{TransactionHash: transfer.Hash()},
+		{TransactionHash: self.Hash()},
+		{TransactionHash: deploy.Hash(), ContractAddress: historyContract},
+		{TransactionHash: resolved.Hash(), Logs: []*statetype.Log{
+			stateApp.NewTransferLog("escrow", "alice@bank", big.NewInt(1), 1),
+			stateApp.NewTransferLog("escrow", "carol@bank", big.NewInt(1), 1), // 已记录的账户不重复记录
+		}},
+		{TransactionHash: internal.Hash(), Logs: []*statetype.Log{
+			{Address: historyContract}, // 合约自身的日志
+			stateApp.NewTransferLog(historyContract.Hex(), historyUnknown.Hex(), big.NewInt(1), 1),
+			stateApp.NewTransferLog(historyContract.Hex(), "bob@bank", big.NewInt(1), 1),
+		}},
+	}
+	txs := transactionsByHash([]models.Transaction{transfer, self, deploy, resolved, internal})
+	order := []types.Hash{transfer.Hash(), self.Hash(), deploy.Hash(), resolved.Hash(), internal.Hash(), {0xff}}
+
+	want := []string{
+		"alice@bank:out:0", "bob@bank:in:0",
+		"alice@bank:self:1",
+		"alice@bank:out:2", "contract@bank:in:2",
+		"carol@bank:self:3", "alice@bank:in:3",
+		"alice@bank:out:4", "contract@bank:in:4", addressKey(historyUnknown) + ":in:4", "bob@bank:in:4",
+	}
+	var got []string
+	for _, h := range txHistories(7, order, txs, receipts, resolve) {
+		if h.Height != 7 {
+			t.Fatalf("history %+v at height %d", h, h.Height)
+		}
+		got = append(got, fmt.Sprintf("%s:%s:%d", h.Account, h.Direction, h.TxIndex))
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("%v, want %v", got, want)
+	}
+}
+
+// newHistoryTestApp 开启交易历史的应用，账户体系下alice@bank持有地址historyAlice
+// 高度0至2各有alice的一条历史，高度1的为托管交易
+func newHistoryTestApp(t *testing.T, useEthereum bool) (*application, string) {
+	a := newTestApp(t, useEthereum, 2, func(db interface{}) {
+		if state, ok := db.(*statedb.StateDB); ok {
+			store := accounts.NewAccountStore("alice", "bank")
+			store.SetAddress(historyAlice, nil)
+			state.CreateAccount(store)
+		}
+	})
+	a.txHistory = true
+	a.txIndexer = txindex.NewIndexer(a.kvDB)
+	account := "alice@bank"
+	if useEthereum {
+		account = addressKey(historyAlice)
+	}
+	var histories []*txindex.History
+	for height := uint64(0); height <= 2; height++ {
+		h := &txindex.History{Account: account, Height: height, Hash: types.Hash{byte(height + 1)}, Direction: txindex.DirectionOut}
+		if height == 1 {
+			h.Interpreter = stateApp.EscrowInterpreter
+		}
+		histories = append(histories, h)
+	}
+	if err := a.txIndexer.AddHistory(histories); err != nil {
+		t.Fatal(err)
+	}
+	return a, account
+}
+
+func TestAPI_GetTransactionHistory(t *testing.T) {
+	block := func(n uint64) *hexutil.Uint64 {
+		b := hexutil.Uint64(n)
+		return &b
+	}
+	for _, useEthereum := range []bool{false, true} {
+		a, account := newHistoryTestApp(t, useEthereum)
+		api := a.newAPI()
+		tests := []struct {
+			name    string
+			account string
+			query   HistoryQuery
+			want    []uint64 // 返回记录的高度
+			err     error
+		}{
+			{"all", account, HistoryQuery{}, []uint64{2, 1, 0}, nil},
+			// 账户体系下地址解析为持有该地址的账户，以太坊模式下统一为小写
+			{"by address", "0x" + strings.ToUpper(historyAlice.Hex()[2:]), HistoryQuery{}, []uint64{2, 1, 0}, nil},
+			{"block 0", account, HistoryQuery{ToBlock: block(0)}, []uint64{0}, nil},
+			{"range", account, HistoryQuery{FromBlock: block(1), ToBlock: block(2)}, []uint64{2, 1}, nil},
+			{"interpreter", account, HistoryQuery{Interpreter: stateApp.EscrowInterpreter}, []uint64{1}, nil},
+			{"invalid range", account, HistoryQuery{FromBlock: block(2), ToBlock: block(1)}, nil, errInvalidBlockRange},
+		}
+		for _, tt := range tests {
+			page, err := api.GetTransactionHistory(context.Background(), tt.account, tt.query)
+			if err != tt.err {
+				t.Fatalf("ethereum=%v %s: %v, want %v", useEthereum, tt.name, err, tt.err)
+			}
+			if err != nil {
+				continue
+			}
+			if page.Account != account || len(page.Transactions) != len(tt.want) || page.Next != nil {
+				t.Fatalf("ethereum=%v %s: %+v", useEthereum, tt.name, page)
+			}
+			for i, tx := range page.Transactions {
+				if uint64(tx.BlockNumber) != tt.want[i] || tx.TransactionHash != (types.Hash{byte(tt.want[i] + 1)}) {
+					t.Fatalf("ethereum=%v %s: tx %d %+v", useEthereum, tt.name, i, tx)
+				}
+			}
+		}
+
+		a.txHistory = false
+		if _, err := api.GetTransactionHistory(context.Background(), account, HistoryQuery{}); err != errTxHistoryDisabled {
+			t.Fatalf("ethereum=%v disabled: %v", useEthereum, err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Package app
+//
+// @author: xwc1125
+package app
+
+import (
+	"fmt"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-pkg/util/hexutil"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-protocol/pkg/database/ethStatedb"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
+	"math/big"
+)
+
+// OverrideAccount 执行前对账户状态的覆盖，字段为空时不覆盖
+// State替换合约的全部存储，StateDiff仅覆盖指定的槽位，两者不能同时设置
+// Frozen及Permissions仅账户体系下可用，Permissions仅可覆盖管理员账户
+type OverrideAccount struct {
+	Balance     *hexutil.Big               `json:"balance"`
+	Nonce       *hexutil.Uint64            `json:"nonce"`
+	Code        *hexutil.Bytes             `json:"code"`
+	State       *map[types.Hash]types.Hash `json:"state"`
+	StateDiff   *map[types.Hash]types.Hash `json:"state_diff"`
+	Frozen      *bool                      `json:"frozen"`
+	Permissions *accounts.Permissions      `json:"permissions"`
+}
+
+// StateOverride 状态覆盖，key为账户名或地址，以太坊模式下只能为地址
+type StateOverride map[string]OverrideAccount
+
+// Apply 将覆盖写入状态，状态需为临时加载的副本
+func (diff *StateOverride) Apply(state interface{}) error {
+	if diff == nil {
+		return nil
+	}
+	switch state := state.(type) {
+	case *statedb.StateDB:
+		for key, override := range *diff {
+			if err := applyAccountOverride(state, key, override); err != nil {
+				return fmt.Errorf("override %s: %w", key, err)
+			}
+		}
+		return nil
+	case *ethStatedb.StateDB:
+		for key, override := range *diff {
+			if err := applyEthOverride(state, key, override); err != nil {
+				return fmt.Errorf("override %s: %w", key, err)
+			}
+		}
+		return nil
+	}
+	return errInvalidState
+}
+
+// applyAccountOverride 账户体系下的覆盖，地址未被任何账户持有且需设置代码时创建合约账户
+func applyAccountOverride(state *statedb.StateDB, key string, override OverrideAccount) error {
+	name := key
+	if types.IsHexAddress(key) {
+		addr := types.HexToAddress(key)
+		if name = state.GetOwner(addr); name == "" && override.Code != nil {
+			statedb.NewEvmStateDB(state).CreateAccount(addr)
+			name = state.GetOwner(addr)
+		}
+	}
+	// 管理员身份及权限以域管理员转移后的为准
+	account := accountInterpreter.GetAccount(state, name)
+	if account == nil {
+		return errOverrideNotFound
+	}
+	if override.State != nil && override.StateDiff != nil {
+		return errOverrideConflict
+	}
+	if (override.Code != nil || override.State != nil || override.StateDiff != nil) && !account.IsContract() {
+		return errOverrideContract
+	}
+	// 非管理员账户的权限不生效
+	if override.Permissions != nil && !account.IsAdmin {
+		return errOverrideAdmin
+	}
+
+	if override.Balance != nil {
+		state.SetBalance(name, (*big.Int)(override.Balance))
+	}
+	if override.Nonce != nil {
+		state.SetNonce(name, uint64(*override.Nonce))
+	}
+	if override.Code != nil {
+		state.SetCode(name, *override.Code)
+	}
+	if override.State != nil {
+		// 先清空已有的存储
+		var keys []types.Hash
+		if err := state.ForEachStorage(name, func(key, value types.Hash) bool {
+			keys = append(keys, key)
+			return true
+		}); err != nil {
+			return err
+		}
+		for _, key := range keys {
+			state.SetState(name, key, types.Hash{})
+		}
+		for key, value := range *override.State {
+			state.SetState(name, key, value)
+		}
+	}
+	if override.StateDiff != nil {
+		for key, value := range *override.StateDiff {
+			state.SetState(name, key, value)
+		}
+	}
+	if override.Frozen != nil {
+		accountInterpreter.SetAccountFrozen(state, account.AccountName(), *override.Frozen)
+	}
+	if override.Permissions != nil {
+		accountInterpreter.SetAdminPermissions(state, account.CN, account.Domain, override.Permissions)
+	}
+	return nil
+}
+
+// applyEthOverride 以太坊模式下的覆盖，不存在的地址会被创建
+func applyEthOverride(state *ethStatedb.StateDB, key string, override OverrideAccount) error {
+	if !types.IsHexAddress(key) {
+		return errOverrideNotFound
+	}
+	if override.Frozen != nil || override.Permissions != nil {
+		return errOverrideAccount
+	}
+	if override.State != nil && override.StateDiff != nil {
+		return errOverrideConflict
+	}
+	addr := types.HexToAddress(key)
+
+	if override.Balance != nil {
+		state.SetBalance(addr, (*big.Int)(override.Balance))
+	}
+	if override.Nonce != nil {
+		state.SetNonce(addr, uint64(*override.Nonce))
+	}
+	if override.Code != nil {
+		state.SetCode(addr, *override.Code)
+	}
+	if override.State != nil {
+		var keys []types.Hash
+		if err := state.ForEachStorage(addr, func(key, value types.Hash) bool {
+			keys = append(keys, key)
+			return true
+		}); err != nil {
+			return err
+		}
+		for _, key := range keys {
+			state.SetState(addr, key, types.Hash{})
+		}
+		for key, value := range *override.State {
+			state.SetState(addr, key, value)
+		}
+	}
+	if override.StateDiff != nil {
+		for key, value := range *override.StateDiff {
+			state.SetState(addr, key, value)
+		}
+	}
+	return nil
+}
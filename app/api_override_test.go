@@ -0,0 +1,225 @@
+// Package app
+//
+// @author: xwc1125
+package app
+
+import (
+	"bytes"
+	"errors"
+	"github.com/chain5j/chain5j-pkg/database/kvstore/memorydb"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-pkg/util/hexutil"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-protocol/pkg/database/ethStatedb"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
+	"math/big"
+	"testing"
+)
+
+var (
+	overrideContract = types.HexToAddress("0x00000000000000000000000000000000000000aa")
+	overrideNew      = types.HexToAddress("0x00000000000000000000000000000000000000bb")
+	overrideSlot1    = types.BigToHash(big.NewInt(1))
+	overrideSlot2    = types.BigToHash(big.NewInt(2))
+	overrideValue    = types.BigToHash(big.NewInt(42))
+)
+
+func overrideBalance(n int64) *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(n))
+}
+
+func overrideNonce(n uint64) *hexutil.Uint64 {
+	nonce := hexutil.Uint64(n)
+	return &nonce
+}
+
+func overrideCode(code string) *hexutil.Bytes {
+	data := hexutil.Bytes(hexutil.MustDecode(code))
+	return &data
+}
+
+func overrideStorage(kvs map[types.Hash]types.Hash) *map[types.Hash]types.Hash {
+	return &kvs
+}
+
+func overrideBool(b bool) *bool {
+	return &b
+}
+
+// newOverrideState 账户体系下的状态，包含用户alice@bank、已冻结的carol@bank、管理员admin@bank及存储了slot1的合约
+// 域fund的管理员已由old转移给new
+func newOverrideState(t *testing.T) (*statedb.StateDB, string) {
+	db := memorydb.New()
+	state, err := statedb.New(types.Hash{}, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := accounts.NewAccountStore("alice", "bank")
+	store.Balance = big.NewInt(1000)
+	state.CreateAccount(store)
+	admin := accounts.NewAccountStore("admin", "bank")
+	admin.IsAdmin = true
+	state.CreateAccount(admin)
+	carol := accounts.NewAccountStore("carol", "bank")
+	carol.IsFrozen = true
+	state.CreateAccount(carol)
+	setRegistry(t, state, &accountInterpreter.FreezeRecord{Operator: "admin@bank"}, "freeze", "carol@bank")
+
+	former := accounts.NewAccountStore("old", "fund")
+	former.IsAdmin = true
+	state.CreateAccount(former)
+	state.CreateAccount(accounts.NewAccountStore("new", "fund"))
+	setRegistry(t, state, &accountInterpreter.DomainMeta{Admin: "new", FormerAdmins: []string{"old"}}, "domain", "fund")
+
+	statedb.NewEvmStateDB(state).CreateAccount(overrideContract)
+	contract := state.GetOwner(overrideContract)
+	state.SetCode(contract, []byte{0x00})
+	state.SetState(contract, overrideSlot1, overrideValue)
+	// 提交后存储可被遍历
+	root, err := state.Commit(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state, err = statedb.New(root, db); err != nil {
+		t.Fatal(err)
+	}
+	return state, contract
+}
+
+func TestStateOverride_Account(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		diff  OverrideAccount
+		err   error
+		check func(state *statedb.StateDB, contract string) bool
+	}{
+		{"balance and nonce", "alice@bank", OverrideAccount{Balance: overrideBalance(7), Nonce: overrideNonce(3)}, nil,
+			func(state *statedb.StateDB, contract string) bool {
+				return state.GetBalance("alice@bank").Int64() == 7 && state.GetNonce("alice@bank") == 3
+			}},
+		{"frozen", "alice@bank", OverrideAccount{Frozen: overrideBool(true)}, nil,
+			func(state *statedb.StateDB, contract string) bool {
+				store := state.GetAccount("alice@bank")
+				return store.IsFrozen && accountInterpreter.IsAccountFrozen(state, store, 1000)
+			}},
+		{"unfrozen", "carol@bank", OverrideAccount{Frozen: overrideBool(false)}, nil,
+			func(state *statedb.StateDB, contract string) bool {
+				return !state.GetAccount("carol@bank").IsFrozen && accountInterpreter.GetAccountFreeze(state, "carol@bank") == nil
+			}},
+		{"permissions", "admin@bank", OverrideAccount{Permissions: &accounts.Permissions{EnableRegisterUser: true}}, nil,
+			func(state *statedb.StateDB, contract string) bool {
+				p := state.GetAccount("admin@bank").Permissions
+				return p != nil && p.EnableRegisterUser
+			}},
+		{"permissions on transferred admin", "new@fund", OverrideAccount{Permissions: &accounts.Permissions{EnableRegisterUser: true}}, nil,
+			func(state *statedb.StateDB, contract string) bool {
+				p := accountInterpreter.GetAccount(state, "new@fund").Permissions
+				return p != nil && p.EnableRegisterUser
+			}},
+		{"permissions on former admin", "old@fund", OverrideAccount{Permissions: &accounts.Permissions{}}, errOverrideAdmin, nil},
+		{"contract by address", overrideContract.Hex(), OverrideAccount{Code: overrideCode("0x6001")}, nil,
+			func(state *statedb.StateDB, contract string) bool {
+				return bytes.Equal(state.GetCode(contract), []byte{0x60, 0x01})
+			}},
+		{"state replaces storage", overrideContract.Hex(), OverrideAccount{State: overrideStorage(map[types.Hash]types.Hash{overrideSlot2: overrideValue})}, nil,
+			func(state *statedb.StateDB, contract string) bool {
+				return state.GetState(contract, overrideSlot1) == (types.Hash{}) && state.GetState(contract, overrideSlot2) == overrideValue
+			}},
+		{"state diff keeps storage", overrideContract.Hex(), OverrideAccount{StateDiff: overrideStorage(map[types.Hash]types.Hash{overrideSlot2: overrideValue})}, nil,
+			func(state *statedb.StateDB, contract string) bool {
+				return state.GetState(contract, overrideSlot1) == overrideValue && state.GetState(contract, overrideSlot2) == overrideValue
+			}},
+		{"new contract", overrideNew.Hex(), OverrideAccount{Code: overrideCode("0x6002")}, nil,
+			func(state *statedb.StateDB, contract string) bool {
+				owner := state.GetOwner(overrideNew)
+				return owner != "" && bytes.Equal(state.GetCode(owner), []byte{0x60, 0x02})
+			}},
+		{"unknown account", "bob@bank", OverrideAccount{Balance: overrideBalance(1)}, errOverrideNotFound, nil},
+		{"unknown address", overrideNew.Hex(), OverrideAccount{Balance: overrideBalance(1)}, errOverrideNotFound, nil},
+		{"permissions on user", "alice@bank", OverrideAccount{Permissions: &accounts.Permissions{}}, errOverrideAdmin, nil},
+		{"code on user", "alice@bank", OverrideAccount{Code: overrideCode("0x6001")}, errOverrideContract, nil},
+		{"state conflict", overrideContract.Hex(), OverrideAccount{
+			State:     overrideStorage(map[types.Hash]types.Hash{}),
+			StateDiff: overrideStorage(map[types.Hash]types.Hash{}),
+		}, errOverrideConflict, nil},
+	}
+	for _, tt := range tests {
+		state, contract := newOverrideState(t)
+		diff := StateOverride{tt.key: tt.diff}
+		err := diff.Apply(state)
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+		if tt.check != nil && !tt.check(state, contract) {
+			t.Fatalf("%s: override not applied", tt.name)
+		}
+	}
+}
+
+func TestStateOverride_Eth(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		diff  OverrideAccount
+		err   error
+		check func(state *ethStatedb.StateDB) bool
+	}{
+		{"balance nonce code", overrideNew.Hex(), OverrideAccount{Balance: overrideBalance(7), Nonce: overrideNonce(3), Code: overrideCode("0x6001")}, nil,
+			func(state *ethStatedb.StateDB) bool {
+				return state.GetBalance(overrideNew).Int64() == 7 && state.GetNonce(overrideNew) == 3 &&
+					bytes.Equal(state.GetCode(overrideNew), []byte{0x60, 0x01})
+			}},
+		{"state replaces storage", overrideContract.Hex(), OverrideAccount{State: overrideStorage(map[types.Hash]types.Hash{overrideSlot2: overrideValue})}, nil,
+			func(state *ethStatedb.StateDB) bool {
+				return state.GetState(overrideContract, overrideSlot1) == (types.Hash{}) && state.GetState(overrideContract, overrideSlot2) == overrideValue
+			}},
+		{"state diff keeps storage", overrideContract.Hex(), OverrideAccount{StateDiff: overrideStorage(map[types.Hash]types.Hash{overrideSlot2: overrideValue})}, nil,
+			func(state *ethStatedb.StateDB) bool {
+				return state.GetState(overrideContract, overrideSlot1) == overrideValue && state.GetState(overrideContract, overrideSlot2) == overrideValue
+			}},
+		{"account name", "alice@bank", OverrideAccount{Balance: overrideBalance(1)}, errOverrideNotFound, nil},
+		{"frozen", overrideContract.Hex(), OverrideAccount{Frozen: overrideBool(true)}, errOverrideAccount, nil},
+		{"permissions", overrideContract.Hex(), OverrideAccount{Permissions: &accounts.Permissions{}}, errOverrideAccount, nil},
+		{"state conflict", overrideContract.Hex(), OverrideAccount{
+			State:     overrideStorage(map[types.Hash]types.Hash{}),
+			StateDiff: overrideStorage(map[types.Hash]types.Hash{}),
+		}, errOverrideConflict, nil},
+	}
+	for _, tt := range tests {
+		state, err := ethStatedb.New(types.Hash{}, ethStatedb.NewDatabase(memorydb.New()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		state.SetCode(overrideContract, []byte{0x00})
+		state.SetState(overrideContract, overrideSlot1, overrideValue)
+		// 提交后存储可被遍历
+		root, err := state.Commit(true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state, err = ethStatedb.New(root, state.Database()); err != nil {
+			t.Fatal(err)
+		}
+
+		diff := StateOverride{tt.key: tt.diff}
+		if err := diff.Apply(state); !errors.Is(err, tt.err) {
+			t.Fatalf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+		if tt.check != nil && !tt.check(state) {
+			t.Fatalf("%s: override not applied", tt.name)
+		}
+	}
+}
+
+func TestStateOverride_InvalidState(t *testing.T) {
+	var empty *StateOverride
+	if err := empty.Apply(nil); err != nil {
+		t.Fatalf("nil override: %v", err)
+	}
+	diff := StateOverride{}
+	if err := diff.Apply(nil); err != errInvalidState {
+		t.Fatalf("%v, want %v", err, errInvalidState)
+	}
+}
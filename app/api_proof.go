@@ -0,0 +1,321 @@
+// Package app
+//
+// @author: xwc1125
+package app
+
+import (
+	"context"
+	"github.com/chain5j/chain5j-pkg/codec"
+	"github.com/chain5j/chain5j-pkg/network/rpc"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-pkg/util/hexutil"
+	"github.com/chain5j/chain5j-protocol/models"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"github.com/chain5j/chain5j-protocol/pkg/database/basedb"
+	"github.com/chain5j/chain5j-protocol/pkg/database/ethStatedb"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
+	"github.com/chain5j/chain5j-stateApp/statediff"
+	"math/big"
+	"strings"
+)
+
+// ProofRoots 账户体系下组成状态根的各树的根，状态根为四者拼接后的keccak256
+type ProofRoots struct {
+	AccountRoot types.Hash `json:"account_root"`
+	MapRoot     types.Hash `json:"map_root"`
+	KVSRoot     types.Hash `json:"kvs_root"`
+	XRoot       types.Hash `json:"x_root"`
+}
+
+// StorageProof 合约存储槽位的证明，以账户的storage_hash为根
+type StorageProof struct {
+	Key   types.Hash      `json:"key"`
+	Value types.Hash      `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// DomainProof 域记录的证明，以x_root为根，树中的key为"domains-"+域名，值为rlp编码的DomainStore
+// Admin、ExpiryHeight及Frozen为block_number时生效的值，由registry中DomainMeta及域冻结记录的证明得出
+type DomainProof struct {
+	Domain       string                `json:"domain"`
+	Proof        []hexutil.Bytes       `json:"proof"`
+	Record       *accounts.DomainStore `json:"record,omitempty"` // 域不存在时为空
+	Admin        string                `json:"admin"`            // 当前管理员，管理员转移后不再是record中的admin
+	ExpiryHeight hexutil.Uint64        `json:"expiry_height"`    // 所在顶级域的过期高度，为0表示不过期
+	Frozen       bool                  `json:"frozen"`           // 域或其上级域是否被冻结
+}
+
+// SystemRecordProof 系统账户中一条记录的证明，以系统账户的storage_hash为根
+// 记录rlp编码后按32字节分段存储，Slots依次为长度槽位keccak256(key)及各数据槽位keccak256(keccak256(key))+i
+type SystemRecordProof struct {
+	Key   string          `json:"key"`
+	Slots []*StorageProof `json:"slots"`
+}
+
+// RegistryProof 域注册系统账户的证明，DomainMeta及冻结记录等扩展状态存储在该账户的storage中
+type RegistryProof struct {
+	Account      string               `json:"account"`
+	AccountProof []hexutil.Bytes      `json:"account_proof"`
+	StorageHash  types.Hash           `json:"storage_hash"`
+	Records      []*SystemRecordProof `json:"records"`
+}
+
+// AccountProof 账户的默克尔证明，以区块头StateRoots中STATE的值为根
+// 账户体系下账户证明以account_root为根，树中的key为账户全名，值为rlp编码的AccountStore，并附带账户所在域的证明
+// 以太坊模式下与eth_getProof一致，账户证明直接以状态根为根
+// 账户树中的is_frozen为原始标记，frozen为block_number时生效的冻结状态，由registry中账户冻结记录的证明得出
+type AccountProof struct {
+	Account      string          `json:"account"` // 账户体系下为账户全名，以太坊模式下为地址
+	BlockNumber  hexutil.Uint64  `json:"block_number"`
+	StateRoot    types.Hash      `json:"state_root"`
+	Roots        *ProofRoots     `json:"roots,omitempty"`
+	AccountProof []hexutil.Bytes `json:"account_proof"`
+	Balance      *hexutil.Big    `json:"balance"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	IsFrozen     bool            `json:"is_frozen"`
+	Frozen       bool            `json:"frozen"`
+	CodeHash     types.Hash      `json:"code_hash"`
+	StorageHash  types.Hash      `json:"storage_hash"`
+	StorageProof []*StorageProof `json:"storage_proof"`
+	Domain       *DomainProof    `json:"domain,omitempty"`
+	Registry     *RegistryProof  `json:"registry,omitempty"`
+}
+
+// StateDomainProof 单独查询的域记录证明
+type StateDomainProof struct {
+	BlockNumber hexutil.Uint64 `json:"block_number"`
+	StateRoot   types.Hash     `json:"state_root"`
+	Roots       *ProofRoots    `json:"roots"`
+	*DomainProof
+	Registry *RegistryProof `json:"registry"`
+}
+
+// GetProof 账户及合约存储的默克尔证明，账户不存在时返回不存在的证明
+// 账户体系下account为账户名或账户持有的地址，以太坊模式下为地址
+func (api *API) GetProof(ctx context.Context, account string, storageKeys []types.Hash, blockNrOrHash rpc.BlockNumberOrHash) (*AccountProof, error) {
+	db, header, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if db == nil || err != nil {
+		return nil, err
+	}
+	stateRoot, err := headerStateRoot(header)
+	if err != nil {
+		return nil, err
+	}
+	if api.app.useEthereum {
+		if !types.IsHexAddress(account) {
+			return nil, accountNotFound(account)
+		}
+		return ethAccountProof(db.(*ethStatedb.StateDB), header.Height, stateRoot, types.HexToAddress(account), storageKeys)
+	}
+
+	state := db.(*statedb.StateDB)
+	roots, err := statediff.ReadRoots(api.app.kvDB, stateRoot)
+	if err != nil {
+		return nil, err
+	}
+	treeDB := basedb.NewDatabase(api.app.kvDB)
+
+	account = strings.ToLower(account)
+	if types.IsHexAddress(account) {
+		if owner := accountInterpreter.AddressOwner(state, types.HexToAddress(account)); owner != "" {
+			account = owner
+		}
+	}
+	result := &AccountProof{
+		Account:      account,
+		BlockNumber:  hexutil.Uint64(header.Height),
+		StateRoot:    stateRoot,
+		Roots:        (*ProofRoots)(roots),
+		Balance:      (*hexutil.Big)(new(big.Int)),
+		StorageHash:  types.EmptyRootHash,
+		StorageProof: make([]*StorageProof, 0, len(storageKeys)),
+	}
+	store := state.GetAccount(account)
+	if store != nil {
+		// 合约账户可省略域，树中的key为账户全名
+		result.Account = store.AccountName()
+		result.Balance = (*hexutil.Big)(store.Balance)
+		result.Nonce = hexutil.Uint64(store.Nonce)
+		result.IsFrozen = store.IsFrozen
+		result.Frozen = accountInterpreter.IsAccountFrozen(state, store, header.Height)
+		if store.IsContract() {
+			result.CodeHash = types.BytesToHash(store.CodeHash())
+			result.StorageHash = store.StorageRoot()
+		}
+	}
+	if result.AccountProof, err = proveKey(treeDB, roots.AccountRoot, []byte(result.Account)); err != nil {
+		return nil, err
+	}
+	for _, key := range storageKeys {
+		proof := &StorageProof{Key: key}
+		if store != nil && store.IsContract() {
+			proof.Value = state.GetState(result.Account, key)
+		}
+		if proof.Proof, err = proveKey(treeDB, result.StorageHash, key.Bytes()); err != nil {
+			return nil, err
+		}
+		result.StorageProof = append(result.StorageProof, proof)
+	}
+
+	domain := ""
+	if store != nil {
+		domain = store.Domain
+	} else if i := strings.Index(account, accounts.DomainLinkFlag); i >= 0 {
+		domain = account[i+len(accounts.DomainLinkFlag):]
+	}
+	if domain != "" {
+		if result.Domain, err = proveDomain(state, treeDB, roots.XRoot, domain, header.Height); err != nil {
+			return nil, err
+		}
+	}
+	if result.Registry, err = proveRegistry(state, treeDB, roots.AccountRoot, accountInterpreter.StatusKeys(result.Account, domain)); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetDomainProof 域记录的默克尔证明，域不存在时返回不存在的证明
+func (api *API) GetDomainProof(ctx context.Context, domain string, blockNrOrHash rpc.BlockNumberOrHash) (*StateDomainProof, error) {
+	if api.app.useEthereum {
+		return nil, errAccountAPIUnavailable
+	}
+	db, header, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if db == nil || err != nil {
+		return nil, err
+	}
+	stateRoot, err := headerStateRoot(header)
+	if err != nil {
+		return nil, err
+	}
+	roots, err := statediff.ReadRoots(api.app.kvDB, stateRoot)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		state  = db.(*statedb.StateDB)
+		treeDB = basedb.NewDatabase(api.app.kvDB)
+	)
+	domain = strings.ToLower(domain)
+	proof, err := proveDomain(state, treeDB, roots.XRoot, domain, header.Height)
+	if err != nil {
+		return nil, err
+	}
+	registry, err := proveRegistry(state, treeDB, roots.AccountRoot, accountInterpreter.StatusKeys("", domain))
+	if err != nil {
+		return nil, err
+	}
+	return &StateDomainProof{
+		BlockNumber: hexutil.Uint64(header.Height),
+		StateRoot:   stateRoot,
+		Roots:       (*ProofRoots)(roots),
+		DomainProof: proof,
+		Registry:    registry,
+	}, nil
+}
+
+func proveDomain(state *statedb.StateDB, db basedb.Database, xRoot types.Hash, domain string, height uint64) (*DomainProof, error) {
+	proof, err := proveKey(db, xRoot, statediff.DomainKey(domain))
+	if err != nil {
+		return nil, err
+	}
+	return &DomainProof{
+		Domain:       domain,
+		Proof:        proof,
+		Record:       state.GetDomain(domain),
+		Admin:        accountInterpreter.DomainAdmin(state, domain),
+		ExpiryHeight: hexutil.Uint64(accountInterpreter.DomainExpiryHeight(state, domain)),
+		Frozen:       accountInterpreter.IsDomainFrozen(state, domain, height),
+	}, nil
+}
+
+// proveRegistry 域注册系统账户及其中各记录所在槽位的证明，记录不存在时证明其长度槽位为空
+func proveRegistry(state *statedb.StateDB, db basedb.Database, accountRoot types.Hash, keys [][]byte) (*RegistryProof, error) {
+	result := &RegistryProof{
+		Account:     stateApp.SystemAccountName(stateApp.DomainRegistryAddress),
+		StorageHash: types.EmptyRootHash,
+		Records:     make([]*SystemRecordProof, 0, len(keys)),
+	}
+	if store := state.GetAccount(result.Account); store != nil {
+		result.StorageHash = store.StorageRoot()
+	}
+	var err error
+	if result.AccountProof, err = proveKey(db, accountRoot, []byte(result.Account)); err != nil {
+		return nil, err
+	}
+	system := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress)
+	for _, key := range keys {
+		record := &SystemRecordProof{Key: string(key)}
+		for _, slot := range system.Slots(key) {
+			proof := &StorageProof{Key: slot, Value: state.GetState(result.Account, slot)}
+			if proof.Proof, err = proveKey(db, result.StorageHash, slot.Bytes()); err != nil {
+				return nil, err
+			}
+			record.Slots = append(record.Slots, proof)
+		}
+		result.Records = append(result.Records, record)
+	}
+	return result, nil
+}
+
+// ethAccountProof 以太坊模式下的证明，与eth_getProof一致
+func ethAccountProof(state *ethStatedb.StateDB, height uint64, stateRoot types.Hash, addr types.Address, storageKeys []types.Hash) (*AccountProof, error) {
+	accountProof, err := state.GetProof(addr)
+	if err != nil {
+		return nil, err
+	}
+	result := &AccountProof{
+		Account:      strings.ToLower(addr.Hex()),
+		BlockNumber:  hexutil.Uint64(height),
+		StateRoot:    stateRoot,
+		AccountProof: toHexSlice(accountProof),
+		Balance:      (*hexutil.Big)(state.GetBalance(addr)),
+		Nonce:        hexutil.Uint64(state.GetNonce(addr)),
+		CodeHash:     state.GetCodeHash(addr),
+		StorageHash:  types.EmptyRootHash,
+		StorageProof: make([]*StorageProof, 0, len(storageKeys)),
+	}
+	storage := state.StorageTree(addr)
+	if storage != nil {
+		result.StorageHash = storage.Hash()
+	}
+	for _, key := range storageKeys {
+		proof := &StorageProof{Key: key, Value: state.GetState(addr, key), Proof: make([]hexutil.Bytes, 0)}
+		if storage != nil {
+			storageProof, err := state.GetStorageProof(addr, key)
+			if err != nil {
+				return nil, err
+			}
+			proof.Proof = toHexSlice(storageProof)
+		}
+		result.StorageProof = append(result.StorageProof, proof)
+	}
+	return result, nil
+}
+
+func proveKey(db basedb.Database, root types.Hash, key []byte) ([]hexutil.Bytes, error) {
+	proof, err := statediff.Prove(db, root, key)
+	if err != nil {
+		return nil, err
+	}
+	return toHexSlice(proof), nil
+}
+
+func toHexSlice(b [][]byte) []hexutil.Bytes {
+	r := make([]hexutil.Bytes, len(b))
+	for i := range b {
+		r[i] = b[i]
+	}
+	return r
+}
+
+// headerStateRoot 区块头StateRoots中STATE的值
+func headerStateRoot(header *models.Header) (types.Hash, error) {
+	roots := statetype.NewRoots()
+	if err := codec.Coder().Decode(header.StateRoots, roots); err != nil {
+		return types.Hash{}, err
+	}
+	return roots.GetObj("STATE"), nil
+}
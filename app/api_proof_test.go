@@ -0,0 +1,456 @@
+// Package app
+//
+// @author: xwc1125
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-pkg/util/hexutil"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-protocol/pkg/database/ethStatedb"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
+	"github.com/chain5j/chain5j-stateApp/stateproof"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+)
+
+var updateVectors = flag.Bool("update", false, "regenerate the state proof vectors in stateproof/testdata")
+
+var (
+	proofAlice    = types.HexToAddress("0x00000000000000000000000000000000000000f1")
+	proofContract = types.HexToAddress("0x00000000000000000000000000000000000000f2")
+	proofAbsent   = types.HexToAddress("0x00000000000000000000000000000000000000f3")
+	proofSlot     = types.BigToHash(big.NewInt(1))
+	proofEmpty    = types.BigToHash(big.NewInt(2))
+	proofValue    = types.BigToHash(big.NewInt(42))
+)
+
+// verifyProof 以json传给轻客户端的证明，使用区块头中的状态根校验
+func verifyProof(t *testing.T, a *application, result interface{}, proof interface {
+	Verify(types.Hash, uint64) error
+}) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, proof); err != nil {
+		t.Fatal(err)
+	}
+	header := a.blockRW.CurrentHeader()
+	root, err := headerStateRoot(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proof.Verify(root, header.Height)
+}
+
+// accountProof 校验账户证明，返回证明的账户，账户不存在时为nil
+type accountProof struct {
+	stateproof.AccountProof
+	store *accounts.AccountStore
+}
+
+func (p *accountProof) Verify(root types.Hash, height uint64) (err error) {
+	p.store, err = p.AccountProof.Verify(root, height)
+	return err
+}
+
+type domainProof struct {
+	stateproof.StateDomainProof
+	record *accounts.DomainStore
+}
+
+func (p *domainProof) Verify(root types.Hash, height uint64) (err error) {
+	p.record, err = p.StateDomainProof.Verify(root, height)
+	return err
+}
+
+// newProofTestApp 账户体系下域bank中有持有地址proofAlice的alice及已冻结的bob，合约的slot存有proofValue
+func newProofTestApp(t *testing.T) (*application, string) {
+	var contract string
+	a := newTestApp(t, false, 1, func(db interface{}) {
+		state := db.(*statedb.StateDB)
+		state.AddDomain("bank", accounts.DomainStore{Admin: "root"})
+		alice := accounts.NewAccountStore("alice", "bank")
+		alice.Balance = big.NewInt(250)
+		alice.SetAddress(proofAlice, nil)
+		state.CreateAccount(alice)
+		bob := accounts.NewAccountStore("bob", "bank")
+		bob.Balance = big.NewInt(40)
+		bob.IsFrozen = true
+		state.CreateAccount(bob)
+
+		statedb.NewEvmStateDB(state).CreateAccount(proofContract)
+		contract = state.GetOwner(proofContract)
+		state.SetCode(contract, []byte{0x00})
+		state.SetState(contract, proofSlot, proofValue)
+	})
+	return a, contract
+}
+
+func TestAPI_GetProof_Account(t *testing.T) {
+	a, contract := newProofTestApp(t)
+	api := a.newAPI()
+	tests := []struct {
+		name    string
+		account string
+		keys    []types.Hash
+		want    string // 证明的账户，为空时证明账户不存在
+		balance int64
+		frozen  bool
+		domain  bool // 附带存在的域记录
+		values  []types.Hash
+	}{
+		{"by name", "Alice@Bank", nil, "alice@bank", 250, false, true, nil},
+		{"by address", proofAlice.Hex(), nil, "alice@bank", 250, false, true, nil},
+		{"frozen", "bob@bank", nil, "bob@bank", 40, true, true, nil},
+		{"absent", "eve@bank", nil, "", 0, false, true, nil},
+		{"absent domain", "eve@nobank", nil, "", 0, false, false, nil},
+		{"contract storage", proofContract.Hex(), []types.Hash{proofSlot, proofEmpty}, contract, 0, false, false, []types.Hash{proofValue, {}}},
+		{"absent storage", "eve@bank", []types.Hash{proofSlot}, "", 0, false, true, []types.Hash{{}}},
+	}
+	for _, tt := range tests {
+		result, err := api.GetProof(context.Background(), tt.account, tt.keys, latestBlock(nil))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var proof accountProof
+		if err := verifyProof(t, a, result, &proof); err != nil {
+			t.Fatalf("%s: verify %v", tt.name, err)
+		}
+		if (proof.store != nil) != (tt.want != "") {
+			t.Fatalf("%s: exists %v", tt.name, proof.store != nil)
+		}
+		if proof.store != nil && (proof.store.AccountName() != tt.want || proof.store.Balance.Int64() != tt.balance || proof.store.IsFrozen != tt.frozen) {
+			t.Fatalf("%s: account %s balance %v frozen %v", tt.name, proof.store.AccountName(), proof.store.Balance, proof.store.IsFrozen)
+		}
+		if tt.domain != (proof.Domain != nil && proof.Domain.Record != nil) {
+			t.Fatalf("%s: domain %+v", tt.name, proof.Domain)
+		}
+		if len(proof.StorageProof) != len(tt.values) {
+			t.Fatalf("%s: %d storage proofs", tt.name, len(proof.StorageProof))
+		}
+		for i, slot := range proof.StorageProof {
+			if slot.Value != tt.values[i] {
+				t.Fatalf("%s: slot %s = %s, want %s", tt.name, slot.Key.Hex(), slot.Value.Hex(), tt.values[i].Hex())
+			}
+		}
+	}
+
+	// 篡改的余额无法通过校验
+	result, err := api.GetProof(context.Background(), "alice@bank", nil, latestBlock(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result.Balance.ToInt().SetInt64(251)
+	if err := verifyProof(t, a, result, &accountProof{}); err == nil {
+		t.Fatal("tampered balance accepted")
+	}
+}
+
+func TestAPI_GetProof_Ethereum(t *testing.T) {
+	a := newTestApp(t, true, 1, func(db interface{}) {
+		state := db.(*ethStatedb.StateDB)
+		state.AddBalance(proofAlice, big.NewInt(5000))
+		state.SetNonce(proofAlice, 2)
+		state.SetCode(proofContract, []byte{0x00})
+		state.SetState(proofContract, proofSlot, proofValue)
+	})
+	api := a.newAPI()
+	tests := []struct {
+		name    string
+		account string
+		keys    []types.Hash
+		balance int64
+		nonce   uint64
+		values  []types.Hash
+	}{
+		{"account", proofAlice.Hex(), nil, 5000, 2, nil},
+		{"contract storage", proofContract.Hex(), []types.Hash{proofSlot, proofEmpty}, 0, 0, []types.Hash{proofValue, {}}},
+		{"absent", proofAbsent.Hex(), []types.Hash{proofSlot}, 0, 0, []types.Hash{{}}},
+	}
+	for _, tt := range tests {
+		result, err := api.GetProof(context.Background(), tt.account, tt.keys, latestBlock(nil))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var proof accountProof
+		if err := verifyProof(t, a, result, &proof); err != nil {
+			t.Fatalf("%s: verify %v", tt.name, err)
+		}
+		if proof.Roots != nil || proof.Balance.ToInt().Int64() != tt.balance || uint64(proof.Nonce) != tt.nonce {
+			t.Fatalf("%s: %+v", tt.name, proof.AccountProof)
+		}
+		if len(proof.StorageProof) != len(tt.values) {
+			t.Fatalf("%s: %d storage proofs", tt.name, len(proof.StorageProof))
+		}
+		for i, slot := range proof.StorageProof {
+			if slot.Value != tt.values[i] {
+				t.Fatalf("%s: slot %s = %s, want %s", tt.name, slot.Key.Hex(), slot.Value.Hex(), tt.values[i].Hex())
+			}
+		}
+	}
+
+	if _, err := api.GetProof(context.Background(), "alice@bank", nil, latestBlock(nil)); errorCode(err) != AccountNotFoundCode {
+		t.Fatalf("account name: %v", err)
+	}
+	if _, err := api.GetDomainProof(context.Background(), "bank", latestBlock(nil)); err != errAccountAPIUnavailable {
+		t.Fatalf("domain proof: %v, want %v", err, errAccountAPIUnavailable)
+	}
+}
+
+func TestAPI_GetDomainProof(t *testing.T) {
+	a, _ := newProofTestApp(t)
+	api := a.newAPI()
+	tests := []struct {
+		domain string
+		admin  string // 为空时证明域不存在
+	}{
+		{"bank", "root"},
+		{"BANK", "root"},
+		{"nobank", ""},
+	}
+	for _, tt := range tests {
+		result, err := api.GetDomainProof(context.Background(), tt.domain, latestBlock(nil))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.domain, err)
+		}
+		var proof domainProof
+		if err := verifyProof(t, a, result, &proof); err != nil {
+			t.Fatalf("%s: verify %v", tt.domain, err)
+		}
+		if (proof.record != nil) != (tt.admin != "") || (proof.record != nil && proof.record.Admin != tt.admin) {
+			t.Fatalf("%s: record %+v", tt.domain, proof.record)
+		}
+	}
+}
+
+// proofVector stateproof/testdata下的测试向量，state_roots及height为区块头中的StateRoots及高度
+type proofVector struct {
+	StateRoots hexutil.Bytes  `json:"state_roots"`
+	Height     hexutil.Uint64 `json:"height"`
+	Proof      interface{}    `json:"proof"`
+}
+
+// setRegistry 写入域注册系统账户中的DomainMeta或冻结记录
+func setRegistry(t *testing.T, state *statedb.StateDB, val interface{}, prefix string, fields ...string) {
+	if err := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress).Set(stateApp.SystemKey(prefix, fields...), val); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newRegistryProofTestApp 高度10时，域bank的管理员已由admin转移给alice并在100过期，子域ops.bank被冻结
+// bob@bank的冻结仍然生效，dave@bank的冻结已于高度5到期但账户中的标记未清除
+func newRegistryProofTestApp(t *testing.T) *application {
+	return newTestApp(t, false, 10, func(db interface{}) {
+		state := db.(*statedb.StateDB)
+		state.AddDomain("bank", accounts.DomainStore{Admin: "admin", Number: 1})
+		state.AddDomain("ops.bank", accounts.DomainStore{Admin: "ops", Number: 2})
+		for _, cn := range []string{"alice", "bob", "dave"} {
+			store := accounts.NewAccountStore(cn, "bank")
+			store.Balance = big.NewInt(10)
+			store.IsFrozen = cn != "alice"
+			state.CreateAccount(store)
+		}
+		setRegistry(t, state, &accountInterpreter.DomainMeta{Admin: "alice", FormerAdmins: []string{"admin"}, ExpiryHeight: 100}, "domain", "bank")
+		setRegistry(t, state, &accountInterpreter.FreezeRecord{Reason: accountInterpreter.FreezeReasonCompliance, Operator: "admin@bank", Height: 2}, "freeze", "bob@bank")
+		setRegistry(t, state, &accountInterpreter.FreezeRecord{Reason: accountInterpreter.FreezeReasonCompliance, Operator: "admin@bank", Height: 2, UnfreezeHeight: 5}, "freeze", "dave@bank")
+		setRegistry(t, state, &accountInterpreter.FreezeRecord{Reason: accountInterpreter.FreezeReasonCompliance, Operator: "alice@bank", Height: 3}, "domain_freeze", "ops.bank")
+	})
+}
+
+func TestAPI_GetProof_Registry(t *testing.T) {
+	a := newRegistryProofTestApp(t)
+	api := a.newAPI()
+	tests := []struct {
+		account  string
+		isFrozen bool // 账户树中的原始标记
+		frozen   bool // 生效的冻结状态
+	}{
+		{"alice@bank", false, false},
+		{"bob@bank", true, true},
+		{"dave@bank", true, false},
+		{"eve@bank", false, false},
+	}
+	for _, tt := range tests {
+		result, err := api.GetProof(context.Background(), tt.account, nil, latestBlock(nil))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.account, err)
+		}
+		if result.IsFrozen != tt.isFrozen || result.Frozen != tt.frozen {
+			t.Fatalf("%s: is_frozen %v frozen %v", tt.account, result.IsFrozen, result.Frozen)
+		}
+		if result.Domain.Admin != "alice" || result.Domain.ExpiryHeight != 100 || result.Domain.Frozen {
+			t.Fatalf("%s: domain %+v", tt.account, result.Domain)
+		}
+		if err := verifyProof(t, a, result, &accountProof{}); err != nil {
+			t.Fatalf("%s: verify %v", tt.account, err)
+		}
+
+		// 声明与冻结记录不一致的生效状态无法通过校验
+		result.Frozen = !result.Frozen
+		if err := verifyProof(t, a, result, &accountProof{}); err == nil {
+			t.Fatalf("%s: tampered frozen accepted", tt.account)
+		}
+	}
+
+	domains := []struct {
+		domain string
+		admin  string
+		frozen bool
+	}{
+		{"bank", "alice", false},
+		{"ops.bank", "ops", true},
+	}
+	for _, tt := range domains {
+		result, err := api.GetDomainProof(context.Background(), tt.domain, latestBlock(nil))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.domain, err)
+		}
+		if result.Admin != tt.admin || result.ExpiryHeight != 100 || result.Frozen != tt.frozen {
+			t.Fatalf("%s: %+v", tt.domain, result.DomainProof)
+		}
+		if err := verifyProof(t, a, result, &domainProof{}); err != nil {
+			t.Fatalf("%s: verify %v", tt.domain, err)
+		}
+
+		// 转移前的管理员及缺少的冻结记录均无法通过校验
+		result.Admin = result.Record.Admin + "x"
+		if err := verifyProof(t, a, result, &domainProof{}); err == nil {
+			t.Fatalf("%s: stale admin accepted", tt.domain)
+		}
+		result.Admin = tt.admin
+		result.Registry.Records = result.Registry.Records[:len(result.Registry.Records)-1]
+		if err := verifyProof(t, a, result, &domainProof{}); err == nil {
+			t.Fatalf("%s: missing freeze record accepted", tt.domain)
+		}
+	}
+}
+
+// TestAPI_ProofVectors 由apps_getProof及apps_getDomainProof生成stateproof的测试向量
+// 证明格式或状态编码变化时以 go test ./app -run TestAPI_ProofVectors -update 重新生成，否则校验已有向量是否一致
+func TestAPI_ProofVectors(t *testing.T) {
+	var (
+		alice    = types.HexToAddress("0xa100000000000000000000000000000000000000")
+		bob      = types.HexToAddress("0xa200000000000000000000000000000000000000")
+		contract = types.HexToAddress("0xc000000000000000000000000000000000000000")
+		slots    = []types.Hash{types.BigToHash(big.NewInt(1)), types.BigToHash(big.NewInt(2)), types.BigToHash(big.NewInt(3))}
+	)
+	a := newTestApp(t, false, 1, func(db interface{}) {
+		state := db.(*statedb.StateDB)
+		state.AddDomain("bank", accounts.DomainStore{Admin: "admin", Number: 1})
+		for _, acc := range []struct {
+			cn      string
+			addr    types.Address
+			balance int64
+			frozen  bool
+		}{{"alice", alice, 250, false}, {"bob", bob, 40, true}} {
+			store := accounts.NewAccountStore(acc.cn, "bank")
+			store.Balance = big.NewInt(acc.balance)
+			store.IsFrozen = acc.frozen
+			store.SetAddress(acc.addr, nil)
+			state.CreateAccount(store)
+		}
+		statedb.NewEvmStateDB(state).CreateAccount(contract)
+		name := state.GetOwner(contract)
+		state.SetCode(name, []byte{0x00})
+		state.SetState(name, slots[0], types.BigToHash(big.NewInt(0x2a)))
+		state.SetState(name, slots[1], types.BigToHash(big.NewInt(0x07)))
+	})
+	api := a.newAPI()
+	vectors := map[string]func() (interface{}, error){
+		"account": func() (interface{}, error) {
+			return api.GetProof(context.Background(), "alice@bank", nil, latestBlock(nil))
+		},
+		"account_frozen": func() (interface{}, error) {
+			return api.GetProof(context.Background(), "bob@bank", nil, latestBlock(nil))
+		},
+		"account_absent": func() (interface{}, error) {
+			return api.GetProof(context.Background(), "carol@bank", nil, latestBlock(nil))
+		},
+		"contract_storage": func() (interface{}, error) {
+			return api.GetProof(context.Background(), contract.Hex(), slots, latestBlock(nil))
+		},
+		"domain": func() (interface{}, error) { return api.GetDomainProof(context.Background(), "bank", latestBlock(nil)) },
+		"domain_absent": func() (interface{}, error) {
+			return api.GetDomainProof(context.Background(), "nobank", latestBlock(nil))
+		},
+	}
+	writeProofVectors(t, a, vectors)
+
+	a = newRegistryProofTestApp(t)
+	api = a.newAPI()
+	writeProofVectors(t, a, map[string]func() (interface{}, error){
+		"account_registry": func() (interface{}, error) {
+			return api.GetProof(context.Background(), "bob@bank", nil, latestBlock(nil))
+		},
+		"account_unfrozen": func() (interface{}, error) {
+			return api.GetProof(context.Background(), "dave@bank", nil, latestBlock(nil))
+		},
+		"domain_frozen": func() (interface{}, error) {
+			return api.GetDomainProof(context.Background(), "ops.bank", latestBlock(nil))
+		},
+	})
+
+	var (
+		ethAccount  = types.HexToAddress("0xe100000000000000000000000000000000000000")
+		ethContract = types.HexToAddress("0xe200000000000000000000000000000000000000")
+		ethAbsent   = types.HexToAddress("0xe900000000000000000000000000000000000000")
+	)
+	a = newTestApp(t, true, 1, func(db interface{}) {
+		state := db.(*ethStatedb.StateDB)
+		state.AddBalance(ethAccount, big.NewInt(5000))
+		state.SetNonce(ethAccount, 2)
+		state.SetCode(ethContract, []byte{0x00})
+		state.SetState(ethContract, slots[0], types.BigToHash(big.NewInt(0x63)))
+	})
+	api = a.newAPI()
+	writeProofVectors(t, a, map[string]func() (interface{}, error){
+		"eth_account": func() (interface{}, error) {
+			return api.GetProof(context.Background(), ethAccount.Hex(), nil, latestBlock(nil))
+		},
+		"eth_account_absent": func() (interface{}, error) {
+			return api.GetProof(context.Background(), ethAbsent.Hex(), nil, latestBlock(nil))
+		},
+		"eth_contract_storage": func() (interface{}, error) {
+			return api.GetProof(context.Background(), ethContract.Hex(), slots[:2], latestBlock(nil))
+		},
+	})
+}
+
+func writeProofVectors(t *testing.T, a *application, vectors map[string]func() (interface{}, error)) {
+	for name, prove := range vectors {
+		proof, err := prove()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		header := a.blockRW.CurrentHeader()
+		data, err := json.MarshalIndent(&proofVector{StateRoots: header.StateRoots, Height: hexutil.Uint64(header.Height), Proof: proof}, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(data, '\n')
+		path := filepath.Join("..", "stateproof", "testdata", name+".json")
+		if *updateVectors {
+			if err := ioutil.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, want) {
+			t.Fatalf("%s: vector out of date, regenerate with -update", name)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+// Package app
+//
+// @author: xwc1125
+package app
+
+import (
+	"context"
+	"github.com/chain5j/chain5j-pkg/codec"
+	"github.com/chain5j/chain5j-pkg/network/rpc"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-pkg/util/hexutil"
+	"github.com/chain5j/chain5j-protocol/models"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"github.com/chain5j/chain5j-protocol/pkg/database/ethStatedb"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
+	"github.com/chain5j/chain5j-stateApp/statediff"
+	"reflect"
+	"strings"
+)
+
+// SimulateResult 交易模拟执行的结果
+type SimulateResult struct {
+	Receipt  *statetype.Receipt       `json:"receipt,omitempty"`
+	Logs     []*statetype.Log         `json:"logs"`
+	GasUsed  hexutil.Uint64           `json:"gas_used"`
+	Failed   bool                     `json:"failed"`          // evm执行失败，状态变更仅包含gas的扣除
+	Error    string                   `json:"error,omitempty"` // 校验或执行交易的错误，此时无状态变更
+	Accounts []*statediff.AccountDiff `json:"accounts"`        // 变更的账户，包括余额、nonce及合约存储
+	Domains  []*DomainChange          `json:"domains"`         // 变更的域
+	Names    map[types.Address]string `json:"names,omitempty"` // 收据及日志中地址所属的账户名，仅开启解析时返回
+}
+
+// DomainChange 域的变更，域新建时Pre为空
+type DomainChange struct {
+	Domain string      `json:"domain"`
+	Pre    *DomainInfo `json:"pre,omitempty"`
+	Post   *DomainInfo `json:"post,omitempty"`
+}
+
+// simulatedTx 未签名的交易，模拟执行时以指定的地址作为签名地址
+type simulatedTx struct {
+	*stateApp.Transaction
+	signer types.Address
+}
+
+func (tx *simulatedTx) Signer() (types.Address, error) {
+	return tx.signer, nil
+}
+
+// Simulate 在指定区块状态的临时副本上校验并执行交易，返回收据及状态变更，不会上链，也不更新nonce缓存
+// 未签名的交易以发送方账户的主地址作为签名地址，依赖签名公钥的校验会失败
+// overrides在执行前写入状态，返回的状态变更不包含覆盖本身
+func (api *API) Simulate(ctx context.Context, tx *stateApp.Transaction, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride) (*SimulateResult, error) {
+	interpreter, ok := interpreters[tx.Interpreter()]
+	if !ok || api.app.checkInterpreters(tx.Interpreter()) != nil {
+		return nil, errInvalidInterpreter
+	}
+	header, err := api.backend.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	roots := statetype.NewRoots()
+	if err := codec.Coder().Decode(header.StateRoots, roots); err != nil {
+		return nil, err
+	}
+	preRoot := roots.GetObj("STATE")
+
+	// 临时状态提交时仅写入内存
+	db := statediff.NewOverlayDB(api.app.kvDB)
+	var (
+		stateDB  *statedb.StateDB
+		ethState *ethStatedb.StateDB
+		stx      models.StateTransaction = tx
+	)
+	if api.app.useEthereum {
+		if ethState, err = ethStatedb.New(preRoot, ethStatedb.NewDatabase(db)); err != nil {
+			return nil, err
+		}
+		if overrides != nil {
+			// 覆盖后的状态作为比较的起点
+			if err := overrides.Apply(ethState); err != nil {
+				return nil, err
+			}
+			ethState.IntermediateRoot(false)
+			if preRoot, err = ethState.Commit(false); err != nil {
+				return nil, err
+			}
+			if ethState, err = ethStatedb.New(preRoot, ethState.Database()); err != nil {
+				return nil, err
+			}
+		}
+		if !tx.Signed() && types.IsHexAddress(tx.From()) {
+			stx = &simulatedTx{Transaction: tx, signer: types.HexToAddress(tx.From())}
+		}
+	} else {
+		if stateDB, err = statedb.New(preRoot, db); err != nil {
+			return nil, err
+		}
+		if overrides != nil {
+			// 覆盖后的状态作为比较的起点
+			if err := overrides.Apply(stateDB); err != nil {
+				return nil, err
+			}
+			stateDB.IntermediateRoot(false)
+			if preRoot, err = stateDB.Commit(false); err != nil {
+				return nil, err
+			}
+			if stateDB, err = statedb.New(preRoot, db); err != nil {
+				return nil, err
+			}
+		}
+		if account := stateDB.GetAccount(tx.From()); !tx.Signed() && account != nil {
+			stx = &simulatedTx{Transaction: tx, signer: accountInterpreter.PrimaryAddress(account)}
+		}
+	}
+
+	interpreterCtx, err := stateApp.NewInterpreterCtx(stateDB, ethState, preRoot, header, api.app.blockRW, header.GasLimit, api.app.config)
+	if err != nil {
+		return nil, err
+	}
+	result := &SimulateResult{
+		Logs:     make([]*statetype.Log, 0),
+		Accounts: make([]*statediff.AccountDiff, 0),
+		Domains:  make([]*DomainChange, 0),
+	}
+	if err := interpreter.VerifyTx(interpreterCtx, stx); err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	interpreterCtx.Prepare(tx.Hash(), types.Hash{}, 0)
+	receipt, err := interpreter.ApplyTransaction(interpreterCtx, stx, new(uint64))
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	if receipt != nil {
+		result.Receipt = receipt
+		result.GasUsed = hexutil.Uint64(receipt.GasUsed)
+		result.Failed = receipt.Status == statetype.ReceiptStatusFailed
+		if receipt.Logs != nil {
+			result.Logs = receipt.Logs
+		}
+	}
+
+	if api.app.useEthereum {
+		ethState.IntermediateRoot(false)
+		postRoot, err := ethState.Commit(false)
+		if err != nil {
+			return nil, err
+		}
+		diff, err := statediff.EthDiff(ethState.Database(), preRoot, postRoot)
+		if err != nil {
+			return nil, err
+		}
+		result.Accounts = diff.Accounts
+		return result, nil
+	}
+
+	stateDB.IntermediateRoot(false)
+	postRoot, err := stateDB.Commit(false)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := statediff.Diff(db, preRoot, postRoot)
+	if err != nil {
+		return nil, err
+	}
+	result.Accounts = diff.Accounts
+	if result.Domains, err = diffDomains(db, preRoot, postRoot, header, tx, diff); err != nil {
+		return nil, err
+	}
+	if api.app.resolveNames && receipt != nil {
+		postState, err := statedb.New(postRoot, db)
+		if err != nil {
+			return nil, err
+		}
+		addrs := []types.Address{receipt.ContractAddress}
+		for _, l := range receipt.Logs {
+			addrs = append(addrs, l.Address)
+		}
+		result.Names = accountNames(postState, addrs)
+	}
+	return result, nil
+}
+
+// diffDomains 比较域记录变更及交易相关账户所在域的扩展信息变更
+func diffDomains(db *statediff.OverlayDB, preRoot, postRoot types.Hash, header *models.Header, tx *stateApp.Transaction, diff *statediff.StateDiff) ([]*DomainChange, error) {
+	preState, err := statedb.New(preRoot, db)
+	if err != nil {
+		return nil, err
+	}
+	postState, err := statedb.New(postRoot, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		domains []string
+		seen    = make(map[string]bool)
+	)
+	addDomain := func(domain string) {
+		if domain != "" && !seen[domain] {
+			seen[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+	for _, d := range diff.Domains {
+		addDomain(d.Domain)
+	}
+	for _, account := range diff.Accounts {
+		if account.Pre != nil {
+			addDomain(account.Pre.Domain)
+		}
+		if account.Post != nil {
+			addDomain(account.Post.Domain)
+		}
+	}
+	for _, name := range []string{tx.From(), tx.To()} {
+		if i := strings.Index(name, accounts.DomainLinkFlag); i >= 0 {
+			addDomain(name[i+len(accounts.DomainLinkFlag):])
+		}
+	}
+
+	changes := make([]*DomainChange, 0)
+	for _, domain := range domains {
+		pre := domainInfo(preState, domain, header.Height)
+		post := domainInfo(postState, domain, header.Height)
+		if reflect.DeepEqual(pre, post) {
+			continue
+		}
+		changes = append(changes, &DomainChange{Domain: domain, Pre: pre, Post: post})
+	}
+	return changes, nil
+}
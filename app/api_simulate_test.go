@@ -0,0 +1,39 @@
+// Package app
+//
+// @author: xwc1125
+package app
+
+import (
+	"github.com/chain5j/chain5j-pkg/crypto/signature"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models"
+	stateApp "github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/internal/testutil"
+	"math/big"
+	"testing"
+)
+
+// 模拟执行指定的签名地址不写入交易本身
+func TestSimulatedTx_Signer(t *testing.T) {
+	tx := stateApp.NewTransaction("alice@bank", "bob@bank", stateApp.BaseInterpreter, 0, 0, stateApp.TxGas, big.NewInt(1), nil, 0, nil)
+	signer := types.HexToAddress("0x00000000000000000000000000000000000000aa")
+	var stx models.StateTransaction = &simulatedTx{Transaction: tx, signer: signer}
+
+	if got, err := stx.Signer(); err != nil || got != signer {
+		t.Fatalf("simulated signer %s, %v", got.Hex(), err)
+	}
+	if stx.Hash() != tx.Hash() || stx.From() != tx.From() {
+		t.Fatal("simulated tx differs from the original")
+	}
+	if got, err := tx.Signer(); err == nil && got == signer {
+		t.Fatal("signer stored in the unsigned transaction")
+	}
+
+	key, _ := testutil.NewKey(t)
+	if _, err := tx.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := tx.Signer(); err != nil || got != signature.PubkeyToAddress(&key.PublicKey) {
+		t.Fatalf("signed signer %s, %v", got.Hex(), err)
+	}
+}
@@ -0,0 +1,277 @@
+// Package app
+//
+// @author: xwc1125
+package app
+
+import (
+	"context"
+	"github.com/chain5j/chain5j-pkg/codec/rlp"
+	"github.com/chain5j/chain5j-pkg/network/rpc"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-pkg/util/hexutil"
+	"github.com/chain5j/chain5j-protocol/models"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"github.com/chain5j/chain5j-stateApp"
+)
+
+const (
+	txStatusPending   = "pending"   // 交易池中待打包
+	txStatusSucceeded = "succeeded" // 已上链且执行成功
+	txStatusFailed    = "failed"    // 已上链但执行失败
+)
+
+// TxInfo 交易及其所在区块，交易池中的交易区块信息为空
+type TxInfo struct {
+	Transaction      *stateApp.Transaction `json:"transaction"`
+	BlockHash        *types.Hash           `json:"block_hash,omitempty"`
+	BlockNumber      *hexutil.Uint64       `json:"block_number,omitempty"`
+	TransactionIndex *hexutil.Uint64       `json:"transaction_index,omitempty"`
+	Status           string                `json:"status"`
+}
+
+// TxReceipt 交易收据，交易池中的交易仅包含交易hash、发送方、接收方及状态
+type TxReceipt struct {
+	TransactionHash   types.Hash               `json:"transaction_hash"`
+	TransactionIndex  hexutil.Uint64           `json:"transaction_index"`
+	BlockHash         types.Hash               `json:"block_hash"`
+	BlockNumber       hexutil.Uint64           `json:"block_number"`
+	From              string                   `json:"from"`
+	To                string                   `json:"to"`
+	Status            string                   `json:"status"`
+	GasUsed           hexutil.Uint64           `json:"gas_used"`
+	CumulativeGasUsed hexutil.Uint64           `json:"cumulative_gas_used"`
+	ContractAddress   *types.Address           `json:"contract_address,omitempty"`
+	Logs              []*statetype.Log         `json:"logs"`
+	LogsBloom         statetype.Bloom          `json:"logs_bloom"`
+	Names             map[types.Address]string `json:"names,omitempty"` // 合约及日志地址所属的账户名，仅开启解析时返回
+}
+
+// GetTransactionByHash 查询交易，已上链及交易池中均不存在时返回空
+func (api *API) GetTransactionByHash(ctx context.Context, hash types.Hash) (*TxInfo, error) {
+	if tx, lookup := api.app.getTransaction(hash); tx != nil {
+		receipt := api.app.getReceipt(hash, lookup)
+		status := txStatusSucceeded
+		if receipt != nil && receipt.Status == statetype.ReceiptStatusFailed {
+			status = txStatusFailed
+		}
+		blockNumber, index := hexutil.Uint64(lookup.Height), hexutil.Uint64(lookup.Index)
+		return &TxInfo{
+			Transaction:      tx,
+			BlockHash:        &lookup.BlockHash,
+			BlockNumber:      &blockNumber,
+			TransactionIndex: &index,
+			Status:           status,
+		}, nil
+	}
+	if tx := api.app.pendingTransaction(hash); tx != nil {
+		return &TxInfo{Transaction: tx, Status: txStatusPending}, nil
+	}
+	return nil, nil
+}
+
+// GetTransactionReceipt 查询交易收据，已上链及交易池中均不存在时返回空
+func (api *API) GetTransactionReceipt(ctx context.Context, hash types.Hash) (*TxReceipt, error) {
+	if tx, lookup := api.app.getTransaction(hash); tx != nil {
+		receipt := api.app.getReceipt(hash, lookup)
+		if receipt == nil {
+			return nil, nil
+		}
+		return api.app.newTxReceipt(tx, receipt, lookup), nil
+	}
+	if tx := api.app.pendingTransaction(hash); tx != nil {
+		return &TxReceipt{
+			TransactionHash: hash,
+			From:            tx.From(),
+			To:              tx.To(),
+			Status:          txStatusPending,
+			Logs:            make([]*statetype.Log, 0),
+		}, nil
+	}
+	return nil, nil
+}
+
+// GetBlockReceipts 查询区块中全部交易的收据
+func (api *API) GetBlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*TxReceipt, error) {
+	header, err := api.backend.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	block := api.app.blockRW.GetBlock(header.Hash(), header.Height)
+	if block == nil {
+		return nil, errBlockNotFound
+	}
+	receipts, err := api.app.db.GetReceipts(header.Hash(), header.Height)
+	if err != nil {
+		return nil, err
+	}
+	txs := blockTransactions(block)
+	positions := make(map[types.Hash]uint64, len(receipts))
+	for i, hash := range txOrder(blockTxList(block), receipts) {
+		positions[hash] = uint64(i)
+	}
+
+	result := make([]*TxReceipt, 0, len(receipts))
+	for _, receipt := range receipts {
+		tx := txs[receipt.TransactionHash]
+		if tx == nil {
+			tx = api.app.hookTransaction(receipt.TransactionHash)
+		}
+		if tx == nil {
+			continue
+		}
+		lookup := &txLookup{BlockHash: header.Hash(), Height: header.Height, Index: positions[receipt.TransactionHash]}
+		result = append(result, api.app.newTxReceipt(tx, receipt, lookup))
+	}
+	return result, nil
+}
+
+func (a *application) newTxReceipt(tx *stateApp.Transaction, receipt *statetype.Receipt, lookup *txLookup) *TxReceipt {
+	result := &TxReceipt{
+		TransactionHash:   receipt.TransactionHash,
+		TransactionIndex:  hexutil.Uint64(lookup.Index),
+		BlockHash:         lookup.BlockHash,
+		BlockNumber:       hexutil.Uint64(lookup.Height),
+		From:              tx.From(),
+		To:                tx.To(),
+		Status:            txStatusSucceeded,
+		GasUsed:           hexutil.Uint64(receipt.GasUsed),
+		CumulativeGasUsed: hexutil.Uint64(receipt.CumulativeGasUsed),
+		Logs:              receipt.Logs,
+		LogsBloom:         receipt.LogsBloom,
+	}
+	if receipt.Status == statetype.ReceiptStatusFailed {
+		result.Status = txStatusFailed
+	}
+	if result.Logs == nil {
+		result.Logs = make([]*statetype.Log, 0)
+	}
+	addrs := make([]types.Address, 0, len(receipt.Logs)+1)
+	if receipt.ContractAddress != (types.Address{}) {
+		result.ContractAddress = &receipt.ContractAddress
+		addrs = append(addrs, receipt.ContractAddress)
+	}
+	for _, l := range receipt.Logs {
+		addrs = append(addrs, l.Address)
+	}
+	result.Names = a.resolveAccountNames(addrs)
+	return result
+}
+
+// txLookup 交易所在的区块及位置
+type txLookup struct {
+	BlockHash types.Hash
+	Height    uint64
+	Index     uint64
+}
+
+// getTransaction 查询已上链的交易，优先使用提交时写入的索引，索引启用前的交易从区块数据库中查询
+func (a *application) getTransaction(hash types.Hash) (*stateApp.Transaction, *txLookup) {
+	if entry, ok := a.txIndexer.Lookup(hash); ok {
+		block := a.blockRW.GetBlock(entry.BlockHash, entry.Height)
+		if block == nil {
+			return nil, nil
+		}
+		tx := blockTransactions(block)[hash]
+		if tx == nil {
+			tx = a.hookTransaction(hash)
+		}
+		if tx == nil {
+			return nil, nil
+		}
+		return tx, &txLookup{BlockHash: entry.BlockHash, Height: entry.Height, Index: entry.Index}
+	}
+
+	txI, blockHash, height, index, err := a.db.GetTransaction(hash)
+	if err != nil || txI == nil {
+		return nil, nil
+	}
+	tx, ok := txI.(*stateApp.Transaction)
+	if !ok {
+		return nil, nil
+	}
+	return tx, &txLookup{BlockHash: blockHash, Height: height, Index: index}
+}
+
+// getReceipt 读取交易的收据，区块数据库中的交易位置可能与收据的顺序不一致，此时按hash查找
+func (a *application) getReceipt(hash types.Hash, lookup *txLookup) *statetype.Receipt {
+	receipts, err := a.db.GetReceipts(lookup.BlockHash, lookup.Height)
+	if err != nil {
+		return nil
+	}
+	if lookup.Index < uint64(len(receipts)) && receipts[lookup.Index].TransactionHash == hash {
+		return receipts[lookup.Index]
+	}
+	for _, receipt := range receipts {
+		if receipt.TransactionHash == hash {
+			return receipt
+		}
+	}
+	return nil
+}
+
+// pendingTransaction 交易池中待打包的交易
+func (a *application) pendingTransaction(hash types.Hash) *stateApp.Transaction {
+	if a.txPool == nil {
+		return nil
+	}
+	if txI, _ := a.txPool.Get(hash); txI != nil {
+		if tx, ok := txI.(*stateApp.Transaction); ok {
+			return tx
+		}
+	}
+	return nil
+}
+
+// hookTransaction 区块级处理执行的交易，如到期执行的定时交易，此类交易不在区块中，提交时写入索引
+func (a *application) hookTransaction(hash types.Hash) *stateApp.Transaction {
+	enc, ok := a.txIndexer.Tx(hash)
+	if !ok {
+		return nil
+	}
+	tx := new(stateApp.Transaction)
+	if err := rlp.DecodeBytes(enc, tx); err != nil {
+		return nil
+	}
+	return tx
+}
+
+// blockTransactions 区块中的交易，按hash索引
+func blockTransactions(block *models.Block) map[types.Hash]*stateApp.Transaction {
+	return transactionsByHash(blockTxList(block))
+}
+
+// blockTxList 区块中的交易，按在区块中的顺序排列
+func blockTxList(block *models.Block) []models.Transaction {
+	var txs []models.Transaction
+	for _, list := range block.Transactions() {
+		txs = append(txs, list...)
+	}
+	return txs
+}
+
+func transactionsByHash(txs []models.Transaction) map[types.Hash]*stateApp.Transaction {
+	byHash := make(map[types.Hash]*stateApp.Transaction, len(txs))
+	for _, txI := range txs {
+		if tx, ok := txI.(*stateApp.Transaction); ok {
+			byHash[tx.Hash()] = tx
+		}
+	}
+	return byHash
+}
+
+// txOrder 区块执行的交易按位置排列的hash，依次为区块中的交易及区块级处理的收据
+// 区块级处理的收据虽可能排在收据列表的前部，其位置在区块中的交易之后，使交易的位置与区块中的顺序一致
+func txOrder(blockTxs []models.Transaction, receipts []*statetype.Receipt) []types.Hash {
+	hashes := make([]types.Hash, 0, len(receipts))
+	inBlock := make(map[types.Hash]bool, len(blockTxs))
+	for _, tx := range blockTxs {
+		hashes = append(hashes, tx.Hash())
+		inBlock[tx.Hash()] = true
+	}
+	for _, receipt := range receipts {
+		if !inBlock[receipt.TransactionHash] {
+			hashes = append(hashes, receipt.TransactionHash)
+		}
+	}
+	return hashes
+}
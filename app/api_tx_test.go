@@ -0,0 +1,239 @@
+// Package app
+//
+// @author: xwc1125
+package app
+
+import (
+	"context"
+	"github.com/chain5j/chain5j-pkg/codec/rlp"
+	"github.com/chain5j/chain5j-pkg/network/rpc"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-protocol/protocol"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/internal/testutil"
+	"github.com/chain5j/chain5j-stateApp/txindex"
+	"math/big"
+	"testing"
+)
+
+// testDatabase 只保存收据的区块数据库，未实现的方法调用时panic
+type testDatabase struct {
+	protocol.Database
+	receipts map[uint64]statetype.Receipts
+}
+
+func (db *testDatabase) GetReceipts(hash types.Hash, height uint64) (statetype.Receipts, error) {
+	return db.receipts[height], nil
+}
+
+func (db *testDatabase) GetTransaction(hash types.Hash) (models.Transaction, types.Hash, uint64, uint64, error) {
+	return nil, types.Hash{}, 0, 0, errTxNotFound
+}
+
+// testTxPool 只能按hash查询的交易池
+type testTxPool struct {
+	protocol.TxPool
+	txs map[types.Hash]models.Transaction
+}
+
+func (p *testTxPool) Get(hash types.Hash) (models.Transaction, models.TxStatus) {
+	return p.txs[hash], 0
+}
+
+var txTestContract = types.HexToAddress("0x00000000000000000000000000000000000000c1")
+
+// txTestApp 高度1的区块包含交易transfer及failed，区块级处理执行了定时交易hook，交易池中有交易pending
+type txTestApp struct {
+	*application
+	transfer, failed, hook, pending *stateApp.Transaction
+	contract                        string // 日志合约地址所属的账户名
+}
+
+func newTxTestApp(t *testing.T, useEthereum bool) *txTestApp {
+	ta := &txTestApp{
+		transfer: stateApp.NewTransaction("alice@bank", "bob@bank", "", 0, 0, stateApp.TxGas, big.NewInt(1), nil, 0, nil),
+		failed:   stateApp.NewTransaction("alice@bank", "bob@bank", "", 1, 0, stateApp.TxGas, big.NewInt(2), nil, 0, nil),
+		hook:     stateApp.NewTransaction("carol@bank", "bob@bank", stateApp.ScheduleInterpreter, 0, 0, stateApp.TxGas, big.NewInt(3), nil, 0, nil),
+		pending:  stateApp.NewTransaction("alice@bank", "bob@bank", "", 2, 0, stateApp.TxGas, big.NewInt(4), nil, 0, nil),
+	}
+	// 定时交易执行的是用户签名的交易，签名后的交易才能从索引中解码
+	key, _ := testutil.NewKey(t)
+	for _, tx := range []*stateApp.Transaction{ta.transfer, ta.failed, ta.hook, ta.pending} {
+		if _, err := tx.Sign(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ta.application = newTestApp(t, useEthereum, 1, func(db interface{}) {
+		if state, ok := db.(*statedb.StateDB); ok {
+			statedb.NewEvmStateDB(state).CreateAccount(txTestContract)
+			ta.contract = state.GetOwner(txTestContract)
+		}
+	})
+	chain := ta.blockRW.(*testChain)
+	chain.txs = models.Transactions{models.TransactionSortedList{ta.transfer, ta.failed}}
+	header := chain.header
+
+	// 收据按txOrder的顺序存储，区块级处理的收据在区块交易的收据之后
+	receipts := statetype.Receipts{
+		{TransactionHash: ta.transfer.Hash(), Status: statetype.ReceiptStatusSuccessful, GasUsed: 1,
+			Logs: []*statetype.Log{{Address: txTestContract, BlockHeight: 1}}},
+		{TransactionHash: ta.failed.Hash(), Status: statetype.ReceiptStatusFailed, GasUsed: 2},
+		{TransactionHash: ta.hook.Hash(), Status: statetype.ReceiptStatusSuccessful, GasUsed: 3},
+	}
+	ta.db = &testDatabase{receipts: map[uint64]statetype.Receipts{1: receipts}}
+	ta.txPool = &testTxPool{txs: map[types.Hash]models.Transaction{ta.pending.Hash(): ta.pending}}
+	ta.txIndexer = txindex.NewIndexer(ta.kvDB)
+
+	enc, err := rlp.EncodeToBytes(ta.hook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockTxs := []models.Transaction{ta.transfer, ta.failed}
+	if err := ta.txIndexer.Add(header.Hash(), 1, txOrder(blockTxs, receipts), map[types.Hash][]byte{ta.hook.Hash(): enc}); err != nil {
+		t.Fatal(err)
+	}
+	return ta
+}
+
+func TestAPI_GetTransactionByHash(t *testing.T) {
+	ta := newTxTestApp(t, false)
+	api := ta.newAPI()
+	tests := []struct {
+		name   string
+		hash   types.Hash
+		status string
+		index  int64 // -1表示无区块信息
+	}{
+		{"transfer", ta.transfer.Hash(), txStatusSucceeded, 0},
+		{"failed", ta.failed.Hash(), txStatusFailed, 1},
+		{"hook", ta.hook.Hash(), txStatusSucceeded, 2},
+		{"pending", ta.pending.Hash(), txStatusPending, -1},
+	}
+	for _, tt := range tests {
+		info, err := api.GetTransactionByHash(context.Background(), tt.hash)
+		if err != nil || info == nil {
+			t.Fatalf("%s: %v %v", tt.name, info, err)
+		}
+		if info.Transaction.Hash() != tt.hash || info.Status != tt.status {
+			t.Fatalf("%s: %s status %s", tt.name, info.Transaction.Hash().Hex(), info.Status)
+		}
+		if tt.index < 0 {
+			if info.BlockHash != nil || info.BlockNumber != nil || info.TransactionIndex != nil {
+				t.Fatalf("%s: pending with block %+v", tt.name, info)
+			}
+			continue
+		}
+		if info.BlockNumber == nil || *info.BlockNumber != 1 || info.TransactionIndex == nil || int64(*info.TransactionIndex) != tt.index {
+			t.Fatalf("%s: block %v index %v", tt.name, info.BlockNumber, info.TransactionIndex)
+		}
+	}
+	if info, err := api.GetTransactionByHash(context.Background(), types.Hash{1}); info != nil || err != nil {
+		t.Fatalf("unknown: %v %v", info, err)
+	}
+}
+
+func TestAPI_GetTransactionReceipt(t *testing.T) {
+	for _, useEthereum := range []bool{false, true} {
+		ta := newTxTestApp(t, useEthereum)
+		ta.resolveNames = true
+		api := ta.newAPI()
+		tests := []struct {
+			name    string
+			hash    types.Hash
+			status  string
+			index   uint64
+			gasUsed uint64
+			from    string
+		}{
+			{"transfer", ta.transfer.Hash(), txStatusSucceeded, 0, 1, "alice@bank"},
+			{"failed", ta.failed.Hash(), txStatusFailed, 1, 2, "alice@bank"},
+			{"hook", ta.hook.Hash(), txStatusSucceeded, 2, 3, "carol@bank"},
+			{"pending", ta.pending.Hash(), txStatusPending, 0, 0, "alice@bank"},
+		}
+		for _, tt := range tests {
+			receipt, err := api.GetTransactionReceipt(context.Background(), tt.hash)
+			if err != nil || receipt == nil {
+				t.Fatalf("%s: %v %v", tt.name, receipt, err)
+			}
+			if receipt.TransactionHash != tt.hash || receipt.Status != tt.status || uint64(receipt.TransactionIndex) != tt.index ||
+				uint64(receipt.GasUsed) != tt.gasUsed || receipt.From != tt.from || receipt.Logs == nil {
+				t.Fatalf("%s: %+v", tt.name, receipt)
+			}
+		}
+
+		// 日志地址的账户名仅在账户体系下解析
+		receipt, _ := api.GetTransactionReceipt(context.Background(), ta.transfer.Hash())
+		if useEthereum && receipt.Names != nil {
+			t.Fatalf("ethereum: names %v", receipt.Names)
+		}
+		if !useEthereum && receipt.Names[txTestContract] != ta.contract {
+			t.Fatalf("account: names %v, want %s", receipt.Names, ta.contract)
+		}
+	}
+}
+
+func TestAPI_GetBlockReceipts(t *testing.T) {
+	ta := newTxTestApp(t, false)
+	api := ta.newAPI()
+	receipts, err := api.GetBlockReceipts(context.Background(), latestBlock(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 位置与区块中的交易顺序一致，区块级处理的交易排在最后
+	want := map[types.Hash]uint64{ta.transfer.Hash(): 0, ta.failed.Hash(): 1, ta.hook.Hash(): 2}
+	if len(receipts) != len(want) {
+		t.Fatalf("%d receipts, want %d", len(receipts), len(want))
+	}
+	for _, receipt := range receipts {
+		if index, ok := want[receipt.TransactionHash]; !ok || uint64(receipt.TransactionIndex) != index || receipt.BlockNumber != 1 {
+			t.Fatalf("receipt %+v", receipt)
+		}
+	}
+
+	if _, err := api.GetBlockReceipts(context.Background(), rpc.BlockNumberOrHashWithNumber(2)); err == nil {
+		t.Fatal("missing block: no error")
+	}
+}
+
+func TestOrderReceipts(t *testing.T) {
+	tx1 := stateApp.NewTransaction("alice@bank", "bob@bank", "", 0, 0, stateApp.TxGas, big.NewInt(1), nil, 0, nil)
+	tx2 := stateApp.NewTransaction("alice@bank", "bob@bank", "", 1, 0, stateApp.TxGas, big.NewInt(1), nil, 0, nil)
+	beginHash, endHash := types.Hash{1}, types.Hash{2}
+
+	// 执行顺序：区块开始的处理、区块交易、区块结束的处理
+	begin := []*statetype.Receipt{{TransactionHash: beginHash, CumulativeGasUsed: 3}}
+	txs := []*statetype.Receipt{
+		{TransactionHash: tx1.Hash(), CumulativeGasUsed: 8},
+		{TransactionHash: tx2.Hash(), CumulativeGasUsed: 15},
+	}
+	end := []*statetype.Receipt{{TransactionHash: endHash, CumulativeGasUsed: 19}}
+
+	receipts := orderReceipts(begin, txs, end)
+	tests := []struct {
+		hash       types.Hash
+		gas        uint64
+		cumulative uint64
+	}{
+		{tx1.Hash(), 5, 5},
+		{tx2.Hash(), 7, 12},
+		{beginHash, 3, 15},
+		{endHash, 4, 19},
+	}
+	if len(receipts) != len(tests) {
+		t.Fatalf("%d receipts, want %d", len(receipts), len(tests))
+	}
+	// 存储顺序与txOrder一致
+	order := txOrder([]models.Transaction{tx1, tx2}, receipts)
+	for i, tt := range tests {
+		receipt := receipts[i]
+		if receipt.TransactionHash != tt.hash || receipt.GasUsed != tt.gas || receipt.CumulativeGasUsed != tt.cumulative {
+			t.Fatalf("receipt %d: %+v", i, receipt)
+		}
+		if order[i] != receipt.TransactionHash {
+			t.Fatalf("receipt %d: not in txOrder", i)
+		}
+	}
+}
@@ -5,37 +5,67 @@ package app
 
 import (
 	"context"
+	evm "github.com/chain5j/chain5j-evm"
 	"github.com/chain5j/chain5j-pkg/math"
 	"github.com/chain5j/chain5j-pkg/network/rpc"
-	"github.com/chain5j/chain5j-pkg/types"
 	"github.com/chain5j/chain5j-pkg/util/hexutil"
 	"github.com/chain5j/chain5j-protocol/models"
 	"github.com/chain5j/chain5j-protocol/models/vm"
+	"github.com/chain5j/chain5j-protocol/pkg/database/ethStatedb"
 	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp/interpreter/ethereumInterpreter"
 	"github.com/chain5j/chain5j-stateApp/interpreter/evmInterpreter"
 	"github.com/chain5j/logger"
+	"math/big"
 	"time"
 )
 
-func (api *API) Call(ctx context.Context, args models.Message, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
-	result, _, _, err := api.doCall(ctx, args, blockNr, 5*time.Second)
+// CallMsg 合约调用的参数，字段与models.Message一致
+// From、To可为账户名或地址，To为账户名时需为合约账户
+// OverrideBalance为true时将发送方的余额置为最大值，便于未持有余额的账户试调用
+type CallMsg struct {
+	From            string   `json:"from"`
+	To              string   `json:"to"`
+	Amount          *big.Int `json:"amount"`
+	GasLimit        uint64   `json:"gas_limit"`
+	GasPrice        *big.Int `json:"gas_price"`
+	Data            []byte   `json:"data"`
+	OverrideBalance bool     `json:"override_balance"`
+}
+
+// Call 在指定区块的状态上执行合约调用，overrides为执行前对状态的临时覆盖
+func (api *API) Call(ctx context.Context, args CallMsg, blockNr rpc.BlockNumber, overrides *StateOverride) (hexutil.Bytes, error) {
+	result, _, _, err := api.doCall(ctx, args, rpc.BlockNumberOrHashWithNumber(blockNr), overrides, 5*time.Second, evm.Config{})
 	return (hexutil.Bytes)(result), err
 }
 
-func (api *API) doCall(ctx context.Context, args models.Message, blockNr rpc.BlockNumber, timeout time.Duration) ([]byte, uint64, bool, error) {
+func (api *API) doCall(ctx context.Context, args CallMsg, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride, timeout time.Duration, vmConfig evm.Config) ([]byte, uint64, bool, error) {
 	defer func(start time.Time) { logger.Trace("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
 
-	db, header, err := api.backend.StateAndHeaderByNumber(ctx, blockNr)
-	stateDB := db.(*statedb.StateDB)
-	if stateDB == nil || err != nil {
+	db, header, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if err := overrides.Apply(db); err != nil {
+		return nil, 0, false, err
+	}
+	from, to, err := api.resolveCall(db, args.From, args.To)
+	if err != nil {
 		return nil, 0, false, err
 	}
 
 	// Set default gas & gas price if none were set
-	gas, gasPrice := uint64(args.GasLimit()), args.GasPrice()
+	gas, gasPrice, value := args.GasLimit, args.GasPrice, args.Amount
 	if gas == 0 {
 		gas = math.MaxUint64 / 2
 	}
+	if gasPrice == nil {
+		gasPrice = new(big.Int)
+	}
+	if value == nil {
+		value = new(big.Int)
+	}
+	msg := models.NewEvmMessage(from, to, 0, value, gas, gasPrice, args.Data, false)
 
 	// Setup context so it may be cancelled the call has completed
 	// or, in case of unmetered gas, setup a context with a timeout.
@@ -48,30 +78,49 @@ func (api *API) doCall(ctx context.Context, args models.Message, blockNr rpc.Blo
 	// Make sure the context is cancelled when the call has completed
 	// this makes sure resources are cleaned up.
 	defer cancel()
-	var to *types.Address
-	if args.To() != "" {
-		toAddress := types.HexToAddress(args.To())
-		to = &toAddress
-	}
-	msg := models.NewEvmMessage(types.HexToAddress(args.From()), to, 0, args.Value(), gas, gasPrice, args.Input(), false)
-	// Get a new instance of the EVM.
-	evm, vmError, err := api.backend.GetEVM(ctx, msg, stateDB, header)
-	if err != nil {
-		return nil, 0, false, err
-	}
-	// Wait for the context to be done and cancel the evm. Even if the
-	// EVM has finished, cancelling may be done (repeatedly)
-	go func() {
-		<-ctx.Done()
-		evm.Cancel()
-	}()
 
 	// Setup the gas pool (also for unmetered requests)
 	// and apply the message.
 	gp := new(vm.GasPool).AddGas(math.MaxUint64)
-	res, gas, failed, err := evmInterpreter.ApplyMessage(evm, msg, gp)
-	if err := vmError(); err != nil {
-		return nil, 0, false, err
+	switch state := db.(type) {
+	case *ethStatedb.StateDB:
+		if args.OverrideBalance {
+			state.SetBalance(from, math.MaxBig256)
+		}
+		vmenv, vmError, err := api.backend.GetEthEVM(ctx, msg, state, header, vmConfig)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		// Wait for the context to be done and cancel the evm. Even if the
+		// EVM has finished, cancelling may be done (repeatedly)
+		go func() {
+			<-ctx.Done()
+			vmenv.Cancel()
+		}()
+		res, gas, failed, err := ethereumInterpreter.ApplyMessage(vmenv, msg, gp)
+		if err := vmError(); err != nil {
+			return nil, 0, false, err
+		}
+		return res, gas, failed, err
+	case *statedb.StateDB:
+		if args.OverrideBalance {
+			if owner := state.GetOwner(from); owner != "" {
+				state.SetBalance(owner, math.MaxBig256)
+			}
+		}
+		vmenv, vmError, err := api.backend.GetEVM(ctx, msg, state, header, vmConfig)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		go func() {
+			<-ctx.Done()
+			vmenv.Cancel()
+		}()
+		res, gas, failed, err := evmInterpreter.ApplyMessage(vmenv, msg, gp)
+		if err := vmError(); err != nil {
+			return nil, 0, false, err
+		}
+		return res, gas, failed, err
 	}
-	return res, gas, failed, err
+	return nil, 0, false, errInvalidState
 }
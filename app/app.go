@@ -7,6 +7,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/chain5j/chain5j-pkg/codec/rlp"
 	"github.com/chain5j/chain5j-pkg/database/kvstore"
 	"github.com/chain5j/chain5j-pkg/types"
 	"github.com/chain5j/chain5j-pkg/util/dateutil"
@@ -17,6 +18,9 @@ import (
 	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
 	"github.com/chain5j/chain5j-protocol/protocol"
 	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/filters"
+	"github.com/chain5j/chain5j-stateApp/interpreter/evmInterpreter"
+	"github.com/chain5j/chain5j-stateApp/txindex"
 	"github.com/chain5j/chain5j-stateApp/txpool"
 	"github.com/chain5j/logger"
 	"sync"
@@ -42,13 +46,28 @@ type application struct {
 	nonce       *Nonce
 	useEthereum bool // 是否采用以太坊地址模型
 
+	resolveNames bool // 账户体系下为日志及收据附加地址所属的账户名
+	txHistory    bool // 提交时建立账户的交易历史索引
+
+	precompiles       evmInterpreter.PrecompileConfig // 账户体系的预编译合约的启用高度及gas
+	transferLogHeight uint64                          // 合约内部调用记录转账日志的启用高度
+	logLimits         filters.Limits                  // 日志查询的限制
+	accountScanLimit  uint64                          // 账户列表及子域查询最多遍历的记录数，为0时不限制
+
+	bloomIndexer *filters.BloomIndexer // 日志的bloom索引
+	events       *filters.EventSystem  // 日志订阅
+	txIndexer    *txindex.Indexer      // 交易到区块的索引
+
 	commitLock sync.RWMutex
 }
 
 func NewApplication(rootCtx context.Context, opts ...option) (protocol.Application, error) {
 	a := &application{
-		log:     logger.New("stateApp"),
-		rootCtx: rootCtx,
+		log:              logger.New("stateApp"),
+		rootCtx:          rootCtx,
+		logLimits:        filters.DefaultLimits(),
+		accountScanLimit: defaultAccountScanLimit,
+		precompiles:      evmInterpreter.PrecompileConfig{Gas: evmInterpreter.DefaultPrecompileGas},
 	}
 	if err := apply(a, opts...); err != nil {
 		a.log.Error("apply is error", "err", err)
@@ -57,15 +76,57 @@ func NewApplication(rootCtx context.Context, opts ...option) (protocol.Applicati
 	initInterpreter(a.nodeKey)
 
 	a.useEthereum = a.config.ChainConfig().StateApp.UseEthereum
+	// 预编译合约为evm的全局注册，以太坊模式下不注册
+	if !a.useEthereum {
+		evmInterpreter.RegisterPrecompiles(a.precompiles)
+	}
+	stateApp.SetTransferLogHeight(a.transferLogHeight)
 	a.nonce = newNonce()
-	a.apis.RegisterAPI([]protocol.API{
+	a.bloomIndexer = filters.NewBloomIndexer(a.kvDB)
+	a.events = filters.NewEventSystem()
+	a.txIndexer = txindex.NewIndexer(a.kvDB)
+
+	// 日志查询与订阅在以太坊模式下位于eth命名空间
+	filterNamespace := "apps"
+	if a.useEthereum {
+		filterNamespace = "eth"
+	}
+	apis := []protocol.API{
 		{
 			Namespace: "apps",
 			Version:   "1.0",
 			Service:   a.newAPI(),
 			Public:    true,
 		},
-	})
+		{
+			Namespace: "account",
+			Version:   "1.0",
+			Service:   a.newAccountAPI(),
+			Public:    true,
+		},
+		{
+			Namespace: "debug",
+			Version:   "1.0",
+			Service:   a.newDebugAPI(),
+			Public:    false,
+		},
+		{
+			Namespace: filterNamespace,
+			Version:   "1.0",
+			Service:   a.newFilterAPI(),
+			Public:    true,
+		},
+	}
+	// 以太坊模式下提供以太坊兼容的接口
+	if a.useEthereum {
+		apis = append(apis, protocol.API{
+			Namespace: "eth",
+			Version:   "1.0",
+			Service:   a.newEthAPI(),
+			Public:    true,
+		})
+	}
+	a.apis.RegisterAPI(apis)
 	return a, nil
 }
 
@@ -240,6 +301,9 @@ func (a *application) Prepare(ctx protocol.AppContext, header *models.Header, tx
 		errTxs   []models.Transaction
 	)
 
+	// 区块开始的处理，先于交易池的交易执行
+	beginReceipts, beginTxs := a.runBlockHooks(interpreterCtx, header, true, usedGas)
+
 	// 计算gasUsed
 	for _, txI := range txs {
 		if tx, ok = txI.(*stateApp.Transaction); !ok {
@@ -289,9 +353,23 @@ func (a *application) Prepare(ctx protocol.AppContext, header *models.Header, tx
 		}
 	}
 
+	// 区块结束的处理
+	endReceipts, endTxs := a.runBlockHooks(interpreterCtx, header, false, usedGas)
+
+	// 区块级处理的收据排在区块中的交易之后，与txOrder一致
+	hookReceipts := append(append([]*statetype.Receipt{}, beginReceipts...), endReceipts...)
+	for i, receipt := range hookReceipts {
+		for _, l := range receipt.Logs {
+			l.TxIndex = uint(tcount + i)
+		}
+	}
+	receipts = orderReceipts(beginReceipts, receipts, endReceipts)
+
 	root := context.intermediateRoot()
 
 	context.addReceipt(receipts)
+	context.addTxs(okTxs)
+	context.addHookTxs(append(beginTxs, endTxs...))
 
 	a.log.Debug("Prepare Elapsed", "elapsed", dateutil.PrettyDuration(time.Since(t)), "count", txs.Len())
 	return &models.TxsStatus{
@@ -302,6 +380,72 @@ func (a *application) Prepare(ctx protocol.AppContext, header *models.Header, tx
 	}
 }
 
+// orderReceipts 按txOrder的顺序排列收据：区块交易的收据在前，区块级处理的收据在后
+// 各收据的gas按执行顺序的累计值计算，再按新的顺序重新累计
+func orderReceipts(beginReceipts, txReceipts, endReceipts []*statetype.Receipt) []*statetype.Receipt {
+	var prev uint64
+	for _, list := range [][]*statetype.Receipt{beginReceipts, txReceipts, endReceipts} {
+		for _, receipt := range list {
+			if receipt.CumulativeGasUsed >= prev {
+				receipt.GasUsed = receipt.CumulativeGasUsed - prev
+				prev = receipt.CumulativeGasUsed
+			}
+		}
+	}
+
+	receipts := make([]*statetype.Receipt, 0, len(beginReceipts)+len(txReceipts)+len(endReceipts))
+	receipts = append(receipts, txReceipts...)
+	receipts = append(receipts, beginReceipts...)
+	receipts = append(receipts, endReceipts...)
+	var cumulative uint64
+	for _, receipt := range receipts {
+		cumulative += receipt.GasUsed
+		receipt.CumulativeGasUsed = cumulative
+	}
+	return receipts
+}
+
+// runBlockHooks 按解析器的注册顺序执行区块级处理，返回系统收据及其中对应的交易
+// 执行失败的解析器回滚其全部状态修改
+func (a *application) runBlockHooks(ctx *stateApp.InterpreterContext, header *models.Header, begin bool, usedGas *uint64) ([]*statetype.Receipt, []models.Transaction) {
+	var (
+		receipts []*statetype.Receipt
+		txs      []models.Transaction
+	)
+	for _, hook := range blockHooks() {
+		snap := ctx.Snapshot()
+
+		var (
+			hookReceipts []*statetype.Receipt
+			err          error
+		)
+		if begin {
+			hookReceipts, err = hook.BeginBlock(ctx, header)
+		} else {
+			hookReceipts, err = hook.EndBlock(ctx, header)
+		}
+		if err != nil {
+			ctx.RevertToSnapshot(snap)
+			a.log.Error("block hook", "begin", begin, "err", err)
+			continue
+		}
+
+		resolver, _ := hook.(stateApp.HookTxResolver)
+		for _, receipt := range hookReceipts {
+			*usedGas += receipt.GasUsed
+			receipt.CumulativeGasUsed = *usedGas
+			receipts = append(receipts, receipt)
+			if resolver == nil {
+				continue
+			}
+			if tx := resolver.HookTransaction(ctx, receipt); tx != nil {
+				txs = append(txs, tx)
+			}
+		}
+	}
+	return receipts, txs
+}
+
 // Commit 提交入库
 func (a *application) Commit(ctx protocol.AppContext, header *models.Header) error {
 	context := ctx.(*stateContext)
@@ -328,6 +472,11 @@ func (a *application) Commit(ctx protocol.AppContext, header *models.Header) err
 	}
 
 	a.storeReceipts(header, context.receipts)
+	a.indexTxs(header, context)
+	if a.txHistory {
+		a.indexHistory(context, header.Height)
+	}
+	a.indexLogs(header, context.receipts)
 
 	a.log.Debug("Commit Elapsed", "elapsed", dateutil.PrettyDuration(time.Since(t)))
 	return err
@@ -344,6 +493,47 @@ func (a *application) storeReceipts(header *models.Header, receipts []*statetype
 	a.db.WriteReceipts(header.Hash(), header.Height, receipts)
 }
 
+// indexTxs 写入交易到区块的索引，区块级处理执行的交易不在区块中，同时写入交易内容
+func (a *application) indexTxs(header *models.Header, context *stateContext) {
+	hookTxs := make(map[types.Hash][]byte, len(context.hookTxs))
+	for _, tx := range context.hookTxs {
+		enc, err := rlp.EncodeToBytes(tx)
+		if err != nil {
+			a.log.Error("encode hook tx", "hash", tx.Hash(), "err", err)
+			continue
+		}
+		hookTxs[tx.Hash()] = enc
+	}
+	if err := a.txIndexer.Add(header.Hash(), header.Height, txOrder(context.txs, context.receipts), hookTxs); err != nil {
+		a.log.Error("index txs", "height", header.Height, "err", err)
+	}
+}
+
+// indexHistory 写入账户的交易历史
+func (a *application) indexHistory(context *stateContext, height uint64) {
+	resolve := addressKey
+	if !a.useEthereum {
+		resolve = stateResolver(context.stateDB)
+	}
+	txs := transactionsByHash(append(append([]models.Transaction{}, context.txs...), context.hookTxs...))
+	if err := a.txIndexer.AddHistory(txHistories(height, txOrder(context.txs, context.receipts), txs, context.receipts, resolve)); err != nil {
+		a.log.Error("index tx history", "height", height, "err", err)
+	}
+}
+
+// indexLogs 写入日志的bloom索引，并推送给订阅者
+func (a *application) indexLogs(header *models.Header, receipts []*statetype.Receipt) {
+	if err := a.bloomIndexer.Add(header.Height, receipts); err != nil {
+		a.log.Error("index logs bloom", "height", header.Height, "err", err)
+	}
+
+	var logs []*statetype.Log
+	for _, receipt := range receipts {
+		logs = append(logs, receipt.Logs...)
+	}
+	a.events.PublishLogs(logs)
+}
+
 func (a *application) checkInterpreters(interpreter string) error {
 	if a.useEthereum {
 		if interpreter != stateApp.EthereumInterpreter {
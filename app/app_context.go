@@ -5,6 +5,7 @@ package app
 
 import (
 	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models"
 	"github.com/chain5j/chain5j-protocol/models/statetype"
 	"github.com/chain5j/chain5j-protocol/pkg/database/ethStatedb"
 	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
@@ -24,6 +25,8 @@ type stateContext struct {
 	preRoot     types.Hash
 
 	receipts []*statetype.Receipt
+	txs      []models.Transaction // 执行成功的交易，用于提交时建立交易历史
+	hookTxs  []models.Transaction // 区块级处理执行的交易，不在区块中，提交时单独写入索引
 }
 
 func (ctx *stateContext) Caller() string {
@@ -38,6 +41,14 @@ func (ctx *stateContext) addReceipt(receipts []*statetype.Receipt) {
 	ctx.receipts = append(ctx.receipts, receipts...)
 }
 
+func (ctx *stateContext) addTxs(txs []models.Transaction) {
+	ctx.txs = append(ctx.txs, txs...)
+}
+
+func (ctx *stateContext) addHookTxs(txs []models.Transaction) {
+	ctx.hookTxs = append(ctx.hookTxs, txs...)
+}
+
 func (ctx *stateContext) getNonce(account string) uint64 {
 	if ctx.useEthereum {
 		return ctx.ethState.GetNonce(types.HexToAddress(account))
@@ -3,12 +3,73 @@
 // @author: xwc1125
 package app
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	errTxLooLarge         = errors.New("tx size is over")
 	errTxParse            = errors.New("parse tx is error")
 	errInvalidInterpreter = errors.New("invalid interpreter")
+	errTxNotFound         = errors.New("transaction not found")
+	errBlockNotFound      = errors.New("block not found")
+	errNotTraceable       = errors.New("transaction interpreter does not support tracing")
+	errInvalidState       = errors.New("invalid state")
+	errTxAlwaysFailing    = errors.New("transaction always fails")
+	errNotEstimable       = errors.New("interpreter does not support gas estimation")
+	errNotContract        = errors.New("to account is not a contract")
+	errTxPoolUnavailable  = errors.New("transaction pool is not available")
+	errExecutionReverted  = errors.New("execution reverted")
+	errFilterNotFound     = errors.New("filter not found")
+	errOverrideNotFound   = errors.New("override account not found")
+	errOverrideContract   = errors.New("code and storage overrides require a contract account")
+	errOverrideConflict   = errors.New("state and state_diff can not be both set")
+	errOverrideAccount    = errors.New("frozen and permissions overrides require account mode")
+	errOverrideAdmin      = errors.New("permissions override requires an admin account")
+
+	errTxHistoryDisabled = errors.New("transaction history index is not enabled")
+	errInvalidBlockRange = errors.New("invalid block range")
+
+	errAccountNotFound       = errors.New("account not found")
+	errDomainNotFound        = errors.New("domain not found")
+	errAccountAPIUnavailable = errors.New("account api is not available in ethereum mode")
+	errScanLimitExceeded     = errors.New("too many records to scan, query a narrower domain")
 
 	ErrReplaceUnderpriced = errors.New("replacement transaction underpriced")
 )
+
+// 资源不存在时返回的rpc错误码
+const (
+	AccountNotFoundCode = -32001 // 账户或地址不存在
+	DomainNotFoundCode  = -32002 // 域不存在
+)
+
+// notFoundError 资源不存在的错误，携带固定的rpc错误码，可通过errors.Is与对应的错误比较
+type notFoundError struct {
+	err  error
+	name string
+	code int
+}
+
+func (e *notFoundError) Error() string {
+	return fmt.Sprintf("%s: %s", e.err, e.name)
+}
+
+func (e *notFoundError) ErrorCode() int {
+	return e.code
+}
+
+func (e *notFoundError) Unwrap() error {
+	return e.err
+}
+
+// accountNotFound 账户不存在的错误，消息以"account not found"开头
+func accountNotFound(account string) error {
+	return &notFoundError{err: errAccountNotFound, name: account, code: AccountNotFoundCode}
+}
+
+// domainNotFound 域不存在的错误，消息以"domain not found"开头
+func domainNotFound(domain string) error {
+	return &notFoundError{err: errDomainNotFound, name: domain, code: DomainNotFoundCode}
+}
@@ -9,22 +9,51 @@ import (
 	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
 	"github.com/chain5j/chain5j-stateApp/interpreter/baseInterpreter"
 	"github.com/chain5j/chain5j-stateApp/interpreter/caInterpreter"
+	"github.com/chain5j/chain5j-stateApp/interpreter/escrowInterpreter"
 	"github.com/chain5j/chain5j-stateApp/interpreter/ethereumInterpreter"
 	"github.com/chain5j/chain5j-stateApp/interpreter/evmInterpreter"
 	"github.com/chain5j/chain5j-stateApp/interpreter/lostInterpreter"
 	"github.com/chain5j/chain5j-stateApp/interpreter/permissionInterpreter"
+	"github.com/chain5j/chain5j-stateApp/interpreter/scheduleInterpreter"
 )
 
-var interpreters map[string]stateApp.Interpreter
+var (
+	interpreters     map[string]stateApp.Interpreter
+	interpreterNames []string // 解析器的注册顺序，区块级处理按此顺序执行
+)
 
 // initInterpreter 初始化解析器
 func initInterpreter(nodeKey protocol.NodeKey) {
 	interpreters = make(map[string]stateApp.Interpreter)
-	interpreters[stateApp.BaseInterpreter] = baseInterpreter.NewInterpreter()
-	interpreters[stateApp.AccountInterpreter] = accountInterpreter.NewInterpreter()
-	interpreters[stateApp.LostInterpreter] = lostInterpreter.NewInterpreter()
-	interpreters[stateApp.EvmInterpreter] = evmInterpreter.NewInterpreter()
-	interpreters[stateApp.CAInterpreter] = caInterpreter.NewInterpreter()
-	interpreters[stateApp.EthereumInterpreter] = ethereumInterpreter.NewInterpreter()
-	interpreters[stateApp.PermissionInterpreter] = permissionInterpreter.NewInterpreter(nodeKey)
+	interpreterNames = nil
+	registerInterpreter(stateApp.BaseInterpreter, baseInterpreter.NewInterpreter())
+	registerInterpreter(stateApp.AccountInterpreter, accountInterpreter.NewInterpreter())
+	registerInterpreter(stateApp.LostInterpreter, lostInterpreter.NewInterpreter())
+	registerInterpreter(stateApp.EvmInterpreter, evmInterpreter.NewInterpreter())
+	registerInterpreter(stateApp.CAInterpreter, caInterpreter.NewInterpreter())
+	registerInterpreter(stateApp.EthereumInterpreter, ethereumInterpreter.NewInterpreter())
+	registerInterpreter(stateApp.PermissionInterpreter, permissionInterpreter.NewInterpreter(nodeKey))
+	registerInterpreter(stateApp.EscrowInterpreter, escrowInterpreter.NewInterpreter())
+	registerInterpreter(stateApp.ScheduleInterpreter, scheduleInterpreter.NewInterpreter(func(name string) (stateApp.Interpreter, bool) {
+		interpreter, ok := interpreters[name]
+		return interpreter, ok
+	}))
+}
+
+func registerInterpreter(name string, interpreter stateApp.Interpreter) {
+	if _, ok := interpreters[name]; !ok {
+		interpreterNames = append(interpreterNames, name)
+	}
+	interpreters[name] = interpreter
+}
+
+// blockHooks 实现了区块级处理的解析器，按注册顺序排列
+func blockHooks() []stateApp.BlockHook {
+	hooks := make([]stateApp.BlockHook, 0)
+	for _, name := range interpreterNames {
+		if hook, ok := interpreters[name].(stateApp.BlockHook); ok {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks
 }
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"github.com/chain5j/chain5j-pkg/database/kvstore"
 	"github.com/chain5j/chain5j-protocol/protocol"
+	"github.com/chain5j/chain5j-stateApp/filters"
+	"github.com/chain5j/chain5j-stateApp/interpreter/evmInterpreter"
 )
 
 type option func(f *application) error
@@ -59,3 +61,59 @@ func WithApis(apis protocol.APIs) option {
 		return nil
 	}
 }
+
+// WithResolveNames 账户体系下为日志及收据附加地址所属的账户名
+func WithResolveNames(resolve bool) option {
+	return func(f *application) error {
+		f.resolveNames = resolve
+		return nil
+	}
+}
+
+// WithTxHistory 提交区块时建立账户的交易历史索引，供按账户查询交易
+func WithTxHistory(enable bool) option {
+	return func(f *application) error {
+		f.txHistory = enable
+		return nil
+	}
+}
+
+// WithPrecompileHeight 账户体系的预编译合约自该高度起启用，之前的高度该地址与空账户一致
+func WithPrecompileHeight(height uint64) option {
+	return func(f *application) error {
+		f.precompiles.Height = height
+		return nil
+	}
+}
+
+// WithPrecompileGas 账户体系的预编译合约的gas，默认为evmInterpreter.DefaultPrecompileGas，各节点须一致
+func WithPrecompileGas(gas evmInterpreter.PrecompileGas) option {
+	return func(f *application) error {
+		f.precompiles.Gas = gas
+		return nil
+	}
+}
+
+// WithTransferLogHeight 合约内部调用的转账自该高度起记录转账日志，用于接收方的交易历史，之前高度的收据不变
+func WithTransferLogHeight(height uint64) option {
+	return func(f *application) error {
+		f.transferLogHeight = height
+		return nil
+	}
+}
+
+// WithLogLimits 日志查询的最大区块范围及最大日志数，为0时不限制
+func WithLogLimits(limits filters.Limits) option {
+	return func(f *application) error {
+		f.logLimits = limits
+		return nil
+	}
+}
+
+// WithAccountScanLimit 账户列表及子域查询最多遍历的账户树或域树记录数，为0时不限制
+func WithAccountScanLimit(limit uint64) option {
+	return func(f *application) error {
+		f.accountScanLimit = limit
+		return nil
+	}
+}
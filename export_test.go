@@ -0,0 +1,10 @@
+// Package stateApp
+//
+// @author: xwc1125
+package stateApp
+
+// 供外部测试包校验槽位的计算
+var (
+	HeadSlot = headSlot
+	DataSlot = dataSlot
+)
@@ -0,0 +1,193 @@
+// Package filters
+//
+// @author: xwc1125
+package filters
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/chain5j/chain5j-pkg/network/rpc"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-pkg/util/hexutil"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+)
+
+var (
+	errInvalidAddresses  = errors.New("invalid addresses in query")
+	errInvalidTopics     = errors.New("invalid topic(s)")
+	errBlockHashAndRange = errors.New("cannot specify both BlockHash and FromBlock/ToBlock")
+)
+
+// FilterCriteria 日志的查询条件
+// BlockHash与FromBlock/ToBlock互斥，Addresses中任一地址匹配即可，Topics按位置匹配，空位置匹配任意值
+type FilterCriteria struct {
+	BlockHash *types.Hash
+	FromBlock *rpc.BlockNumber
+	ToBlock   *rpc.BlockNumber
+	Addresses []types.Address
+	Topics    [][]types.Hash
+}
+
+func (crit *FilterCriteria) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		BlockHash *types.Hash      `json:"blockHash"`
+		FromBlock *rpc.BlockNumber `json:"fromBlock"`
+		ToBlock   *rpc.BlockNumber `json:"toBlock"`
+		Addresses interface{}      `json:"address"`
+		Topics    []interface{}    `json:"topics"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if raw.BlockHash != nil {
+		if raw.FromBlock != nil || raw.ToBlock != nil {
+			return errBlockHashAndRange
+		}
+		crit.BlockHash = raw.BlockHash
+	} else {
+		crit.FromBlock, crit.ToBlock = raw.FromBlock, raw.ToBlock
+	}
+
+	switch addr := raw.Addresses.(type) {
+	case nil:
+	case string:
+		a, err := decodeAddress(addr)
+		if err != nil {
+			return err
+		}
+		crit.Addresses = []types.Address{a}
+	case []interface{}:
+		crit.Addresses = make([]types.Address, 0, len(addr))
+		for _, item := range addr {
+			s, ok := item.(string)
+			if !ok {
+				return errInvalidAddresses
+			}
+			a, err := decodeAddress(s)
+			if err != nil {
+				return err
+			}
+			crit.Addresses = append(crit.Addresses, a)
+		}
+	default:
+		return errInvalidAddresses
+	}
+
+	crit.Topics = make([][]types.Hash, len(raw.Topics))
+	for i, t := range raw.Topics {
+		switch topic := t.(type) {
+		case nil:
+		case string:
+			h, err := decodeTopic(topic)
+			if err != nil {
+				return err
+			}
+			crit.Topics[i] = []types.Hash{h}
+		case []interface{}:
+			for _, item := range topic {
+				// 位置中包含null时匹配任意值
+				if item == nil {
+					crit.Topics[i] = nil
+					break
+				}
+				s, ok := item.(string)
+				if !ok {
+					return errInvalidTopics
+				}
+				h, err := decodeTopic(s)
+				if err != nil {
+					return err
+				}
+				crit.Topics[i] = append(crit.Topics[i], h)
+			}
+		default:
+			return errInvalidTopics
+		}
+	}
+	return nil
+}
+
+func decodeAddress(s string) (types.Address, error) {
+	b, err := hexutil.Decode(s)
+	if err == nil && len(b) != types.AddressLength {
+		err = fmt.Errorf("hex has invalid length %d after decoding; expected %d for address", len(b), types.AddressLength)
+	}
+	return types.BytesToAddress(b), err
+}
+
+func decodeTopic(s string) (types.Hash, error) {
+	b, err := hexutil.Decode(s)
+	if err == nil && len(b) != types.HashLength {
+		err = fmt.Errorf("hex has invalid length %d after decoding; expected %d for topic", len(b), types.HashLength)
+	}
+	return types.BytesToHash(b), err
+}
+
+// MatchBloom bloom是否可能包含满足条件的日志
+func (crit *FilterCriteria) MatchBloom(bloom statetype.Bloom) bool {
+	if len(crit.Addresses) > 0 {
+		var included bool
+		for _, addr := range crit.Addresses {
+			if statetype.BloomLookup(bloom, addr) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+
+	for _, sub := range crit.Topics {
+		included := len(sub) == 0 // 空位置匹配任意值
+		for _, topic := range sub {
+			if statetype.BloomLookup(bloom, topic) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterLogs 筛选满足条件的日志
+func (crit *FilterCriteria) FilterLogs(logs []*statetype.Log) []*statetype.Log {
+	var ret []*statetype.Log
+Logs:
+	for _, l := range logs {
+		if len(crit.Addresses) > 0 && !includesAddress(crit.Addresses, l.Address) {
+			continue
+		}
+		if len(crit.Topics) > len(l.Topics) {
+			continue
+		}
+		for i, sub := range crit.Topics {
+			match := len(sub) == 0
+			for _, topic := range sub {
+				if l.Topics[i] == topic {
+					match = true
+					break
+				}
+			}
+			if !match {
+				continue Logs
+			}
+		}
+		ret = append(ret, l)
+	}
+	return ret
+}
+
+func includesAddress(addresses []types.Address, a types.Address) bool {
+	for _, addr := range addresses {
+		if addr == a {
+			return true
+		}
+	}
+	return false
+}
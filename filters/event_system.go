@@ -0,0 +1,87 @@
+// Package filters
+//
+// @author: xwc1125
+package filters
+
+import (
+	"github.com/chain5j/chain5j-pkg/network/rpc"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"github.com/chain5j/logger"
+	"sync"
+)
+
+// logsChanSize 订阅者未及时接收时缓存的区块数，超出后丢弃
+const logsChanSize = 64
+
+// EventSystem 将新提交区块的日志推送给订阅者
+type EventSystem struct {
+	log  logger.Logger
+	mu   sync.RWMutex
+	subs map[rpc.ID]*Subscription
+}
+
+func NewEventSystem() *EventSystem {
+	return &EventSystem{
+		log:  logger.New("filters"),
+		subs: make(map[rpc.ID]*Subscription),
+	}
+}
+
+// Subscription 日志订阅，每个区块提交后收到其中满足条件的日志
+type Subscription struct {
+	ID   rpc.ID
+	crit FilterCriteria
+	logs chan []*statetype.Log
+	es   *EventSystem
+	once sync.Once
+}
+
+// Logs 接收日志的通道，取消订阅后关闭
+func (s *Subscription) Logs() <-chan []*statetype.Log {
+	return s.logs
+}
+
+// Unsubscribe 取消订阅，可重复调用
+func (s *Subscription) Unsubscribe() {
+	s.once.Do(func() {
+		s.es.mu.Lock()
+		delete(s.es.subs, s.ID)
+		s.es.mu.Unlock()
+		close(s.logs)
+	})
+}
+
+// SubscribeLogs 订阅满足条件的日志，区块范围条件不生效
+func (es *EventSystem) SubscribeLogs(crit FilterCriteria) *Subscription {
+	sub := &Subscription{
+		ID:   rpc.NewID(),
+		crit: crit,
+		logs: make(chan []*statetype.Log, logsChanSize),
+		es:   es,
+	}
+	es.mu.Lock()
+	es.subs[sub.ID] = sub
+	es.mu.Unlock()
+	return sub
+}
+
+// PublishLogs 推送新提交区块的日志，不阻塞区块提交
+func (es *EventSystem) PublishLogs(logs []*statetype.Log) {
+	if len(logs) == 0 {
+		return
+	}
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+
+	for _, sub := range es.subs {
+		matched := sub.crit.FilterLogs(logs)
+		if len(matched) == 0 {
+			continue
+		}
+		select {
+		case sub.logs <- matched:
+		default:
+			es.log.Warn("drop logs of slow subscriber", "id", sub.ID, "count", len(matched))
+		}
+	}
+}
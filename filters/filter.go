@@ -0,0 +1,166 @@
+// Package filters
+//
+// @author: xwc1125
+package filters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/chain5j/chain5j-pkg/network/rpc"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+)
+
+var (
+	errBlockNotFound      = errors.New("block not found")
+	errInvalidBlockRange  = errors.New("invalid block range")
+	errBlockRangeTooLarge = errors.New("block range too large")
+	errTooManyLogs        = errors.New("too many logs")
+)
+
+// 日志查询的默认限制
+const (
+	DefaultMaxBlockRange uint64 = 10000 // 单次查询的最大区块数
+	DefaultMaxLogs       int    = 10000 // 单次查询返回的最大日志数
+)
+
+// Limits 日志查询的限制，为0时不限制
+type Limits struct {
+	MaxBlockRange uint64 // 单次查询的最大区块数
+	MaxLogs       int    // 单次查询返回的最大日志数，超出时返回错误，需缩小查询范围
+}
+
+// DefaultLimits 默认的日志查询限制
+func DefaultLimits() Limits {
+	return Limits{
+		MaxBlockRange: DefaultMaxBlockRange,
+		MaxLogs:       DefaultMaxLogs,
+	}
+}
+
+// Backend 查询日志所需的链数据
+type Backend interface {
+	CurrentHeader() *models.Header
+	GetHeaderByNumber(number uint64) *models.Header
+	GetHeaderByHash(hash types.Hash) *models.Header
+	GetReceipts(hash types.Hash, height uint64) (statetype.Receipts, error)
+}
+
+// Filter 按区块范围或区块hash查询日志
+type Filter struct {
+	backend Backend
+	indexer *BloomIndexer
+	crit    FilterCriteria
+	limits  Limits
+}
+
+func NewFilter(backend Backend, indexer *BloomIndexer, crit FilterCriteria, limits Limits) *Filter {
+	return &Filter{
+		backend: backend,
+		indexer: indexer,
+		crit:    crit,
+		limits:  limits,
+	}
+}
+
+// Logs 满足条件的日志，区块范围未指定时为最新区块
+func (f *Filter) Logs(ctx context.Context) ([]*statetype.Log, error) {
+	if f.crit.BlockHash != nil {
+		header := f.backend.GetHeaderByHash(*f.crit.BlockHash)
+		if header == nil {
+			return nil, errBlockNotFound
+		}
+		logs, err := f.blockLogs(header, false)
+		if err != nil {
+			return nil, err
+		}
+		return logs, f.checkLogs(len(logs))
+	}
+
+	head := f.backend.CurrentHeader().Height
+	from, to := resolveNumber(f.crit.FromBlock, head), resolveNumber(f.crit.ToBlock, head)
+	if from > to {
+		return nil, errInvalidBlockRange
+	}
+	if to > head {
+		to = head
+	}
+	if f.limits.MaxBlockRange > 0 && from <= to && to-from+1 > f.limits.MaxBlockRange {
+		return nil, fmt.Errorf("%w: max %d blocks", errBlockRangeTooLarge, f.limits.MaxBlockRange)
+	}
+	return f.rangeLogs(ctx, from, to)
+}
+
+// rangeLogs 先以分段bloom跳过整个分段，再以区块bloom跳过单个区块
+func (f *Filter) rangeLogs(ctx context.Context, from, to uint64) ([]*statetype.Log, error) {
+	var logs []*statetype.Log
+	for height := from; height <= to; {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		section := height / SectionSize
+		if bloom, ok := f.indexer.SectionBloom(section); ok && !f.crit.MatchBloom(bloom) {
+			height = (section + 1) * SectionSize
+			continue
+		}
+		bloom, indexed := f.indexer.BlockBloom(height)
+		if indexed && !f.crit.MatchBloom(bloom) {
+			height++
+			continue
+		}
+
+		header := f.backend.GetHeaderByNumber(height)
+		if header == nil {
+			break
+		}
+		found, err := f.blockLogs(header, !indexed)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, found...)
+		if err := f.checkLogs(len(logs)); err != nil {
+			return nil, err
+		}
+		height++
+	}
+	return logs, nil
+}
+
+// blockLogs 区块中满足条件的日志，先以收据的bloom排除不相关的收据
+// backfill为true时区块尚无bloom索引，读取收据后补写
+func (f *Filter) blockLogs(header *models.Header, backfill bool) ([]*statetype.Log, error) {
+	receipts, err := f.backend.GetReceipts(header.Hash(), header.Height)
+	if err != nil {
+		return nil, err
+	}
+	if backfill {
+		// 补写失败不影响本次查询，下次查询时重新补写
+		_ = f.indexer.Backfill(header.Height, receipts)
+	}
+	var logs []*statetype.Log
+	for _, receipt := range receipts {
+		if !f.crit.MatchBloom(receipt.LogsBloom) {
+			continue
+		}
+		logs = append(logs, f.crit.FilterLogs(receipt.Logs)...)
+	}
+	return logs, nil
+}
+
+func (f *Filter) checkLogs(count int) error {
+	if f.limits.MaxLogs > 0 && count > f.limits.MaxLogs {
+		return fmt.Errorf("%w: max %d logs", errTooManyLogs, f.limits.MaxLogs)
+	}
+	return nil
+}
+
+// resolveNumber 未指定、latest及pending均视为最新区块
+func resolveNumber(number *rpc.BlockNumber, head uint64) uint64 {
+	if number == nil || *number < 0 {
+		return head
+	}
+	return uint64(*number)
+}
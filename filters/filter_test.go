@@ -0,0 +1,139 @@
+// Package filters
+//
+// @author: xwc1125
+package filters
+
+import (
+	"context"
+	"errors"
+	"github.com/chain5j/chain5j-pkg/crypto/signature"
+	"github.com/chain5j/chain5j-pkg/database/kvstore/memorydb"
+	"github.com/chain5j/chain5j-pkg/network/rpc"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"testing"
+)
+
+// testBackend 每个区块一笔收据，记录读取收据的区块
+type testBackend struct {
+	head     uint64
+	receipts map[uint64]statetype.Receipts
+	reads    []uint64
+}
+
+func (b *testBackend) CurrentHeader() *models.Header {
+	return b.GetHeaderByNumber(b.head)
+}
+
+func (b *testBackend) GetHeaderByNumber(number uint64) *models.Header {
+	if number > b.head {
+		return nil
+	}
+	return &models.Header{Height: number, Signature: &signature.SignResult{}}
+}
+
+func (b *testBackend) GetHeaderByHash(hash types.Hash) *models.Header {
+	return nil
+}
+
+func (b *testBackend) GetReceipts(hash types.Hash, height uint64) (statetype.Receipts, error) {
+	b.reads = append(b.reads, height)
+	return b.receipts[height], nil
+}
+
+// newTestBackend 区块0至head，logs中的区块各有count条contract的日志，其余区块的日志属于other
+func newTestBackend(head uint64, contract, other types.Address, logs map[uint64]int) *testBackend {
+	b := &testBackend{head: head, receipts: make(map[uint64]statetype.Receipts)}
+	for height := uint64(0); height <= head; height++ {
+		receipt := &statetype.Receipt{Logs: []*statetype.Log{{Address: other, BlockHeight: height}}}
+		for i := 0; i < logs[height]; i++ {
+			receipt.Logs = append(receipt.Logs, &statetype.Log{Address: contract, BlockHeight: height})
+		}
+		receipt.LogsBloom = statetype.CreateBloom(statetype.Receipts{receipt})
+		b.receipts[height] = statetype.Receipts{receipt}
+	}
+	return b
+}
+
+func blockNumber(n int64) *rpc.BlockNumber {
+	number := rpc.BlockNumber(n)
+	return &number
+}
+
+func TestFilter_Limits(t *testing.T) {
+	contract := types.HexToAddress("0x00000000000000000000000000000000000000aa")
+	other := types.HexToAddress("0x00000000000000000000000000000000000000bb")
+	backend := newTestBackend(20, contract, other, map[uint64]int{3: 2, 12: 1})
+	indexer := NewBloomIndexer(memorydb.New())
+
+	tests := []struct {
+		name   string
+		from   int64
+		to     int64
+		limits Limits
+		count  int
+		err    error
+	}{
+		{"all", 0, 20, Limits{}, 3, nil},
+		{"within limits", 0, 20, Limits{MaxBlockRange: 21, MaxLogs: 3}, 3, nil},
+		{"latest", 10, -1, Limits{MaxBlockRange: 11}, 1, nil},
+		{"range too large", 0, 20, Limits{MaxBlockRange: 20}, 0, errBlockRangeTooLarge},
+		{"too many logs", 0, 20, Limits{MaxLogs: 2}, 0, errTooManyLogs},
+		{"beyond head", 15, 100, Limits{MaxBlockRange: 6}, 0, nil},
+		{"invalid range", 5, 4, Limits{}, 0, errInvalidBlockRange},
+	}
+	for _, tt := range tests {
+		crit := FilterCriteria{FromBlock: blockNumber(tt.from), ToBlock: blockNumber(tt.to), Addresses: []types.Address{contract}}
+		logs, err := NewFilter(backend, indexer, crit, tt.limits).Logs(context.Background())
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+		if len(logs) != tt.count {
+			t.Fatalf("%s: %d logs, want %d", tt.name, len(logs), tt.count)
+		}
+	}
+}
+
+// 索引启用前的区块在首次查询时补写bloom，此后跳过不相关的区块
+func TestFilter_Backfill(t *testing.T) {
+	contract := types.HexToAddress("0x00000000000000000000000000000000000000aa")
+	other := types.HexToAddress("0x00000000000000000000000000000000000000bb")
+	backend := newTestBackend(9, contract, other, map[uint64]int{2: 1, 7: 1})
+	indexer := NewBloomIndexer(memorydb.New())
+	// 自区块5起建立索引
+	for height := uint64(5); height <= 9; height++ {
+		if err := indexer.Add(height, backend.receipts[height]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	crit := FilterCriteria{FromBlock: blockNumber(0), ToBlock: blockNumber(9), Addresses: []types.Address{contract}}
+	want := [][]uint64{
+		{0, 1, 2, 3, 4, 7}, // 首次查询读取全部未建索引的区块
+		{2, 7},             // 补写后仅读取bloom匹配的区块
+	}
+	for i, reads := range want {
+		backend.reads = nil
+		logs, err := NewFilter(backend, indexer, crit, DefaultLimits()).Logs(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(logs) != 2 || logs[0].BlockHeight != 2 || logs[1].BlockHeight != 7 {
+			t.Fatalf("query %d: logs %v", i, logs)
+		}
+		if len(backend.reads) != len(reads) {
+			t.Fatalf("query %d: read %v, want %v", i, backend.reads, reads)
+		}
+		for j := range reads {
+			if backend.reads[j] != reads[j] {
+				t.Fatalf("query %d: read %v, want %v", i, backend.reads, reads)
+			}
+		}
+	}
+
+	// 补写的区块不合并到分段bloom
+	if _, ok := indexer.SectionBloom(0); ok {
+		t.Fatal("section bloom available before index start")
+	}
+}
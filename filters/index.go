@@ -0,0 +1,112 @@
+// Package filters
+//
+// @author: xwc1125
+package filters
+
+import (
+	"encoding/binary"
+	"github.com/chain5j/chain5j-pkg/database/kvstore"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"sync"
+)
+
+// SectionSize 每个分段包含的区块数
+const SectionSize = 4096
+
+var (
+	blockBloomPrefix   = []byte("stateApp-bloom-b") // blockBloomPrefix + height -> 区块bloom
+	sectionBloomPrefix = []byte("stateApp-bloom-s") // sectionBloomPrefix + section -> 分段bloom
+	indexStartKey      = []byte("stateApp-bloom-start")
+)
+
+// BloomIndexer 日志的bloom索引，区块提交时写入
+// 每个区块记录其全部收据bloom的并集，每个分段记录其区块bloom的并集，查询时据此跳过不相关的区块
+// 索引启用前的区块没有bloom，查询时读取收据并补写区块bloom，此后的查询可据此跳过
+type BloomIndexer struct {
+	db kvstore.Database
+	mu sync.Mutex
+}
+
+func NewBloomIndexer(db kvstore.Database) *BloomIndexer {
+	return &BloomIndexer{db: db}
+}
+
+// Add 写入区块的bloom，并合并到所在分段
+func (idx *BloomIndexer) Add(height uint64, receipts statetype.Receipts) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if _, ok := idx.start(); !ok {
+		if err := idx.db.Put(indexStartKey, encodeUint64(height)); err != nil {
+			return err
+		}
+	}
+
+	var bloom statetype.Bloom
+	for _, receipt := range receipts {
+		orBloom(&bloom, receipt.LogsBloom)
+	}
+	if err := idx.db.Put(indexKey(blockBloomPrefix, height), bloom.Bytes()); err != nil {
+		return err
+	}
+
+	section := height / SectionSize
+	sectionBloom, _ := idx.get(indexKey(sectionBloomPrefix, section))
+	orBloom(&sectionBloom, bloom)
+	return idx.db.Put(indexKey(sectionBloomPrefix, section), sectionBloom.Bytes())
+}
+
+// Backfill 补写索引启用前区块的bloom，不合并到分段，分段bloom仅覆盖索引启用后的区块
+func (idx *BloomIndexer) Backfill(height uint64, receipts statetype.Receipts) error {
+	var bloom statetype.Bloom
+	for _, receipt := range receipts {
+		orBloom(&bloom, receipt.LogsBloom)
+	}
+	return idx.db.Put(indexKey(blockBloomPrefix, height), bloom.Bytes())
+}
+
+// BlockBloom 区块的bloom，区块未建索引时返回false
+func (idx *BloomIndexer) BlockBloom(height uint64) (statetype.Bloom, bool) {
+	return idx.get(indexKey(blockBloomPrefix, height))
+}
+
+// SectionBloom 分段的bloom，分段中存在未建索引的区块时返回false
+func (idx *BloomIndexer) SectionBloom(section uint64) (statetype.Bloom, bool) {
+	start, ok := idx.start()
+	if !ok || section*SectionSize < start {
+		return statetype.Bloom{}, false
+	}
+	return idx.get(indexKey(sectionBloomPrefix, section))
+}
+
+func (idx *BloomIndexer) start() (uint64, bool) {
+	data, err := idx.db.Get(indexStartKey)
+	if err != nil || len(data) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(data), true
+}
+
+func (idx *BloomIndexer) get(key []byte) (statetype.Bloom, bool) {
+	data, err := idx.db.Get(key)
+	if err != nil || len(data) != statetype.BloomByteLength {
+		return statetype.Bloom{}, false
+	}
+	return statetype.BytesToBloom(data), true
+}
+
+func orBloom(dst *statetype.Bloom, src statetype.Bloom) {
+	for i := range dst {
+		dst[i] |= src[i]
+	}
+}
+
+func indexKey(prefix []byte, n uint64) []byte {
+	return append(append([]byte{}, prefix...), encodeUint64(n)...)
+}
+
+func encodeUint64(n uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, n)
+	return enc
+}
@@ -0,0 +1,100 @@
+// Package testutil 测试共用的日志、状态、账户及解析器上下文
+//
+// @author: xwc1125
+package testutil
+
+import (
+	"crypto/ecdsa"
+	"github.com/chain5j/chain5j-pkg/codec"
+	"github.com/chain5j/chain5j-pkg/crypto/signature"
+	"github.com/chain5j/chain5j-pkg/database/kvstore/memorydb"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-protocol/pkg/database/ethStatedb"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-protocol/protocol"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/logger"
+	"github.com/chain5j/logger/zap"
+	"math/big"
+	"testing"
+)
+
+// 状态数据库及各模块创建时需要root logger，引用本包的测试无需再各自注册
+func init() {
+	logger.RegisterLog(zap.InitWithConfig(&logger.LogConfig{
+		Console: logger.ConsoleLogConfig{
+			Level:   4,
+			Modules: "*",
+			Console: true,
+		},
+	}))
+}
+
+// Encode 以链上的编码方式编码v
+func Encode(t *testing.T, v interface{}) []byte {
+	data, err := codec.Coder().Encode(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// NewState 基于内存数据库的空状态
+func NewState(t *testing.T) *statedb.StateDB {
+	state, err := statedb.New(types.Hash{}, memorydb.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+// NewEthState 基于内存数据库的空以太坊状态
+func NewEthState(t *testing.T) *ethStatedb.StateDB {
+	state, err := ethStatedb.New(types.Hash{}, ethStatedb.NewDatabase(memorydb.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+// NewKey 生成私钥及其地址
+func NewKey(t *testing.T) (*ecdsa.PrivateKey, types.Address) {
+	key, err := signature.GenerateKeyWithECDSA(signature.S256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, signature.PubkeyToAddress(&key.PublicKey)
+}
+
+// NewAccount 创建绑定新私钥地址的账户，返回私钥及账户，写入前可通过setup修改账户
+func NewAccount(t *testing.T, state *statedb.StateDB, cn, domain string, balance int64, setup ...func(store *accounts.AccountStore)) (*ecdsa.PrivateKey, *accounts.AccountStore) {
+	key, addr := NewKey(t)
+	store := accounts.NewAccountStore(cn, domain)
+	store.Balance = big.NewInt(balance)
+	store.SetAddress(addr, nil)
+	for _, fn := range setup {
+		fn(store)
+	}
+	state.CreateAccount(store)
+	return key, store
+}
+
+// NewCtx 指定高度的解析器上下文，config可为nil
+func NewCtx(t *testing.T, state *statedb.StateDB, height uint64, config protocol.Config) stateApp.InterpreterCtx {
+	ctx, err := stateApp.NewInterpreterCtx(state, nil, types.Hash{}, &models.Header{Height: height}, nil, 1<<32, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctx
+}
+
+// NewEthCtx 以太坊状态下指定高度的解析器上下文
+func NewEthCtx(t *testing.T, state *ethStatedb.StateDB, height uint64, config protocol.Config) stateApp.InterpreterCtx {
+	ctx, err := stateApp.NewInterpreterCtx(nil, state, types.Hash{}, &models.Header{Height: height}, nil, 1<<32, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctx
+}
@@ -4,6 +4,7 @@
 package stateApp
 
 import (
+	evm "github.com/chain5j/chain5j-evm"
 	"github.com/chain5j/chain5j-pkg/types"
 	"github.com/chain5j/chain5j-protocol/models"
 	"github.com/chain5j/chain5j-protocol/models/statetype"
@@ -21,8 +22,13 @@ const (
 	CAInterpreter         = "chain5j.ca"
 	EthereumInterpreter   = "chain5j.ethereum"
 	PermissionInterpreter = "chain5j.permission"
+	EscrowInterpreter     = "chain5j.escrow"
+	ScheduleInterpreter   = "chain5j.schedule"
 )
 
+// TxGas 原生解析器每笔交易的gas
+const TxGas uint64 = 21000
+
 type InterpreterContext struct {
 	stateDB       *statedb.StateDB
 	ethStateDB    *ethStatedb.StateDB
@@ -109,6 +115,32 @@ type Interpreter interface {
 	ApplyTransaction(ctx InterpreterCtx, tx models.StateTransaction, usedGas *uint64) (*statetype.Receipt, error)
 }
 
+// BlockHook 区块级处理，解析器可选实现
+// BeginBlock在交易池的交易之前执行，EndBlock在其之后执行，按解析器的注册顺序调用
+// 返回的系统收据需设置GasUsed，CumulativeGasUsed及日志的TxIndex由调用方统一计算
+type BlockHook interface {
+	BeginBlock(ctx InterpreterCtx, header *models.Header) ([]*statetype.Receipt, error)
+	EndBlock(ctx InterpreterCtx, header *models.Header) ([]*statetype.Receipt, error)
+}
+
+// HookTxResolver 区块级处理执行了不在区块中的交易时实现，返回系统收据对应的交易，用于建立交易索引及历史
+// 收据不对应交易时返回nil
+type HookTxResolver interface {
+	HookTransaction(ctx InterpreterCtx, receipt *statetype.Receipt) *Transaction
+}
+
+// GasSchedule 原生解析器的gas计费，解析器可选实现
+// 原生解析器的gas与gasLimit无关，Gas即为交易执行后收据中的GasUsed
+type GasSchedule interface {
+	Gas(tx models.StateTransaction) uint64
+}
+
+// TraceableInterpreter 支持执行跟踪的解析器，解析器可选实现
+// TraceTransaction与ApplyTransaction的执行结果一致，执行期间由tracer记录evm的执行过程
+type TraceableInterpreter interface {
+	TraceTransaction(ctx InterpreterCtx, tx models.StateTransaction, usedGas *uint64, tracer evm.Tracer) (*statetype.Receipt, error)
+}
+
 type InterpreterCtx interface {
 	Prepare(thash, bhash types.Hash, tcount int)
 	Snapshot() int
@@ -6,7 +6,6 @@ package accountInterpreter
 import (
 	"errors"
 	"github.com/chain5j/chain5j-pkg/codec"
-	"github.com/chain5j/chain5j-pkg/types"
 	"github.com/chain5j/chain5j-protocol/models"
 	"github.com/chain5j/chain5j-protocol/models/accounts"
 	"github.com/chain5j/chain5j-protocol/models/statetype"
@@ -30,14 +29,15 @@ func NewInterpreter() *AccountInterpreter {
 
 func (interpreter *AccountInterpreter) VerifyTx(ctx stateApp.InterpreterCtx, tx models.StateTransaction) error {
 	stateDB := ctx.StateDB()
+	height := ctx.Header().Height
 
-	accountFrom := stateDB.GetAccount(tx.From())
+	accountFrom := GetAccount(stateDB, tx.From())
 	// 账户未找到
 	if accountFrom == nil {
 		return stateApp.ErrFromAccountNotFound
 	}
-	if accountFrom.IsFrozen {
-		return stateApp.ErrFrozenAccount
+	if err := VerifyAccountFrozen(stateDB, accountFrom, height); err != nil {
+		return err
 	}
 
 	// 检查签名
@@ -45,15 +45,34 @@ func (interpreter *AccountInterpreter) VerifyTx(ctx stateApp.InterpreterCtx, tx
 	if err != nil {
 		return stateApp.ErrInvalidSigner
 	}
-	if err := checkSigner(accountFrom, signer); err != nil {
-		return stateApp.ErrInvalidSigner
-	}
 
 	var txData accounts.AccountOpData
 	if err := accounts.DecodeAccountOpData(tx.Input(), &txData); err != nil {
 		return err
 	}
 
+	switch txData.Operation {
+	case RemoveSessionKeyOp:
+		// 会话密钥可以移除自身，不受范围限制，由VerifyRemoveSessionKeyOp校验
+	case ReleaseVestingOp:
+		// 释放归属计划只转入本账户，允许使用范围内的会话密钥
+		// 创建归属计划的受益人及金额不在交易的接收方及金额中，无法按范围限制，须使用控制地址签名
+		if err := VerifySigner(stateDB, accountFrom, signer, stateApp.AccountInterpreter, tx, height); err != nil {
+			return err
+		}
+	default:
+		if err := VerifyControlSigner(accountFrom, signer); err != nil {
+			return err
+		}
+	}
+
+	// 宽限期内仍允许域管理员续期
+	if txData.Operation != RenewDomainOp {
+		if err := VerifyDomainActive(stateDB, accountFrom.Domain, height); err != nil {
+			return err
+		}
+	}
+
 	switch txData.Operation {
 	case accounts.RegisterAccountOp:
 		var accountRegister accounts.AccountStore
@@ -67,7 +86,7 @@ func (interpreter *AccountInterpreter) VerifyTx(ctx stateApp.InterpreterCtx, tx
 			return errInvalidInput
 		}
 
-		if err := VerifyAccountRegister(stateDB, accountFrom, &accountRegister); err != nil {
+		if err := VerifyAccountRegister(stateDB, accountFrom, &accountRegister, height); err != nil {
 			return err
 		}
 
@@ -84,12 +103,12 @@ func (interpreter *AccountInterpreter) VerifyTx(ctx stateApp.InterpreterCtx, tx
 		}
 
 	case accounts.FrozenAccountOp:
-		if err := VerifyAccountFrozenOp(stateDB, accountFrom, txData.Data); err != nil {
+		if err := VerifyAccountFrozenOp(stateDB, accountFrom, txData.Data, height); err != nil {
 			return err
 		}
 
 	case accounts.UpdateDataPermissionOp:
-		if err := VerifyUpdateDataPermissionOp(stateDB, accountFrom, txData.Data); err != nil {
+		if err := VerifyUpdateDataPermissionOp(stateDB, accountFrom, txData.Data, height); err != nil {
 			return err
 		}
 
@@ -97,6 +116,81 @@ func (interpreter *AccountInterpreter) VerifyTx(ctx stateApp.InterpreterCtx, tx
 		if err := VerifySetPartnerOp(stateDB, accountFrom, txData.Data); err != nil {
 			return err
 		}
+
+	case TransferDomainOp:
+		if err := VerifyTransferDomainOp(stateDB, accountFrom, txData.Data, height); err != nil {
+			return err
+		}
+
+	case AcceptDomainOp:
+		if err := VerifyAcceptDomainOp(stateDB, accountFrom, txData.Data); err != nil {
+			return err
+		}
+
+	case SetDomainExpiryOp:
+		if err := VerifyDomainExpiryOp(stateDB, accountFrom, txData.Data, height); err != nil {
+			return err
+		}
+
+	case RenewDomainOp:
+		if err := VerifyRenewDomainOp(stateDB, accountFrom, txData.Data, height); err != nil {
+			return err
+		}
+
+	case DefineRoleOp:
+		if err := VerifyDefineRoleOp(stateDB, accountFrom, txData.Data); err != nil {
+			return err
+		}
+
+	case AssignRoleOp:
+		if err := VerifyAssignRoleOp(stateDB, accountFrom, txData.Data); err != nil {
+			return err
+		}
+
+	case FreezeAccountOp:
+		if err := VerifyFreezeAccountOp(stateDB, accountFrom, txData.Data, height); err != nil {
+			return err
+		}
+
+	case FreezeDomainOp:
+		if err := VerifyFreezeDomainOp(stateDB, accountFrom, txData.Data, height); err != nil {
+			return err
+		}
+
+	case DelegateAdminOp:
+		if err := VerifyDelegateAdminOp(stateDB, accountFrom, txData.Data, height); err != nil {
+			return err
+		}
+
+	case RevokeDelegationOp:
+		if err := VerifyRevokeDelegationOp(stateDB, accountFrom, txData.Data); err != nil {
+			return err
+		}
+
+	case AddSessionKeyOp:
+		if err := VerifyAddSessionKeyOp(stateDB, accountFrom, signer, txData.Data, height); err != nil {
+			return err
+		}
+
+	case RemoveSessionKeyOp:
+		if err := VerifyRemoveSessionKeyOp(stateDB, accountFrom, signer, txData.Data); err != nil {
+			return err
+		}
+
+	case SetSpendingLimitOp:
+		if err := VerifySetSpendingLimitOp(stateDB, accountFrom, txData.Data); err != nil {
+			return err
+		}
+
+	case CreateVestingOp:
+		if err := VerifyCreateVestingOp(stateDB, accountFrom, txData.Data, ctx.Header().Timestamp); err != nil {
+			return err
+		}
+
+	case ReleaseVestingOp:
+		if err := VerifyReleaseVestingOp(stateDB, accountFrom, txData.Data, height, ctx.Header().Timestamp); err != nil {
+			return err
+		}
 		//case TODO:
 	default:
 		return stateApp.ErrInvalidAccountOp
@@ -105,8 +199,26 @@ func (interpreter *AccountInterpreter) VerifyTx(ctx stateApp.InterpreterCtx, tx
 	return nil
 }
 
+// Gas 每笔交易的gas固定为stateApp.TxGas
+func (interpreter *AccountInterpreter) Gas(tx models.StateTransaction) uint64 {
+	return stateApp.TxGas
+}
+
+// BeginBlock 在区块开始时解冻当前高度到期的账户及域，不产生收据
+func (interpreter *AccountInterpreter) BeginBlock(ctx stateApp.InterpreterCtx, header *models.Header) ([]*statetype.Receipt, error) {
+	if stateDB := ctx.StateDB(); stateDB != nil {
+		ApplyScheduledUnfreezes(stateDB, header.Height)
+	}
+	return nil, nil
+}
+
+func (interpreter *AccountInterpreter) EndBlock(ctx stateApp.InterpreterCtx, header *models.Header) ([]*statetype.Receipt, error) {
+	return nil, nil
+}
+
 func (interpreter *AccountInterpreter) ApplyTransaction(ctx stateApp.InterpreterCtx, tx models.StateTransaction, usedGas *uint64) (*statetype.Receipt, error) {
 	stateDB := ctx.StateDB()
+	height := ctx.Header().Height
 
 	if err := interpreter.VerifyTx(ctx, tx); err != nil {
 		return nil, err
@@ -136,19 +248,64 @@ func (interpreter *AccountInterpreter) ApplyTransaction(ctx stateApp.Interpreter
 		RegisterDomain(stateDB, &accountRegister, ctx.BlockReadWriter().CurrentBlock().Height()+1)
 
 	case accounts.FrozenAccountOp:
-		FrozenAccount(stateDB, txData.Data)
+		FrozenAccount(stateDB, tx.From(), txData.Data, height)
 
 	case accounts.UpdateDataPermissionOp:
 		UpdatePermission(stateDB, txData.Data)
 
 	case accounts.SetPartnerOp:
 		SetPartner(stateDB, tx.From(), txData.Data)
+
+	case TransferDomainOp:
+		TransferDomain(stateDB, txData.Data)
+
+	case AcceptDomainOp:
+		AcceptDomain(stateDB, txData.Data)
+
+	case SetDomainExpiryOp:
+		SetDomainExpiry(stateDB, txData.Data)
+
+	case RenewDomainOp:
+		RenewDomain(stateDB, txData.Data, height)
+
+	case DefineRoleOp:
+		DefineRole(stateDB, txData.Data)
+
+	case AssignRoleOp:
+		AssignRole(stateDB, txData.Data)
+
+	case FreezeAccountOp:
+		FreezeAccount(stateDB, tx.From(), txData.Data, height)
+
+	case FreezeDomainOp:
+		FreezeDomain(stateDB, tx.From(), txData.Data, height)
+
+	case DelegateAdminOp:
+		DelegateAdmin(stateDB, tx.From(), txData.Data, height)
+
+	case RevokeDelegationOp:
+		RevokeDelegation(stateDB, tx.From(), txData.Data)
+
+	case AddSessionKeyOp:
+		AddSessionKey(stateDB, tx.From(), txData.Data, height)
+
+	case RemoveSessionKeyOp:
+		RemoveSessionKey(stateDB, tx.From(), txData.Data)
+
+	case SetSpendingLimitOp:
+		SetSpendingLimit(stateDB, txData.Data)
+
+	case CreateVestingOp:
+		CreateVesting(stateDB, tx.From(), txData.Data, height, ctx.Header().Timestamp)
+
+	case ReleaseVestingOp:
+		ReleaseVesting(stateDB, tx.From(), txData.Data, height, ctx.Header().Timestamp)
 		//case TODO:
 	default:
 		return nil, stateApp.ErrInvalidAccountOp
 	}
 
-	*usedGas += uint64(21000)
+	*usedGas += interpreter.Gas(tx)
 
 	account := tx.From()
 	stateDB.SetNonce(account, stateDB.GetNonce(account)+1)
@@ -157,18 +314,10 @@ func (interpreter *AccountInterpreter) ApplyTransaction(ctx stateApp.Interpreter
 		Status:            1,
 		CumulativeGasUsed: *usedGas,
 		TransactionHash:   tx.Hash(),
-		GasUsed:           uint64(21000),
-		Logs:              nil,
+		GasUsed:           interpreter.Gas(tx),
+		Logs:              stateDB.GetLogs(tx.Hash()),
 	}
+	receipt.LogsBloom = statetype.CreateBloom(statetype.Receipts{receipt})
 
 	return receipt, nil
 }
-
-func checkSigner(store *accounts.AccountStore, signer types.Address) error {
-
-	if !store.ContainAddress(signer) {
-		return stateApp.ErrInvalidSigner
-	}
-
-	return nil
-}
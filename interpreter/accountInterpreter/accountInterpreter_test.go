@@ -0,0 +1,39 @@
+// Package accountInterpreter
+//
+// @author: xwc1125
+package accountInterpreter
+
+import (
+	"crypto/ecdsa"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/internal/testutil"
+	"math/big"
+	"testing"
+)
+
+// newTestAccount 创建账户，admin为true时同时注册域。域信息写入后需计算中间根才可读取
+func newTestAccount(state *statedb.StateDB, cn, domain string, addr types.Address, balance int64, admin bool) *accounts.AccountStore {
+	store := accounts.NewAccountStore(cn, domain)
+	store.Balance = big.NewInt(balance)
+	store.SetAddress(addr, nil)
+	if admin {
+		RegisterDomain(state, store, 1)
+		state.IntermediateRoot(false)
+	} else {
+		state.CreateAccount(store)
+	}
+	return store
+}
+
+// newOpTx 构造并签名账户操作交易
+func newOpTx(t *testing.T, key *ecdsa.PrivateKey, from string, op accounts.AccountOp, data interface{}) *stateApp.Transaction {
+	input := testutil.Encode(t, &accounts.AccountOpData{Operation: op, Data: testutil.Encode(t, data)})
+	tx := stateApp.NewTransaction(from, from, stateApp.AccountInterpreter, 0, 0, stateApp.TxGas, big.NewInt(0), input, 0, nil)
+	if _, err := tx.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
@@ -3,10 +3,37 @@
 // @author: xwc1125
 package accountInterpreter
 
+import "github.com/chain5j/chain5j-protocol/models/accounts"
+
 const (
 	MinAccountNameLen = 3
 	MaxAccountNameLen = 64
 
 	MinDomainLen = 3
 	MaxDomainLen = 64
+
+	// BlocksPerDay 以区块数表示的一天，按3秒出块估算，过期、宽限等高度均以区块数计算
+	BlocksPerDay = uint64(24 * 3600 / 3)
+
+	DomainGracePeriod    = 7 * BlocksPerDay // 域过期后的宽限区块数
+	MaxDomainRenewBlocks = 10 * 365 * BlocksPerDay
+)
+
+// 扩展的账户操作，避开accounts中已定义的操作
+const (
+	TransferDomainOp   accounts.AccountOp = iota + 100 // 发起域管理员转移
+	AcceptDomainOp                                     // 接受域管理员转移
+	SetDomainExpiryOp                                  // 设置顶级域过期高度
+	RenewDomainOp                                      // 域续期
+	DefineRoleOp                                       // 定义域角色
+	AssignRoleOp                                       // 分配账户角色
+	FreezeAccountOp                                    // 冻结账户，附带原因及自动解冻高度
+	FreezeDomainOp                                     // 冻结整个域
+	DelegateAdminOp                                    // 委托管理员权限
+	RevokeDelegationOp                                 // 撤销委托
+	AddSessionKeyOp                                    // 添加会话密钥
+	RemoveSessionKeyOp                                 // 移除会话密钥
+	SetSpendingLimitOp                                 // 设置账户转出额度
+	CreateVestingOp                                    // 创建锁仓
+	ReleaseVestingOp                                   // 释放已到期的锁仓
 )
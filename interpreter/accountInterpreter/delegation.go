@@ -0,0 +1,311 @@
+// Package accountInterpreter
+//
+// @author: xwc1125
+package accountInterpreter
+
+import (
+	"errors"
+	"github.com/chain5j/chain5j-pkg/codec"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp"
+	"strings"
+)
+
+var (
+	errInvalidDelegateBlocks = errors.New("invalid delegation blocks")
+	errInvalidDelegate       = errors.New("invalid delegate account")
+	errDelegationNonExists   = errors.New("delegation not exists")
+	errTooManyDelegations    = errors.New("too many delegations")
+)
+
+const (
+	MaxDelegateBlocks = 365 * BlocksPerDay
+	MaxDelegations    = 32 // 单个账户可接受或授予的委托数量上限
+)
+
+// Delegation 管理员委托，被委托账户在过期前可以以授权人的身份行使部分权限
+type Delegation struct {
+	Grantor      string               `json:"grantor"`       // 授权管理员账户名称
+	Grantee      string               `json:"grantee"`       // 被委托账户名称
+	Permissions  accounts.Permissions `json:"permissions"`   // 委托的权限，不超过授权人权限
+	Height       uint64               `json:"height"`        // 授权高度
+	ExpiryHeight uint64               `json:"expiry_height"` // 过期高度
+}
+
+// Active 委托在指定高度是否有效
+func (d *Delegation) Active(height uint64) bool {
+	return height < d.ExpiryHeight
+}
+
+// DelegateAdminData 委托管理员权限的数据对象，对同一账户重复委托时覆盖原委托
+type DelegateAdminData struct {
+	CN          string
+	Domain      string
+	Permissions accounts.Permissions
+	Blocks      uint64
+}
+
+func (data *DelegateAdminData) Normalize() {
+	data.CN = strings.ToLower(data.CN)
+	data.Domain = strings.ToLower(data.Domain)
+}
+
+// RevokeDelegationData 撤销委托的数据对象，授权人撤销对CN@Domain的委托，或被委托人放弃来自CN@Domain的委托
+type RevokeDelegationData struct {
+	CN     string
+	Domain string
+}
+
+func (data *RevokeDelegationData) Normalize() {
+	data.CN = strings.ToLower(data.CN)
+	data.Domain = strings.ToLower(data.Domain)
+}
+
+func delegationKey(grantee string) []byte {
+	return stateApp.SystemKey("delegation", grantee)
+}
+
+func grantedDelegationKey(grantor string) []byte {
+	return stateApp.SystemKey("delegation_granted", grantor)
+}
+
+// DelegationsTo 账户接受的全部委托，包含已过期的委托
+func DelegationsTo(state *statedb.StateDB, grantee string) []Delegation {
+	var delegations []Delegation
+	ok, err := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress).Get(delegationKey(grantee), &delegations)
+	if !ok || err != nil {
+		return nil
+	}
+	return delegations
+}
+
+// DelegationsFrom 账户授予的全部委托，包含已过期的委托
+func DelegationsFrom(state *statedb.StateDB, grantor string) []Delegation {
+	var grantees []string
+	ok, err := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress).Get(grantedDelegationKey(grantor), &grantees)
+	if !ok || err != nil {
+		return nil
+	}
+
+	var delegations []Delegation
+	for _, grantee := range grantees {
+		for _, d := range DelegationsTo(state, grantee) {
+			if d.Grantor == grantor {
+				delegations = append(delegations, d)
+			}
+		}
+	}
+	return delegations
+}
+
+func setDelegationsTo(state *statedb.StateDB, grantee string, delegations []Delegation) {
+	store := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress)
+	if len(delegations) == 0 {
+		store.Delete(delegationKey(grantee))
+		return
+	}
+	store.Set(delegationKey(grantee), delegations)
+}
+
+func setGrantees(state *statedb.StateDB, grantor string, grantees []string) {
+	store := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress)
+	if len(grantees) == 0 {
+		store.Delete(grantedDelegationKey(grantor))
+		return
+	}
+	store.Set(grantedDelegationKey(grantor), grantees)
+}
+
+func getGrantees(state *statedb.StateDB, grantor string) []string {
+	var grantees []string
+	stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress).Get(grantedDelegationKey(grantor), &grantees)
+	return grantees
+}
+
+// authority 执行管理操作时的身份：账户自身或其代表的授权管理员
+type authority struct {
+	account *accounts.AccountStore
+	caps    Capability
+}
+
+// authorities 账户可以行使的全部身份，自身在前，其后为有效的委托
+// 委托能力取委托时的权限与授权人当前能力的交集，授权人失去相应能力或被冻结时委托失效
+func authorities(state *statedb.StateDB, accountFrom *accounts.AccountStore, height uint64) []authority {
+	list := []authority{{account: accountFrom, caps: AccountCapabilities(state, accountFrom)}}
+	for _, d := range DelegationsTo(state, accountFrom.AccountName()) {
+		if !d.Active(height) {
+			continue
+		}
+		grantor := GetAccount(state, d.Grantor)
+		if grantor == nil || VerifyAccountStatus(state, grantor, height) != nil {
+			continue
+		}
+		caps := PermissionCapabilities(&d.Permissions) & AccountCapabilities(state, grantor)
+		if caps == 0 {
+			continue
+		}
+		list = append(list, authority{account: grantor, caps: caps})
+	}
+	return list
+}
+
+// verifyWithAuthority 依次以账户自身及其委托身份校验，任一通过即可，均失败时返回自身的校验结果
+func verifyWithAuthority(state *statedb.StateDB, accountFrom *accounts.AccountStore, height uint64, verify func(from *accounts.AccountStore, caps Capability) error) error {
+	var first error
+	for i, auth := range authorities(state, accountFrom, height) {
+		err := verify(auth.account, auth.caps)
+		if err == nil {
+			return nil
+		}
+		if i == 0 {
+			first = err
+		}
+	}
+	return first
+}
+
+func VerifyDelegateAdminOp(state *statedb.StateDB, accountFrom *accounts.AccountStore, input []byte, height uint64) error {
+	var data DelegateAdminData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	if data.Blocks == 0 || data.Blocks > MaxDelegateBlocks {
+		return errInvalidDelegateBlocks
+	}
+
+	// 只能委托自己拥有的能力，委托的能力不能再次委托
+	caps := AccountCapabilities(state, accountFrom)
+	if caps == 0 {
+		return errUnauthorized
+	}
+	granted := PermissionCapabilities(&data.Permissions)
+	if granted == 0 || !caps.Has(granted) {
+		return errInvalidPermission
+	}
+
+	grantee := data.CN + accounts.DomainLinkFlag + data.Domain
+	if grantee == accountFrom.AccountName() {
+		return errInvalidDelegate
+	}
+	if GetAccount(state, grantee) == nil {
+		return errAccountNonExists
+	}
+
+	if len(activeDelegations(DelegationsTo(state, grantee), height)) >= MaxDelegations {
+		return errTooManyDelegations
+	}
+	if len(activeDelegations(DelegationsFrom(state, accountFrom.AccountName()), height)) >= MaxDelegations {
+		return errTooManyDelegations
+	}
+
+	return nil
+}
+
+func DelegateAdmin(state *statedb.StateDB, grantor string, input []byte, height uint64) error {
+	var data DelegateAdminData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	grantee := data.CN + accounts.DomainLinkFlag + data.Domain
+	delegations := removeDelegation(activeDelegations(DelegationsTo(state, grantee), height), grantor)
+	delegations = append(delegations, Delegation{
+		Grantor:      grantor,
+		Grantee:      grantee,
+		Permissions:  data.Permissions,
+		Height:       height,
+		ExpiryHeight: height + data.Blocks,
+	})
+	setDelegationsTo(state, grantee, delegations)
+
+	// 同时清理授权人已过期的委托记录
+	grantees := []string{grantee}
+	for _, d := range activeDelegations(DelegationsFrom(state, grantor), height) {
+		if !containsString(grantees, d.Grantee) {
+			grantees = append(grantees, d.Grantee)
+		}
+	}
+	setGrantees(state, grantor, grantees)
+
+	return nil
+}
+
+func VerifyRevokeDelegationOp(state *statedb.StateDB, accountFrom *accounts.AccountStore, input []byte) error {
+	var data RevokeDelegationData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	account := accountFrom.AccountName()
+	other := data.CN + accounts.DomainLinkFlag + data.Domain
+	for _, d := range DelegationsTo(state, other) {
+		if d.Grantor == account {
+			return nil
+		}
+	}
+	for _, d := range DelegationsTo(state, account) {
+		if d.Grantor == other {
+			return nil
+		}
+	}
+	return errDelegationNonExists
+}
+
+func RevokeDelegation(state *statedb.StateDB, account string, input []byte) error {
+	var data RevokeDelegationData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	other := data.CN + accounts.DomainLinkFlag + data.Domain
+	// 授权人撤销
+	revokeDelegation(state, account, other)
+	// 被委托人放弃
+	revokeDelegation(state, other, account)
+
+	return nil
+}
+
+func revokeDelegation(state *statedb.StateDB, grantor, grantee string) {
+	delegations := DelegationsTo(state, grantee)
+	remain := removeDelegation(delegations, grantor)
+	if len(remain) == len(delegations) {
+		return
+	}
+	setDelegationsTo(state, grantee, remain)
+
+	grantees := getGrantees(state, grantor)
+	remainGrantees := make([]string, 0, len(grantees))
+	for _, g := range grantees {
+		if g != grantee {
+			remainGrantees = append(remainGrantees, g)
+		}
+	}
+	setGrantees(state, grantor, remainGrantees)
+}
+
+func activeDelegations(delegations []Delegation, height uint64) []Delegation {
+	active := make([]Delegation, 0, len(delegations))
+	for _, d := range delegations {
+		if d.Active(height) {
+			active = append(active, d)
+		}
+	}
+	return active
+}
+
+func removeDelegation(delegations []Delegation, grantor string) []Delegation {
+	remain := make([]Delegation, 0, len(delegations))
+	for _, d := range delegations {
+		if d.Grantor != grantor {
+			remain = append(remain, d)
+		}
+	}
+	return remain
+}
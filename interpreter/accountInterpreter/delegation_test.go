@@ -0,0 +1,64 @@
+// Package accountInterpreter
+//
+// @author: xwc1125
+package accountInterpreter
+
+import (
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-stateApp/internal/testutil"
+	"testing"
+)
+
+func TestDelegatedAuthority(t *testing.T) {
+	state := testutil.NewState(t)
+	newTestAccount(state, "admin", "bank", types.Address{1}, 0, true)
+	newTestAccount(state, "alice", "bank", types.Address{2}, 0, false)
+	newTestAccount(state, "bob", "bank", types.Address{3}, 0, false)
+	newTestAccount(state, "carol", "bank", types.Address{4}, 0, false)
+
+	delegate := testutil.Encode(t, &DelegateAdminData{CN: "alice", Domain: "bank", Permissions: accounts.Permissions{EnableFrozenUser: true}, Blocks: 100})
+	if err := VerifyDelegateAdminOp(state, GetAccount(state, "admin@bank"), delegate, 10); err != nil {
+		t.Fatal(err)
+	}
+	DelegateAdmin(state, "admin@bank", delegate, 10)
+
+	freezeBob := testutil.Encode(t, &FreezeAccountData{CN: "bob", Domain: "bank", Frozen: true})
+	register := accounts.NewAccountStore("dave", "bank")
+	tests := []struct {
+		name   string
+		setup  func()
+		height uint64
+		err    error
+	}{
+		{"active", nil, 50, nil},
+		{"expired", nil, 110, errUnauthorized},
+		{"grantor frozen", func() {
+			FreezeAccount(state, "", testutil.Encode(t, &FreezeAccountData{CN: "admin", Domain: "bank", Frozen: true, UnfreezeHeight: 60}), 50)
+		}, 55, errUnauthorized},
+		{"grantor unfrozen", func() { ApplyScheduledUnfreezes(state, 60) }, 60, nil},
+		{"revoked", func() {
+			RevokeDelegation(state, "alice@bank", testutil.Encode(t, &RevokeDelegationData{CN: "admin", Domain: "bank"}))
+		}, 60, errUnauthorized},
+	}
+	for _, tt := range tests {
+		if tt.setup != nil {
+			tt.setup()
+		}
+		if err := VerifyFreezeAccountOp(state, GetAccount(state, "alice@bank"), freezeBob, tt.height); err != tt.err {
+			t.Fatalf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+		// 未委托的注册权限始终不可用
+		if err := VerifyAccountRegister(state, GetAccount(state, "alice@bank"), register, tt.height); err != errUnauthorized {
+			t.Fatalf("%s: register %v", tt.name, err)
+		}
+	}
+	if len(DelegationsFrom(state, "admin@bank")) != 0 {
+		t.Fatal("revoked delegation still listed")
+	}
+
+	tooLong := testutil.Encode(t, &DelegateAdminData{CN: "carol", Domain: "bank", Permissions: accounts.Permissions{EnableFrozenUser: true}, Blocks: MaxDelegateBlocks + 1})
+	if err := VerifyDelegateAdminOp(state, GetAccount(state, "admin@bank"), tooLong, 10); err != errInvalidDelegateBlocks {
+		t.Fatalf("delegate too long: %v", err)
+	}
+}
@@ -3,8 +3,404 @@
 // @author: xwc1125
 package accountInterpreter
 
-import "strings"
+import (
+	"errors"
+	"github.com/chain5j/chain5j-pkg/codec"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp"
+	"strings"
+)
+
+var (
+	errDomainNonExists      = errors.New("domain not exists")
+	errDomainExpired        = errors.New("domain expired")
+	errNotTopLevelDomain    = errors.New("not a top-level domain")
+	errNoPendingTransfer    = errors.New("no pending domain transfer")
+	errDomainNotExpirable   = errors.New("domain has no expiry")
+	errDomainRenewTooLate   = errors.New("domain grace period is over")
+	errInvalidDomainAdmin   = errors.New("invalid domain admin")
+	errInvalidExpiryHeight  = errors.New("invalid domain expiry height")
+	errInvalidRenewInterval = errors.New("invalid domain renew interval")
+)
+
+const (
+	DomainStatusActive  = "active"  // 正常
+	DomainStatusGrace   = "grace"   // 已过期，处于宽限期，仅允许续期
+	DomainStatusExpired = "expired" // 已过期且超过宽限期
+)
+
+// DomainMeta 域的扩展信息
+type DomainMeta struct {
+	Admin        string                // 当前管理员名称，不包含域。为空时以DomainStore.Admin为准
+	Permissions  *accounts.Permissions `rlp:"nil"` // 当前管理员权限
+	PendingAdmin string                // 待接受转移的管理员名称
+	FormerAdmins []string              // 已移交管理权的管理员名称
+	ExpiryHeight uint64                // 过期高度，为0表示不过期
+}
+
+// TransferDomainData 发起域管理员转移的数据对象，NewAdmin为空时取消转移
+type TransferDomainData struct {
+	Domain   string
+	NewAdmin string
+}
+
+func (data *TransferDomainData) Normalize() {
+	data.Domain = strings.ToLower(data.Domain)
+	data.NewAdmin = strings.ToLower(data.NewAdmin)
+}
+
+// AcceptDomainData 接受域管理员转移的数据对象
+type AcceptDomainData struct {
+	Domain string
+}
+
+func (data *AcceptDomainData) Normalize() {
+	data.Domain = strings.ToLower(data.Domain)
+}
+
+// DomainExpiryData 设置顶级域过期高度的数据对象，ExpiryHeight为0时取消过期
+type DomainExpiryData struct {
+	Domain       string
+	ExpiryHeight uint64
+}
+
+func (data *DomainExpiryData) Normalize() {
+	data.Domain = strings.ToLower(data.Domain)
+}
+
+// RenewDomainData 域续期的数据对象
+type RenewDomainData struct {
+	Domain string
+	Blocks uint64
+}
+
+func (data *RenewDomainData) Normalize() {
+	data.Domain = strings.ToLower(data.Domain)
+}
 
 func isSubDomain(domain, subdomain string) bool {
 	return strings.HasSuffix(subdomain, "."+domain)
-}
\ No newline at end of file
+}
+
+// InDomain 账户是否属于指定的域或其子域
+func InDomain(store *accounts.AccountStore, domain string) bool {
+	domain = strings.ToLower(domain)
+	return store.Domain == domain || isSubDomain(domain, store.Domain)
+}
+
+// topLevelDomain 获取域对应的顶级域
+func topLevelDomain(domain string) string {
+	if i := strings.LastIndex(domain, "."); i >= 0 {
+		return domain[i+1:]
+	}
+	return domain
+}
+
+func domainMetaKey(domain string) []byte {
+	return stateApp.SystemKey("domain", domain)
+}
+
+// GetDomainMeta 获取域的扩展信息，不存在时返回nil
+func GetDomainMeta(state *statedb.StateDB, domain string) *DomainMeta {
+	var meta DomainMeta
+	ok, err := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress).Get(domainMetaKey(domain), &meta)
+	if !ok || err != nil {
+		return nil
+	}
+	return &meta
+}
+
+func setDomainMeta(state *statedb.StateDB, domain string, meta *DomainMeta) {
+	stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress).Set(domainMetaKey(domain), meta)
+}
+
+// DomainAdmin 获取域当前管理员名称，不包含域
+func DomainAdmin(state *statedb.StateDB, domain string) string {
+	if meta := GetDomainMeta(state, domain); meta != nil && meta.Admin != "" {
+		return meta.Admin
+	}
+	if store := state.GetDomain(domain); store != nil {
+		return store.Admin
+	}
+	return ""
+}
+
+// DomainExpiryHeight 域所在顶级域的过期高度，为0表示不过期
+func DomainExpiryHeight(state *statedb.StateDB, domain string) uint64 {
+	if meta := GetDomainMeta(state, topLevelDomain(domain)); meta != nil {
+		return meta.ExpiryHeight
+	}
+	return 0
+}
+
+// DomainStatus 根据过期高度计算域在指定高度的状态
+func DomainStatus(meta *DomainMeta, height uint64) string {
+	if meta == nil || meta.ExpiryHeight == 0 || height < meta.ExpiryHeight {
+		return DomainStatusActive
+	}
+	if height < meta.ExpiryHeight+DomainGracePeriod {
+		return DomainStatusGrace
+	}
+	return DomainStatusExpired
+}
+
+// VerifyDomainActive 账户所在的顶级域过期后，其下所有账户的操作均被阻止
+func VerifyDomainActive(state *statedb.StateDB, domain string, height uint64) error {
+	if domain == "" || domain == accounts.ContractDomain {
+		return nil
+	}
+	if DomainStatus(GetDomainMeta(state, topLevelDomain(domain)), height) != DomainStatusActive {
+		return errDomainExpired
+	}
+	return nil
+}
+
+// GetAccount 获取账户，并根据域管理员的转移情况修正管理员标识及权限
+// 域管理员转移后账户中的IsAdmin及Permissions不再更新，读取管理员身份时均需通过此方法
+func GetAccount(state *statedb.StateDB, account string) *accounts.AccountStore {
+	return ApplyDomainAdmin(state, state.GetAccount(account))
+}
+
+// ApplyDomainAdmin 根据域管理员的转移情况修正账户的管理员标识及权限，用于直接从账户树中读取的账户
+func ApplyDomainAdmin(state *statedb.StateDB, store *accounts.AccountStore) *accounts.AccountStore {
+	if store == nil || store.Domain == "" {
+		return store
+	}
+
+	meta := GetDomainMeta(state, store.Domain)
+	if meta == nil || meta.Admin == "" {
+		return store
+	}
+
+	if store.CN == meta.Admin {
+		store.IsAdmin = true
+		store.Permissions = copyPermissions(meta.Permissions)
+		return store
+	}
+	for _, former := range meta.FormerAdmins {
+		if store.CN == former {
+			store.IsAdmin = false
+			store.Permissions = nil
+			break
+		}
+	}
+	return store
+}
+
+func copyPermissions(p *accounts.Permissions) *accounts.Permissions {
+	if p == nil {
+		return nil
+	}
+	cpy := *p
+	return &cpy
+}
+
+func VerifyTransferDomainOp(state *statedb.StateDB, accountFrom *accounts.AccountStore, input []byte, height uint64) error {
+	var data TransferDomainData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	if state.GetDomain(data.Domain) == nil {
+		return errDomainNonExists
+	}
+
+	// 只有域的当前管理员可以发起转移
+	if accountFrom.Domain != data.Domain || accountFrom.CN != DomainAdmin(state, data.Domain) {
+		return errUnauthorized
+	}
+
+	if data.NewAdmin == "" {
+		return nil
+	}
+	if data.NewAdmin == accountFrom.CN {
+		return errInvalidDomainAdmin
+	}
+
+	newAdmin := GetAccount(state, data.NewAdmin+accounts.DomainLinkFlag+data.Domain)
+	if newAdmin == nil {
+		return errAccountNonExists
+	}
+	if IsAccountFrozen(state, newAdmin, height) {
+		return stateApp.ErrFrozenAccount
+	}
+
+	return nil
+}
+
+func TransferDomain(state *statedb.StateDB, input []byte) error {
+	var data TransferDomainData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	meta := GetDomainMeta(state, data.Domain)
+	if meta == nil {
+		meta = new(DomainMeta)
+	}
+	meta.PendingAdmin = data.NewAdmin
+	setDomainMeta(state, data.Domain, meta)
+
+	return nil
+}
+
+func VerifyAcceptDomainOp(state *statedb.StateDB, accountFrom *accounts.AccountStore, input []byte) error {
+	var data AcceptDomainData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	meta := GetDomainMeta(state, data.Domain)
+	if meta == nil || meta.PendingAdmin == "" {
+		return errNoPendingTransfer
+	}
+
+	// 只有被指定的新管理员可以接受
+	if accountFrom.Domain != data.Domain || accountFrom.CN != meta.PendingAdmin {
+		return errUnauthorized
+	}
+
+	return nil
+}
+
+func AcceptDomain(state *statedb.StateDB, input []byte) error {
+	var data AcceptDomainData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	meta := GetDomainMeta(state, data.Domain)
+	if meta == nil {
+		return errNoPendingTransfer
+	}
+	domainStore := state.GetDomain(data.Domain)
+	if domainStore == nil {
+		return errDomainNonExists
+	}
+
+	formerAdmin := DomainAdmin(state, data.Domain)
+	if meta.Permissions == nil {
+		if former := GetAccount(state, formerAdmin+accounts.DomainLinkFlag+data.Domain); former != nil {
+			meta.Permissions = copyPermissions(former.Permissions)
+		}
+	}
+
+	formerAdmins := make([]string, 0, len(meta.FormerAdmins)+1)
+	for _, cn := range meta.FormerAdmins {
+		if cn != meta.PendingAdmin {
+			formerAdmins = append(formerAdmins, cn)
+		}
+	}
+	meta.FormerAdmins = append(formerAdmins, formerAdmin)
+	meta.Admin = meta.PendingAdmin
+	meta.PendingAdmin = ""
+	setDomainMeta(state, data.Domain, meta)
+
+	state.AddDomain(data.Domain, accounts.DomainStore{
+		Admin:  meta.Admin,
+		Number: domainStore.Number,
+	})
+
+	return nil
+}
+
+// isRootRegistrar 账户是否为根上拥有注册域权限的账户，可以管理全部顶级域
+func isRootRegistrar(state *statedb.StateDB, store *accounts.AccountStore) bool {
+	return store.Domain == "" && HasCapability(state, store, CapRegisterDomain)
+}
+
+func VerifyDomainExpiryOp(state *statedb.StateDB, accountFrom *accounts.AccountStore, input []byte, height uint64) error {
+	var data DomainExpiryData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	// 只有根上拥有注册域权限的账户可以设置，域自身的管理员不能取消过期
+	if !isRootRegistrar(state, accountFrom) {
+		return errUnauthorized
+	}
+
+	if topLevelDomain(data.Domain) != data.Domain {
+		return errNotTopLevelDomain
+	}
+	if state.GetDomain(data.Domain) == nil {
+		return errDomainNonExists
+	}
+	if data.ExpiryHeight != 0 && data.ExpiryHeight <= height {
+		return errInvalidExpiryHeight
+	}
+
+	return nil
+}
+
+func SetDomainExpiry(state *statedb.StateDB, input []byte) error {
+	var data DomainExpiryData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	meta := GetDomainMeta(state, data.Domain)
+	if meta == nil {
+		meta = new(DomainMeta)
+	}
+	meta.ExpiryHeight = data.ExpiryHeight
+	setDomainMeta(state, data.Domain, meta)
+
+	return nil
+}
+
+func VerifyRenewDomainOp(state *statedb.StateDB, accountFrom *accounts.AccountStore, input []byte, height uint64) error {
+	var data RenewDomainData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	if data.Blocks == 0 || data.Blocks > MaxDomainRenewBlocks {
+		return errInvalidRenewInterval
+	}
+
+	meta := GetDomainMeta(state, data.Domain)
+	if meta == nil || meta.ExpiryHeight == 0 {
+		return errDomainNotExpirable
+	}
+	if DomainStatus(meta, height) == DomainStatusExpired {
+		return errDomainRenewTooLate
+	}
+
+	// 域管理员或根上拥有注册域权限的账户可以续期，通过角色获得注册域能力的其他域账户不能续期
+	isAdmin := accountFrom.Domain == data.Domain && accountFrom.CN == DomainAdmin(state, data.Domain)
+	if !isAdmin && !isRootRegistrar(state, accountFrom) {
+		return errUnauthorized
+	}
+
+	return nil
+}
+
+// RenewDomain 域续期，宽限期内续期时从当前高度开始计算，保证续期后域处于有效状态
+func RenewDomain(state *statedb.StateDB, input []byte, height uint64) error {
+	var data RenewDomainData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	meta := GetDomainMeta(state, data.Domain)
+	if meta == nil {
+		return errDomainNotExpirable
+	}
+	if meta.ExpiryHeight < height {
+		meta.ExpiryHeight = height
+	}
+	meta.ExpiryHeight += data.Blocks
+	setDomainMeta(state, data.Domain, meta)
+
+	return nil
+}
@@ -0,0 +1,182 @@
+// Package accountInterpreter
+//
+// @author: xwc1125
+package accountInterpreter
+
+import (
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-stateApp/internal/testutil"
+	"strings"
+	"testing"
+)
+
+func TestRenewDomain(t *testing.T) {
+	tests := []struct {
+		name   string
+		expiry uint64
+		height uint64
+		blocks uint64
+		want   uint64
+	}{
+		{"active", 1000, 500, 100, 1100},
+		{"grace", 1000, 1000 + DomainGracePeriod - 1, 100, 1000 + DomainGracePeriod + 99},
+		{"at expiry", 1000, 1000, 100, 1100},
+	}
+	for _, tt := range tests {
+		state := testutil.NewState(t)
+		newTestAccount(state, "admin", "bank", types.Address{1}, 0, true)
+		setDomainMeta(state, "bank", &DomainMeta{ExpiryHeight: tt.expiry})
+
+		input := testutil.Encode(t, &RenewDomainData{Domain: "bank", Blocks: tt.blocks})
+		admin := GetAccount(state, "admin@bank")
+		if err := VerifyRenewDomainOp(state, admin, input, tt.height); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if err := RenewDomain(state, input, tt.height); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		meta := GetDomainMeta(state, "bank")
+		if meta.ExpiryHeight != tt.want {
+			t.Fatalf("%s: expiry %d, want %d", tt.name, meta.ExpiryHeight, tt.want)
+		}
+		if DomainStatus(meta, tt.height) != DomainStatusActive {
+			t.Fatalf("%s: domain not active after renew", tt.name)
+		}
+	}
+
+	state := testutil.NewState(t)
+	newTestAccount(state, "admin", "bank", types.Address{1}, 0, true)
+	setDomainMeta(state, "bank", &DomainMeta{ExpiryHeight: 1000})
+	input := testutil.Encode(t, &RenewDomainData{Domain: "bank", Blocks: 100})
+	if err := VerifyRenewDomainOp(state, GetAccount(state, "admin@bank"), input, 1000+DomainGracePeriod); err != errDomainRenewTooLate {
+		t.Fatalf("renew after grace: %v", err)
+	}
+	input = testutil.Encode(t, &RenewDomainData{Domain: "bank", Blocks: MaxDomainRenewBlocks + 1})
+	if err := VerifyRenewDomainOp(state, GetAccount(state, "admin@bank"), input, 500); err != errInvalidRenewInterval {
+		t.Fatalf("renew too long: %v", err)
+	}
+}
+
+func TestGetAccount_TransferredAdmin(t *testing.T) {
+	state := testutil.NewState(t)
+	newTestAccount(state, "admin", "bank", types.Address{1}, 0, true)
+	newTestAccount(state, "bob", "bank", types.Address{2}, 0, false)
+
+	transfer := testutil.Encode(t, &TransferDomainData{Domain: "bank", NewAdmin: "bob"})
+	if err := VerifyTransferDomainOp(state, GetAccount(state, "admin@bank"), transfer, 10); err != nil {
+		t.Fatal(err)
+	}
+	TransferDomain(state, transfer)
+	accept := testutil.Encode(t, &AcceptDomainData{Domain: "bank"})
+	if err := VerifyAcceptDomainOp(state, GetAccount(state, "bob@bank"), accept); err != nil {
+		t.Fatal(err)
+	}
+	if err := AcceptDomain(state, accept); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		account string
+		raw     bool
+		admin   bool
+	}{
+		{"admin@bank", true, false},
+		{"bob@bank", false, true},
+	}
+	for _, tt := range tests {
+		if raw := state.GetAccount(tt.account); raw.IsAdmin != tt.raw {
+			t.Fatalf("%s: raw admin %v, want %v", tt.account, raw.IsAdmin, tt.raw)
+		}
+		store := GetAccount(state, tt.account)
+		if store.IsAdmin != tt.admin || (store.Permissions != nil) != tt.admin {
+			t.Fatalf("%s: admin %v permissions %v", tt.account, store.IsAdmin, store.Permissions)
+		}
+		if HasCapability(state, store, CapRegisterUser) != tt.admin {
+			t.Fatalf("%s: register capability mismatch", tt.account)
+		}
+	}
+
+	// 原管理员无法再发起转移
+	if err := VerifyTransferDomainOp(state, GetAccount(state, "admin@bank"), transfer, 10); err != errUnauthorized {
+		t.Fatalf("former admin transfer: %v", err)
+	}
+}
+
+// 其他域的账户即使拥有注册域能力也不能管理本域的过期
+func TestVerifyDomainExpiry_CrossDomain(t *testing.T) {
+	state := testutil.NewState(t)
+	newTestAccount(state, "admin", "bank", types.Address{1}, 0, true)
+	newTestAccount(state, "admin", "other", types.Address{2}, 0, true)
+	newTestAccount(state, "ops", "other", types.Address{3}, 0, false)
+	DefineRole(state, testutil.Encode(t, &DefineRoleData{Domain: "other", Name: "registrar", Capabilities: CapRegisterDomain}))
+	AssignRole(state, testutil.Encode(t, &AssignRoleData{CN: "ops", Domain: "other", Roles: []string{"registrar"}}))
+	root := accounts.NewAccountStore("root", "")
+	root.SetAddress(types.Address{4}, nil)
+	root.IsAdmin = true
+	root.Permissions = &accounts.Permissions{EnableRegisterDomain: true}
+	state.CreateAccount(root)
+	setDomainMeta(state, "bank", &DomainMeta{ExpiryHeight: 1000})
+
+	expiry := testutil.Encode(t, &DomainExpiryData{Domain: "bank", ExpiryHeight: 2000})
+	renew := testutil.Encode(t, &RenewDomainData{Domain: "bank", Blocks: 100})
+	tests := []struct {
+		from   string
+		expiry error
+		renew  error
+	}{
+		{"root", nil, nil},
+		{"admin@bank", errUnauthorized, nil},
+		{"admin@other", errUnauthorized, errUnauthorized},
+		{"ops@other", errUnauthorized, errUnauthorized},
+	}
+	for _, tt := range tests {
+		from := GetAccount(state, tt.from)
+		if tt.from == "ops@other" && !HasCapability(state, from, CapRegisterDomain) {
+			t.Fatal("role not granted")
+		}
+		if err := VerifyDomainExpiryOp(state, from, expiry, 10); err != tt.expiry {
+			t.Fatalf("%s expiry: %v, want %v", tt.from, err, tt.expiry)
+		}
+		if err := VerifyRenewDomainOp(state, from, renew, 10); err != tt.renew {
+			t.Fatalf("%s renew: %v, want %v", tt.from, err, tt.renew)
+		}
+	}
+}
+
+func TestDomainAccounts(t *testing.T) {
+	state := testutil.NewState(t)
+	newTestAccount(state, "admin", "bank", types.Address{1}, 0, true)
+	for i, cn := range []string{"zed", "amy", "bob"} {
+		store := accounts.NewAccountStore(cn, "bank")
+		store.SetAddress(types.Address{byte(2 + i)}, nil)
+		RegisterUser(state, store)
+	}
+	// 已存在的账户及重新注册的域不重复索引，创世时写入的域没有索引
+	RegisterUser(state, accounts.NewAccountStore("amy", "bank"))
+	RegisterDomain(state, accounts.NewAccountStore("admin", "bank"), 2)
+	newTestAccount(state, "carol", "shop", types.Address{9}, 0, false)
+
+	tests := []struct {
+		domain        string
+		offset, limit uint64
+		indexed       bool
+		count         uint64
+		names         []string
+	}{
+		{"bank", 0, 10, true, 4, []string{"admin@bank", "zed@bank", "amy@bank", "bob@bank"}},
+		{"bank", 1, 2, true, 4, []string{"zed@bank", "amy@bank"}},
+		{"bank", 4, 2, true, 4, []string{}},
+		{"shop", 0, 10, false, 0, []string{}},
+	}
+	for _, tt := range tests {
+		count, ok := DomainAccountCount(state, tt.domain)
+		if ok != tt.indexed || count != tt.count {
+			t.Fatalf("%s: count %d, indexed %v", tt.domain, count, ok)
+		}
+		names := DomainAccounts(state, tt.domain, tt.offset, tt.limit)
+		if strings.Join(names, ",") != strings.Join(tt.names, ",") {
+			t.Fatalf("%s %d: %v, want %v", tt.domain, tt.offset, names, tt.names)
+		}
+	}
+}
@@ -8,45 +8,369 @@ import (
 	"github.com/chain5j/chain5j-pkg/codec"
 	"github.com/chain5j/chain5j-protocol/models/accounts"
 	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp"
+	"strconv"
+	"strings"
 )
 
 var (
-	errAccountNonExists = errors.New("account not exists")
+	errAccountNonExists  = errors.New("account not exists")
+	errDomainFrozen      = errors.New("frozen domain")
+	errInvalidUnfreezeAt = errors.New("invalid unfreeze height")
+	errInvalidFreezeRef  = errors.New("freeze reference too long")
 )
 
-func VerifyAccountFrozenOp(state *statedb.StateDB, accountFrom *accounts.AccountStore, input []byte) error {
-	var frozenData accounts.FrozenAccountData
-	err := codec.Coder().Decode(input, &frozenData)
-	if err != nil {
-		return errInvalidInput
+// FreezeReason 冻结原因
+type FreezeReason uint32
+
+const (
+	FreezeReasonOther      FreezeReason = iota // 其他
+	FreezeReasonCompliance                     // 合规要求
+	FreezeReasonSecurity                       // 安全风险
+	FreezeReasonJudicial                       // 司法要求
+	FreezeReasonLostKey                        // 密钥丢失
+)
+
+const (
+	MaxFreezeReferenceLen = 128
+	MaxUnfreezePerHeight  = 256 // 同一高度处理的自动解冻数量上限，超出的顺延至之后的高度
+)
+
+// FreezeAccountData 冻结/解冻账户的数据对象，UnfreezeHeight不为0时到达该高度自动解冻
+type FreezeAccountData struct {
+	CN             string
+	Domain         string
+	Frozen         bool
+	Reason         FreezeReason
+	Reference      string
+	UnfreezeHeight uint64
+}
+
+func (data *FreezeAccountData) Normalize() {
+	data.CN = strings.ToLower(data.CN)
+	data.Domain = strings.ToLower(data.Domain)
+}
+
+// FreezeDomainData 冻结/解冻整个域及其全部子域的数据对象
+type FreezeDomainData struct {
+	Domain         string
+	Frozen         bool
+	Reason         FreezeReason
+	Reference      string
+	UnfreezeHeight uint64
+}
+
+func (data *FreezeDomainData) Normalize() {
+	data.Domain = strings.ToLower(data.Domain)
+}
+
+// FreezeRecord 当前生效的冻结信息
+type FreezeRecord struct {
+	Reason         FreezeReason `json:"reason"`
+	Reference      string       `json:"reference"`
+	Operator       string       `json:"operator"`
+	Height         uint64       `json:"height"`
+	UnfreezeHeight uint64       `json:"unfreeze_height"`
+}
+
+// Active 冻结在指定高度是否仍然生效
+func (r *FreezeRecord) Active(height uint64) bool {
+	return r.UnfreezeHeight == 0 || height < r.UnfreezeHeight
+}
+
+// FreezeEvent 冻结历史
+type FreezeEvent struct {
+	Target         string       `json:"target"` // 账户名称或域
+	Domain         bool         `json:"domain"` // 是否为域冻结
+	Frozen         bool         `json:"frozen"`
+	Reason         FreezeReason `json:"reason"`
+	Reference      string       `json:"reference"`
+	Operator       string       `json:"operator"`
+	Height         uint64       `json:"height"`
+	UnfreezeHeight uint64       `json:"unfreeze_height"`
+}
+
+func freezeKey(account string) []byte {
+	return stateApp.SystemKey("freeze", account)
+}
+
+func domainFreezeKey(domain string) []byte {
+	return stateApp.SystemKey("domain_freeze", domain)
+}
+
+// freezeHistoryKey 冻结历史按事件逐条存储，freeze_history/<target>记录事件数量
+func freezeHistoryKey(target string) []byte {
+	return stateApp.SystemKey("freeze_history", target)
+}
+
+func freezeEventKey(target string, index uint64) []byte {
+	return stateApp.SystemKey("freeze_history", target, strconv.FormatUint(index, 10))
+}
+
+func unfreezeKey(height uint64) []byte {
+	return stateApp.SystemKey("unfreeze_at", strconv.FormatUint(height, 10))
+}
+
+// unfreezeTarget 到期自动解冻的账户或域，Height为冻结记录中的解冻高度
+type unfreezeTarget struct {
+	Target string
+	Domain bool
+	Height uint64
+}
+
+// getFreezeRecord 获取账户或域的冻结信息，不存在时返回nil
+func getFreezeRecord(state *statedb.StateDB, key []byte) *FreezeRecord {
+	var record FreezeRecord
+	ok, err := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress).Get(key, &record)
+	if !ok || err != nil {
+		return nil
 	}
-	frozenData.Normalize()
+	return &record
+}
 
-	// 检查权限
-	if !accountFrom.IsAdmin {
-		return errUnauthorized
+// GetAccountFreeze 获取账户当前的冻结信息
+func GetAccountFreeze(state *statedb.StateDB, account string) *FreezeRecord {
+	return getFreezeRecord(state, freezeKey(account))
+}
+
+// GetDomainFreeze 获取域当前的冻结信息
+func GetDomainFreeze(state *statedb.StateDB, domain string) *FreezeRecord {
+	return getFreezeRecord(state, domainFreezeKey(domain))
+}
+
+// IsAccountFrozen 账户在指定高度是否被冻结，已到达自动解冻高度的视为已解冻
+// 自动解冻在到期高度的BeginBlock中执行，区块执行后账户中的IsFrozen与此结果一致
+func IsAccountFrozen(state *statedb.StateDB, store *accounts.AccountStore, height uint64) bool {
+	if !store.IsFrozen {
+		return false
+	}
+	if record := GetAccountFreeze(state, store.AccountName()); record != nil {
+		return record.Active(height)
 	}
+	return true
+}
 
-	accountToName := frozenData.CN + "@" + frozenData.Domain
-	accountTo := state.GetAccount(accountToName)
+// IsDomainFrozen 域或其任一上级域在指定高度是否被冻结
+func IsDomainFrozen(state *statedb.StateDB, domain string, height uint64) bool {
+	for _, d := range domainChain(domain) {
+		if record := GetDomainFreeze(state, d); record != nil && record.Active(height) {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyAccountFrozen 校验账户本身或其所在域是否被冻结
+func VerifyAccountFrozen(state *statedb.StateDB, store *accounts.AccountStore, height uint64) error {
+	if IsAccountFrozen(state, store, height) {
+		return stateApp.ErrFrozenAccount
+	}
+	if IsDomainFrozen(state, store.Domain, height) {
+		return errDomainFrozen
+	}
+	return nil
+}
+
+// VerifyAccountStatus 校验发起账户的状态：账户冻结、域冻结及域过期
+func VerifyAccountStatus(state *statedb.StateDB, store *accounts.AccountStore, height uint64) error {
+	if err := VerifyAccountFrozen(state, store, height); err != nil {
+		return err
+	}
+	return VerifyDomainActive(state, store.Domain, height)
+}
+
+// StatusKeys 账户及其所在域的生效状态所依赖的系统账户记录，用于状态证明，account为空时仅包含域
+// 依次为账户冻结、域及顶级域的DomainMeta、域链上各域的冻结
+func StatusKeys(account, domain string) [][]byte {
+	var keys [][]byte
+	if account != "" {
+		keys = append(keys, freezeKey(account))
+	}
+	if domain == "" {
+		return keys
+	}
+	keys = append(keys, domainMetaKey(domain))
+	if top := topLevelDomain(domain); top != domain {
+		keys = append(keys, domainMetaKey(top))
+	}
+	for _, d := range domainChain(domain) {
+		keys = append(keys, domainFreezeKey(d))
+	}
+	return keys
+}
+
+// FreezeHistoryCount 账户或域的冻结事件数量
+func FreezeHistoryCount(state *statedb.StateDB, target string) uint64 {
+	var count uint64
+	stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress).Get(freezeHistoryKey(target), &count)
+	return count
+}
+
+// FreezeHistory 账户或域自第offset条起的冻结事件，按发生顺序排列
+func FreezeHistory(state *statedb.StateDB, target string, offset, limit uint64) []FreezeEvent {
+	store := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress)
+	count := FreezeHistoryCount(state, target)
+	events := make([]FreezeEvent, 0)
+	for i := offset; i < count && i < offset+limit; i++ {
+		var event FreezeEvent
+		if ok, err := store.Get(freezeEventKey(target, i), &event); ok && err == nil {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// RecentFreezeHistory 账户及其所在域链上最近的limit条冻结事件，按高度排序
+func RecentFreezeHistory(state *statedb.StateDB, account string, limit uint64) []FreezeEvent {
+	recent := func(target string) []FreezeEvent {
+		count := FreezeHistoryCount(state, target)
+		if count > limit {
+			return FreezeHistory(state, target, count-limit, limit)
+		}
+		return FreezeHistory(state, target, 0, limit)
+	}
+
+	events := recent(account)
+	domain := ""
+	if i := strings.Index(account, accounts.DomainLinkFlag); i >= 0 {
+		domain = account[i+1:]
+	}
+	for _, d := range domainChain(domain) {
+		events = mergeFreezeEvents(events, recent(d))
+	}
+	if uint64(len(events)) > limit {
+		events = events[uint64(len(events))-limit:]
+	}
+	return events
+}
+
+func appendFreezeHistory(state *statedb.StateDB, event FreezeEvent) {
+	store := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress)
+	count := FreezeHistoryCount(state, event.Target)
+	store.Set(freezeEventKey(event.Target, count), &event)
+	store.Set(freezeHistoryKey(event.Target), count+1)
+}
+
+// mergeFreezeEvents 合并两个已按高度排序的历史
+func mergeFreezeEvents(a, b []FreezeEvent) []FreezeEvent {
+	merged := make([]FreezeEvent, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if b[j].Height < a[i].Height {
+			merged = append(merged, b[j])
+			j++
+		} else {
+			merged = append(merged, a[i])
+			i++
+		}
+	}
+	merged = append(merged, a[i:]...)
+	return append(merged, b[j:]...)
+}
+
+func verifyFreezeTarget(state *statedb.StateDB, accountFrom *accounts.AccountStore, cn, domain string, height uint64) error {
+	accountTo := GetAccount(state, cn+accounts.DomainLinkFlag+domain)
 	if accountTo == nil {
 		return errAccountNonExists
 	}
 
-	if accountFrom.Domain == accountTo.Domain {
-		if accountTo.IsAdmin {
+	return verifyWithAuthority(state, accountFrom, height, func(from *accounts.AccountStore, caps Capability) error {
+		// 检查权限
+		if !caps.Has(CapFreezeUser) {
+			return errUnauthorized
+		}
+
+		if from.Domain == accountTo.Domain {
+			if accountTo.IsAdmin {
+				return errUnauthorized
+			}
+		} else if !isSubDomain(from.Domain, domain) {
 			return errUnauthorized
 		}
-	} else if isSubDomain(accountFrom.Domain, frozenData.Domain) {
+
 		return nil
-	} else {
-		return errUnauthorized
-	}
+	})
+}
 
+func verifyFreezeSchedule(state *statedb.StateDB, frozen bool, reference string, unfreezeHeight, height uint64) error {
+	if len(reference) > MaxFreezeReferenceLen {
+		return errInvalidFreezeRef
+	}
+	if !frozen || unfreezeHeight == 0 {
+		return nil
+	}
+	if unfreezeHeight <= height {
+		return errInvalidUnfreezeAt
+	}
 	return nil
 }
 
-func FrozenAccount(state *statedb.StateDB, input []byte) error {
+func unfreezesAt(state *statedb.StateDB, height uint64) []unfreezeTarget {
+	var targets []unfreezeTarget
+	ok, err := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress).Get(unfreezeKey(height), &targets)
+	if !ok || err != nil {
+		return nil
+	}
+	return targets
+}
+
+// scheduleUnfreeze 登记到期自动解冻，该高度已满时顺延至之后第一个未满的高度。
+// 冻结记录在解冻高度即已失效，顺延只推迟冻结标志及记录的清理
+func scheduleUnfreeze(state *statedb.StateDB, target string, domain bool, height uint64) {
+	at := height
+	targets := unfreezesAt(state, at)
+	for len(targets) >= MaxUnfreezePerHeight {
+		at++
+		targets = unfreezesAt(state, at)
+	}
+	targets = append(targets, unfreezeTarget{Target: target, Domain: domain, Height: height})
+	stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress).Set(unfreezeKey(at), targets)
+}
+
+// ApplyScheduledUnfreezes 解冻登记在指定高度的账户及域，已手动解冻或重新冻结的跳过
+func ApplyScheduledUnfreezes(state *statedb.StateDB, height uint64) {
+	targets := unfreezesAt(state, height)
+	if len(targets) == 0 {
+		return
+	}
+	store := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress)
+	for _, t := range targets {
+		key := freezeKey(t.Target)
+		if t.Domain {
+			key = domainFreezeKey(t.Target)
+		}
+		record := getFreezeRecord(state, key)
+		if record == nil || record.UnfreezeHeight != t.Height {
+			continue
+		}
+
+		if !t.Domain {
+			state.UnFrozenAccount(t.Target)
+		}
+		store.Delete(key)
+		appendFreezeHistory(state, FreezeEvent{
+			Target:    t.Target,
+			Domain:    t.Domain,
+			Reason:    record.Reason,
+			Reference: record.Reference,
+			Height:    t.Height,
+		})
+	}
+	store.Delete(unfreezeKey(height))
+}
+
+func VerifyAccountFrozenOp(state *statedb.StateDB, accountFrom *accounts.AccountStore, input []byte, height uint64) error {
+	var frozenData accounts.FrozenAccountData
+	err := codec.Coder().Decode(input, &frozenData)
+	if err != nil {
+		return errInvalidInput
+	}
+	frozenData.Normalize()
+
+	return verifyFreezeTarget(state, accountFrom, frozenData.CN, frozenData.Domain, height)
+}
+
+func FrozenAccount(state *statedb.StateDB, operator string, input []byte, height uint64) error {
 	var frozenData accounts.FrozenAccountData
 	err := codec.Coder().Decode(input, &frozenData)
 	if err != nil {
@@ -54,12 +378,139 @@ func FrozenAccount(state *statedb.StateDB, input []byte) error {
 	}
 	frozenData.Normalize()
 
-	accountTo := frozenData.CN + "@" + frozenData.Domain
-	if frozenData.Frozen {
+	freezeAccount(state, operator, &FreezeAccountData{
+		CN:     frozenData.CN,
+		Domain: frozenData.Domain,
+		Frozen: frozenData.Frozen,
+	}, height)
+
+	return nil
+}
+
+func VerifyFreezeAccountOp(state *statedb.StateDB, accountFrom *accounts.AccountStore, input []byte, height uint64) error {
+	var data FreezeAccountData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	if err := verifyFreezeSchedule(state, data.Frozen, data.Reference, data.UnfreezeHeight, height); err != nil {
+		return err
+	}
+	return verifyFreezeTarget(state, accountFrom, data.CN, data.Domain, height)
+}
+
+func FreezeAccount(state *statedb.StateDB, operator string, input []byte, height uint64) error {
+	var data FreezeAccountData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	freezeAccount(state, operator, &data, height)
+	return nil
+}
+
+// SetAccountFrozen 直接设置账户的冻结状态及冻结记录，不记录冻结历史，用于模拟执行前的状态覆盖
+func SetAccountFrozen(state *statedb.StateDB, account string, frozen bool) {
+	store := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress)
+	if frozen {
+		state.FrozenAccount(account)
+		store.Set(freezeKey(account), &FreezeRecord{Reason: FreezeReasonOther})
+	} else {
+		state.UnFrozenAccount(account)
+		store.Delete(freezeKey(account))
+	}
+}
+
+func freezeAccount(state *statedb.StateDB, operator string, data *FreezeAccountData, height uint64) {
+	accountTo := data.CN + accounts.DomainLinkFlag + data.Domain
+	store := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress)
+	if data.Frozen {
 		state.FrozenAccount(accountTo)
+		store.Set(freezeKey(accountTo), &FreezeRecord{
+			Reason:         data.Reason,
+			Reference:      data.Reference,
+			Operator:       operator,
+			Height:         height,
+			UnfreezeHeight: data.UnfreezeHeight,
+		})
+		if data.UnfreezeHeight != 0 {
+			scheduleUnfreeze(state, accountTo, false, data.UnfreezeHeight)
+		}
 	} else {
 		state.UnFrozenAccount(accountTo)
+		store.Delete(freezeKey(accountTo))
+	}
+
+	appendFreezeHistory(state, FreezeEvent{
+		Target:         accountTo,
+		Frozen:         data.Frozen,
+		Reason:         data.Reason,
+		Reference:      data.Reference,
+		Operator:       operator,
+		Height:         height,
+		UnfreezeHeight: data.UnfreezeHeight,
+	})
+}
+
+func VerifyFreezeDomainOp(state *statedb.StateDB, accountFrom *accounts.AccountStore, input []byte, height uint64) error {
+	var data FreezeDomainData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	if err := verifyFreezeSchedule(state, data.Frozen, data.Reference, data.UnfreezeHeight, height); err != nil {
+		return err
+	}
+
+	if state.GetDomain(data.Domain) == nil {
+		return errDomainNonExists
+	}
+	if !HasCapability(state, accountFrom, CapFreezeUser) {
+		return errUnauthorized
+	}
+	// 只能冻结下级域，根账户可以冻结顶级域
+	if data.Domain == "" || !(isSubDomain(accountFrom.Domain, data.Domain) || accountFrom.Domain == "") {
+		return errUnauthorized
+	}
+
+	return nil
+}
+
+func FreezeDomain(state *statedb.StateDB, operator string, input []byte, height uint64) error {
+	var data FreezeDomainData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	store := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress)
+	if data.Frozen {
+		store.Set(domainFreezeKey(data.Domain), &FreezeRecord{
+			Reason:         data.Reason,
+			Reference:      data.Reference,
+			Operator:       operator,
+			Height:         height,
+			UnfreezeHeight: data.UnfreezeHeight,
+		})
+		if data.UnfreezeHeight != 0 {
+			scheduleUnfreeze(state, data.Domain, true, data.UnfreezeHeight)
+		}
+	} else {
+		store.Delete(domainFreezeKey(data.Domain))
 	}
 
+	appendFreezeHistory(state, FreezeEvent{
+		Target:         data.Domain,
+		Domain:         true,
+		Frozen:         data.Frozen,
+		Reason:         data.Reason,
+		Reference:      data.Reference,
+		Operator:       operator,
+		Height:         height,
+		UnfreezeHeight: data.UnfreezeHeight,
+	})
 	return nil
 }
@@ -0,0 +1,174 @@
+// Package accountInterpreter
+//
+// @author: xwc1125
+package accountInterpreter
+
+import (
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-stateApp/internal/testutil"
+	"testing"
+)
+
+func TestApplyScheduledUnfreezes(t *testing.T) {
+	state := testutil.NewState(t)
+	newTestAccount(state, "admin", "bank", types.Address{1}, 0, true)
+	newTestAccount(state, "alice", "bank", types.Address{2}, 0, false)
+	newTestAccount(state, "bob", "bank", types.Address{3}, 0, false)
+	newTestAccount(state, "carol", "bank", types.Address{4}, 0, false)
+	newTestAccount(state, "x", "a.bank", types.Address{5}, 0, true)
+
+	freeze := func(cn string, unfreeze uint64) {
+		FreezeAccount(state, "admin@bank", testutil.Encode(t, &FreezeAccountData{CN: cn, Domain: "bank", Frozen: true, UnfreezeHeight: unfreeze}), 10)
+	}
+	freeze("alice", 20)
+	freeze("bob", 20)
+	freeze("carol", 20)
+	FreezeDomain(state, "admin@bank", testutil.Encode(t, &FreezeDomainData{Domain: "a.bank", Frozen: true, UnfreezeHeight: 20}), 10)
+	// bob被手动解冻，carol被重新冻结至更晚的高度
+	FreezeAccount(state, "admin@bank", testutil.Encode(t, &FreezeAccountData{CN: "bob", Domain: "bank"}), 12)
+	FreezeAccount(state, "admin@bank", testutil.Encode(t, &FreezeAccountData{CN: "carol", Domain: "bank", Frozen: true, UnfreezeHeight: 30}), 12)
+
+	ApplyScheduledUnfreezes(state, 20)
+
+	tests := []struct {
+		account string
+		frozen  bool
+		history uint64
+	}{
+		{"alice@bank", false, 2},
+		{"bob@bank", false, 2},
+		{"carol@bank", true, 2},
+	}
+	for _, tt := range tests {
+		store := GetAccount(state, tt.account)
+		if store.IsFrozen != tt.frozen || IsAccountFrozen(state, store, 20) != tt.frozen {
+			t.Fatalf("%s: raw %v, frozen %v, want %v", tt.account, store.IsFrozen, IsAccountFrozen(state, store, 20), tt.frozen)
+		}
+		if n := FreezeHistoryCount(state, tt.account); n != tt.history {
+			t.Fatalf("%s: history %d, want %d", tt.account, n, tt.history)
+		}
+	}
+	if GetDomainFreeze(state, "a.bank") != nil || IsDomainFrozen(state, "a.bank", 20) {
+		t.Fatal("domain not unfrozen")
+	}
+	if len(unfreezesAt(state, 20)) != 0 || len(unfreezesAt(state, 30)) != 1 {
+		t.Fatal("unfreeze schedule not maintained")
+	}
+
+	ApplyScheduledUnfreezes(state, 30)
+	if GetAccount(state, "carol@bank").IsFrozen {
+		t.Fatal("carol not unfrozen")
+	}
+}
+
+// 解冻高度已满时顺延处理，冻结在原定高度即失效
+func TestScheduleUnfreeze_Overflow(t *testing.T) {
+	state := testutil.NewState(t)
+	newTestAccount(state, "admin", "bank", types.Address{1}, 0, true)
+	newTestAccount(state, "alice", "bank", types.Address{2}, 0, false)
+	for i := 0; i < MaxUnfreezePerHeight; i++ {
+		scheduleUnfreeze(state, "other@shop", false, 20)
+	}
+
+	FreezeAccount(state, "admin@bank", testutil.Encode(t, &FreezeAccountData{CN: "alice", Domain: "bank", Frozen: true, UnfreezeHeight: 20}), 10)
+	if err := VerifyFreezeAccountOp(state, GetAccount(state, "admin@bank"), testutil.Encode(t, &FreezeAccountData{CN: "alice", Domain: "bank", Frozen: true, UnfreezeHeight: 20}), 10); err != nil {
+		t.Fatal(err)
+	}
+	if len(unfreezesAt(state, 20)) != MaxUnfreezePerHeight || len(unfreezesAt(state, 21)) != 1 {
+		t.Fatal("overflow not moved to the next height")
+	}
+
+	ApplyScheduledUnfreezes(state, 20)
+	alice := GetAccount(state, "alice@bank")
+	if !alice.IsFrozen || IsAccountFrozen(state, alice, 20) {
+		t.Fatalf("height 20: raw %v, frozen %v", alice.IsFrozen, IsAccountFrozen(state, alice, 20))
+	}
+	ApplyScheduledUnfreezes(state, 21)
+	if GetAccount(state, "alice@bank").IsFrozen || GetAccountFreeze(state, "alice@bank") != nil {
+		t.Fatal("alice not unfrozen")
+	}
+	if events := FreezeHistory(state, "alice@bank", 1, 1); len(events) != 1 || events[0].Frozen || events[0].Height != 20 {
+		t.Fatalf("unfreeze event %+v", events)
+	}
+}
+
+func TestFreezeHistory(t *testing.T) {
+	state := testutil.NewState(t)
+	newTestAccount(state, "admin", "bank", types.Address{1}, 0, true)
+	newTestAccount(state, "alice", "bank", types.Address{2}, 0, false)
+
+	const events = 300
+	for i := uint64(0); i < events; i++ {
+		FreezeAccount(state, "admin@bank", testutil.Encode(t, &FreezeAccountData{CN: "alice", Domain: "bank", Frozen: i%2 == 0}), i)
+	}
+	FreezeDomain(state, "", testutil.Encode(t, &FreezeDomainData{Domain: "bank", Frozen: true}), 295)
+
+	if n := FreezeHistoryCount(state, "alice@bank"); n != events {
+		t.Fatalf("history count %d, want %d", n, events)
+	}
+	tests := []struct {
+		offset, limit uint64
+		first         uint64
+		size          int
+	}{
+		{0, 10, 0, 10},
+		{256, 100, 256, events - 256},
+		{events, 10, 0, 0},
+	}
+	for _, tt := range tests {
+		page := FreezeHistory(state, "alice@bank", tt.offset, tt.limit)
+		if len(page) != tt.size {
+			t.Fatalf("offset %d: %d events, want %d", tt.offset, len(page), tt.size)
+		}
+		if len(page) > 0 && page[0].Height != tt.first {
+			t.Fatalf("offset %d: first height %d, want %d", tt.offset, page[0].Height, tt.first)
+		}
+	}
+
+	recent := RecentFreezeHistory(state, "alice@bank", 10)
+	if len(recent) != 10 || recent[9].Height != events-1 || !recent[5].Domain {
+		t.Fatalf("recent history %+v", recent)
+	}
+}
+
+func TestVerifyFreezeDomainOp(t *testing.T) {
+	state := testutil.NewState(t)
+	newTestAccount(state, "admin", "bank", types.Address{1}, 0, true)
+	newTestAccount(state, "x", "a.bank", types.Address{2}, 0, true)
+	newTestAccount(state, "alice", "bank", types.Address{3}, 0, false)
+
+	tests := []struct {
+		name   string
+		from   string
+		domain string
+		err    error
+	}{
+		{"subdomain", "admin@bank", "a.bank", nil},
+		{"missing", "admin@bank", "b.bank", errDomainNonExists},
+		{"own domain", "admin@bank", "bank", errUnauthorized},
+		{"no capability", "alice@bank", "a.bank", errUnauthorized},
+	}
+	for _, tt := range tests {
+		input := testutil.Encode(t, &FreezeDomainData{Domain: tt.domain, Frozen: true})
+		if err := VerifyFreezeDomainOp(state, GetAccount(state, tt.from), input, 10); err != tt.err {
+			t.Fatalf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestVerifyTransferDomainOp_FrozenAdmin(t *testing.T) {
+	state := testutil.NewState(t)
+	newTestAccount(state, "admin", "bank", types.Address{1}, 0, true)
+	newTestAccount(state, "bob", "bank", types.Address{2}, 0, false)
+	FreezeAccount(state, "admin@bank", testutil.Encode(t, &FreezeAccountData{CN: "bob", Domain: "bank", Frozen: true, UnfreezeHeight: 20}), 10)
+
+	input := testutil.Encode(t, &TransferDomainData{Domain: "bank", NewAdmin: "bob"})
+	admin := GetAccount(state, "admin@bank")
+	if err := VerifyTransferDomainOp(state, admin, input, 15); err == nil {
+		t.Fatal("transfer to frozen account accepted")
+	}
+	ApplyScheduledUnfreezes(state, 20)
+	if err := VerifyTransferDomainOp(state, admin, input, 20); err != nil {
+		t.Fatal(err)
+	}
+}
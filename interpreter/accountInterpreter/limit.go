@@ -0,0 +1,300 @@
+// Package accountInterpreter
+//
+// @author: xwc1125
+package accountInterpreter
+
+import (
+	"errors"
+	"github.com/chain5j/chain5j-pkg/codec"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp"
+	"math/big"
+	"strings"
+)
+
+var (
+	errExceedTxLimit     = errors.New("value exceeds per transaction limit")
+	errExceedDailyLimit  = errors.New("value exceeds daily limit")
+	errExceedDomainLimit = errors.New("value exceeds counterparty domain daily limit")
+	errInvalidLimit      = errors.New("invalid spending limit")
+)
+
+const (
+	SpendWindow          = uint64(24 * 3600 * 1000) // 日额度的滑动窗口，区块时间戳为毫秒
+	SpendBuckets         = 24                       // 窗口内按小时分桶累计支出
+	MaxDomainSpendLimits = 32
+
+	spendBucketSize = SpendWindow / SpendBuckets
+)
+
+// DomainSpendLimit 对某个对手方域的日额度
+type DomainSpendLimit struct {
+	Domain string   `json:"domain"`
+	Daily  *big.Int `json:"daily"`
+}
+
+// SpendingLimit 账户的转出额度，为空或为0的PerTx、Daily表示不限制
+type SpendingLimit struct {
+	PerTx   *big.Int           `json:"per_tx,omitempty" rlp:"nil"` // 单笔上限
+	Daily   *big.Int           `json:"daily,omitempty" rlp:"nil"`  // 24小时滑动窗口上限
+	Domains []DomainSpendLimit `json:"domains,omitempty"`          // 按对手方域的24小时上限
+}
+
+// SetSpendingLimitData 设置账户额度的数据对象，Limit的字段全部为空时清除额度
+type SetSpendingLimitData struct {
+	CN     string
+	Domain string
+	Limit  SpendingLimit
+}
+
+func (data *SetSpendingLimitData) Normalize() {
+	data.CN = strings.ToLower(data.CN)
+	data.Domain = strings.ToLower(data.Domain)
+	for i := range data.Limit.Domains {
+		data.Limit.Domains[i].Domain = strings.ToLower(data.Limit.Domains[i].Domain)
+	}
+	data.Limit.normalize()
+}
+
+// SpendBucket 一个时间桶内累计消耗的额度，Index为时间戳所在桶的序号
+type SpendBucket struct {
+	Index  uint64
+	Amount *big.Int
+}
+
+// Allowance 账户在某一时刻的剩余额度，为空的字段表示不限制
+type Allowance struct {
+	PerTx   *big.Int            `json:"per_tx,omitempty"`
+	Daily   *big.Int            `json:"daily,omitempty"`
+	Spent   *big.Int            `json:"spent"`
+	Domains map[string]*big.Int `json:"domains,omitempty"`
+}
+
+// normalize rlp解码时空的*big.Int会变为0，统一将0视为不限制
+func (l *SpendingLimit) normalize() {
+	if l.PerTx != nil && l.PerTx.Sign() == 0 {
+		l.PerTx = nil
+	}
+	if l.Daily != nil && l.Daily.Sign() == 0 {
+		l.Daily = nil
+	}
+}
+
+func (l *SpendingLimit) empty() bool {
+	return l.PerTx == nil && l.Daily == nil && len(l.Domains) == 0
+}
+
+func spendingLimitKey(account string) []byte {
+	return stateApp.SystemKey("spend_limit", account)
+}
+
+// spendTotalKey 账户消耗额度的累计，domain为空时为全部转出，否则为转入该对手方域的部分
+func spendTotalKey(account, domain string) []byte {
+	if domain == "" {
+		return stateApp.SystemKey("spend_total", account)
+	}
+	return stateApp.SystemKey("spend_total", account, domain)
+}
+
+// GetSpendingLimit 获取账户的额度，未设置时返回nil
+func GetSpendingLimit(state *statedb.StateDB, account string) *SpendingLimit {
+	var limit SpendingLimit
+	ok, err := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress).Get(spendingLimitKey(account), &limit)
+	if !ok || err != nil {
+		return nil
+	}
+	limit.normalize()
+	return &limit
+}
+
+// spendBuckets 窗口内仍生效的桶。桶在其结束后满一个窗口才过期，一笔支出最多多计入一个桶的时长
+func spendBuckets(state *statedb.StateDB, key []byte, timestamp uint64) []SpendBucket {
+	var buckets []SpendBucket
+	ok, err := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress).Get(key, &buckets)
+	if !ok || err != nil {
+		return nil
+	}
+
+	current := timestamp / spendBucketSize
+	start := 0
+	for start < len(buckets) && buckets[start].Index+SpendBuckets < current {
+		start++
+	}
+	return buckets[start:]
+}
+
+// spentIn 窗口内消耗的额度
+func spentIn(state *statedb.StateDB, key []byte, timestamp uint64) *big.Int {
+	spent := new(big.Int)
+	for _, b := range spendBuckets(state, key, timestamp) {
+		spent.Add(spent, b.Amount)
+	}
+	return spent
+}
+
+// addSpend 将支出累计到时间戳所在的桶，并丢弃已过期的桶，记录的桶数不超过SpendBuckets+1
+func addSpend(state *statedb.StateDB, key []byte, value *big.Int, timestamp uint64) {
+	buckets := spendBuckets(state, key, timestamp)
+	index := timestamp / spendBucketSize
+	if n := len(buckets); n > 0 && buckets[n-1].Index >= index {
+		buckets[n-1].Amount = new(big.Int).Add(buckets[n-1].Amount, value)
+	} else {
+		buckets = append(buckets, SpendBucket{Index: index, Amount: new(big.Int).Set(value)})
+	}
+	stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress).Set(key, buckets)
+}
+
+// counterpartyDomain 接收账户所在的域
+func counterpartyDomain(to string) string {
+	if i := strings.Index(to, accounts.DomainLinkFlag); i >= 0 {
+		return to[i+1:]
+	}
+	return ""
+}
+
+// AccountAllowance 计算账户在指定时间的剩余额度，未设置额度时返回nil
+func AccountAllowance(state *statedb.StateDB, account string, timestamp uint64) *Allowance {
+	limit := GetSpendingLimit(state, account)
+	if limit == nil {
+		return nil
+	}
+
+	spent := spentIn(state, spendTotalKey(account, ""), timestamp)
+	allowance := &Allowance{
+		Spent: spent,
+	}
+	if limit.PerTx != nil {
+		allowance.PerTx = new(big.Int).Set(limit.PerTx)
+	}
+	if limit.Daily != nil {
+		allowance.Daily = remaining(limit.Daily, spent)
+	}
+	if len(limit.Domains) > 0 {
+		allowance.Domains = make(map[string]*big.Int, len(limit.Domains))
+		for _, d := range limit.Domains {
+			allowance.Domains[d.Domain] = remaining(d.Daily, spentIn(state, spendTotalKey(account, d.Domain), timestamp))
+		}
+	}
+	return allowance
+}
+
+func remaining(limit, used *big.Int) *big.Int {
+	left := new(big.Int).Sub(limit, used)
+	if left.Sign() < 0 {
+		left.SetInt64(0)
+	}
+	return left
+}
+
+// VerifySpend 校验转出是否超出账户额度
+func VerifySpend(state *statedb.StateDB, account, to string, value *big.Int, timestamp uint64) error {
+	if value == nil || value.Sign() <= 0 {
+		return nil
+	}
+	allowance := AccountAllowance(state, account, timestamp)
+	if allowance == nil {
+		return nil
+	}
+
+	if allowance.PerTx != nil && value.Cmp(allowance.PerTx) > 0 {
+		return errExceedTxLimit
+	}
+	if allowance.Daily != nil && value.Cmp(allowance.Daily) > 0 {
+		return errExceedDailyLimit
+	}
+	if left, ok := allowance.Domains[counterpartyDomain(to)]; ok && value.Cmp(left) > 0 {
+		return errExceedDomainLimit
+	}
+	return nil
+}
+
+// RecordSpend 记录转出消耗的额度，未设置额度的账户不记录，对手方域只在设置了其额度时单独累计
+func RecordSpend(state *statedb.StateDB, account, to string, value *big.Int, timestamp uint64) {
+	if value == nil || value.Sign() <= 0 {
+		return
+	}
+	limit := GetSpendingLimit(state, account)
+	if limit == nil {
+		return
+	}
+
+	addSpend(state, spendTotalKey(account, ""), value, timestamp)
+	domain := counterpartyDomain(to)
+	for _, d := range limit.Domains {
+		if d.Domain == domain {
+			addSpend(state, spendTotalKey(account, domain), value, timestamp)
+			break
+		}
+	}
+}
+
+func VerifySetSpendingLimitOp(state *statedb.StateDB, accountFrom *accounts.AccountStore, input []byte) error {
+	var data SetSpendingLimitData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	limit := &data.Limit
+	if limit.PerTx != nil && limit.PerTx.Sign() < 0 || limit.Daily != nil && limit.Daily.Sign() < 0 {
+		return errInvalidLimit
+	}
+	if len(limit.Domains) > MaxDomainSpendLimits {
+		return errInvalidLimit
+	}
+	seen := make(map[string]bool, len(limit.Domains))
+	for _, d := range limit.Domains {
+		if d.Daily == nil || d.Daily.Sign() < 0 || seen[d.Domain] {
+			return errInvalidLimit
+		}
+		seen[d.Domain] = true
+	}
+
+	// 只有拥有更新用户能力的账户可以设置本域普通用户或下级域账户的额度
+	if !HasCapability(state, accountFrom, CapUpdateUser) {
+		return errUnauthorized
+	}
+	accountTo := GetAccount(state, data.CN+accounts.DomainLinkFlag+data.Domain)
+	if accountTo == nil {
+		return errAccountNonExists
+	}
+	if accountFrom.Domain == accountTo.Domain {
+		if accountTo.IsAdmin {
+			return errUnauthorized
+		}
+	} else if !isSubDomain(accountFrom.Domain, accountTo.Domain) {
+		return errUnauthorized
+	}
+
+	return nil
+}
+
+func SetSpendingLimit(state *statedb.StateDB, input []byte) error {
+	var data SetSpendingLimitData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	account := data.CN + accounts.DomainLinkFlag + data.Domain
+	store := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress)
+	// 不再限制的对手方域不再累计
+	if old := GetSpendingLimit(state, account); old != nil {
+		kept := make(map[string]bool, len(data.Limit.Domains))
+		for _, d := range data.Limit.Domains {
+			kept[d.Domain] = true
+		}
+		for _, d := range old.Domains {
+			if !kept[d.Domain] {
+				store.Delete(spendTotalKey(account, d.Domain))
+			}
+		}
+	}
+	if data.Limit.empty() {
+		store.Delete(spendingLimitKey(account))
+		store.Delete(spendTotalKey(account, ""))
+		return nil
+	}
+	return store.Set(spendingLimitKey(account), &data.Limit)
+}
@@ -0,0 +1,129 @@
+// Package accountInterpreter
+//
+// @author: xwc1125
+package accountInterpreter
+
+import (
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-stateApp/internal/testutil"
+	"math/big"
+	"testing"
+)
+
+func TestVerifySpend(t *testing.T) {
+	state := testutil.NewState(t)
+	newTestAccount(state, "admin", "bank", types.Address{1}, 0, true)
+	newTestAccount(state, "alice", "bank", types.Address{2}, 1000, false)
+	SetSpendingLimit(state, testutil.Encode(t, &SetSpendingLimitData{CN: "alice", Domain: "bank", Limit: SpendingLimit{
+		PerTx:   big.NewInt(100),
+		Daily:   big.NewInt(250),
+		Domains: []DomainSpendLimit{{Domain: "corp", Daily: big.NewInt(30)}},
+	}}))
+
+	const hour = SpendWindow / 24
+	tests := []struct {
+		name      string
+		to        string
+		value     int64
+		timestamp uint64
+		err       error
+	}{
+		{"over per tx", "bob@bank", 101, 0, errExceedTxLimit},
+		{"first", "bob@bank", 100, 0, nil},
+		{"second", "bob@bank", 100, hour, nil},
+		{"over daily", "bob@bank", 51, 2 * hour, errExceedDailyLimit},
+		{"domain", "carol@corp", 30, 2 * hour, nil},
+		{"over domain", "carol@corp", 1, 3 * hour, errExceedDomainLimit},
+		{"other domain", "dave@shop", 10, 3 * hour, nil},
+		// 桶在其结束后满一个窗口才过期
+		{"bucket not expired", "bob@bank", 100, SpendWindow, errExceedDailyLimit},
+		{"window slid", "bob@bank", 100, SpendWindow + hour, nil},
+		{"domain bucket not expired", "carol@corp", 30, SpendWindow + 2*hour, errExceedDomainLimit},
+		{"domain window slid", "carol@corp", 30, SpendWindow + 3*hour, nil},
+	}
+	for _, tt := range tests {
+		value := big.NewInt(tt.value)
+		if err := VerifySpend(state, "alice@bank", tt.to, value, tt.timestamp); err != tt.err {
+			t.Fatalf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+		if tt.err == nil {
+			RecordSpend(state, "alice@bank", tt.to, value, tt.timestamp)
+		}
+	}
+
+	allowance := AccountAllowance(state, "alice@bank", SpendWindow+3*hour)
+	if allowance.Spent.Int64() != 140 || allowance.Daily.Int64() != 110 || allowance.Domains["corp"].Int64() != 0 {
+		t.Fatalf("allowance spent %v daily %v corp %v", allowance.Spent, allowance.Daily, allowance.Domains["corp"])
+	}
+
+	// 清除额度后不再限制也不再记录
+	SetSpendingLimit(state, testutil.Encode(t, &SetSpendingLimitData{CN: "alice", Domain: "bank"}))
+	if AccountAllowance(state, "alice@bank", 0) != nil {
+		t.Fatal("limit not cleared")
+	}
+	if err := VerifySpend(state, "alice@bank", "bob@bank", big.NewInt(1000), 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifySpend_DailyOnly(t *testing.T) {
+	state := testutil.NewState(t)
+	newTestAccount(state, "alice", "bank", types.Address{2}, 1000, false)
+	SetSpendingLimit(state, testutil.Encode(t, &SetSpendingLimitData{CN: "alice", Domain: "bank", Limit: SpendingLimit{
+		Daily: big.NewInt(500),
+	}}))
+
+	limit := GetSpendingLimit(state, "alice@bank")
+	if limit == nil || limit.PerTx != nil || limit.Daily.Int64() != 500 {
+		t.Fatalf("limit %+v", limit)
+	}
+	if err := VerifySpend(state, "alice@bank", "bob@bank", big.NewInt(400), 0); err != nil {
+		t.Fatal(err)
+	}
+	RecordSpend(state, "alice@bank", "bob@bank", big.NewInt(400), 0)
+	if err := VerifySpend(state, "alice@bank", "bob@bank", big.NewInt(101), 1); err != errExceedDailyLimit {
+		t.Fatalf("%v, want %v", err, errExceedDailyLimit)
+	}
+}
+
+func TestRecordSpend_Buckets(t *testing.T) {
+	state := testutil.NewState(t)
+	newTestAccount(state, "alice", "bank", types.Address{2}, 0, false)
+	SetSpendingLimit(state, testutil.Encode(t, &SetSpendingLimitData{CN: "alice", Domain: "bank", Limit: SpendingLimit{
+		Domains: []DomainSpendLimit{{Domain: "corp", Daily: big.NewInt(1000)}},
+	}}))
+
+	// 同一桶内的支出累计到一条记录，过期的桶在写入时丢弃
+	const minute = SpendWindow / 24 / 60
+	for i := uint64(0); i < 3*24*60; i += 10 {
+		RecordSpend(state, "alice@bank", "carol@corp", big.NewInt(1), i*minute)
+	}
+	now := 3*SpendWindow - minute
+	for _, domain := range []string{"", "corp"} {
+		buckets := spendBuckets(state, spendTotalKey("alice@bank", domain), now)
+		if len(buckets) != SpendBuckets+1 {
+			t.Fatalf("domain %q: %d buckets", domain, len(buckets))
+		}
+		for _, b := range buckets {
+			if b.Amount.Int64() != 6 {
+				t.Fatalf("domain %q: bucket %d amount %v", domain, b.Index, b.Amount)
+			}
+		}
+	}
+	// 未设置额度的对手方域不单独累计
+	RecordSpend(state, "alice@bank", "bob@shop", big.NewInt(1), now)
+	if spendBuckets(state, spendTotalKey("alice@bank", "shop"), now) != nil {
+		t.Fatal("unlimited domain recorded")
+	}
+
+	// 取消对手方域的额度后删除其累计
+	SetSpendingLimit(state, testutil.Encode(t, &SetSpendingLimitData{CN: "alice", Domain: "bank", Limit: SpendingLimit{
+		Daily: big.NewInt(1000),
+	}}))
+	if spendBuckets(state, spendTotalKey("alice@bank", "corp"), now) != nil {
+		t.Fatal("dropped domain still recorded")
+	}
+	if allowance := AccountAllowance(state, "alice@bank", now); allowance.Spent.Int64() != 6*(SpendBuckets+1)+1 {
+		t.Fatalf("spent %v", allowance.Spent)
+	}
+}
@@ -30,7 +30,7 @@ func IsValidPermission(from, to *accounts.Permissions) bool {
 	return true
 }
 
-func VerifyUpdateDataPermissionOp(state *statedb.StateDB, accountFrom *accounts.AccountStore, input []byte) error {
+func VerifyUpdateDataPermissionOp(state *statedb.StateDB, accountFrom *accounts.AccountStore, input []byte, height uint64) error {
 	var pData accounts.UpdatePermissionData
 	err := codec.Coder().Decode(input, &pData)
 	if err != nil {
@@ -38,26 +38,28 @@ func VerifyUpdateDataPermissionOp(state *statedb.StateDB, accountFrom *accounts.
 	}
 	pData.Normalize()
 
-	// 检查权限
-	if !accountFrom.IsAdmin {
-		return errUnauthorized
-	}
-
 	accountToName := pData.CN + "@" + pData.Domain
-	accountTo := state.GetAccount(accountToName)
+	accountTo := GetAccount(state, accountToName)
 	if accountTo == nil {
 		return errAccountNonExists
 	}
 
-	if !isSubDomain(accountFrom.Domain, pData.Domain) || !accountTo.IsAdmin {
-		return errUnauthorized
-	}
+	return verifyWithAuthority(state, accountFrom, height, func(from *accounts.AccountStore, caps Capability) error {
+		// 检查权限
+		if !caps.Has(CapUpdateUser) {
+			return errUnauthorized
+		}
 
-	if !IsValidPermission(accountFrom.Permissions, &pData.Permissions) {
-		return errUnauthorized
-	}
+		if !isSubDomain(from.Domain, pData.Domain) || !accountTo.IsAdmin {
+			return errUnauthorized
+		}
 
-	return nil
+		if !caps.Has(PermissionCapabilities(&pData.Permissions)) {
+			return errUnauthorized
+		}
+
+		return nil
+	})
 }
 
 func UpdatePermission(state *statedb.StateDB, input []byte) error {
@@ -68,8 +70,16 @@ func UpdatePermission(state *statedb.StateDB, input []byte) error {
 	}
 	pData.Normalize()
 
-	accountTo := pData.CN + "@" + pData.Domain
-	state.UpdatePermission(accountTo, &pData.Permissions)
-
+	SetAdminPermissions(state, pData.CN, pData.Domain, &pData.Permissions)
 	return nil
 }
+
+// SetAdminPermissions 更新管理员权限，转移后的管理员权限记录在域的扩展信息中
+func SetAdminPermissions(state *statedb.StateDB, cn, domain string, permissions *accounts.Permissions) {
+	state.UpdatePermission(cn+accounts.DomainLinkFlag+domain, permissions)
+
+	if meta := GetDomainMeta(state, domain); meta != nil && meta.Admin == cn {
+		meta.Permissions = copyPermissions(permissions)
+		setDomainMeta(state, domain, meta)
+	}
+}
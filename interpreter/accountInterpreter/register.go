@@ -8,6 +8,8 @@ import (
 	"github.com/chain5j/chain5j-pkg/math"
 	"github.com/chain5j/chain5j-protocol/models/accounts"
 	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp"
+	"strconv"
 	"unicode"
 )
 
@@ -49,7 +51,7 @@ func VerifyRegisterDomain(state *statedb.StateDB, accountFrom, accountRegister *
 	}
 
 	// 拥有注册权限
-	if !accountFrom.AuthorizedRegisterDomain() {
+	if !HasCapability(state, accountFrom, CapRegisterDomain) {
 		return errUnauthorized
 	}
 
@@ -61,7 +63,7 @@ func VerifyRegisterDomain(state *statedb.StateDB, accountFrom, accountRegister *
 }
 
 // VerifyAccountRegister TODO
-func VerifyAccountRegister(state *statedb.StateDB, accountFrom, accountRegister *accounts.AccountStore) error {
+func VerifyAccountRegister(state *statedb.StateDB, accountFrom, accountRegister *accounts.AccountStore, height uint64) error {
 	enableDomain := accountFrom.Domain != ""
 	if err := verifyAccountFormat(accountRegister, enableDomain); err != nil {
 		return err
@@ -81,44 +83,51 @@ func VerifyAccountRegister(state *statedb.StateDB, accountFrom, accountRegister
 		return err
 	}
 
-	// 拥有注册权限
-	if !accountFrom.AuthorizedRegisterUser() {
-		return errUnauthorized
-	}
-	if !accountFrom.EnableDeployContract && accountRegister.EnableDeployContract {
-		return errUnauthorized
-	}
-
-	// 域名检查
-	if accountFrom.Domain == accountRegister.Domain {
-		// 不能注册同级管理员
-		if accountRegister.IsAdmin {
-			return errInvalidAdminField
+	err := verifyWithAuthority(state, accountFrom, height, func(from *accounts.AccountStore, caps Capability) error {
+		// 拥有注册权限
+		if !caps.Has(CapRegisterUser) {
+			return errUnauthorized
 		}
-		// 普通用户没有权限设置
-		if accountRegister.Permissions != nil {
-			return errInvalidPermission
+		if !caps.Has(CapDeployContract) && accountRegister.EnableDeployContract {
+			return errUnauthorized
 		}
-	} else if isSubDomain(accountFrom.Domain, accountRegister.Domain) {
-		if accountRegister.IsAdmin {
-			// 注册子域管理员
-			if !accountFrom.Permissions.EnableRegisterSubdomain {
-				return errUnauthorized
+
+		// 域名检查
+		if from.Domain == accountRegister.Domain {
+			// 不能注册同级管理员
+			if accountRegister.IsAdmin {
+				return errInvalidAdminField
 			}
-			if accountRegister.Permissions == nil || !IsValidPermission(accountFrom.Permissions, accountRegister.Permissions) {
+			// 普通用户没有权限设置
+			if accountRegister.Permissions != nil {
 				return errInvalidPermission
 			}
-			if accountRegister.Permissions.EnableRegisterDomain {
-				return errInvalidPermission
+		} else if isSubDomain(from.Domain, accountRegister.Domain) {
+			if accountRegister.IsAdmin {
+				// 注册子域管理员
+				if !caps.Has(CapRegisterSubdomain) {
+					return errUnauthorized
+				}
+				if accountRegister.Permissions == nil || !caps.Has(PermissionCapabilities(accountRegister.Permissions)) {
+					return errInvalidPermission
+				}
+				if accountRegister.Permissions.EnableRegisterDomain {
+					return errInvalidPermission
+				}
+			} else {
+				// 注册子域用户
+				if accountRegister.Permissions != nil {
+					return errInvalidPermission
+				}
 			}
 		} else {
-			// 注册子域用户
-			if accountRegister.Permissions != nil {
-				return errInvalidPermission
-			}
+			return errInvalidDomain
 		}
-	} else {
-		return errInvalidDomain
+
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	accountName := accountRegister.AccountName()
@@ -132,6 +141,7 @@ func VerifyAccountRegister(state *statedb.StateDB, accountFrom, accountRegister
 
 // RegisterUser 注册用户
 func RegisterUser(state *statedb.StateDB, accountRegister *accounts.AccountStore) error {
+	indexAccount(state, accountRegister)
 	state.CreateAccount(accountRegister)
 
 	return nil
@@ -151,6 +161,12 @@ func RegisterDomain(state *statedb.StateDB, accountRegister *accounts.AccountSto
 	accountRegister.EnableDeployContract = true
 	accountRegister.IsFrozen = false
 
+	// 通过注册创建的域自注册起建立账户索引
+	store := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress)
+	if !store.Has(accountIndexKey(accountRegister.Domain)) {
+		store.Set(accountIndexKey(accountRegister.Domain), uint64(0))
+	}
+	indexAccount(state, accountRegister)
 	state.CreateAccount(accountRegister)
 
 	state.AddDomain(accountRegister.Domain, accounts.DomainStore{
@@ -161,9 +177,51 @@ func RegisterDomain(state *statedb.StateDB, accountRegister *accounts.AccountSto
 	return nil
 }
 
+// accountIndexKey 域下的账户按注册顺序逐条存储，account_index/<domain>记录账户数量
+// 只有通过注册创建的域存在索引，创世时写入的域没有索引
+func accountIndexKey(domain string) []byte {
+	return stateApp.SystemKey("account_index", domain)
+}
+
+func accountIndexEntryKey(domain string, index uint64) []byte {
+	return stateApp.SystemKey("account_index", domain, strconv.FormatUint(index, 10))
+}
+
+// indexAccount 将新建的账户加入所在域的索引，需在创建账户之前调用，域没有索引或账户已存在时跳过
+func indexAccount(state *statedb.StateDB, account *accounts.AccountStore) {
+	count, ok := DomainAccountCount(state, account.Domain)
+	if !ok || state.Exist(account.AccountName()) {
+		return
+	}
+	store := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress)
+	store.Set(accountIndexEntryKey(account.Domain, count), account.AccountName())
+	store.Set(accountIndexKey(account.Domain), count+1)
+}
+
+// DomainAccountCount 域下的账户数量，域没有账户索引时返回false
+func DomainAccountCount(state *statedb.StateDB, domain string) (uint64, bool) {
+	var count uint64
+	ok, err := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress).Get(accountIndexKey(domain), &count)
+	return count, ok && err == nil
+}
+
+// DomainAccounts 域下自第offset个起的账户名，按注册顺序排列
+func DomainAccounts(state *statedb.StateDB, domain string, offset, limit uint64) []string {
+	store := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress)
+	count, _ := DomainAccountCount(state, domain)
+	names := make([]string, 0)
+	for i := offset; i < count && i < offset+limit; i++ {
+		var name string
+		if ok, err := store.Get(accountIndexEntryKey(domain, i), &name); ok && err == nil {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 func checkAddress(state *statedb.StateDB, accountRegister *accounts.AccountStore) error {
 	for addr := range accountRegister.Addresses {
-		if state.AddressExist(addr) {
+		if AddressTaken(state, addr) {
 			return errAddressExists
 		}
 	}
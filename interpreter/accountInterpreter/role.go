@@ -0,0 +1,391 @@
+// Package accountInterpreter
+//
+// @author: xwc1125
+package accountInterpreter
+
+import (
+	"errors"
+	"github.com/chain5j/chain5j-pkg/codec"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp"
+	"sort"
+	"strings"
+)
+
+var (
+	errRoleNonExists    = errors.New("role not exists")
+	errInvalidRoleName  = errors.New("invalid role name")
+	errRoleNotInherited = errors.New("role exceeds inherited capabilities")
+)
+
+// Capability 账户能力，位定义与contracts/IChain5j.sol的Chain5jCapabilities一致
+// 铸造代币及数据命名空间不对应原生操作，由合约通过预编译合约查询后执行
+type Capability uint64
+
+const (
+	CapFreezeUser        Capability = 1 << iota // 冻结、解冻用户
+	CapRegisterUser                             // 注册用户
+	CapUpdateUser                               // 更新用户权限
+	CapRegisterSubdomain                        // 注册子域
+	CapRegisterDomain                           // 注册顶级域
+	CapManagePartner                            // 跨域设置找回伙伴
+	CapDeployContract                           // 部署合约
+	CapMintToken                                // 铸造代币
+	CapDataNamespace                            // 写入数据命名空间
+	CapManageRoles                              // 定义、分配角色
+)
+
+const (
+	RoleAuditor   = "auditor"
+	RoleTreasurer = "treasurer"
+	RoleUserAdmin = "user-admin"
+
+	MaxRoleNameLen = 32
+)
+
+// Role 角色及其能力集
+type Role struct {
+	Name         string
+	Capabilities Capability
+	Namespaces   []string // CapDataNamespace 可写的命名空间
+}
+
+// ownerCapabilities 域管理员作为域的所有者拥有的能力，不来自旧版权限，也不能通过委托授予
+const ownerCapabilities = CapManageRoles | CapMintToken | CapDataNamespace
+
+// builtinRoles 内置角色，视为在根上定义，各域只能收紧
+var builtinRoles = map[string]Role{
+	RoleAuditor: {
+		Name: RoleAuditor,
+	},
+	RoleTreasurer: {
+		Name:         RoleTreasurer,
+		Capabilities: CapMintToken,
+	},
+	RoleUserAdmin: {
+		Name:         RoleUserAdmin,
+		Capabilities: CapRegisterUser | CapUpdateUser | CapFreezeUser,
+	},
+}
+
+// Has 是否拥有全部指定能力
+func (c Capability) Has(caps Capability) bool {
+	return c&caps == caps
+}
+
+// DefineRoleData 定义角色的数据对象
+type DefineRoleData struct {
+	Domain       string
+	Name         string
+	Capabilities Capability
+	Namespaces   []string
+}
+
+func (data *DefineRoleData) Normalize() {
+	data.Domain = strings.ToLower(data.Domain)
+	data.Name = strings.ToLower(data.Name)
+	for i, ns := range data.Namespaces {
+		data.Namespaces[i] = strings.ToLower(ns)
+	}
+}
+
+// AssignRoleData 为账户分配角色的数据对象，Roles为空时清除角色
+type AssignRoleData struct {
+	CN     string
+	Domain string
+	Roles  []string
+}
+
+func (data *AssignRoleData) Normalize() {
+	data.CN = strings.ToLower(data.CN)
+	data.Domain = strings.ToLower(data.Domain)
+	for i, r := range data.Roles {
+		data.Roles[i] = strings.ToLower(r)
+	}
+}
+
+// PermissionCapabilities 旧版管理员权限对应的能力
+func PermissionCapabilities(p *accounts.Permissions) Capability {
+	if p == nil {
+		return 0
+	}
+
+	var caps Capability
+	if p.EnableRegisterUser {
+		caps |= CapRegisterUser
+	}
+	if p.EnableUpdateUser {
+		caps |= CapUpdateUser
+	}
+	if p.EnableFrozenUser {
+		caps |= CapFreezeUser
+	}
+	if p.EnableRegisterDomain {
+		caps |= CapRegisterDomain
+	}
+	if p.EnableRegisterSubdomain {
+		caps |= CapRegisterSubdomain
+	}
+	return caps
+}
+
+// IsDomainOwner 账户是否为其所在域当前的管理员，域转移后为新的管理员
+func IsDomainOwner(state *statedb.StateDB, store *accounts.AccountStore) bool {
+	return store.Domain != "" && store.Domain != accounts.ContractDomain && DomainAdmin(state, store.Domain) == store.CN
+}
+
+// AccountCapabilities 账户的全部能力：管理员权限、域所有者能力及所分配角色的能力之和
+func AccountCapabilities(state *statedb.StateDB, store *accounts.AccountStore) Capability {
+	var caps Capability
+	if store.IsAdmin {
+		caps |= PermissionCapabilities(store.Permissions)
+	}
+	if IsDomainOwner(state, store) {
+		caps |= ownerCapabilities
+	}
+	if store.EnableDeployContract {
+		caps |= CapDeployContract
+	}
+	for _, role := range AccountRoles(state, store.AccountName()) {
+		if r := EffectiveRole(state, store.Domain, role); r != nil {
+			caps |= r.Capabilities
+		}
+	}
+	return caps
+}
+
+// HasCapability 账户是否拥有指定能力
+func HasCapability(state *statedb.StateDB, store *accounts.AccountStore, caps Capability) bool {
+	return AccountCapabilities(state, store).Has(caps)
+}
+
+// HasNamespace 账户是否可以写入指定的数据命名空间，域管理员可以写入全部命名空间
+func HasNamespace(state *statedb.StateDB, store *accounts.AccountStore, namespace string) bool {
+	if IsDomainOwner(state, store) {
+		return true
+	}
+	namespace = strings.ToLower(namespace)
+	for _, role := range AccountRoles(state, store.AccountName()) {
+		r := EffectiveRole(state, store.Domain, role)
+		if r != nil && r.Capabilities.Has(CapDataNamespace) && containsString(r.Namespaces, namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+func roleKey(domain, name string) []byte {
+	return stateApp.SystemKey("role", domain, name)
+}
+
+func accountRolesKey(account string) []byte {
+	return stateApp.SystemKey("account_roles", account)
+}
+
+// getRole 获取域上直接定义的角色
+func getRole(state *statedb.StateDB, domain, name string) *Role {
+	var role Role
+	ok, err := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress).Get(roleKey(domain, name), &role)
+	if !ok || err != nil {
+		return nil
+	}
+	return &role
+}
+
+// domainChain 从顶级域到当前域的域链
+func domainChain(domain string) []string {
+	if domain == "" {
+		return nil
+	}
+	labels := strings.Split(domain, ".")
+	chain := make([]string, 0, len(labels))
+	for i := len(labels) - 1; i >= 0; i-- {
+		chain = append(chain, strings.Join(labels[i:], "."))
+	}
+	return chain
+}
+
+// EffectiveRole 角色在域中的有效定义，子域只能在上级定义的基础上收紧
+func EffectiveRole(state *statedb.StateDB, domain, name string) *Role {
+	var role *Role
+	if builtin, ok := builtinRoles[name]; ok {
+		role = copyRole(&builtin)
+	}
+	for _, d := range domainChain(domain) {
+		def := getRole(state, d, name)
+		if def == nil {
+			continue
+		}
+		if role == nil {
+			role = copyRole(def)
+		} else {
+			role = intersectRole(role, def)
+		}
+	}
+	return role
+}
+
+func copyRole(r *Role) *Role {
+	cpy := *r
+	cpy.Namespaces = append([]string(nil), r.Namespaces...)
+	return &cpy
+}
+
+func intersectRole(parent, child *Role) *Role {
+	role := &Role{
+		Name:         child.Name,
+		Capabilities: parent.Capabilities & child.Capabilities,
+	}
+	for _, ns := range child.Namespaces {
+		if containsString(parent.Namespaces, ns) {
+			role.Namespaces = append(role.Namespaces, ns)
+		}
+	}
+	return role
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// AccountRoles 账户已分配的角色
+func AccountRoles(state *statedb.StateDB, account string) []string {
+	var roles []string
+	ok, err := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress).Get(accountRolesKey(account), &roles)
+	if !ok || err != nil {
+		return nil
+	}
+	return roles
+}
+
+// canManageDomain 账户是否可以管理目标域：本域或其子域
+func canManageDomain(accountFrom *accounts.AccountStore, domain string) bool {
+	return accountFrom.Domain == domain || isSubDomain(accountFrom.Domain, domain)
+}
+
+func VerifyDefineRoleOp(state *statedb.StateDB, accountFrom *accounts.AccountStore, input []byte) error {
+	var data DefineRoleData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	if data.Name == "" || len(data.Name) > MaxRoleNameLen {
+		return errInvalidRoleName
+	}
+
+	caps := AccountCapabilities(state, accountFrom)
+	if !caps.Has(CapManageRoles) || !canManageDomain(accountFrom, data.Domain) {
+		return errUnauthorized
+	}
+	// 不能定义超出自身能力的角色
+	if !caps.Has(data.Capabilities) {
+		return errUnauthorized
+	}
+	for _, ns := range data.Namespaces {
+		if !HasNamespace(state, accountFrom, ns) {
+			return errUnauthorized
+		}
+	}
+
+	// 已继承的角色只能收紧
+	if parent := EffectiveRole(state, parentDomain(data.Domain), data.Name); parent != nil {
+		if !parent.Capabilities.Has(data.Capabilities) {
+			return errRoleNotInherited
+		}
+		for _, ns := range data.Namespaces {
+			if !containsString(parent.Namespaces, ns) {
+				return errRoleNotInherited
+			}
+		}
+	}
+
+	return nil
+}
+
+func DefineRole(state *statedb.StateDB, input []byte) error {
+	var data DefineRoleData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+	sort.Strings(data.Namespaces)
+
+	return stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress).Set(roleKey(data.Domain, data.Name), &Role{
+		Name:         data.Name,
+		Capabilities: data.Capabilities,
+		Namespaces:   data.Namespaces,
+	})
+}
+
+func VerifyAssignRoleOp(state *statedb.StateDB, accountFrom *accounts.AccountStore, input []byte) error {
+	var data AssignRoleData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	caps := AccountCapabilities(state, accountFrom)
+	if !caps.Has(CapManageRoles) {
+		return errUnauthorized
+	}
+
+	accountTo := GetAccount(state, data.CN+accounts.DomainLinkFlag+data.Domain)
+	if accountTo == nil {
+		return errAccountNonExists
+	}
+	if accountFrom.Domain == accountTo.Domain {
+		if accountTo.IsAdmin {
+			return errUnauthorized
+		}
+	} else if !isSubDomain(accountFrom.Domain, accountTo.Domain) {
+		return errUnauthorized
+	}
+
+	for _, name := range data.Roles {
+		role := EffectiveRole(state, accountTo.Domain, name)
+		if role == nil {
+			return errRoleNonExists
+		}
+		// 不能分配超出自身能力的角色
+		if !caps.Has(role.Capabilities) {
+			return errUnauthorized
+		}
+		for _, ns := range role.Namespaces {
+			if !HasNamespace(state, accountFrom, ns) {
+				return errUnauthorized
+			}
+		}
+	}
+
+	return nil
+}
+
+func AssignRole(state *statedb.StateDB, input []byte) error {
+	var data AssignRoleData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	store := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress)
+	key := accountRolesKey(data.CN + accounts.DomainLinkFlag + data.Domain)
+	if len(data.Roles) == 0 {
+		store.Delete(key)
+		return nil
+	}
+	return store.Set(key, data.Roles)
+}
+
+func parentDomain(domain string) string {
+	if i := strings.Index(domain, "."); i >= 0 {
+		return domain[i+1:]
+	}
+	return ""
+}
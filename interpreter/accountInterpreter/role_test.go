@@ -0,0 +1,157 @@
+// Package accountInterpreter
+//
+// @author: xwc1125
+package accountInterpreter
+
+import (
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-stateApp/internal/testutil"
+	"math/big"
+	"testing"
+)
+
+func TestVerifySetSpendingLimitOp(t *testing.T) {
+	state := testutil.NewState(t)
+	newTestAccount(state, "admin", "bank", types.Address{1}, 0, true)
+	newTestAccount(state, "ops", "bank", types.Address{2}, 0, false)
+	newTestAccount(state, "alice", "bank", types.Address{3}, 0, false)
+	AssignRole(state, testutil.Encode(t, &AssignRoleData{CN: "ops", Domain: "bank", Roles: []string{RoleUserAdmin}}))
+	newTestAccount(state, "auditor", "bank", types.Address{4}, 0, false)
+	AssignRole(state, testutil.Encode(t, &AssignRoleData{CN: "auditor", Domain: "bank", Roles: []string{RoleAuditor}}))
+
+	input := testutil.Encode(t, &SetSpendingLimitData{CN: "alice", Domain: "bank", Limit: SpendingLimit{PerTx: big.NewInt(10)}})
+	tests := []struct {
+		from string
+		err  error
+	}{
+		{"admin@bank", nil},
+		{"ops@bank", nil},
+		{"auditor@bank", errUnauthorized},
+		{"alice@bank", errUnauthorized},
+	}
+	for _, tt := range tests {
+		if err := VerifySetSpendingLimitOp(state, GetAccount(state, tt.from), input); err != tt.err {
+			t.Fatalf("%s: %v, want %v", tt.from, err, tt.err)
+		}
+	}
+}
+
+func TestVerifyDelegateAdminOp(t *testing.T) {
+	state := testutil.NewState(t)
+	newTestAccount(state, "admin", "bank", types.Address{1}, 0, true)
+	newTestAccount(state, "ops", "bank", types.Address{2}, 0, false)
+	AssignRole(state, testutil.Encode(t, &AssignRoleData{CN: "ops", Domain: "bank", Roles: []string{RoleUserAdmin}}))
+	newTestAccount(state, "alice", "bank", types.Address{3}, 0, false)
+
+	freeze := accounts.Permissions{EnableFrozenUser: true}
+	tests := []struct {
+		name        string
+		from        string
+		to          string
+		permissions accounts.Permissions
+		err         error
+	}{
+		{"admin", "admin@bank", "alice", freeze, nil},
+		{"role holder", "ops@bank", "alice", freeze, nil},
+		{"beyond role", "ops@bank", "alice", accounts.Permissions{EnableRegisterSubdomain: true}, errInvalidPermission},
+		{"empty", "admin@bank", "alice", accounts.Permissions{}, errInvalidPermission},
+		{"plain user", "alice@bank", "ops", freeze, errUnauthorized},
+		{"self", "admin@bank", "admin", freeze, errInvalidDelegate},
+	}
+	for _, tt := range tests {
+		input := testutil.Encode(t, &DelegateAdminData{CN: tt.to, Domain: "bank", Permissions: tt.permissions, Blocks: 100})
+		if err := VerifyDelegateAdminOp(state, GetAccount(state, tt.from), input, 10); err != tt.err {
+			t.Fatalf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	// 委托的能力随授权人当前能力收紧
+	DelegateAdmin(state, "ops@bank", testutil.Encode(t, &DelegateAdminData{CN: "alice", Domain: "bank", Permissions: freeze, Blocks: 100}), 10)
+	alice := GetAccount(state, "alice@bank")
+	if len(authorities(state, alice, 20)) != 2 {
+		t.Fatal("delegation from role holder not active")
+	}
+	AssignRole(state, testutil.Encode(t, &AssignRoleData{CN: "ops", Domain: "bank"}))
+	if len(authorities(state, alice, 20)) != 1 {
+		t.Fatal("delegation survived loss of grantor capability")
+	}
+}
+
+func TestVerifyRoleOps(t *testing.T) {
+	state := testutil.NewState(t)
+	newTestAccount(state, "admin", "bank", types.Address{1}, 0, true)
+	newTestAccount(state, "admin", "a.bank", types.Address{2}, 0, true)
+	// 拥有更新用户权限的管理员并非域的所有者，不能管理角色
+	updater := accounts.NewAccountStore("updater", "bank")
+	updater.SetAddress(types.Address{3}, nil)
+	updater.IsAdmin = true
+	updater.Permissions = &accounts.Permissions{EnableUpdateUser: true}
+	state.CreateAccount(updater)
+	newTestAccount(state, "alice", "bank", types.Address{4}, 0, false)
+	newTestAccount(state, "bob", "a.bank", types.Address{5}, 0, false)
+	DefineRole(state, testutil.Encode(t, &DefineRoleData{Domain: "bank", Name: "ledger", Capabilities: CapDataNamespace, Namespaces: []string{"ledger", "audit"}}))
+	AssignRole(state, testutil.Encode(t, &AssignRoleData{CN: "alice", Domain: "bank", Roles: []string{"ledger"}}))
+
+	tests := []struct {
+		name string
+		from string
+		op   accounts.AccountOp
+		data interface{}
+		err  error
+	}{
+		{"owner assigns treasurer", "admin@bank", AssignRoleOp, &AssignRoleData{CN: "alice", Domain: "bank", Roles: []string{RoleTreasurer}}, nil},
+		{"update permission", "updater@bank", AssignRoleOp, &AssignRoleData{CN: "alice", Domain: "bank", Roles: []string{RoleAuditor}}, errUnauthorized},
+		{"owner defines namespace role", "admin@bank", DefineRoleOp, &DefineRoleData{Domain: "bank", Name: "data", Capabilities: CapDataNamespace, Namespaces: []string{"kyc"}}, nil},
+		{"subdomain restricts", "admin@a.bank", DefineRoleOp, &DefineRoleData{Domain: "a.bank", Name: "ledger", Capabilities: CapDataNamespace, Namespaces: []string{"Ledger"}}, nil},
+		{"subdomain widens", "admin@a.bank", DefineRoleOp, &DefineRoleData{Domain: "a.bank", Name: "ledger", Capabilities: CapDataNamespace, Namespaces: []string{"kyc"}}, errRoleNotInherited},
+		{"subdomain widens caps", "admin@a.bank", DefineRoleOp, &DefineRoleData{Domain: "a.bank", Name: "ledger", Capabilities: CapDataNamespace | CapMintToken}, errRoleNotInherited},
+		{"parent owner", "admin@bank", DefineRoleOp, &DefineRoleData{Domain: "a.bank", Name: RoleTreasurer, Capabilities: CapMintToken}, nil},
+		{"other domain", "admin@a.bank", DefineRoleOp, &DefineRoleData{Domain: "bank", Name: "ops", Capabilities: CapFreezeUser}, errUnauthorized},
+	}
+	for _, tt := range tests {
+		var err error
+		from := GetAccount(state, tt.from)
+		switch tt.op {
+		case AssignRoleOp:
+			err = VerifyAssignRoleOp(state, from, testutil.Encode(t, tt.data))
+		case DefineRoleOp:
+			err = VerifyDefineRoleOp(state, from, testutil.Encode(t, tt.data))
+		}
+		if err != tt.err {
+			t.Fatalf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	// 更新用户权限不含角色管理，因此也无法通过委托授予
+	if caps := PermissionCapabilities(&accounts.Permissions{EnableUpdateUser: true}); caps.Has(CapManageRoles) {
+		t.Fatal("manage roles granted by update permission")
+	}
+	AssignRole(state, testutil.Encode(t, &AssignRoleData{CN: "alice", Domain: "bank", Roles: []string{"ledger", RoleTreasurer}}))
+	alice := GetAccount(state, "alice@bank")
+	if !HasCapability(state, alice, CapMintToken|CapDataNamespace) || HasCapability(state, alice, CapManageRoles) {
+		t.Fatalf("alice capabilities %b", AccountCapabilities(state, alice))
+	}
+
+	DefineRole(state, testutil.Encode(t, &DefineRoleData{Domain: "a.bank", Name: "ledger", Capabilities: CapDataNamespace, Namespaces: []string{"audit", "kyc"}}))
+	AssignRole(state, testutil.Encode(t, &AssignRoleData{CN: "bob", Domain: "a.bank", Roles: []string{"ledger"}}))
+	bob := GetAccount(state, "bob@a.bank")
+	namespaces := []struct {
+		account   *accounts.AccountStore
+		namespace string
+		want      bool
+	}{
+		{alice, "LEDGER", true},
+		{alice, "kyc", false},
+		{bob, "audit", true},
+		{bob, "ledger", false}, // 子域未定义的命名空间不继承
+		{bob, "kyc", false},    // 超出上级定义的命名空间被收紧
+		{GetAccount(state, "admin@bank"), "kyc", true},
+		{GetAccount(state, "updater@bank"), "ledger", false},
+	}
+	for _, tt := range namespaces {
+		if got := HasNamespace(state, tt.account, tt.namespace); got != tt.want {
+			t.Fatalf("%s %s: %v", tt.account.AccountName(), tt.namespace, got)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+// Package accountInterpreter
+//
+// @author: xwc1125
+package accountInterpreter
+
+import (
+	"bytes"
+	"errors"
+	"github.com/chain5j/chain5j-pkg/codec"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+var (
+	errSessionKeyExists     = errors.New("session key already exists")
+	errSessionKeyNonExists  = errors.New("session key not exists")
+	errSessionKeyExpired    = errors.New("session key expired")
+	errSessionKeyScope      = errors.New("transaction out of session key scope")
+	errSessionKeyValueCap   = errors.New("transaction value exceeds session key cap")
+	errTooManySessionKeys   = errors.New("too many session keys")
+	errInvalidSessionExpiry = errors.New("invalid session key expiry height")
+)
+
+const (
+	MaxSessionKeys      = 16
+	MaxSessionKeyBlocks = 30 * BlocksPerDay
+)
+
+// SessionKey 会话密钥，仅能在有效期及限定范围内代表账户签名
+type SessionKey struct {
+	Address      types.Address `json:"address"`
+	ExpiryHeight uint64        `json:"expiry_height"`                 // 过期高度
+	Interpreters []string      `json:"interpreters,omitempty"`        // 允许的解析器，为空时不限制
+	To           []string      `json:"to,omitempty"`                  // 允许的接收者，为空时不限制
+	ValueCap     *big.Int      `json:"value_cap,omitempty" rlp:"nil"` // 单笔交易的value上限，为空或为0时不限制
+}
+
+// Active 会话密钥在指定高度是否有效
+func (k *SessionKey) Active(height uint64) bool {
+	return height < k.ExpiryHeight
+}
+
+// AddSessionKeyData 添加会话密钥的数据对象
+type AddSessionKeyData struct {
+	Address      types.Address
+	ExpiryHeight uint64
+	Interpreters []string
+	To           []string
+	ValueCap     *big.Int `rlp:"nil"`
+}
+
+func (data *AddSessionKeyData) Normalize() {
+	for i, v := range data.Interpreters {
+		data.Interpreters[i] = strings.ToLower(v)
+	}
+	for i, v := range data.To {
+		data.To[i] = strings.ToLower(v)
+	}
+}
+
+// RemoveSessionKeyData 移除会话密钥的数据对象
+type RemoveSessionKeyData struct {
+	Address types.Address
+}
+
+func sessionKeysKey(account string) []byte {
+	return stateApp.SystemKey("session_key", account)
+}
+
+// SessionKeys 账户注册的全部会话密钥，包含已过期的密钥
+func SessionKeys(state *statedb.StateDB, account string) []SessionKey {
+	var keys []SessionKey
+	ok, err := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress).Get(sessionKeysKey(account), &keys)
+	if !ok || err != nil {
+		return nil
+	}
+	return keys
+}
+
+// GetSessionKey 获取账户的会话密钥，不存在时返回nil
+func GetSessionKey(state *statedb.StateDB, account string, addr types.Address) *SessionKey {
+	for _, k := range SessionKeys(state, account) {
+		if k.Address == addr {
+			key := k
+			return &key
+		}
+	}
+	return nil
+}
+
+func setSessionKeys(state *statedb.StateDB, account string, keys []SessionKey) {
+	store := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress)
+	if len(keys) == 0 {
+		store.Delete(sessionKeysKey(account))
+		return
+	}
+	store.Set(sessionKeysKey(account), keys)
+}
+
+// ClearSessionKeys 移除账户的全部会话密钥，用于找回账户时撤销原密钥添加的会话密钥
+// 已移除的地址仍被占用，不能再注册
+func ClearSessionKeys(state *statedb.StateDB, account string) {
+	setSessionKeys(state, account, nil)
+}
+
+// VerifySigner 校验签名者：账户的完全控制地址，或在范围内使用的有效会话密钥
+func VerifySigner(state *statedb.StateDB, store *accounts.AccountStore, signer types.Address, interpreter string, tx models.StateTransaction, height uint64) error {
+	if store.ContainAddress(signer) {
+		return nil
+	}
+
+	key := GetSessionKey(state, store.AccountName(), signer)
+	if key == nil {
+		return stateApp.ErrInvalidSigner
+	}
+	return verifySessionScope(key, interpreter, tx, height)
+}
+
+// VerifyControlSigner 校验签名者为账户的完全控制地址，管理、冻结、委托、额度及找回等操作不接受会话密钥
+func VerifyControlSigner(store *accounts.AccountStore, signer types.Address) error {
+	if !store.ContainAddress(signer) {
+		return stateApp.ErrInvalidSigner
+	}
+	return nil
+}
+
+func verifySessionScope(key *SessionKey, interpreter string, tx models.StateTransaction, height uint64) error {
+	if !key.Active(height) {
+		return errSessionKeyExpired
+	}
+	if len(key.Interpreters) > 0 && !containsString(key.Interpreters, interpreter) {
+		return errSessionKeyScope
+	}
+	if len(key.To) > 0 && !containsString(key.To, strings.ToLower(tx.To())) {
+		return errSessionKeyScope
+	}
+	// rlp解码后空的上限为0，均视为不限制
+	if key.ValueCap != nil && key.ValueCap.Sign() > 0 && tx.Value() != nil && tx.Value().Cmp(key.ValueCap) > 0 {
+		return errSessionKeyValueCap
+	}
+	return nil
+}
+
+// PrimaryAddress 账户的主地址，即排序后的第一个完全控制地址
+func PrimaryAddress(store *accounts.AccountStore) types.Address {
+	addrs := make([]types.Address, 0, len(store.Addresses))
+	for addr := range store.Addresses {
+		addrs = append(addrs, addr)
+	}
+	if len(addrs) == 0 {
+		return types.Address{}
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+	return addrs[0]
+}
+
+func sessionOwnerKey(addr types.Address) []byte {
+	return stateApp.SystemKey("session_owner", addr.Hex())
+}
+
+// sessionKeyOwner 会话密钥地址所属的账户名，不存在时为空
+func sessionKeyOwner(state *statedb.StateDB, addr types.Address) string {
+	var owner string
+	ok, err := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress).Get(sessionOwnerKey(addr), &owner)
+	if !ok || err != nil {
+		return ""
+	}
+	return owner
+}
+
+// AddressOwner 地址所属的账户名，包括账户地址、合约地址及会话密钥地址，不存在时为空
+// 账户地址的映射在注册及找回时建立，会话密钥地址的映射在添加时建立，均不会移除
+func AddressOwner(state *statedb.StateDB, addr types.Address) string {
+	if owner := state.GetOwner(addr); owner != "" {
+		return owner
+	}
+	return sessionKeyOwner(state, addr)
+}
+
+// AddressTaken 地址是否已被账户或会话密钥占用，已移除或过期的会话密钥地址仍被占用
+func AddressTaken(state *statedb.StateDB, addr types.Address) bool {
+	return AddressOwner(state, addr) != ""
+}
+
+func VerifyAddSessionKeyOp(state *statedb.StateDB, accountFrom *accounts.AccountStore, signer types.Address, input []byte, height uint64) error {
+	var data AddSessionKeyData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	// 会话密钥不能管理会话密钥
+	if err := VerifyControlSigner(accountFrom, signer); err != nil {
+		return err
+	}
+
+	if data.Address == (types.Address{}) || AddressTaken(state, data.Address) {
+		return errAddressExists
+	}
+	if data.ExpiryHeight <= height || data.ExpiryHeight > height+MaxSessionKeyBlocks {
+		return errInvalidSessionExpiry
+	}
+
+	keys := SessionKeys(state, accountFrom.AccountName())
+	active := 0
+	for _, k := range keys {
+		if k.Address == data.Address {
+			return errSessionKeyExists
+		}
+		if k.Active(height) {
+			active++
+		}
+	}
+	if active >= MaxSessionKeys {
+		return errTooManySessionKeys
+	}
+
+	return nil
+}
+
+func AddSessionKey(state *statedb.StateDB, account string, input []byte, height uint64) error {
+	var data AddSessionKeyData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	// 同时清理已过期的密钥
+	keys := make([]SessionKey, 0)
+	for _, k := range SessionKeys(state, account) {
+		if k.Active(height) {
+			keys = append(keys, k)
+		}
+	}
+	keys = append(keys, SessionKey{
+		Address:      data.Address,
+		ExpiryHeight: data.ExpiryHeight,
+		Interpreters: data.Interpreters,
+		To:           data.To,
+		ValueCap:     data.ValueCap,
+	})
+	setSessionKeys(state, account, keys)
+	stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress).Set(sessionOwnerKey(data.Address), account)
+
+	return nil
+}
+
+func VerifyRemoveSessionKeyOp(state *statedb.StateDB, accountFrom *accounts.AccountStore, signer types.Address, input []byte) error {
+	var data RemoveSessionKeyData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+
+	// 完全控制地址或会话密钥本身可以移除
+	if !accountFrom.ContainAddress(signer) && signer != data.Address {
+		return stateApp.ErrInvalidSigner
+	}
+	if GetSessionKey(state, accountFrom.AccountName(), data.Address) == nil {
+		return errSessionKeyNonExists
+	}
+
+	return nil
+}
+
+func RemoveSessionKey(state *statedb.StateDB, account string, input []byte) error {
+	var data RemoveSessionKeyData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+
+	keys := SessionKeys(state, account)
+	remain := make([]SessionKey, 0, len(keys))
+	for _, k := range keys {
+		if k.Address != data.Address {
+			remain = append(remain, k)
+		}
+	}
+	setSessionKeys(state, account, remain)
+
+	return nil
+}
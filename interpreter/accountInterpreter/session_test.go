@@ -0,0 +1,181 @@
+// Package accountInterpreter
+//
+// @author: xwc1125
+package accountInterpreter
+
+import (
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/internal/testutil"
+	"math/big"
+	"testing"
+)
+
+func TestVerifyTx_SessionKeySigner(t *testing.T) {
+	state := testutil.NewState(t)
+	ownerKey, ownerAddr := testutil.NewKey(t)
+	sessionKey, sessionAddr := testutil.NewKey(t)
+	strangerKey, _ := testutil.NewKey(t)
+	_, bobAddr := testutil.NewKey(t)
+
+	newTestAccount(state, "admin", "bank", ownerAddr, 1000, true)
+	newTestAccount(state, "bob", "bank", bobAddr, 0, false)
+	newTestAccount(state, "x", "a.bank", types.Address{1}, 0, true)
+	AddSessionKey(state, "admin@bank", testutil.Encode(t, &AddSessionKeyData{Address: sessionAddr, ExpiryHeight: 1000}), 1)
+	ctx := testutil.NewCtx(t, state, 10, nil)
+
+	permissions := accounts.Permissions{EnableFrozenUser: true}
+	tests := []struct {
+		name   string
+		op     accounts.AccountOp
+		data   interface{}
+		signer error // 会话密钥签名的结果，完全控制地址签名均应通过
+	}{
+		{"freeze", FreezeAccountOp, &FreezeAccountData{CN: "bob", Domain: "bank", Frozen: true}, stateApp.ErrInvalidSigner},
+		{"freeze domain", FreezeDomainOp, &FreezeDomainData{Domain: "a.bank", Frozen: true}, stateApp.ErrInvalidSigner},
+		{"legacy freeze", accounts.FrozenAccountOp, &accounts.FrozenAccountData{CN: "bob", Domain: "bank", Frozen: true}, stateApp.ErrInvalidSigner},
+		{"partner", accounts.SetPartnerOp, &accounts.PartnerData{CN: "bob", Domain: "bank"}, stateApp.ErrInvalidSigner},
+		{"delegate", DelegateAdminOp, &DelegateAdminData{CN: "bob", Domain: "bank", Permissions: permissions, Blocks: 10}, stateApp.ErrInvalidSigner},
+		{"transfer domain", TransferDomainOp, &TransferDomainData{Domain: "bank", NewAdmin: "bob"}, stateApp.ErrInvalidSigner},
+		{"define role", DefineRoleOp, &DefineRoleData{Domain: "bank", Name: "ops", Capabilities: CapFreezeUser}, stateApp.ErrInvalidSigner},
+		{"assign role", AssignRoleOp, &AssignRoleData{CN: "bob", Domain: "bank", Roles: []string{RoleAuditor}}, stateApp.ErrInvalidSigner},
+		{"spending limit", SetSpendingLimitOp, &SetSpendingLimitData{CN: "bob", Domain: "bank", Limit: SpendingLimit{PerTx: big.NewInt(1)}}, stateApp.ErrInvalidSigner},
+		{"add session key", AddSessionKeyOp, &AddSessionKeyData{Address: bobAddr, ExpiryHeight: 100}, stateApp.ErrInvalidSigner},
+		{"vesting", CreateVestingOp, &CreateVestingData{CN: "bob", Domain: "bank", Amount: big.NewInt(10), Start: 10, End: 20}, stateApp.ErrInvalidSigner},
+		{"remove self", RemoveSessionKeyOp, &RemoveSessionKeyData{Address: sessionAddr}, nil},
+	}
+	for _, tt := range tests {
+		interpreter := NewInterpreter()
+		// 添加会话密钥要求地址未被占用，控制地址签名时以新地址校验
+		if err := interpreter.VerifyTx(ctx, newOpTx(t, sessionKey, "admin@bank", tt.op, tt.data)); err != tt.signer {
+			t.Fatalf("%s: session key %v, want %v", tt.name, err, tt.signer)
+		}
+		if err := interpreter.VerifyTx(ctx, newOpTx(t, strangerKey, "admin@bank", tt.op, tt.data)); err != stateApp.ErrInvalidSigner {
+			t.Fatalf("%s: stranger %v", tt.name, err)
+		}
+		if tt.op == AddSessionKeyOp {
+			continue
+		}
+		if err := interpreter.VerifyTx(ctx, newOpTx(t, ownerKey, "admin@bank", tt.op, tt.data)); err != nil {
+			t.Fatalf("%s: owner %v", tt.name, err)
+		}
+	}
+}
+
+// 范围内的会话密钥不能通过归属计划将余额转给范围外的受益人
+func TestVerifyTx_SessionKeyVesting(t *testing.T) {
+	state := testutil.NewState(t)
+	_, ownerAddr := testutil.NewKey(t)
+	sessionKey, sessionAddr := testutil.NewKey(t)
+	newTestAccount(state, "alice", "bank", ownerAddr, 1000, false)
+	newTestAccount(state, "bob", "bank", types.Address{2}, 0, false)
+	newTestAccount(state, "carol", "bank", types.Address{3}, 0, false)
+	AddSessionKey(state, "alice@bank", testutil.Encode(t, &AddSessionKeyData{
+		Address:      sessionAddr,
+		ExpiryHeight: 100,
+		To:           []string{"bob@bank"},
+		ValueCap:     big.NewInt(5),
+	}), 1)
+	ctx := testutil.NewCtx(t, state, 10, nil)
+
+	data := &CreateVestingData{CN: "carol", Domain: "bank", Amount: big.NewInt(1000), Start: 10, End: 20}
+	input := testutil.Encode(t, &accounts.AccountOpData{Operation: CreateVestingOp, Data: testutil.Encode(t, data)})
+	tx := stateApp.NewTransaction("alice@bank", "bob@bank", stateApp.AccountInterpreter, 0, 0, stateApp.TxGas, big.NewInt(0), input, 0, nil)
+	if _, err := tx.Sign(sessionKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewInterpreter().VerifyTx(ctx, tx); err != stateApp.ErrInvalidSigner {
+		t.Fatalf("vesting: %v, want %v", err, stateApp.ErrInvalidSigner)
+	}
+	if balance := state.GetBalance("alice@bank"); balance.Int64() != 1000 {
+		t.Fatalf("balance %v", balance)
+	}
+}
+
+func TestVerifySigner_Scope(t *testing.T) {
+	state := testutil.NewState(t)
+	_, ownerAddr := testutil.NewKey(t)
+	_, scopedAddr := testutil.NewKey(t)
+	_, strangerAddr := testutil.NewKey(t)
+	_, openAddr := testutil.NewKey(t)
+	store := newTestAccount(state, "alice", "bank", ownerAddr, 1000, false)
+	AddSessionKey(state, "alice@bank", testutil.Encode(t, &AddSessionKeyData{Address: openAddr, ExpiryHeight: 100}), 1)
+	AddSessionKey(state, "alice@bank", testutil.Encode(t, &AddSessionKeyData{
+		Address:      scopedAddr,
+		ExpiryHeight: 100,
+		Interpreters: []string{stateApp.BaseInterpreter},
+		To:           []string{"bob@bank"},
+		ValueCap:     big.NewInt(50),
+	}), 1)
+
+	tests := []struct {
+		name        string
+		signer      types.Address
+		interpreter string
+		to          string
+		value       int64
+		height      uint64
+		err         error
+	}{
+		{"owner", ownerAddr, stateApp.EscrowInterpreter, "carol@bank", 500, 10, nil},
+		{"in scope", scopedAddr, stateApp.BaseInterpreter, "bob@bank", 50, 10, nil},
+		{"interpreter", scopedAddr, stateApp.EscrowInterpreter, "bob@bank", 50, 10, errSessionKeyScope},
+		{"recipient", scopedAddr, stateApp.BaseInterpreter, "carol@bank", 50, 10, errSessionKeyScope},
+		{"value cap", scopedAddr, stateApp.BaseInterpreter, "bob@bank", 51, 10, errSessionKeyValueCap},
+		{"expired", scopedAddr, stateApp.BaseInterpreter, "bob@bank", 50, 100, errSessionKeyExpired},
+		{"stranger", strangerAddr, stateApp.BaseInterpreter, "bob@bank", 50, 10, stateApp.ErrInvalidSigner},
+		{"uncapped", openAddr, stateApp.BaseInterpreter, "carol@bank", 1000, 10, nil},
+	}
+	for _, tt := range tests {
+		tx := stateApp.NewTransaction("alice@bank", tt.to, tt.interpreter, 0, 0, stateApp.TxGas, big.NewInt(tt.value), nil, 0, nil)
+		if err := VerifySigner(state, store, tt.signer, tt.interpreter, tx, tt.height); err != tt.err {
+			t.Fatalf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestAddressOwner(t *testing.T) {
+	state := testutil.NewState(t)
+	_, ownerAddr := testutil.NewKey(t)
+	_, sessionAddr := testutil.NewKey(t)
+	_, removedAddr := testutil.NewKey(t)
+	_, freeAddr := testutil.NewKey(t)
+	alice := newTestAccount(state, "alice", "bank", ownerAddr, 0, false)
+	AddSessionKey(state, "alice@bank", testutil.Encode(t, &AddSessionKeyData{Address: sessionAddr, ExpiryHeight: 100}), 1)
+	AddSessionKey(state, "alice@bank", testutil.Encode(t, &AddSessionKeyData{Address: removedAddr, ExpiryHeight: 100}), 1)
+	RemoveSessionKey(state, "alice@bank", testutil.Encode(t, &RemoveSessionKeyData{Address: removedAddr}))
+
+	tests := []struct {
+		name  string
+		addr  types.Address
+		owner string
+	}{
+		{"account address", ownerAddr, "alice@bank"},
+		{"session key", sessionAddr, "alice@bank"},
+		{"removed session key", removedAddr, "alice@bank"},
+		{"free", freeAddr, ""},
+	}
+	for _, tt := range tests {
+		if owner := AddressOwner(state, tt.addr); owner != tt.owner {
+			t.Fatalf("%s: owner %q, want %q", tt.name, owner, tt.owner)
+		}
+		if taken := AddressTaken(state, tt.addr); taken != (tt.owner != "") {
+			t.Fatalf("%s: taken %v", tt.name, taken)
+		}
+		// 已占用的地址不能再注册或作为其他会话密钥
+		bob := accounts.NewAccountStore("bob", "bank")
+		bob.SetAddress(tt.addr, nil)
+		want := error(nil)
+		if tt.owner != "" {
+			want = errAddressExists
+		}
+		if err := checkAddress(state, bob); err != want {
+			t.Fatalf("%s: register %v, want %v", tt.name, err, want)
+		}
+		err := VerifyAddSessionKeyOp(state, alice, ownerAddr, testutil.Encode(t, &AddSessionKeyData{Address: tt.addr, ExpiryHeight: 100}), 10)
+		if err != want {
+			t.Fatalf("%s: add session key %v, want %v", tt.name, err, want)
+		}
+	}
+}
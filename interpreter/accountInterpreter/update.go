@@ -19,6 +19,9 @@ func VerifySetPartnerOp(state *statedb.StateDB, accountFrom *accounts.AccountSto
 
 	if accountFrom.Domain == data.Domain || isSubDomain(data.Domain, accountFrom.Domain) || data.CN == "" {
 		return nil
+	} else if HasCapability(state, accountFrom, CapManagePartner) {
+		// 拥有伙伴管理能力的账户可以跨域设置
+		return nil
 	} else {
 		return errInvalidDomain
 	}
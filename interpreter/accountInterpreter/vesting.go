@@ -0,0 +1,306 @@
+// Package accountInterpreter
+//
+// @author: xwc1125
+package accountInterpreter
+
+import (
+	"errors"
+	"github.com/chain5j/chain5j-pkg/codec"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp"
+	"math/big"
+	"strings"
+)
+
+var (
+	errInvalidVesting     = errors.New("invalid vesting schedule")
+	errTooManyVestings    = errors.New("too many vesting schedules")
+	errNothingToRelease   = errors.New("no vested amount to release")
+	errLockedBalance      = errors.New("insufficient spendable balance, amount is locked")
+	errVestingNonExists   = errors.New("vesting schedule not exists")
+	errVestingSelfGranted = errors.New("can't grant vesting to self")
+)
+
+const (
+	MaxVestingSchedules = 32
+)
+
+// VestingSchedule 锁仓计划，锁定的金额计入账户余额，但未释放的部分不可花费
+// 按区块高度或区块时间戳[毫秒]计算：Cliff之前全部锁定，Start至End之间线性或按Steps等分释放
+type VestingSchedule struct {
+	ID          uint64   `json:"id"`
+	Grantor     string   `json:"grantor"`
+	Total       *big.Int `json:"total"`
+	Released    *big.Int `json:"released"`
+	ByTimestamp bool     `json:"by_timestamp"` // 为true时按区块时间戳计算，否则按区块高度
+	Start       uint64   `json:"start"`
+	Cliff       uint64   `json:"cliff"`
+	End         uint64   `json:"end"`
+	Steps       uint64   `json:"steps"` // 为0时线性释放，否则分Steps次等额释放
+}
+
+// CreateVestingData 创建锁仓的数据对象，Amount从发起账户转给受益账户并按计划锁定
+type CreateVestingData struct {
+	CN          string
+	Domain      string
+	Amount      *big.Int
+	ByTimestamp bool
+	Start       uint64
+	Cliff       uint64
+	End         uint64
+	Steps       uint64
+}
+
+func (data *CreateVestingData) Normalize() {
+	data.CN = strings.ToLower(data.CN)
+	data.Domain = strings.ToLower(data.Domain)
+}
+
+// ReleaseVestingData 释放锁仓的数据对象，ID为0时释放全部计划中已到期的金额
+type ReleaseVestingData struct {
+	ID uint64
+}
+
+// Vested 计划在指定高度及时间戳已到期的金额
+func (v *VestingSchedule) Vested(height, timestamp uint64) *big.Int {
+	now := height
+	if v.ByTimestamp {
+		now = timestamp
+	}
+
+	switch {
+	case now < v.Cliff || now < v.Start:
+		return new(big.Int)
+	case now >= v.End:
+		return new(big.Int).Set(v.Total)
+	}
+
+	duration := v.End - v.Start
+	elapsed := now - v.Start
+	if v.Steps > 0 {
+		// duration不能被Steps整除时，elapsed/step可能超过Steps
+		steps := elapsed / (duration / v.Steps)
+		if steps > v.Steps {
+			steps = v.Steps
+		}
+		return new(big.Int).Div(new(big.Int).Mul(v.Total, new(big.Int).SetUint64(steps)), new(big.Int).SetUint64(v.Steps))
+	}
+	return new(big.Int).Div(new(big.Int).Mul(v.Total, new(big.Int).SetUint64(elapsed)), new(big.Int).SetUint64(duration))
+}
+
+// Releasable 可释放的金额
+func (v *VestingSchedule) Releasable(height, timestamp uint64) *big.Int {
+	releasable := v.Vested(height, timestamp)
+	releasable.Sub(releasable, v.Released)
+	if releasable.Sign() < 0 {
+		releasable.SetInt64(0)
+	}
+	return releasable
+}
+
+// Locked 尚未释放的金额
+func (v *VestingSchedule) Locked() *big.Int {
+	return new(big.Int).Sub(v.Total, v.Released)
+}
+
+// BalanceDetail 账户余额明细
+type BalanceDetail struct {
+	Total      *big.Int // 账户余额
+	Spendable  *big.Int // 可花费余额
+	Locked     *big.Int // 锁定余额
+	Releasable *big.Int // 锁定余额中已到期、可释放的金额
+}
+
+func vestingKey(account string) []byte {
+	return stateApp.SystemKey("vesting", account)
+}
+
+func vestingSeqKey(account string) []byte {
+	return stateApp.SystemKey("vesting_seq", account)
+}
+
+// VestingSchedules 账户作为受益人的全部锁仓计划
+func VestingSchedules(state *statedb.StateDB, account string) []VestingSchedule {
+	var schedules []VestingSchedule
+	ok, err := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress).Get(vestingKey(account), &schedules)
+	if !ok || err != nil {
+		return nil
+	}
+	return schedules
+}
+
+func setVestingSchedules(state *statedb.StateDB, account string, schedules []VestingSchedule) {
+	store := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress)
+	if len(schedules) == 0 {
+		store.Delete(vestingKey(account))
+		return
+	}
+	store.Set(vestingKey(account), schedules)
+}
+
+// LockedBalance 账户锁定的余额
+func LockedBalance(state *statedb.StateDB, account string) *big.Int {
+	locked := new(big.Int)
+	for _, v := range VestingSchedules(state, account) {
+		locked.Add(locked, v.Locked())
+	}
+	return locked
+}
+
+// SpendableBalance 账户可花费的余额
+func SpendableBalance(state *statedb.StateDB, account string) *big.Int {
+	spendable := new(big.Int).Sub(state.GetBalance(account), LockedBalance(state, account))
+	if spendable.Sign() < 0 {
+		spendable.SetInt64(0)
+	}
+	return spendable
+}
+
+// GetBalanceDetail 账户的余额明细
+func GetBalanceDetail(state *statedb.StateDB, account string, height, timestamp uint64) *BalanceDetail {
+	detail := &BalanceDetail{
+		Total:      new(big.Int).Set(state.GetBalance(account)),
+		Locked:     new(big.Int),
+		Releasable: new(big.Int),
+	}
+	for _, v := range VestingSchedules(state, account) {
+		detail.Locked.Add(detail.Locked, v.Locked())
+		detail.Releasable.Add(detail.Releasable, v.Releasable(height, timestamp))
+	}
+	detail.Spendable = new(big.Int).Sub(detail.Total, detail.Locked)
+	if detail.Spendable.Sign() < 0 {
+		detail.Spendable.SetInt64(0)
+	}
+	return detail
+}
+
+// VerifySpendable 校验花费金额不超过可花费余额
+func VerifySpendable(state *statedb.StateDB, account string, cost *big.Int) error {
+	if cost == nil || cost.Sign() <= 0 {
+		return nil
+	}
+	if SpendableBalance(state, account).Cmp(cost) < 0 {
+		if state.GetBalance(account).Cmp(cost) < 0 {
+			return stateApp.ErrBalanceNotEnough
+		}
+		return errLockedBalance
+	}
+	return nil
+}
+
+func VerifyCreateVestingOp(state *statedb.StateDB, accountFrom *accounts.AccountStore, input []byte, timestamp uint64) error {
+	var data CreateVestingData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	if data.Amount == nil || data.Amount.Sign() <= 0 {
+		return errInvalidVesting
+	}
+	if data.End <= data.Start || data.Cliff > data.End {
+		return errInvalidVesting
+	}
+	if data.Steps > 0 && (data.End-data.Start)/data.Steps == 0 {
+		return errInvalidVesting
+	}
+
+	beneficiary := data.CN + accounts.DomainLinkFlag + data.Domain
+	if beneficiary == accountFrom.AccountName() {
+		return errVestingSelfGranted
+	}
+	if !state.Exist(beneficiary) {
+		return errAccountNonExists
+	}
+	if len(VestingSchedules(state, beneficiary)) >= MaxVestingSchedules {
+		return errTooManyVestings
+	}
+
+	if err := VerifySpendable(state, accountFrom.AccountName(), data.Amount); err != nil {
+		return err
+	}
+	return VerifySpend(state, accountFrom.AccountName(), beneficiary, data.Amount, timestamp)
+}
+
+func CreateVesting(state *statedb.StateDB, grantor string, input []byte, height, timestamp uint64) error {
+	var data CreateVestingData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+	data.Normalize()
+
+	beneficiary := data.CN + accounts.DomainLinkFlag + data.Domain
+	state.SubBalance(grantor, data.Amount)
+	state.AddBalance(beneficiary, data.Amount)
+	state.AddLog(stateApp.NewTransferLog(grantor, beneficiary, data.Amount, height))
+	RecordSpend(state, grantor, beneficiary, data.Amount, timestamp)
+
+	// 计划编号递增，不因计划移除而复用
+	store := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress)
+	var id uint64
+	store.Get(vestingSeqKey(beneficiary), &id)
+	id++
+	store.Set(vestingSeqKey(beneficiary), id)
+
+	schedules := append(VestingSchedules(state, beneficiary), VestingSchedule{
+		ID:          id,
+		Grantor:     grantor,
+		Total:       new(big.Int).Set(data.Amount),
+		Released:    new(big.Int),
+		ByTimestamp: data.ByTimestamp,
+		Start:       data.Start,
+		Cliff:       data.Cliff,
+		End:         data.End,
+		Steps:       data.Steps,
+	})
+	setVestingSchedules(state, beneficiary, schedules)
+
+	return nil
+}
+
+func VerifyReleaseVestingOp(state *statedb.StateDB, accountFrom *accounts.AccountStore, input []byte, height, timestamp uint64) error {
+	var data ReleaseVestingData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+
+	found := false
+	releasable := new(big.Int)
+	for _, v := range VestingSchedules(state, accountFrom.AccountName()) {
+		if data.ID == 0 || v.ID == data.ID {
+			found = true
+			releasable.Add(releasable, v.Releasable(height, timestamp))
+		}
+	}
+	if !found {
+		return errVestingNonExists
+	}
+	if releasable.Sign() == 0 {
+		return errNothingToRelease
+	}
+
+	return nil
+}
+
+// ReleaseVesting 释放已到期的金额，全部释放的计划被移除
+func ReleaseVesting(state *statedb.StateDB, account string, input []byte, height, timestamp uint64) error {
+	var data ReleaseVestingData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidInput
+	}
+
+	schedules := VestingSchedules(state, account)
+	remain := make([]VestingSchedule, 0, len(schedules))
+	for _, v := range schedules {
+		if data.ID == 0 || v.ID == data.ID {
+			v.Released = new(big.Int).Add(v.Released, v.Releasable(height, timestamp))
+		}
+		if v.Locked().Sign() > 0 {
+			remain = append(remain, v)
+		}
+	}
+	setVestingSchedules(state, account, remain)
+
+	return nil
+}
@@ -0,0 +1,82 @@
+// Package accountInterpreter
+//
+// @author: xwc1125
+package accountInterpreter
+
+import (
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/internal/testutil"
+	"math/big"
+	"testing"
+)
+
+func TestVestingSchedule_Vested(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule VestingSchedule
+		height   uint64
+		want     int64
+	}{
+		{"before cliff", VestingSchedule{Total: big.NewInt(100), Start: 10, Cliff: 15, End: 20}, 14, 0},
+		{"linear", VestingSchedule{Total: big.NewInt(100), Start: 10, Cliff: 15, End: 20}, 15, 50},
+		{"end", VestingSchedule{Total: big.NewInt(100), Start: 10, End: 20}, 25, 100},
+		{"steps", VestingSchedule{Total: big.NewInt(100), Start: 0, End: 10, Steps: 4}, 5, 50},
+		// step=2，elapsed/step=5超过Steps
+		{"steps clamped", VestingSchedule{Total: big.NewInt(100), Start: 0, End: 11, Steps: 4}, 10, 100},
+	}
+	for _, tt := range tests {
+		if got := tt.schedule.Vested(tt.height, 0); got.Int64() != tt.want {
+			t.Fatalf("%s: vested %v, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyCreateVestingOp_SpendingLimit(t *testing.T) {
+	state := testutil.NewState(t)
+	alice := newTestAccount(state, "alice", "bank", types.Address{2}, 1000, false)
+	newTestAccount(state, "bob", "bank", types.Address{3}, 0, false)
+	SetSpendingLimit(state, testutil.Encode(t, &SetSpendingLimitData{CN: "alice", Domain: "bank", Limit: SpendingLimit{
+		Daily: big.NewInt(300),
+	}}))
+
+	vesting := func(amount int64) []byte {
+		return testutil.Encode(t, &CreateVestingData{CN: "bob", Domain: "bank", Amount: big.NewInt(amount), Start: 1, End: 10})
+	}
+	tests := []struct {
+		name   string
+		amount int64
+		err    error
+	}{
+		{"over balance", 2000, stateApp.ErrBalanceNotEnough},
+		{"within limit", 200, nil},
+		{"over daily", 101, errExceedDailyLimit},
+		{"rest of daily", 100, nil},
+	}
+	for _, tt := range tests {
+		err := VerifyCreateVestingOp(state, alice, vesting(tt.amount), 0)
+		if err != tt.err {
+			t.Fatalf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+		if err == nil {
+			CreateVesting(state, "alice@bank", vesting(tt.amount), 0, 0)
+		}
+	}
+
+	if allowance := AccountAllowance(state, "alice@bank", 0); allowance.Spent.Int64() != 300 {
+		t.Fatalf("spent %v, want 300", allowance.Spent)
+	}
+	if locked := LockedBalance(state, "bob@bank"); locked.Int64() != 300 {
+		t.Fatalf("locked %v, want 300", locked)
+	}
+	// 每个计划的发放以转账日志记录受益人
+	var granted int64
+	for _, l := range state.GetLogs(types.Hash{}) {
+		if transfer, ok := stateApp.DecodeTransferLog(l); ok && transfer.From == "alice@bank" && transfer.To == "bob@bank" {
+			granted += transfer.Amount.Int64()
+		}
+	}
+	if granted != 300 {
+		t.Fatalf("transfer logs %d, want 300", granted)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/chain5j/chain5j-protocol/models/statetype"
 	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
 	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
 	"github.com/chain5j/logger"
 	"math/big"
 	"time"
@@ -33,17 +34,17 @@ func (base *BaseInterpreter) VerifyTx(ctx stateApp.InterpreterCtx, tx models.Sta
 	if err != nil {
 		return err
 	}
-	accountFrom := stateDB.GetAccount(tx.From())
+	accountFrom := accountInterpreter.GetAccount(stateDB, tx.From())
 	// 账户未找到
 	if accountFrom == nil {
 		return stateApp.ErrFromAccountNotFound
 	}
-	if accountFrom.IsFrozen {
-		return stateApp.ErrFrozenAccount
+	if err := accountInterpreter.VerifyAccountStatus(stateDB, accountFrom, ctx.Header().Height); err != nil {
+		return err
 	}
 
-	if !accountFrom.ContainAddress(signer) {
-		return stateApp.ErrInvalidSigner
+	if err := accountInterpreter.VerifySigner(stateDB, accountFrom, signer, stateApp.BaseInterpreter, tx, ctx.Header().Height); err != nil {
+		return err
 	}
 
 	accountTo := stateDB.GetAccount(tx.To())
@@ -57,23 +58,37 @@ func (base *BaseInterpreter) VerifyTx(ctx stateApp.InterpreterCtx, tx models.Sta
 	if balance.Cmp(tx.Cost()) < 0 {
 		return stateApp.ErrBalanceNotEnough
 	}
+	// 锁仓中未释放的金额不可花费
+	if err := accountInterpreter.VerifySpendable(stateDB, tx.From(), tx.Cost()); err != nil {
+		return err
+	}
+
+	// check spending limit
+	if err := accountInterpreter.VerifySpend(stateDB, tx.From(), tx.To(), tx.Value(), ctx.Header().Timestamp); err != nil {
+		return err
+	}
 
 	base.log.Debug("VerifyTx Elapsed", "elapsed", dateutil.PrettyDuration(time.Since(t)))
 	return nil
 }
 
+// Gas 每笔交易的gas固定为stateApp.TxGas
+func (base *BaseInterpreter) Gas(tx models.StateTransaction) uint64 {
+	return stateApp.TxGas
+}
+
 func (base *BaseInterpreter) ApplyTransaction(ctx stateApp.InterpreterCtx, tx models.StateTransaction, usedGas *uint64) (*statetype.Receipt, error) {
 	// 计算gasUsed
-	//gasUsed := uint64(21000)
+	//gasUsed := base.Gas(tx)
 	stateDB := ctx.StateDB()
 
-	*usedGas += uint64(21000)
+	*usedGas += base.Gas(tx)
 
 	receipt := &statetype.Receipt{
 		Status:            1,
 		CumulativeGasUsed: *usedGas,
 		TransactionHash:   tx.Hash(),
-		GasUsed:           uint64(21000),
+		GasUsed:           base.Gas(tx),
 		Logs:              nil,
 	}
 
@@ -82,6 +97,7 @@ func (base *BaseInterpreter) ApplyTransaction(ctx stateApp.InterpreterCtx, tx mo
 	if err != nil {
 		return nil, err
 	}
+	accountInterpreter.RecordSpend(stateDB, tx.From(), tx.To(), tx.Value(), ctx.Header().Timestamp)
 	return receipt, nil
 }
 
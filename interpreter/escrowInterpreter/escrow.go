@@ -0,0 +1,368 @@
+// Package escrowInterpreter
+//
+// @author: xwc1125
+package escrowInterpreter
+
+import (
+	"crypto/sha256"
+	"errors"
+	"github.com/chain5j/chain5j-pkg/codec"
+	"github.com/chain5j/chain5j-pkg/codec/rlp"
+	"github.com/chain5j/chain5j-pkg/crypto/hashalg/sha3"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp"
+	"math/big"
+	"strings"
+)
+
+var (
+	errInvalidEscrow     = errors.New("invalid escrow")
+	errEscrowExists      = errors.New("escrow already exists")
+	errEscrowNonExists   = errors.New("escrow not exists")
+	errEscrowClosed      = errors.New("escrow is closed")
+	errInvalidPreimage   = errors.New("invalid hashlock preimage")
+	errEscrowExpired     = errors.New("escrow timelock expired")
+	errEscrowNotExpired  = errors.New("escrow timelock not expired")
+	errNoHashLock        = errors.New("escrow has no hashlock")
+	errNoTimeLock        = errors.New("escrow has no timelock")
+	errUnauthorized      = errors.New("unauthorized")
+	errInvalidEscrowOp   = errors.New("invalid escrow operation")
+	errInvalidEscrowData = errors.New("invalid escrow operation input")
+)
+
+// EscrowOp 托管操作
+type EscrowOp uint8
+
+const (
+	LockOp    EscrowOp = iota // 锁定，金额为交易的value
+	ClaimOp                   // 接收者提供原像领取
+	RefundOp                  // 超时后发送者取回
+	ResolveOp                 // 仲裁者裁决
+)
+
+// HashType 哈希锁算法
+type HashType uint8
+
+const (
+	HashSha256    HashType = iota // 与比特币等账本的原子交换
+	HashKeccak256                 // 与以太坊等账本的原子交换
+)
+
+// EscrowStatus 托管状态
+type EscrowStatus uint8
+
+const (
+	StatusOpen     EscrowStatus = iota // 锁定中
+	StatusClaimed                      // 已由接收者领取或经仲裁放款
+	StatusRefunded                     // 已退回发送者
+)
+
+// 事件
+var (
+	EventLocked   = types.BytesToHash(sha3.Keccak256([]byte("EscrowLocked")))
+	EventClaimed  = types.BytesToHash(sha3.Keccak256([]byte("EscrowClaimed")))
+	EventRefunded = types.BytesToHash(sha3.Keccak256([]byte("EscrowRefunded")))
+	EventResolved = types.BytesToHash(sha3.Keccak256([]byte("EscrowResolved")))
+)
+
+// EscrowOpData 托管交易的input
+type EscrowOpData struct {
+	Operation EscrowOp
+	Data      []byte
+}
+
+// LockData 锁定的数据对象，HashLock与Arbiter至少设置一个
+type LockData struct {
+	Recipient string
+	HashType  HashType
+	HashLock  types.Hash // 为空时不设哈希锁
+	TimeLock  uint64     // 超时高度，为0时不设时间锁
+	Arbiter   string     // 仲裁者，为空时无仲裁
+}
+
+func (data *LockData) Normalize() {
+	data.Recipient = strings.ToLower(data.Recipient)
+	data.Arbiter = strings.ToLower(data.Arbiter)
+}
+
+// ClaimData 领取的数据对象
+type ClaimData struct {
+	ID       types.Hash
+	Preimage []byte
+}
+
+// RefundData 退回的数据对象
+type RefundData struct {
+	ID types.Hash
+}
+
+// ResolveData 仲裁的数据对象，Release为true时放款给接收者，否则退回发送者
+type ResolveData struct {
+	ID      types.Hash
+	Release bool
+}
+
+// Escrow 托管记录，ID为锁定交易的哈希
+type Escrow struct {
+	ID        types.Hash   `json:"id"`
+	Sender    string       `json:"sender"`
+	Recipient string       `json:"recipient"`
+	Amount    *big.Int     `json:"amount"`
+	HashType  HashType     `json:"hash_type"`
+	HashLock  types.Hash   `json:"hash_lock"`
+	TimeLock  uint64       `json:"time_lock"`
+	Arbiter   string       `json:"arbiter,omitempty"`
+	Height    uint64       `json:"height"`
+	Status    EscrowStatus `json:"status"`
+	Preimage  []byte       `json:"preimage,omitempty"`
+}
+
+func escrowKey(id types.Hash) []byte {
+	return stateApp.SystemKey("escrow", id.Hex())
+}
+
+func escrowStore(state *statedb.StateDB) *stateApp.SystemStore {
+	return stateApp.NewSystemStore(state, stateApp.EscrowAddress)
+}
+
+// GetEscrow 获取托管记录，不存在时返回nil
+func GetEscrow(state *statedb.StateDB, id types.Hash) *Escrow {
+	var escrow Escrow
+	ok, err := escrowStore(state).Get(escrowKey(id), &escrow)
+	if !ok || err != nil {
+		return nil
+	}
+	return &escrow
+}
+
+func setEscrow(state *statedb.StateDB, escrow *Escrow) {
+	escrowStore(state).Set(escrowKey(escrow.ID), escrow)
+}
+
+// escrowAccount 持有锁定资金的系统账户
+func escrowAccount() string {
+	return stateApp.SystemAccountName(stateApp.EscrowAddress)
+}
+
+// verifyPreimage 校验原像
+func verifyPreimage(hashType HashType, hashLock types.Hash, preimage []byte) bool {
+	switch hashType {
+	case HashSha256:
+		sum := sha256.Sum256(preimage)
+		return types.BytesToHash(sum[:]) == hashLock
+	case HashKeccak256:
+		return types.BytesToHash(sha3.Keccak256(preimage)) == hashLock
+	}
+	return false
+}
+
+// payout 从托管账户支付锁定的资金，接收方不是交易的接收方，记录转账日志
+func payout(state *statedb.StateDB, to string, amount *big.Int, height uint64) {
+	state.SubBalance(escrowAccount(), amount)
+	state.AddBalance(to, amount)
+	state.AddLog(stateApp.NewTransferLog(escrowAccount(), to, amount, height))
+}
+
+// emit 记录托管事件
+func emit(state *statedb.StateDB, event types.Hash, escrow *Escrow, height uint64) {
+	data, _ := rlp.EncodeToBytes(escrow)
+	state.AddLog(&statetype.Log{
+		Address:     stateApp.EscrowAddress,
+		Topics:      []types.Hash{event, escrow.ID},
+		Data:        data,
+		BlockHeight: height,
+	})
+}
+
+func decodeOp(input []byte) (*EscrowOpData, error) {
+	var op EscrowOpData
+	if err := codec.Coder().Decode(input, &op); err != nil {
+		return nil, errInvalidEscrowData
+	}
+	return &op, nil
+}
+
+func verifyLock(state *statedb.StateDB, from, to string, id types.Hash, value *big.Int, input []byte, height uint64) error {
+	var data LockData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidEscrowData
+	}
+	data.Normalize()
+
+	if value == nil || value.Sign() <= 0 {
+		return errInvalidEscrow
+	}
+	if data.HashLock == (types.Hash{}) && data.Arbiter == "" {
+		return errInvalidEscrow
+	}
+	if data.HashType > HashKeccak256 {
+		return errInvalidEscrow
+	}
+	// 只有哈希锁时必须设置时间锁，避免资金永久锁定
+	if data.Arbiter == "" && data.TimeLock == 0 {
+		return errInvalidEscrow
+	}
+	if data.TimeLock != 0 && data.TimeLock <= height {
+		return errInvalidEscrow
+	}
+	if data.Recipient == "" || data.Recipient == from || !state.Exist(data.Recipient) {
+		return stateApp.ErrToAccountNotFound
+	}
+	// 交易的接收者必须为托管的接收者，以便会话密钥及额度按接收者校验
+	if data.Recipient != strings.ToLower(to) {
+		return errInvalidEscrow
+	}
+	if data.Arbiter != "" && (data.Arbiter == from || data.Arbiter == data.Recipient || !state.Exist(data.Arbiter)) {
+		return errInvalidEscrow
+	}
+	if GetEscrow(state, id) != nil {
+		return errEscrowExists
+	}
+	return nil
+}
+
+func lock(state *statedb.StateDB, from string, id types.Hash, value *big.Int, input []byte, height uint64) {
+	var data LockData
+	codec.Coder().Decode(input, &data)
+	data.Normalize()
+
+	escrow := &Escrow{
+		ID:        id,
+		Sender:    from,
+		Recipient: data.Recipient,
+		Amount:    new(big.Int).Set(value),
+		HashType:  data.HashType,
+		HashLock:  data.HashLock,
+		TimeLock:  data.TimeLock,
+		Arbiter:   data.Arbiter,
+		Height:    height,
+		Status:    StatusOpen,
+	}
+	setEscrow(state, escrow)
+
+	state.SubBalance(from, value)
+	state.AddBalance(escrowAccount(), value)
+
+	emit(state, EventLocked, escrow, height)
+}
+
+func openEscrow(state *statedb.StateDB, id types.Hash) (*Escrow, error) {
+	escrow := GetEscrow(state, id)
+	if escrow == nil {
+		return nil, errEscrowNonExists
+	}
+	if escrow.Status != StatusOpen {
+		return nil, errEscrowClosed
+	}
+	return escrow, nil
+}
+
+func verifyClaim(state *statedb.StateDB, from string, input []byte, height uint64) error {
+	var data ClaimData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidEscrowData
+	}
+
+	escrow, err := openEscrow(state, data.ID)
+	if err != nil {
+		return err
+	}
+	if escrow.Recipient != from {
+		return errUnauthorized
+	}
+	if escrow.HashLock == (types.Hash{}) {
+		return errNoHashLock
+	}
+	if escrow.TimeLock != 0 && height >= escrow.TimeLock {
+		return errEscrowExpired
+	}
+	if !verifyPreimage(escrow.HashType, escrow.HashLock, data.Preimage) {
+		return errInvalidPreimage
+	}
+	return nil
+}
+
+func claim(state *statedb.StateDB, input []byte, height uint64) {
+	var data ClaimData
+	codec.Coder().Decode(input, &data)
+
+	escrow := GetEscrow(state, data.ID)
+	escrow.Status = StatusClaimed
+	escrow.Preimage = data.Preimage
+	setEscrow(state, escrow)
+
+	payout(state, escrow.Recipient, escrow.Amount, height)
+
+	emit(state, EventClaimed, escrow, height)
+}
+
+func verifyRefund(state *statedb.StateDB, from string, input []byte, height uint64) error {
+	var data RefundData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidEscrowData
+	}
+
+	escrow, err := openEscrow(state, data.ID)
+	if err != nil {
+		return err
+	}
+	if escrow.Sender != from {
+		return errUnauthorized
+	}
+	if escrow.TimeLock == 0 {
+		return errNoTimeLock
+	}
+	if height < escrow.TimeLock {
+		return errEscrowNotExpired
+	}
+	return nil
+}
+
+func refund(state *statedb.StateDB, input []byte, height uint64) {
+	var data RefundData
+	codec.Coder().Decode(input, &data)
+
+	escrow := GetEscrow(state, data.ID)
+	escrow.Status = StatusRefunded
+	setEscrow(state, escrow)
+
+	payout(state, escrow.Sender, escrow.Amount, height)
+
+	emit(state, EventRefunded, escrow, height)
+}
+
+func verifyResolve(state *statedb.StateDB, from string, input []byte) error {
+	var data ResolveData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidEscrowData
+	}
+
+	escrow, err := openEscrow(state, data.ID)
+	if err != nil {
+		return err
+	}
+	if escrow.Arbiter == "" || escrow.Arbiter != from {
+		return errUnauthorized
+	}
+	return nil
+}
+
+func resolve(state *statedb.StateDB, input []byte, height uint64) {
+	var data ResolveData
+	codec.Coder().Decode(input, &data)
+
+	escrow := GetEscrow(state, data.ID)
+	to := escrow.Sender
+	escrow.Status = StatusRefunded
+	if data.Release {
+		to = escrow.Recipient
+		escrow.Status = StatusClaimed
+	}
+	setEscrow(state, escrow)
+
+	payout(state, to, escrow.Amount, height)
+
+	emit(state, EventResolved, escrow, height)
+}
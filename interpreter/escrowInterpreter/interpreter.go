@@ -0,0 +1,136 @@
+// Package escrowInterpreter
+//
+// @author: xwc1125
+package escrowInterpreter
+
+import (
+	"fmt"
+	"github.com/chain5j/chain5j-protocol/models"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
+	"github.com/chain5j/logger"
+)
+
+type Interpreter struct {
+	log logger.Logger
+}
+
+func NewInterpreter() *Interpreter {
+	return &Interpreter{
+		log: logger.New("escrow_interpreter"),
+	}
+}
+
+func (i *Interpreter) VerifyTx(ctx stateApp.InterpreterCtx, tx models.StateTransaction) error {
+	stateDB := ctx.StateDB()
+	height := ctx.Header().Height
+
+	signer, err := tx.Signer()
+	if err != nil {
+		return stateApp.ErrInvalidSigner
+	}
+	accountFrom := accountInterpreter.GetAccount(stateDB, tx.From())
+	// 账户未找到
+	if accountFrom == nil {
+		return stateApp.ErrFromAccountNotFound
+	}
+	if err := accountInterpreter.VerifyAccountStatus(stateDB, accountFrom, height); err != nil {
+		return err
+	}
+	if err := accountInterpreter.VerifySigner(stateDB, accountFrom, signer, stateApp.EscrowInterpreter, tx, height); err != nil {
+		return err
+	}
+
+	op, err := decodeOp(tx.Input())
+	if err != nil {
+		return err
+	}
+
+	from := accountFrom.AccountName()
+	if op.Operation != LockOp && tx.Value() != nil && tx.Value().Sign() != 0 {
+		return errInvalidEscrow
+	}
+
+	switch op.Operation {
+	case LockOp:
+		if err := verifyLock(stateDB, from, tx.To(), tx.Hash(), tx.Value(), op.Data, height); err != nil {
+			return err
+		}
+
+		// check balance
+		if stateDB.GetBalance(from).Cmp(tx.Cost()) < 0 {
+			return stateApp.ErrBalanceNotEnough
+		}
+		if err := accountInterpreter.VerifySpendable(stateDB, from, tx.Cost()); err != nil {
+			return err
+		}
+		if err := accountInterpreter.VerifySpend(stateDB, from, tx.To(), tx.Value(), ctx.Header().Timestamp); err != nil {
+			return err
+		}
+
+	case ClaimOp:
+		return verifyClaim(stateDB, from, op.Data, height)
+
+	case RefundOp:
+		return verifyRefund(stateDB, from, op.Data, height)
+
+	case ResolveOp:
+		return verifyResolve(stateDB, from, op.Data)
+
+	default:
+		return errInvalidEscrowOp
+	}
+
+	return nil
+}
+
+// Gas 每笔交易的gas固定为stateApp.TxGas
+func (i *Interpreter) Gas(tx models.StateTransaction) uint64 {
+	return stateApp.TxGas
+}
+
+func (i *Interpreter) ApplyTransaction(ctx stateApp.InterpreterCtx, tx models.StateTransaction, usedGas *uint64) (*statetype.Receipt, error) {
+	stateDB := ctx.StateDB()
+	height := ctx.Header().Height
+
+	if err := i.VerifyTx(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	account := tx.From()
+	currentNonce := stateDB.GetNonce(account)
+	if currentNonce != tx.Nonce() {
+		return nil, fmt.Errorf("stateDB nonce and tx nonce is diff,stateDB nonce = %d, txNonce = %d", currentNonce, tx.Nonce())
+	}
+	stateDB.SetNonce(account, currentNonce+1)
+
+	op, _ := decodeOp(tx.Input())
+	switch op.Operation {
+	case LockOp:
+		lock(stateDB, account, tx.Hash(), tx.Value(), op.Data, height)
+		accountInterpreter.RecordSpend(stateDB, account, tx.To(), tx.Value(), ctx.Header().Timestamp)
+
+	case ClaimOp:
+		claim(stateDB, op.Data, height)
+
+	case RefundOp:
+		refund(stateDB, op.Data, height)
+
+	case ResolveOp:
+		resolve(stateDB, op.Data, height)
+	}
+
+	*usedGas += i.Gas(tx)
+
+	receipt := &statetype.Receipt{
+		Status:            statetype.ReceiptStatusSuccessful,
+		CumulativeGasUsed: *usedGas,
+		TransactionHash:   tx.Hash(),
+		GasUsed:           i.Gas(tx),
+		Logs:              stateDB.GetLogs(tx.Hash()),
+	}
+	receipt.LogsBloom = statetype.CreateBloom(statetype.Receipts{receipt})
+
+	return receipt, nil
+}
@@ -0,0 +1,159 @@
+// Package escrowInterpreter
+//
+// @author: xwc1125
+package escrowInterpreter
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/internal/testutil"
+	"math/big"
+	"testing"
+)
+
+type testAccount struct {
+	name  string
+	key   *ecdsa.PrivateKey
+	nonce uint64
+}
+
+func newTestAccount(t *testing.T, state *statedb.StateDB, cn string, balance int64) *testAccount {
+	key, store := testutil.NewAccount(t, state, cn, "bank", balance)
+	return &testAccount{name: store.AccountName(), key: key}
+}
+
+// newEscrowTx 构造并签名托管交易
+func newEscrowTx(t *testing.T, from *testAccount, to string, value int64, op EscrowOp, data interface{}) *stateApp.Transaction {
+	input := testutil.Encode(t, &EscrowOpData{Operation: op, Data: testutil.Encode(t, data)})
+	tx := stateApp.NewTransaction(from.name, to, stateApp.EscrowInterpreter, from.nonce, 0, stateApp.TxGas, big.NewInt(value), input, 0, nil)
+	if _, err := tx.Sign(from.key); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func TestEscrow(t *testing.T) {
+	state := testutil.NewState(t)
+	alice := newTestAccount(t, state, "alice", 1000)
+	bob := newTestAccount(t, state, "bob", 0)
+	carol := newTestAccount(t, state, "carol", 0)
+
+	secret := []byte("secret")
+	sum := sha256.Sum256(secret)
+	hashLock := types.BytesToHash(sum[:])
+	htlc := &LockData{Recipient: bob.name, HashType: HashSha256, HashLock: hashLock, TimeLock: 20}
+	arbitrated := &LockData{Recipient: bob.name, Arbiter: carol.name}
+
+	var htlcID, arbitratedID types.Hash
+	tests := []struct {
+		name   string
+		height uint64
+		tx     func() *stateApp.Transaction
+		err    error
+	}{
+		{"lock without value", 1, func() *stateApp.Transaction { return newEscrowTx(t, alice, bob.name, 0, LockOp, htlc) }, errInvalidEscrow},
+		{"lock without timelock", 1, func() *stateApp.Transaction {
+			return newEscrowTx(t, alice, bob.name, 100, LockOp, &LockData{Recipient: bob.name, HashLock: hashLock})
+		}, errInvalidEscrow},
+		{"lock to other recipient", 1, func() *stateApp.Transaction { return newEscrowTx(t, alice, carol.name, 100, LockOp, htlc) }, errInvalidEscrow},
+		{"lock over balance", 1, func() *stateApp.Transaction { return newEscrowTx(t, alice, bob.name, 2000, LockOp, htlc) }, stateApp.ErrBalanceNotEnough},
+		{"lock htlc", 1, func() *stateApp.Transaction {
+			tx := newEscrowTx(t, alice, bob.name, 100, LockOp, htlc)
+			htlcID = tx.Hash()
+			return tx
+		}, nil},
+		{"lock arbitrated", 1, func() *stateApp.Transaction {
+			tx := newEscrowTx(t, alice, bob.name, 200, LockOp, arbitrated)
+			arbitratedID = tx.Hash()
+			return tx
+		}, nil},
+		{"claim by sender", 10, func() *stateApp.Transaction {
+			return newEscrowTx(t, alice, bob.name, 0, ClaimOp, &ClaimData{ID: htlcID, Preimage: secret})
+		}, errUnauthorized},
+		{"claim wrong preimage", 10, func() *stateApp.Transaction {
+			return newEscrowTx(t, bob, bob.name, 0, ClaimOp, &ClaimData{ID: htlcID, Preimage: []byte("wrong")})
+		}, errInvalidPreimage},
+		{"claim after timelock", 20, func() *stateApp.Transaction {
+			return newEscrowTx(t, bob, bob.name, 0, ClaimOp, &ClaimData{ID: htlcID, Preimage: secret})
+		}, errEscrowExpired},
+		{"refund before timelock", 10, func() *stateApp.Transaction {
+			return newEscrowTx(t, alice, alice.name, 0, RefundOp, &RefundData{ID: htlcID})
+		}, errEscrowNotExpired},
+		{"resolve without arbiter", 10, func() *stateApp.Transaction {
+			return newEscrowTx(t, carol, carol.name, 0, ResolveOp, &ResolveData{ID: htlcID, Release: true})
+		}, errUnauthorized},
+		{"claim", 10, func() *stateApp.Transaction {
+			return newEscrowTx(t, bob, bob.name, 0, ClaimOp, &ClaimData{ID: htlcID, Preimage: secret})
+		}, nil},
+		{"refund claimed", 20, func() *stateApp.Transaction {
+			return newEscrowTx(t, alice, alice.name, 0, RefundOp, &RefundData{ID: htlcID})
+		}, errEscrowClosed},
+		{"refund without timelock", 20, func() *stateApp.Transaction {
+			return newEscrowTx(t, alice, alice.name, 0, RefundOp, &RefundData{ID: arbitratedID})
+		}, errNoTimeLock},
+		{"claim without hashlock", 20, func() *stateApp.Transaction {
+			return newEscrowTx(t, bob, bob.name, 0, ClaimOp, &ClaimData{ID: arbitratedID})
+		}, errNoHashLock},
+		{"resolve by recipient", 20, func() *stateApp.Transaction {
+			return newEscrowTx(t, bob, bob.name, 0, ResolveOp, &ResolveData{ID: arbitratedID, Release: true})
+		}, errUnauthorized},
+		{"resolve refund", 20, func() *stateApp.Transaction {
+			return newEscrowTx(t, carol, carol.name, 0, ResolveOp, &ResolveData{ID: arbitratedID})
+		}, nil},
+		{"resolve closed", 20, func() *stateApp.Transaction {
+			return newEscrowTx(t, carol, carol.name, 0, ResolveOp, &ResolveData{ID: arbitratedID, Release: true})
+		}, errEscrowClosed},
+	}
+
+	// 释放及退回的资金以转账日志记录接收方
+	payees := map[string]string{"claim": bob.name, "resolve refund": alice.name}
+
+	interpreter := NewInterpreter()
+	senders := map[string]*testAccount{alice.name: alice, bob.name: bob, carol.name: carol}
+	for _, tt := range tests {
+		ctx := testutil.NewCtx(t, state, tt.height, nil)
+		tx := tt.tx()
+		if err := interpreter.VerifyTx(ctx, tx); err != tt.err {
+			t.Fatalf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+		if tt.err != nil {
+			continue
+		}
+		ctx.Prepare(tx.Hash(), types.Hash{}, 0)
+		var usedGas uint64
+		receipt, err := interpreter.ApplyTransaction(ctx, tx, &usedGas)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		senders[tx.From()].nonce++
+
+		var payee string
+		for _, l := range receipt.Logs {
+			if transfer, ok := stateApp.DecodeTransferLog(l); ok {
+				payee = transfer.To
+			}
+		}
+		if payee != payees[tt.name] {
+			t.Fatalf("%s: transfer to %q, want %q", tt.name, payee, payees[tt.name])
+		}
+	}
+
+	if escrow := GetEscrow(state, htlcID); escrow.Status != StatusClaimed || string(escrow.Preimage) != string(secret) {
+		t.Fatalf("htlc escrow %+v", escrow)
+	}
+	if escrow := GetEscrow(state, arbitratedID); escrow.Status != StatusRefunded {
+		t.Fatalf("arbitrated escrow %+v", escrow)
+	}
+	if balance := state.GetBalance(alice.name); balance.Int64() != 900 {
+		t.Fatalf("alice balance %v, want 900", balance)
+	}
+	if balance := state.GetBalance(bob.name); balance.Int64() != 100 {
+		t.Fatalf("bob balance %v, want 100", balance)
+	}
+	if balance := state.GetBalance(escrowAccount()); balance.Sign() != 0 {
+		t.Fatalf("escrow balance %v, want 0", balance)
+	}
+}
@@ -0,0 +1,118 @@
+// Package ethereumInterpreter
+//
+// @author: xwc1125
+package ethereumInterpreter
+
+import (
+	"errors"
+	"fmt"
+	"github.com/chain5j/chain5j-pkg/codec"
+	"github.com/chain5j/chain5j-pkg/crypto/hashalg/sha3"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"github.com/chain5j/chain5j-protocol/pkg/database/ethStatedb"
+	"github.com/chain5j/chain5j-stateApp"
+	"math/big"
+)
+
+var (
+	errUnauthorized      = errors.New("unauthorized")
+	errInvalidDeployerOp = errors.New("invalid deployer operation")
+)
+
+// DeployerOp 合约部署白名单的操作
+type DeployerOp uint8
+
+const (
+	AddDeployerOp    DeployerOp = iota // 添加允许部署合约的地址
+	RemoveDeployerOp                   // 移除允许部署合约的地址
+	SetAdminOp                         // 转移白名单管理员
+)
+
+// DeployerOpData 发往stateApp.DeployerAddress的交易input，须由白名单管理员签名
+type DeployerOpData struct {
+	Operation DeployerOp
+	Address   types.Address
+}
+
+// 白名单存储在stateApp.DeployerAddress的storage中，布局与solidity中位于槽0的mapping(address => bool)一致，槽1为管理员地址
+// 初始的白名单及管理员在创世时通过alloc写入该地址的storage，alloc中该地址的nonce须不为0，否则作为空账户被删除
+var deployerAdminSlot = types.BigToHash(big.NewInt(1))
+
+func deployerSlot(addr types.Address) types.Hash {
+	return types.BytesToHash(sha3.Keccak256(types.BytesToHash(addr.Bytes()).Bytes(), types.Hash{}.Bytes()))
+}
+
+// CanDeploy 地址是否允许部署合约
+func CanDeploy(state *ethStatedb.StateDB, addr types.Address) bool {
+	return state.GetState(stateApp.DeployerAddress, deployerSlot(addr)) != (types.Hash{})
+}
+
+// DeployerAdmin 白名单管理员，未设置时为空地址
+func DeployerAdmin(state *ethStatedb.StateDB) types.Address {
+	return types.BytesToAddress(state.GetState(stateApp.DeployerAddress, deployerAdminSlot).Bytes())
+}
+
+func decodeDeployerOp(input []byte) (*DeployerOpData, error) {
+	var data DeployerOpData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return nil, errInvalidDeployerOp
+	}
+	return &data, nil
+}
+
+func verifyDeployerOp(state *ethStatedb.StateDB, signer types.Address, tx models.StateTransaction) error {
+	admin := DeployerAdmin(state)
+	if admin == (types.Address{}) || signer != admin {
+		return errUnauthorized
+	}
+	if tx.Value() != nil && tx.Value().Sign() != 0 {
+		return errInvalidDeployerOp
+	}
+	data, err := decodeDeployerOp(tx.Input())
+	if err != nil {
+		return err
+	}
+	if data.Operation > SetAdminOp || data.Address == (types.Address{}) {
+		return errInvalidDeployerOp
+	}
+	return nil
+}
+
+// applyDeployerOp 修改白名单，与原生解析器一致，gas固定为stateApp.TxGas
+func applyDeployerOp(ctx stateApp.InterpreterCtx, tx models.StateTransaction, usedGas *uint64) (*statetype.Receipt, error) {
+	state := ctx.EthStateDB()
+	signer := types.HexToAddress(tx.From())
+
+	if nonce := state.GetNonce(signer); nonce != tx.Nonce() {
+		return nil, fmt.Errorf("stateDB nonce and tx nonce is diff,stateDB nonce = %d, txNonce = %d", nonce, tx.Nonce())
+	}
+	if err := ctx.GasPool().SubGas(stateApp.TxGas); err != nil {
+		return nil, err
+	}
+	state.SetNonce(signer, tx.Nonce()+1)
+
+	// storage不计入账户是否为空，设置nonce避免白名单账户在Finalise时作为空账户被删除
+	if state.GetNonce(stateApp.DeployerAddress) == 0 {
+		state.SetNonce(stateApp.DeployerAddress, 1)
+	}
+	data, _ := decodeDeployerOp(tx.Input())
+	switch data.Operation {
+	case AddDeployerOp:
+		state.SetState(stateApp.DeployerAddress, deployerSlot(data.Address), types.BigToHash(big.NewInt(1)))
+	case RemoveDeployerOp:
+		state.SetState(stateApp.DeployerAddress, deployerSlot(data.Address), types.Hash{})
+	case SetAdminOp:
+		state.SetState(stateApp.DeployerAddress, deployerAdminSlot, types.BytesToHash(data.Address.Bytes()))
+	}
+	state.Finalise(true)
+
+	*usedGas += stateApp.TxGas
+
+	receipt := statetype.NewReceipt(false, *usedGas)
+	receipt.TransactionHash = tx.Hash()
+	receipt.GasUsed = stateApp.TxGas
+	receipt.LogsBloom = statetype.CreateBloom(statetype.Receipts{receipt})
+	return receipt, nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/chain5j/chain5j-pkg/types"
 	"github.com/chain5j/chain5j-protocol/models"
 	"github.com/chain5j/chain5j-protocol/protocol"
+	"github.com/chain5j/chain5j-stateApp"
 	"math/big"
 )
 
@@ -22,7 +23,7 @@ func NewEVMContext(msg models.VmMessage, header *models.Header, chain protocol.C
 
 	return evm.Context{
 		CanTransfer: CanTransfer,
-		Transfer:    Transfer,
+		Transfer:    newTransfer(header.Height),
 		GetHash:     GetHashFn(header, chain),
 		Origin:      types.DomainToAddress(msg.From()),
 		Coinbase:    beneficiary,
@@ -69,3 +70,16 @@ func Transfer(db evm.StateDB, sender, recipient types.Address, amount *big.Int)
 	db.SubBalance(sender, amount)
 	db.AddBalance(recipient, amount)
 }
+
+// newTransfer 一条消息执行中的转账，首次为交易本身的转账，之后为合约内部调用的转账
+// 内部调用的接收方不是交易的接收方，自启用高度起记录转账日志
+func newTransfer(height uint64) evm.TransferFunc {
+	internal := false
+	return func(db evm.StateDB, sender, recipient types.Address, amount *big.Int) {
+		Transfer(db, sender, recipient, amount)
+		if internal && amount.Sign() > 0 && stateApp.TransferLogEnabled(height) {
+			db.AddLog(stateApp.NewTransferLog(sender.Hex(), recipient.Hex(), amount, height))
+		}
+		internal = true
+	}
+}
@@ -5,15 +5,19 @@ package ethereumInterpreter
 
 import (
 	"errors"
+	evm "github.com/chain5j/chain5j-evm"
 	"github.com/chain5j/chain5j-pkg/types"
 	"github.com/chain5j/chain5j-protocol/models"
 	"github.com/chain5j/chain5j-protocol/models/statetype"
 	"github.com/chain5j/chain5j-stateApp"
 	"github.com/chain5j/logger"
+	"math/big"
 )
 
 var (
 	errBalanceNotEnough = errors.New("balance not enough")
+	errDeployNotAllowed = errors.New("address is not allowed to deploy contract")
+	errInvalidChainId   = errors.New("invalid chain id")
 )
 
 type Interpreter struct {
@@ -37,6 +41,20 @@ func (i *Interpreter) VerifyTx(ctx stateApp.InterpreterCtx, tx models.StateTrans
 	if types.HexToAddress(tx.From()) != signer {
 		return stateApp.ErrInvalidSigner
 	}
+	// 以太坊签名的交易须采用EIP-155签名且链ID与本链一致，防止跨链重放
+	if ethTx, ok := tx.(*stateApp.Transaction); ok {
+		if chainId := ethTx.EthChainId(); chainId != nil && chainId.Cmp(new(big.Int).SetUint64(ctx.ChainConfig().ChainID)) != 0 {
+			return errInvalidChainId
+		}
+	}
+
+	// 发往白名单地址的交易为白名单管理操作，不经过evm执行
+	if tx.To() != "" && types.HexToAddress(tx.To()) == stateApp.DeployerAddress {
+		return verifyDeployerOp(stateDB, signer, tx)
+	}
+	if tx.To() == "" && !CanDeploy(stateDB, signer) {
+		return errDeployNotAllowed
+	}
 
 	// check balance
 	balance := stateDB.GetBalance(signer)
@@ -48,9 +66,22 @@ func (i *Interpreter) VerifyTx(ctx stateApp.InterpreterCtx, tx models.StateTrans
 }
 
 func (i *Interpreter) ApplyTransaction(ctx stateApp.InterpreterCtx, tx models.StateTransaction, usedGas *uint64) (*statetype.Receipt, error) {
+	return i.apply(ctx, tx, usedGas, evm.Config{DisableCreate: true})
+}
+
+// TraceTransaction 执行交易，并由tracer记录evm的执行过程
+func (i *Interpreter) TraceTransaction(ctx stateApp.InterpreterCtx, tx models.StateTransaction, usedGas *uint64, tracer evm.Tracer) (*statetype.Receipt, error) {
+	return i.apply(ctx, tx, usedGas, evm.Config{Debug: true, Tracer: tracer, DisableCreate: true})
+}
+
+func (i *Interpreter) apply(ctx stateApp.InterpreterCtx, tx models.StateTransaction, usedGas *uint64, vmConfig evm.Config) (*statetype.Receipt, error) {
+	if tx.To() != "" && types.HexToAddress(tx.To()) == stateApp.DeployerAddress {
+		return applyDeployerOp(ctx, tx, usedGas)
+	}
+
 	conf := ctx.ChainConfig()
 
 	// TODO 这里用 ctx.BlockReadWriter.CurrentBlock().Header() 并不太恰当，应该是当前处理的区块，而不是已经存储的区块。
-	receipt, _, err := applyTransaction(&conf, ctx.BlockReadWriter(), ctx.Header(), tx, ctx.EthStateDB(), ctx.GasPool(), usedGas)
+	receipt, _, err := applyTransaction(&conf, ctx.BlockReadWriter(), ctx.Header(), tx, ctx.EthStateDB(), ctx.GasPool(), usedGas, vmConfig)
 	return receipt, err
 }
@@ -0,0 +1,213 @@
+// Package ethereumInterpreter
+//
+// @author: xwc1125
+package ethereumInterpreter
+
+import (
+	"crypto/ecdsa"
+	"github.com/chain5j/chain5j-pkg/codec/rlp"
+	"github.com/chain5j/chain5j-pkg/crypto/hashalg"
+	"github.com/chain5j/chain5j-pkg/crypto/signature/secp256k1"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-pkg/util/hexutil"
+	"github.com/chain5j/chain5j-protocol/models"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"github.com/chain5j/chain5j-protocol/pkg/database/ethStatedb"
+	"github.com/chain5j/chain5j-protocol/protocol"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/internal/testutil"
+	"math/big"
+	"testing"
+)
+
+const testChainId = 1337
+
+// testConfig 仅提供链配置
+type testConfig struct {
+	protocol.Config
+}
+
+func (c *testConfig) ChainConfig() models.ChainConfig {
+	return models.ChainConfig{ChainID: testChainId}
+}
+
+func newTestKey(t *testing.T, state *ethStatedb.StateDB) (*ecdsa.PrivateKey, types.Address) {
+	key, addr := testutil.NewKey(t)
+	state.AddBalance(addr, big.NewInt(1e18))
+	return key, addr
+}
+
+// signEthTx 按EIP-155签名的以太坊交易，chainId为0时为EIP-155之前的签名
+func signEthTx(t *testing.T, key *ecdsa.PrivateKey, chainId int64, nonce uint64, to *types.Address, data []byte) *stateApp.Transaction {
+	var toBytes []byte
+	if to != nil {
+		toBytes = to.Bytes()
+	}
+	fields := []interface{}{nonce, new(big.Int), uint64(1000000), toBytes, new(big.Int), data}
+	if chainId != 0 {
+		fields = append(fields, big.NewInt(chainId), uint(0), uint(0))
+	}
+	hash, err := hashalg.RlpHash(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := secp256k1.Sign(key, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := big.NewInt(int64(sig[64]) + 27)
+	if chainId != 0 {
+		v = big.NewInt(chainId*2 + 35 + int64(sig[64]))
+	}
+	raw, err := rlp.EncodeToBytes(append(fields[:6], v, new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64])))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := stateApp.DecodeEthTransaction(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func TestDeployerRegistry(t *testing.T) {
+	state := testutil.NewEthState(t)
+	adminKey, admin := newTestKey(t, state)
+	deployerKey, deployer := newTestKey(t, state)
+	_, other := newTestKey(t, state)
+
+	// 创世时写入的管理员
+	state.SetNonce(stateApp.DeployerAddress, 1)
+	state.SetState(stateApp.DeployerAddress, deployerAdminSlot, types.BytesToHash(admin.Bytes()))
+
+	registry := stateApp.DeployerAddress
+	op := func(operation DeployerOp, addr types.Address) []byte {
+		return testutil.Encode(t, &DeployerOpData{Operation: operation, Address: addr})
+	}
+	var nonces = map[types.Address]uint64{}
+	tests := []struct {
+		name string
+		key  *ecdsa.PrivateKey
+		from types.Address
+		to   *types.Address
+		data []byte
+		err  error
+	}{
+		{"deploy not allowed", deployerKey, deployer, nil, []byte{0x00}, errDeployNotAllowed},
+		{"add by non admin", deployerKey, deployer, &registry, op(AddDeployerOp, deployer), errUnauthorized},
+		{"invalid op", adminKey, admin, &registry, op(SetAdminOp+1, deployer), errInvalidDeployerOp},
+		{"empty address", adminKey, admin, &registry, op(AddDeployerOp, types.Address{}), errInvalidDeployerOp},
+		{"add", adminKey, admin, &registry, op(AddDeployerOp, deployer), nil},
+		{"deploy allowed", deployerKey, deployer, nil, []byte{0x00}, nil},
+		{"remove", adminKey, admin, &registry, op(RemoveDeployerOp, deployer), nil},
+		{"deploy removed", deployerKey, deployer, nil, []byte{0x00}, errDeployNotAllowed},
+		{"transfer admin", adminKey, admin, &registry, op(SetAdminOp, other), nil},
+		{"add by former admin", adminKey, admin, &registry, op(AddDeployerOp, deployer), errUnauthorized},
+	}
+	interpreter := NewInterpreter()
+	ctx := testutil.NewEthCtx(t, state, 1, &testConfig{})
+	for _, tt := range tests {
+		tx := signEthTx(t, tt.key, testChainId, nonces[tt.from], tt.to, tt.data)
+		if err := interpreter.VerifyTx(ctx, tx); err != tt.err {
+			t.Fatalf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+		if tt.err != nil || tt.to == nil {
+			continue
+		}
+		var usedGas uint64
+		receipt, err := interpreter.ApplyTransaction(ctx, tx, &usedGas)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if receipt.GasUsed != stateApp.TxGas || usedGas != stateApp.TxGas {
+			t.Fatalf("%s: gas used %d", tt.name, receipt.GasUsed)
+		}
+		nonces[tt.from]++
+	}
+
+	if DeployerAdmin(state) != other {
+		t.Fatalf("admin %s, want %s", DeployerAdmin(state).Hex(), other.Hex())
+	}
+	if state.GetNonce(admin) != 3 {
+		t.Fatalf("admin nonce %d, want 3", state.GetNonce(admin))
+	}
+}
+
+func TestVerifyTx_ChainId(t *testing.T) {
+	state := testutil.NewEthState(t)
+	key, _ := newTestKey(t, state)
+	to := types.HexToAddress("0x3535353535353535353535353535353535353535")
+	tests := []struct {
+		name    string
+		chainId int64
+		err     error
+	}{
+		{"same chain", testChainId, nil},
+		{"pre eip155", 0, errInvalidChainId},
+		{"other chain", 1, errInvalidChainId},
+	}
+	interpreter := NewInterpreter()
+	ctx := testutil.NewEthCtx(t, state, 1, &testConfig{})
+	for _, tt := range tests {
+		tx := signEthTx(t, key, tt.chainId, 0, &to, nil)
+		if err := interpreter.VerifyTx(ctx, tx); err != tt.err {
+			t.Fatalf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+// factoryCode 部署后每次调用以CREATE创建一个空合约，并将其地址存入槽0
+var factoryCode = hexutil.MustDecode("0x6a600060006000f060005500600052600b6015f3")
+
+// 工厂合约在执行中创建合约时，交易发送方同样需要在白名单中
+func TestApplyTransaction_FactoryCreate(t *testing.T) {
+	state := testutil.NewEthState(t)
+	deployerKey, deployer := newTestKey(t, state)
+	otherKey, other := newTestKey(t, state)
+	state.SetNonce(stateApp.DeployerAddress, 1)
+	state.SetState(stateApp.DeployerAddress, deployerSlot(deployer), types.BytesToHash([]byte{1}))
+
+	interpreter := NewInterpreter()
+	ctx := testutil.NewEthCtx(t, state, 1, &testConfig{})
+	apply := func(key *ecdsa.PrivateKey, nonce uint64, to *types.Address, data []byte) *statetype.Receipt {
+		tx := signEthTx(t, key, testChainId, nonce, to, data)
+		if err := interpreter.VerifyTx(ctx, tx); err != nil {
+			t.Fatal(err)
+		}
+		var usedGas uint64
+		receipt, err := interpreter.ApplyTransaction(ctx, tx, &usedGas)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return receipt
+	}
+
+	receipt := apply(deployerKey, 0, nil, factoryCode)
+	if receipt.Status != statetype.ReceiptStatusSuccessful {
+		t.Fatal("deploy failed")
+	}
+	factory := receipt.ContractAddress
+
+	tests := []struct {
+		name    string
+		key     *ecdsa.PrivateKey
+		from    types.Address
+		success bool
+	}{
+		{"not allowed", otherKey, other, false},
+		{"allowed", deployerKey, deployer, true},
+	}
+	for _, tt := range tests {
+		nonce := state.GetNonce(tt.from)
+		receipt := apply(tt.key, nonce, &factory, nil)
+		if (receipt.Status == statetype.ReceiptStatusSuccessful) != tt.success {
+			t.Fatalf("%s: status %d", tt.name, receipt.Status)
+		}
+		if created := state.GetState(factory, types.Hash{}) != (types.Hash{}); created != tt.success {
+			t.Fatalf("%s: created %v", tt.name, created)
+		}
+		if state.GetNonce(tt.from) != nonce+1 {
+			t.Fatalf("%s: nonce %d", tt.name, state.GetNonce(tt.from))
+		}
+	}
+}
@@ -6,30 +6,50 @@ package ethereumInterpreter
 import (
 	"encoding/json"
 	evm "github.com/chain5j/chain5j-evm"
+	"github.com/chain5j/chain5j-pkg/types"
 	"github.com/chain5j/chain5j-protocol/models"
 	"github.com/chain5j/chain5j-protocol/models/statetype"
 	"github.com/chain5j/chain5j-protocol/models/vm"
 	"github.com/chain5j/chain5j-protocol/pkg/crypto"
 	"github.com/chain5j/chain5j-protocol/pkg/database/ethStatedb"
 	"github.com/chain5j/chain5j-protocol/protocol"
+	"github.com/chain5j/chain5j-stateApp/tracers"
 	"github.com/chain5j/logger"
+	"math/big"
 )
 
-func applyTransaction(config *models.ChainConfig, bc protocol.ChainContext, header *models.Header, tx models.StateTransaction, sdb *ethStatedb.StateDB, gp *vm.GasPool, usedGas *uint64) (*statetype.Receipt, uint64, error) {
+func applyTransaction(config *models.ChainConfig, bc protocol.ChainContext, header *models.Header, tx models.StateTransaction, sdb *ethStatedb.StateDB, gp *vm.GasPool, usedGas *uint64, vmConfig evm.Config) (*statetype.Receipt, uint64, error) {
 	msg, err := models.TxAsVmMessage(tx)
 	if err != nil {
 		return nil, 0, err
 	}
+	if vmConfig.Debug {
+		var to *types.Address
+		if msg.To() != "" {
+			addr := types.HexToAddress(msg.To())
+			to = &addr
+		}
+		tracers.CaptureTxStart(vmConfig.Tracer, sdb, types.HexToAddress(msg.From()), to)
+	}
 
 	context := NewEVMContext(msg, header, bc, nil)
 	// Create a new environment which holds all relevant information
 	// about the transaction and calling mechanisms.
-	vmenv := evm.NewEVM(context, sdb, config, evm.Config{DisableCreate: true})
+	// 部署权限须在evm的创建中检查，工厂合约创建的合约同样需要交易发送方在白名单中
+	from := types.HexToAddress(msg.From())
+	db := &createGuard{StateDB: sdb, deny: !CanDeploy(sdb, from)}
+	vmenv := evm.NewEVM(context, db, config, vmConfig)
+	snapshot, nonce := sdb.Snapshot(), sdb.GetNonce(from)
 	_, gas, failed, err := ApplyMessage(vmenv, msg, gp)
 
 	if err != nil {
 		return nil, 0, err
 	}
+	if db.denied {
+		logger.Error("deploy contract not allowed", "from", msg.From(), "err", errDeployNotAllowed)
+		revertDeniedCreate(sdb, vmenv, snapshot, from, nonce, gas, msg.GasPrice())
+		failed = true
+	}
 
 	// Update the state with pending changes
 	sdb.Finalise(true)
@@ -55,3 +75,27 @@ func applyTransaction(config *models.ChainConfig, bc protocol.ChainContext, head
 
 	return receipt, gas, err
 }
+
+// createGuard evm使用的状态，交易发送方不允许部署合约时记录执行中的合约创建(包括工厂合约的CREATE及CREATE2)
+type createGuard struct {
+	*ethStatedb.StateDB
+	deny   bool
+	denied bool
+}
+
+// CreateAccount evm只在创建合约时创建账户，不允许部署时记录，由执行交易后整体撤销
+func (db *createGuard) CreateAccount(addr types.Address) {
+	if db.deny {
+		db.denied = true
+	}
+	db.StateDB.CreateAccount(addr)
+}
+
+// revertDeniedCreate 撤销交易的执行结果，与执行失败的交易一致，递增nonce并收取已使用的gas
+func revertDeniedCreate(db evm.StateDB, vmenv *evm.EVM, snapshot int, from types.Address, nonce, gas uint64, gasPrice *big.Int) {
+	db.RevertToSnapshot(snapshot)
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(gas), gasPrice)
+	db.SubBalance(from, fee)
+	db.AddBalance(vmenv.Coinbase(), fee)
+	db.SetNonce(from, nonce+1)
+}
@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Apache-2.0
+// chain5j账户体系的预编译合约接口，仅在chain5j.evm解析器下可用，均为只读查询
+// 预编译合约没有合约代码，需使用solidity >=0.8.10，以免调用前的extcodesize检查失败
+pragma solidity ^0.8.10;
+
+// 能力位，与accountInterpreter.Capability一致
+library Chain5jCapabilities {
+    uint256 internal constant FREEZE_USER = 1 << 0;
+    uint256 internal constant REGISTER_USER = 1 << 1;
+    uint256 internal constant UPDATE_USER = 1 << 2;
+    uint256 internal constant REGISTER_SUBDOMAIN = 1 << 3;
+    uint256 internal constant REGISTER_DOMAIN = 1 << 4;
+    uint256 internal constant MANAGE_PARTNER = 1 << 5;
+    uint256 internal constant DEPLOY_CONTRACT = 1 << 6;
+    uint256 internal constant MINT_TOKEN = 1 << 7;
+    uint256 internal constant DATA_NAMESPACE = 1 << 8;
+    uint256 internal constant MANAGE_ROLES = 1 << 9;
+}
+
+// 账户查询，地址0x0000000000000000000000000000000000000c01
+interface IChain5jAccounts {
+    // 账户名称(如alice@bank)解析为地址，合约账户为合约地址，其他账户为主地址；不存在时返回0地址
+    function resolve(string calldata name) external view returns (address);
+
+    // 地址所属的账户名称，不存在时返回空字符串
+    function nameOf(address addr) external view returns (string memory);
+
+    // 账户是否存在
+    function exists(string calldata name) external view returns (bool);
+
+    // 地址所属账户本身或其所在域是否被冻结
+    function isFrozen(address addr) external view returns (bool);
+
+    // 地址所属账户是否为管理员及其能力位，见Chain5jCapabilities
+    function permissionsOf(address addr) external view returns (bool isAdmin, uint256 capabilities);
+
+    // 地址所属账户是否可以写入指定的数据命名空间，所在域的管理员可以写入全部命名空间
+    function hasNamespace(address addr, string calldata namespace) external view returns (bool);
+}
+
+// 域查询，地址0x0000000000000000000000000000000000000c02
+interface IChain5jDomains {
+    // 地址所属账户所在的域，不存在时返回空字符串
+    function domainOf(address addr) external view returns (string memory);
+
+    // 地址所属账户是否属于指定的域或其子域
+    function isMember(address addr, string calldata domain) external view returns (bool);
+
+    // 域是否存在且未过期
+    function domainExists(string calldata domain) external view returns (bool);
+
+    // 域或其任一上级域是否被冻结
+    function isDomainFrozen(string calldata domain) external view returns (bool);
+}
+
+library Chain5j {
+    IChain5jAccounts internal constant ACCOUNTS = IChain5jAccounts(0x0000000000000000000000000000000000000C01);
+    IChain5jDomains internal constant DOMAINS = IChain5jDomains(0x0000000000000000000000000000000000000c02);
+}
@@ -7,7 +7,9 @@ import (
 	evm "github.com/chain5j/chain5j-evm"
 	"github.com/chain5j/chain5j-pkg/types"
 	"github.com/chain5j/chain5j-protocol/models"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
 	"github.com/chain5j/chain5j-protocol/protocol"
+	"github.com/chain5j/chain5j-stateApp"
 	"math/big"
 )
 
@@ -22,7 +24,7 @@ func NewEVMContext(msg models.VmMessage, header *models.Header, chain protocol.C
 
 	return evm.Context{
 		CanTransfer: CanTransfer,
-		Transfer:    Transfer,
+		Transfer:    newTransfer(header.Height),
 		GetHash:     GetHashFn(header, chain),
 		Origin:      types.DomainToAddress(msg.From()),
 		Coinbase:    beneficiary,
@@ -66,7 +68,79 @@ func CanTransfer(db evm.StateDB, addr types.Address, amount *big.Int) bool {
 }
 
 // Transfer subtracts amount from sender and adds amount to recipient using the given Db
+// 预编译合约没有对应的账户，不接收转账
 func Transfer(db evm.StateDB, sender, recipient types.Address, amount *big.Int) {
+	if sdb, ok := db.(*StateDB); ok && sdb.IsPrecompile(recipient) {
+		return
+	}
 	db.SubBalance(sender, amount)
 	db.AddBalance(recipient, amount)
 }
+
+// newTransfer 一条消息执行中的转账，首次为交易本身的转账，之后为合约内部调用的转账
+// 内部调用的接收方不是交易的接收方，自启用高度起记录转账日志
+func newTransfer(height uint64) evm.TransferFunc {
+	internal := false
+	return func(db evm.StateDB, sender, recipient types.Address, amount *big.Int) {
+		Transfer(db, sender, recipient, amount)
+		if sdb, ok := db.(*StateDB); ok && sdb.IsPrecompile(recipient) {
+			return
+		}
+		if internal && amount.Sign() > 0 && stateApp.TransferLogEnabled(height) {
+			db.AddLog(stateApp.NewTransferLog(sender.Hex(), recipient.Hex(), amount, height))
+		}
+		internal = true
+	}
+}
+
+// StateDB evm使用的状态
+// 预编译合约没有对应的账户，视为已存在，避免被当作新地址调用
+type StateDB struct {
+	*statedb.EVMStateDB
+	state       *statedb.StateDB
+	height      uint64
+	precompiles *PrecompileConfig // 当前高度启用的账户体系预编译合约，未启用时为nil
+
+	denyCreate   bool // 交易发送方不允许部署合约
+	createDenied bool // 执行中创建了合约
+}
+
+// NewStateDB 创建height高度执行的evm所使用的状态
+func NewStateDB(sdb *statedb.StateDB, height uint64) *StateDB {
+	db := &StateDB{
+		EVMStateDB: statedb.NewEvmStateDB(sdb),
+		state:      sdb,
+		height:     height,
+	}
+	if precompileConfig != nil && height >= precompileConfig.Height {
+		db.precompiles = precompileConfig
+	}
+	return db
+}
+
+// IsPrecompile 地址是否为当前高度启用的预编译合约
+func (db *StateDB) IsPrecompile(addr types.Address) bool {
+	return db.precompiles != nil && isPrecompile(addr)
+}
+
+func (db *StateDB) Exist(addr types.Address) bool {
+	return db.IsPrecompile(addr) || db.EVMStateDB.Exist(addr)
+}
+
+// DenyCreate 交易发送方不允许部署合约时调用，之后执行中的合约创建(包括工厂合约的CREATE及CREATE2)均被记录
+func (db *StateDB) DenyCreate() {
+	db.denyCreate = true
+}
+
+// CreateDenied 执行中是否创建了不允许部署的合约，为true时交易须整体失败
+func (db *StateDB) CreateDenied() bool {
+	return db.createDenied
+}
+
+// CreateAccount evm只在创建合约时创建账户，不允许部署时记录，由执行交易后整体撤销
+func (db *StateDB) CreateAccount(addr types.Address) {
+	if db.denyCreate {
+		db.createDenied = true
+	}
+	db.EVMStateDB.CreateAccount(addr)
+}
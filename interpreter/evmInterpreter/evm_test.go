@@ -0,0 +1,68 @@
+// Package evmInterpreter
+//
+// @author: xwc1125
+package evmInterpreter
+
+import (
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/internal/testutil"
+	"math/big"
+	"testing"
+)
+
+func TestTransfer_Log(t *testing.T) {
+	RegisterPrecompiles(testPrecompiles)
+	stateApp.SetTransferLogHeight(testPrecompileHeight + 1)
+	defer stateApp.SetTransferLogHeight(0)
+
+	var (
+		contract = types.HexToAddress("0x00000000000000000000000000000000000000d1")
+		caller   = types.HexToAddress("0x00000000000000000000000000000000000000d2")
+		payee    = types.HexToAddress("0x00000000000000000000000000000000000000d3")
+	)
+	tests := []struct {
+		name      string
+		height    uint64
+		transfers []types.Address // 依次转账1的接收方，首次为交易本身的转账
+		logged    []types.Address
+	}{
+		{"top level only", testPrecompileHeight + 1, []types.Address{contract}, nil},
+		{"internal", testPrecompileHeight + 1, []types.Address{contract, payee, caller}, []types.Address{payee, caller}},
+		{"precompile", testPrecompileHeight + 1, []types.Address{contract, AccountsPrecompileAddress}, nil},
+		{"before activation", testPrecompileHeight, []types.Address{contract, payee}, nil},
+	}
+	for _, tt := range tests {
+		state := testutil.NewState(t)
+		db := NewStateDB(state, tt.height)
+		for _, addr := range []types.Address{contract, caller, payee} {
+			db.CreateAccount(addr)
+		}
+		db.AddBalance(caller, big.NewInt(10))
+		db.AddBalance(contract, big.NewInt(10))
+
+		transfer := newTransfer(tt.height)
+		sender := caller
+		for _, to := range tt.transfers {
+			transfer(db, sender, to, big.NewInt(1))
+			sender = contract
+		}
+		// 0值的内部调用不记录
+		transfer(db, contract, payee, new(big.Int))
+
+		var logged []types.Address
+		for _, l := range state.GetLogs(types.Hash{}) {
+			if tl, ok := stateApp.DecodeTransferLog(l); ok && tl.Amount.Int64() == 1 {
+				logged = append(logged, types.HexToAddress(tl.To))
+			}
+		}
+		if len(logged) != len(tt.logged) {
+			t.Fatalf("%s: logged %v, want %v", tt.name, logged, tt.logged)
+		}
+		for i := range logged {
+			if logged[i] != tt.logged[i] {
+				t.Fatalf("%s: logged %v, want %v", tt.name, logged, tt.logged)
+			}
+		}
+	}
+}
@@ -5,14 +5,17 @@ package evmInterpreter
 
 import (
 	"errors"
+	evm "github.com/chain5j/chain5j-evm"
 	"github.com/chain5j/chain5j-protocol/models"
 	"github.com/chain5j/chain5j-protocol/models/statetype"
 	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
 	"github.com/chain5j/logger"
 )
 
 var (
-	errInvalidContract = errors.New("invalid contract")
+	errInvalidContract  = errors.New("invalid contract")
+	errDeployNotAllowed = errors.New("account is not allowed to deploy contract")
 )
 
 type Interpreter struct {
@@ -34,20 +37,20 @@ func (i *Interpreter) VerifyTx(ctx stateApp.InterpreterCtx, tx models.StateTrans
 		return err
 	}
 
-	accountFrom := stateDB.GetAccount(tx.From())
+	accountFrom := accountInterpreter.GetAccount(stateDB, tx.From())
 	// 账户未找到
 	if accountFrom == nil {
 		i.log.Error("[VerifyTx] stateDB.GetAccount err", "from", tx.From(), "err", stateApp.ErrFromAccountNotFound)
 		return stateApp.ErrFromAccountNotFound
 	}
-	if accountFrom.IsFrozen {
-		i.log.Error("[VerifyTx] accountFrom err", "from", tx.From(), "err", stateApp.ErrFrozenAccount)
-		return stateApp.ErrFrozenAccount
+	if err := accountInterpreter.VerifyAccountStatus(stateDB, accountFrom, ctx.Header().Height); err != nil {
+		i.log.Error("[VerifyTx] accountFrom err", "from", tx.From(), "err", err)
+		return err
 	}
 
-	if !accountFrom.ContainAddress(signer) {
-		i.log.Error("[VerifyTx] accountFrom is not contain signer", "from", tx.From(), "err", stateApp.ErrInvalidSigner)
-		return stateApp.ErrInvalidSigner
+	if err := accountInterpreter.VerifySigner(stateDB, accountFrom, signer, stateApp.EvmInterpreter, tx, ctx.Header().Height); err != nil {
+		i.log.Error("[VerifyTx] accountFrom signer err", "from", tx.From(), "signer", signer, "err", err)
+		return err
 	}
 
 	if tx.To() != "" {
@@ -57,18 +60,44 @@ func (i *Interpreter) VerifyTx(ctx stateApp.InterpreterCtx, tx models.StateTrans
 			i.log.Error("[VerifyTx] to address is not exist", "to", tx.To(), "err", stateApp.ErrToAccountNotFound)
 			return stateApp.ErrToAccountNotFound
 		}
+	} else if !accountInterpreter.HasCapability(stateDB, accountFrom, accountInterpreter.CapDeployContract) {
+		// 部署合约，需账户允许部署合约
+		i.log.Error("[VerifyTx] deploy contract not allowed", "from", tx.From(), "err", errDeployNotAllowed)
+		return errDeployNotAllowed
+	}
+
+	if err := accountInterpreter.VerifySpendable(stateDB, tx.From(), tx.Cost()); err != nil {
+		i.log.Error("[VerifyTx] spendable balance err", "from", tx.From(), "cost", tx.Cost(), "err", err)
+		return err
+	}
+	if err := accountInterpreter.VerifySpend(stateDB, tx.From(), tx.To(), tx.Value(), ctx.Header().Timestamp); err != nil {
+		i.log.Error("[VerifyTx] spending limit err", "from", tx.From(), "value", tx.Value(), "err", err)
+		return err
 	}
 
 	return nil
 }
 
 func (i *Interpreter) ApplyTransaction(ctx stateApp.InterpreterCtx, tx models.StateTransaction, usedGas *uint64) (*statetype.Receipt, error) {
+	return i.apply(ctx, tx, usedGas, evm.Config{DisableCreate: true})
+}
+
+// TraceTransaction 执行交易，并由tracer记录evm的执行过程
+func (i *Interpreter) TraceTransaction(ctx stateApp.InterpreterCtx, tx models.StateTransaction, usedGas *uint64, tracer evm.Tracer) (*statetype.Receipt, error) {
+	return i.apply(ctx, tx, usedGas, evm.Config{Debug: true, Tracer: tracer, DisableCreate: true})
+}
+
+func (i *Interpreter) apply(ctx stateApp.InterpreterCtx, tx models.StateTransaction, usedGas *uint64, vmConfig evm.Config) (*statetype.Receipt, error) {
 	conf := ctx.ChainConfig()
 
 	// TODO 这里用 ctx.BlockReadWriter.CurrentBlock().Header() 并不太恰当，应该是当前处理的区块，而不是已经存储的区块。
-	receipt, _, err := i.applyTransaction(&conf, ctx.BlockReadWriter(), ctx.Header(), tx, ctx.StateDB(), ctx.GasPool(), usedGas)
+	receipt, _, err := i.applyTransaction(&conf, ctx.BlockReadWriter(), ctx.Header(), tx, ctx.StateDB(), ctx.GasPool(), usedGas, vmConfig)
 	if err != nil {
 		i.log.Error("[ApplyTransaction] applyTransaction err", "err", err)
+		return receipt, err
+	}
+	if receipt.Status == statetype.ReceiptStatusSuccessful {
+		accountInterpreter.RecordSpend(ctx.StateDB(), tx.From(), tx.To(), tx.Value(), ctx.Header().Timestamp)
 	}
 	return receipt, err
 }
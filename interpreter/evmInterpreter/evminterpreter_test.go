@@ -0,0 +1,137 @@
+// Package evmInterpreter
+//
+// @author: xwc1125
+package evmInterpreter
+
+import (
+	"crypto/ecdsa"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-pkg/util/hexutil"
+	"github.com/chain5j/chain5j-protocol/models"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-protocol/protocol"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/internal/testutil"
+	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
+	"math/big"
+	"testing"
+)
+
+func newTestAccount(t *testing.T, state *statedb.StateDB, cn string, deploy bool) *ecdsa.PrivateKey {
+	key, _ := testutil.NewAccount(t, state, cn, "bank", 1000, func(store *accounts.AccountStore) {
+		store.EnableDeployContract = deploy
+	})
+	return key
+}
+
+func TestVerifyTx_Deploy(t *testing.T) {
+	state := testutil.NewState(t)
+	plainKey := newTestAccount(t, state, "plain", false)
+	enabledKey := newTestAccount(t, state, "enabled", true)
+	deployerKey := newTestAccount(t, state, "deployer", false)
+	accountInterpreter.DefineRole(state, testutil.Encode(t, &accountInterpreter.DefineRoleData{
+		Domain: "bank", Name: "developer", Capabilities: accountInterpreter.CapDeployContract,
+	}))
+	accountInterpreter.AssignRole(state, testutil.Encode(t, &accountInterpreter.AssignRoleData{CN: "deployer", Domain: "bank", Roles: []string{"developer"}}))
+
+	tests := []struct {
+		name string
+		key  *ecdsa.PrivateKey
+		from string
+		err  error
+	}{
+		{"without capability", plainKey, "plain@bank", errDeployNotAllowed},
+		{"account enabled", enabledKey, "enabled@bank", nil},
+		{"role capability", deployerKey, "deployer@bank", nil},
+	}
+	interpreter := NewInterpreter()
+	ctx := testutil.NewCtx(t, state, 1, nil)
+	for _, tt := range tests {
+		tx := stateApp.NewTransaction(tt.from, "", stateApp.EvmInterpreter, 0, 0, 1000000, big.NewInt(0), []byte{0x00}, 0, nil)
+		if _, err := tx.Sign(tt.key); err != nil {
+			t.Fatal(err)
+		}
+		if err := interpreter.VerifyTx(ctx, tx); err != tt.err {
+			t.Fatalf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+// testConfig 仅提供链配置
+type testConfig struct {
+	protocol.Config
+}
+
+func (c *testConfig) ChainConfig() models.ChainConfig {
+	return models.ChainConfig{ChainID: 1}
+}
+
+// factoryCode 部署后每次调用以CREATE创建一个空合约，并将其地址存入槽0
+var factoryCode = hexutil.MustDecode("0x6a600060006000f060005500600052600b6015f3")
+
+// 工厂合约在执行中创建合约时，交易发送方同样需要部署合约的能力
+func TestApplyTransaction_FactoryCreate(t *testing.T) {
+	state := testutil.NewState(t)
+	ownerKey := newTestAccount(t, state, "owner", true)
+	plainKey := newTestAccount(t, state, "plain", false)
+	for _, account := range []string{"owner@bank", "plain@bank"} {
+		state.AddBalance(account, big.NewInt(1e7))
+	}
+	ctx := testutil.NewCtx(t, state, 1, &testConfig{})
+	interpreter := NewInterpreter()
+	apply := func(key *ecdsa.PrivateKey, from, to string, nonce uint64, input []byte) *statetype.Receipt {
+		tx := stateApp.NewTransaction(from, to, stateApp.EvmInterpreter, nonce, 1, 1000000, big.NewInt(0), input, 0, nil)
+		if _, err := tx.Sign(key); err != nil {
+			t.Fatal(err)
+		}
+		ctx.Prepare(tx.Hash(), types.Hash{}, 0)
+		var usedGas uint64
+		receipt, err := interpreter.ApplyTransaction(ctx, tx, &usedGas)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return receipt
+	}
+
+	receipt := apply(ownerKey, "owner@bank", "", 0, factoryCode)
+	if receipt.Status != statetype.ReceiptStatusSuccessful {
+		t.Fatal("deploy failed")
+	}
+	factory := state.GetOwner(receipt.ContractAddress)
+	if factory == "" {
+		t.Fatal("factory not registered")
+	}
+
+	tests := []struct {
+		name    string
+		key     *ecdsa.PrivateKey
+		from    string
+		success bool
+	}{
+		{"without capability", plainKey, "plain@bank", false},
+		{"with capability", ownerKey, "owner@bank", true},
+	}
+	for _, tt := range tests {
+		// 按合约账户名称调用工厂合约
+		nonce := state.GetNonce(tt.from)
+		balance := new(big.Int).Set(state.GetBalance(tt.from))
+		receipt := apply(tt.key, tt.from, factory, nonce, nil)
+		if (receipt.Status == statetype.ReceiptStatusSuccessful) != tt.success {
+			t.Fatalf("%s: status %d", tt.name, receipt.Status)
+		}
+		created := state.GetState(factory, types.Hash{}) != (types.Hash{})
+		if created != tt.success {
+			t.Fatalf("%s: created %v", tt.name, created)
+		}
+		// 失败的交易同样递增nonce并收取gas费用
+		if state.GetNonce(tt.from) != nonce+1 {
+			t.Fatalf("%s: nonce %d", tt.name, state.GetNonce(tt.from))
+		}
+		fee := new(big.Int).Sub(balance, state.GetBalance(tt.from))
+		if fee.Uint64() != receipt.GasUsed || fee.Sign() == 0 {
+			t.Fatalf("%s: fee %v, gas used %d", tt.name, fee, receipt.GasUsed)
+		}
+	}
+}
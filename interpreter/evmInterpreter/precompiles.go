@@ -0,0 +1,289 @@
+// Package evmInterpreter
+//
+// @author: xwc1125
+package evmInterpreter
+
+import (
+	"bytes"
+	"errors"
+	evm "github.com/chain5j/chain5j-evm"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-protocol/pkg/abi"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-protocol/protocol"
+	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
+	"math/big"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	errPrecompileInput   = errors.New("invalid precompile input")
+	errPrecompileUnbound = errors.New("precompile called without a bound state")
+)
+
+// 账户体系的预编译合约地址，接口定义见contracts/IChain5j.sol
+var (
+	AccountsPrecompileAddress = types.HexToAddress("0x0000000000000000000000000000000000000c01")
+	DomainsPrecompileAddress  = types.HexToAddress("0x0000000000000000000000000000000000000c02")
+)
+
+// PrecompileGas 预编译合约的gas，属于共识参数，各节点须一致
+type PrecompileGas struct {
+	Base    uint64 // 每次调用的基础费用
+	Read    uint64 // 每次状态读取
+	PerWord uint64 // 每32字节输入
+}
+
+// DefaultPrecompileGas 默认的gas，状态读取参照SLOAD
+var DefaultPrecompileGas = PrecompileGas{Base: 200, Read: 800, PerWord: 3}
+
+// PrecompileConfig 账户体系预编译合约的配置
+type PrecompileConfig struct {
+	Height uint64 // 启用高度，之前的高度该地址与空账户一致
+	Gas    PrecompileGas
+}
+
+const accountsABI = `[
+	{"type":"function","name":"resolve","constant":true,"inputs":[{"name":"name","type":"string"}],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"nameOf","constant":true,"inputs":[{"name":"addr","type":"address"}],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"exists","constant":true,"inputs":[{"name":"name","type":"string"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"isFrozen","constant":true,"inputs":[{"name":"addr","type":"address"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"permissionsOf","constant":true,"inputs":[{"name":"addr","type":"address"}],"outputs":[{"name":"isAdmin","type":"bool"},{"name":"capabilities","type":"uint256"}]},
+	{"type":"function","name":"hasNamespace","constant":true,"inputs":[{"name":"addr","type":"address"},{"name":"namespace","type":"string"}],"outputs":[{"name":"","type":"bool"}]}
+]`
+
+const domainsABI = `[
+	{"type":"function","name":"domainOf","constant":true,"inputs":[{"name":"addr","type":"address"}],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"isMember","constant":true,"inputs":[{"name":"addr","type":"address"},{"name":"domain","type":"string"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"domainExists","constant":true,"inputs":[{"name":"domain","type":"string"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"isDomainFrozen","constant":true,"inputs":[{"name":"domain","type":"string"}],"outputs":[{"name":"","type":"bool"}]}
+]`
+
+var (
+	registerOnce     sync.Once
+	precompileConfig *PrecompileConfig // 已注册的配置，未注册时为nil
+	precompileStates sync.Map          // goroutine id -> *StateDB
+)
+
+// RegisterPrecompiles 注册账户体系的预编译合约，自config.Height起启用
+// evm的预编译合约为全局注册，仅在账户体系下注册，以太坊模式下不注册
+func RegisterPrecompiles(config PrecompileConfig) {
+	registerOnce.Do(func() {
+		precompileConfig = &config
+		evm.PrecompiledContractsIstanbul[AccountsPrecompileAddress] = newStatePrecompile(accountsABI, map[string]uint64{
+			"resolve":       1,
+			"nameOf":        1,
+			"exists":        1,
+			"isFrozen":      3,
+			"permissionsOf": 3,
+			"hasNamespace":  3,
+		}, runAccounts)
+		evm.PrecompiledContractsIstanbul[DomainsPrecompileAddress] = newStatePrecompile(domainsABI, map[string]uint64{
+			"domainOf":       1,
+			"isMember":       1,
+			"domainExists":   2,
+			"isDomainFrozen": 2,
+		}, runDomains)
+	})
+}
+
+// isPrecompile 地址是否为已注册的预编译合约
+func isPrecompile(addr types.Address) bool {
+	switch addr {
+	case AccountsPrecompileAddress, DomainsPrecompileAddress:
+		_, ok := evm.PrecompiledContractsIstanbul[addr]
+		return ok
+	}
+	return false
+}
+
+// BindPrecompiles 将vm的状态绑定到当前goroutine，供预编译合约读取，返回解绑函数
+// evm的预编译合约只能获取input，而一次evm执行始终在调用方的goroutine内完成，因此按goroutine绑定，各evm之间互不影响
+// ApplyMessage中自动绑定，直接调用evm时需自行绑定，未绑定时调用预编译合约失败
+func BindPrecompiles(vm protocol.VM) func() {
+	sdb, ok := vm.DB().(*StateDB)
+	if !ok {
+		return func() {}
+	}
+	id := goroutineID()
+	prev, loaded := precompileStates.Load(id)
+	precompileStates.Store(id, sdb)
+	return func() {
+		if loaded {
+			precompileStates.Store(id, prev)
+		} else {
+			precompileStates.Delete(id)
+		}
+	}
+}
+
+func boundState() *StateDB {
+	if sdb, ok := precompileStates.Load(goroutineID()); ok {
+		return sdb.(*StateDB)
+	}
+	return nil
+}
+
+// goroutineID 当前goroutine的id，取自runtime.Stack的首行"goroutine N [...]"
+func goroutineID() uint64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	s := bytes.TrimPrefix(buf[:n], []byte("goroutine "))
+	if i := bytes.IndexByte(s, ' '); i >= 0 {
+		s = s[:i]
+	}
+	id, _ := strconv.ParseUint(string(s), 10, 64)
+	return id
+}
+
+type precompileHandler func(state *statedb.StateDB, height uint64, method string, args []interface{}) ([]interface{}, error)
+
+// statePrecompile 按abi分发的只读预编译合约
+type statePrecompile struct {
+	abi     abi.ABI
+	reads   map[string]uint64 // 各方法的状态读取次数
+	handler precompileHandler
+}
+
+func newStatePrecompile(definition string, reads map[string]uint64, handler precompileHandler) *statePrecompile {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return &statePrecompile{
+		abi:     parsed,
+		reads:   reads,
+		handler: handler,
+	}
+}
+
+// RequiredGas 未到启用高度时与调用空账户一致，不收取费用
+func (p *statePrecompile) RequiredGas(input []byte) uint64 {
+	sdb := boundState()
+	if sdb == nil || sdb.precompiles == nil {
+		return 0
+	}
+	gas := sdb.precompiles.Gas.Base + uint64(len(input)+31)/32*sdb.precompiles.Gas.PerWord
+	if len(input) < 4 {
+		return gas
+	}
+	if method, err := p.abi.MethodById(input[:4]); err == nil {
+		gas += p.reads[method.Name] * sdb.precompiles.Gas.Read
+	}
+	return gas
+}
+
+func (p *statePrecompile) Run(input []byte) ([]byte, error) {
+	sdb := boundState()
+	if sdb == nil {
+		return nil, errPrecompileUnbound
+	}
+	if sdb.precompiles == nil {
+		return nil, nil
+	}
+	if len(input) < 4 {
+		return nil, errPrecompileInput
+	}
+	method, err := p.abi.MethodById(input[:4])
+	if err != nil {
+		return nil, errPrecompileInput
+	}
+	args, err := method.Inputs.UnpackValues(input[4:])
+	if err != nil || len(args) != len(method.Inputs) {
+		return nil, errPrecompileInput
+	}
+
+	result, err := p.handler(sdb.state, sdb.height, method.Name, args)
+	if err != nil {
+		return nil, err
+	}
+	return method.Outputs.Pack(result...)
+}
+
+// accountAddress 账户对应的地址，合约账户为合约地址，其他账户为主地址
+func accountAddress(store *accounts.AccountStore) types.Address {
+	if store.IsContract() {
+		return types.HexToAddress(store.CN)
+	}
+	return accountInterpreter.PrimaryAddress(store)
+}
+
+func accountOf(state *statedb.StateDB, addr types.Address) *accounts.AccountStore {
+	owner := state.GetOwner(addr)
+	if owner == "" {
+		return nil
+	}
+	return accountInterpreter.GetAccount(state, owner)
+}
+
+func runAccounts(state *statedb.StateDB, height uint64, method string, args []interface{}) ([]interface{}, error) {
+	switch method {
+	case "resolve":
+		store := state.GetAccount(strings.ToLower(args[0].(string)))
+		if store == nil {
+			return []interface{}{types.Address{}}, nil
+		}
+		return []interface{}{accountAddress(store)}, nil
+
+	case "nameOf":
+		return []interface{}{state.GetOwner(args[0].(types.Address))}, nil
+
+	case "exists":
+		return []interface{}{state.GetAccount(strings.ToLower(args[0].(string))) != nil}, nil
+
+	case "isFrozen":
+		store := accountOf(state, args[0].(types.Address))
+		if store == nil {
+			return []interface{}{false}, nil
+		}
+		frozen := accountInterpreter.IsAccountFrozen(state, store, height) || accountInterpreter.IsDomainFrozen(state, store.Domain, height)
+		return []interface{}{frozen}, nil
+
+	case "permissionsOf":
+		store := accountOf(state, args[0].(types.Address))
+		if store == nil {
+			return []interface{}{false, new(big.Int)}, nil
+		}
+		caps := accountInterpreter.AccountCapabilities(state, store)
+		return []interface{}{store.IsAdmin, new(big.Int).SetUint64(uint64(caps))}, nil
+
+	case "hasNamespace":
+		store := accountOf(state, args[0].(types.Address))
+		if store == nil {
+			return []interface{}{false}, nil
+		}
+		return []interface{}{accountInterpreter.HasNamespace(state, store, args[1].(string))}, nil
+	}
+	return nil, errPrecompileInput
+}
+
+func runDomains(state *statedb.StateDB, height uint64, method string, args []interface{}) ([]interface{}, error) {
+	switch method {
+	case "domainOf":
+		store := accountOf(state, args[0].(types.Address))
+		if store == nil {
+			return []interface{}{""}, nil
+		}
+		return []interface{}{store.Domain}, nil
+
+	case "isMember":
+		store := accountOf(state, args[0].(types.Address))
+		if store == nil {
+			return []interface{}{false}, nil
+		}
+		return []interface{}{accountInterpreter.InDomain(store, args[1].(string))}, nil
+
+	case "domainExists":
+		domain := strings.ToLower(args[0].(string))
+		exists := state.GetDomain(domain) != nil && accountInterpreter.VerifyDomainActive(state, domain, height) == nil
+		return []interface{}{exists}, nil
+
+	case "isDomainFrozen":
+		return []interface{}{accountInterpreter.IsDomainFrozen(state, strings.ToLower(args[0].(string)), height)}, nil
+	}
+	return nil, errPrecompileInput
+}
@@ -0,0 +1,164 @@
+// Package evmInterpreter
+//
+// @author: xwc1125
+package evmInterpreter
+
+import (
+	"bytes"
+	evm "github.com/chain5j/chain5j-evm"
+	"github.com/chain5j/chain5j-pkg/crypto/signature"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models"
+	"github.com/chain5j/chain5j-protocol/models/vm"
+	"github.com/chain5j/chain5j-protocol/pkg/abi"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp/internal/testutil"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testPrecompileHeight = 5
+
+// testPrecompiles 测试中注册的预编译合约配置
+var testPrecompiles = PrecompileConfig{Height: testPrecompileHeight, Gas: DefaultPrecompileGas}
+
+// callPrecompile 在height高度的evm中调用预编译合约，返回输出及消耗的gas
+func callPrecompile(t *testing.T, state *statedb.StateDB, height uint64, addr types.Address, input []byte) ([]byte, uint64) {
+	from := types.DomainToAddress("caller@bank")
+	msg := models.NewEvmMessage(from, &addr, 0, new(big.Int), 100000, new(big.Int), input, false)
+	header := &models.Header{Height: height}
+	vmenv := evm.NewEVM(NewEVMContext(msg, header, nil, nil), NewStateDB(state, height), nil, evm.Config{})
+	defer BindPrecompiles(vmenv)()
+	ret, left, err := vmenv.Call(models.AccountRef(from), addr, input, 100000, new(big.Int))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ret, 100000 - left
+}
+
+func packResolve(t *testing.T, name string) ([]byte, abi.Method) {
+	parsed, err := abi.JSON(strings.NewReader(accountsABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	input, err := parsed.Pack("resolve", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return input, parsed.Methods["resolve"]
+}
+
+func TestPrecompiles(t *testing.T) {
+	RegisterPrecompiles(testPrecompiles)
+
+	state := testutil.NewState(t)
+	key := newTestAccount(t, state, "alice", false)
+	alice := signature.PubkeyToAddress(&key.PublicKey)
+	input, method := packResolve(t, "alice@bank")
+	resolved, err := method.Outputs.Pack(alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	missing, err := method.Outputs.Pack(types.Address{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// resolve读取一次状态
+	resolveGas := DefaultPrecompileGas.Base + uint64(len(input)+31)/32*DefaultPrecompileGas.PerWord + DefaultPrecompileGas.Read
+	tests := []struct {
+		name   string
+		height uint64
+		input  []byte
+		want   []byte
+		gas    uint64
+	}{
+		{"before activation", testPrecompileHeight - 1, input, nil, 0},
+		{"resolve", testPrecompileHeight, input, resolved, resolveGas},
+		{"resolve missing", testPrecompileHeight, mustPack(t, "bob@bank"), missing, resolveGas},
+	}
+	for _, tt := range tests {
+		ret, gas := callPrecompile(t, state, tt.height, AccountsPrecompileAddress, tt.input)
+		if !bytes.Equal(ret, tt.want) {
+			t.Fatalf("%s: ret %x, want %x", tt.name, ret, tt.want)
+		}
+		if gas != tt.gas {
+			t.Fatalf("%s: gas %d, want %d", tt.name, gas, tt.gas)
+		}
+	}
+
+	// 未绑定状态时调用失败，不能当作空账户返回空结果
+	p := evm.PrecompiledContractsIstanbul[AccountsPrecompileAddress]
+	if _, err := p.Run(input); err != errPrecompileUnbound {
+		t.Fatalf("unbound run: %v", err)
+	}
+	from := types.DomainToAddress("caller@bank")
+	vmenv := evm.NewEVM(evm.Context{CanTransfer: CanTransfer, Transfer: Transfer}, NewStateDB(state, testPrecompileHeight), nil, evm.Config{})
+	if _, left, err := vmenv.Call(models.AccountRef(from), AccountsPrecompileAddress, input, 100000, new(big.Int)); err == nil || left != 0 {
+		t.Fatalf("unbound call: %v, gas left %d", err, left)
+	}
+}
+
+// ApplyMessage自动绑定状态
+func TestPrecompiles_ApplyMessage(t *testing.T) {
+	RegisterPrecompiles(testPrecompiles)
+
+	state := testutil.NewState(t)
+	key := newTestAccount(t, state, "alice", false)
+	input, method := packResolve(t, "alice@bank")
+	want, err := method.Outputs.Pack(signature.PubkeyToAddress(&key.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := AccountsPrecompileAddress
+	from := signature.PubkeyToAddress(&key.PublicKey)
+	msg := models.NewEvmMessage(from, &addr, 0, new(big.Int), 100000, new(big.Int), input, false)
+	header := &models.Header{Height: testPrecompileHeight, GasLimit: 100000}
+	vmenv := evm.NewEVM(NewEVMContext(msg, header, nil, nil), NewStateDB(state, testPrecompileHeight), nil, evm.Config{})
+	ret, _, failed, err := ApplyMessage(vmenv, msg, new(vm.GasPool).AddGas(100000))
+	if err != nil || failed {
+		t.Fatalf("apply: %v failed %v", err, failed)
+	}
+	if !bytes.Equal(ret, want) {
+		t.Fatalf("ret %x, want %x", ret, want)
+	}
+}
+
+func mustPack(t *testing.T, name string) []byte {
+	input, _ := packResolve(t, name)
+	return input
+}
+
+// 并发执行的evm各自读取绑定的状态
+func TestPrecompiles_Concurrent(t *testing.T) {
+	RegisterPrecompiles(testPrecompiles)
+
+	input, method := packResolve(t, "alice@bank")
+	var wg sync.WaitGroup
+	errs := make(chan string, 8)
+	for i := 0; i < 8; i++ {
+		state := testutil.NewState(t)
+		key := newTestAccount(t, state, "alice", false)
+		want, err := method.Outputs.Pack(signature.PubkeyToAddress(&key.PublicKey))
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if ret, _ := callPrecompile(t, state, testPrecompileHeight, AccountsPrecompileAddress, input); !bytes.Equal(ret, want) {
+					errs <- "resolved address of another state"
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
@@ -14,6 +14,8 @@ import (
 	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
 	"github.com/chain5j/chain5j-protocol/protocol"
 	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
+	"github.com/chain5j/chain5j-stateApp/tracers"
 	"math/big"
 )
 
@@ -29,6 +31,11 @@ func (i *Interpreter) TxAsMessage(db *statedb.StateDB, tx models.StateTransactio
 		return nil, err
 	}
 
+	// 会话密钥签名时，以账户的主地址执行
+	if accountFrom := db.GetAccount(tx.From()); accountFrom != nil && !accountFrom.ContainAddress(from) {
+		from = accountInterpreter.PrimaryAddress(accountFrom)
+	}
+
 	if tx.To() != "" {
 		account := db.GetAccount(tx.To())
 		if account == nil {
@@ -48,24 +55,43 @@ func (i *Interpreter) TxAsMessage(db *statedb.StateDB, tx models.StateTransactio
 	return models.NewEvmMessage(from, to, tx.Nonce(), tx.Value(), tx.GasLimit(), new(big.Int).SetUint64(tx.GasPrice()), tx.Input(), true), nil
 }
 
-func (i *Interpreter) applyTransaction(config *models.ChainConfig, bc protocol.ChainContext, header *models.Header, tx models.StateTransaction, sdb *statedb.StateDB, gp *vm.GasPool, usedGas *uint64) (*statetype.Receipt, uint64, error) {
+func (i *Interpreter) applyTransaction(config *models.ChainConfig, bc protocol.ChainContext, header *models.Header, tx models.StateTransaction, sdb *statedb.StateDB, gp *vm.GasPool, usedGas *uint64, vmConfig evm.Config) (*statetype.Receipt, uint64, error) {
 	msg, err := i.TxAsMessage(sdb, tx)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	evmdb := statedb.NewEvmStateDB(sdb)
+	evmdb := NewStateDB(sdb, header.Height)
+	// 部署权限须在evm的创建中检查，工厂合约创建的合约同样需要交易发送方允许部署合约
+	if accountFrom := accountInterpreter.GetAccount(sdb, tx.From()); accountFrom == nil || !accountInterpreter.HasCapability(sdb, accountFrom, accountInterpreter.CapDeployContract) {
+		evmdb.DenyCreate()
+	}
+	if vmConfig.Debug {
+		var to *types.Address
+		if msg.To() != "" {
+			addr := types.DomainToAddress(msg.To())
+			to = &addr
+		}
+		tracers.CaptureTxStart(vmConfig.Tracer, evmdb, types.DomainToAddress(msg.From()), to)
+	}
 
 	context := NewEVMContext(msg, header, bc, nil)
 	// Create a new environment which holds all relevant information
 	// about the transaction and calling mechanisms.
-	vmenv := evm.NewEVM(context, evmdb, config, evm.Config{DisableCreate: true})
+	vmenv := evm.NewEVM(context, evmdb, config, vmConfig)
+	from := types.DomainToAddress(msg.From())
+	snapshot, nonce := evmdb.Snapshot(), evmdb.GetNonce(from)
 	_, gas, failed, err := ApplyMessage(vmenv, msg, gp)
 
 	if err != nil {
 		i.log.Error("[applyTransaction] ApplyMessage is err", "err", err)
 		return nil, 0, err
 	}
+	if evmdb.CreateDenied() {
+		i.log.Error("[applyTransaction] deploy contract not allowed", "from", tx.From(), "err", errDeployNotAllowed)
+		revertDeniedCreate(evmdb, vmenv, snapshot, from, nonce, gas, msg.GasPrice())
+		failed = true
+	}
 
 	// Update the stateApp with pending changes
 	evmdb.Finalise(true)
@@ -78,9 +104,10 @@ func (i *Interpreter) applyTransaction(config *models.ChainConfig, bc protocol.C
 	receipt.TransactionHash = tx.Hash()
 	receipt.GasUsed = gas
 	// if the transaction created a contract, store the creation address in the receipt.
+	// 合约账户由evm创建时注册为合约域下的账户，之后可按账户名称调用
 	if msg.To() == "" {
 		receipt.ContractAddress = crypto.CreateAddress(vmenv.Context.Origin, tx.Nonce())
-		i.log.Info("[Contract] deploy contract", "ContractAddress", receipt.ContractAddress)
+		i.log.Info("[Contract] deploy contract", "ContractAddress", receipt.ContractAddress, "account", stateApp.SystemAccountName(receipt.ContractAddress))
 	}
 	// Set the receipt logs and create a bloom for filtering
 	receipt.Logs = sdb.GetLogs(tx.Hash())
@@ -91,3 +118,12 @@ func (i *Interpreter) applyTransaction(config *models.ChainConfig, bc protocol.C
 
 	return receipt, gas, err
 }
+
+// revertDeniedCreate 撤销交易的执行结果，与执行失败的交易一致，递增nonce并收取已使用的gas
+func revertDeniedCreate(db evm.StateDB, vmenv *evm.EVM, snapshot int, from types.Address, nonce, gas uint64, gasPrice *big.Int) {
+	db.RevertToSnapshot(snapshot)
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(gas), gasPrice)
+	db.SubBalance(from, fee)
+	db.AddBalance(vmenv.Coinbase(), fee)
+	db.SetNonce(from, nonce+1)
+}
@@ -108,6 +108,7 @@ func NewStateTransition(evm protocol.VM, msg models.VmMessage, gp *vm.GasPool) *
 // indicates a core error meaning that the message would always fail for that particular
 // state and would never be accepted within a block.
 func ApplyMessage(evm protocol.VM, msg models.VmMessage, gp *vm.GasPool) ([]byte, uint64, bool, error) {
+	defer BindPrecompiles(evm)()
 	return NewStateTransition(evm, msg, gp).TransitionDb()
 }
 
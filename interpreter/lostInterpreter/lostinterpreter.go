@@ -13,11 +13,13 @@ import (
 	"github.com/chain5j/chain5j-protocol/models/statetype"
 	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
 	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
 	"github.com/chain5j/logger"
 )
 
 var (
-	errUnauthorized = errors.New("unauthorized")
+	errUnauthorized  = errors.New("unauthorized")
+	errAddressExists = errors.New("recover address already exists")
 )
 
 var (
@@ -38,13 +40,13 @@ func NewInterpreter() *LostInterpreter {
 func (interpreter *LostInterpreter) VerifyTx(ctx stateApp.InterpreterCtx, tx models.StateTransaction) error {
 	stateDB := ctx.StateDB()
 
-	accountFrom := stateDB.GetAccount(tx.From())
+	accountFrom := accountInterpreter.GetAccount(stateDB, tx.From())
 	// 账户未找到
 	if accountFrom == nil {
 		return stateApp.ErrFromAccountNotFound
 	}
-	if accountFrom.IsFrozen {
-		return stateApp.ErrFrozenAccount
+	if err := accountInterpreter.VerifyAccountStatus(stateDB, accountFrom, ctx.Header().Height); err != nil {
+		return err
 	}
 
 	// 检查签名
@@ -60,8 +62,8 @@ func (interpreter *LostInterpreter) VerifyTx(ctx stateApp.InterpreterCtx, tx mod
 
 	switch txData.Operation {
 	case accounts.LostRequestOp:
-		if err := checkSigner(accountFrom, signer); err != nil {
-			return stateApp.ErrInvalidSigner
+		if err := accountInterpreter.VerifyControlSigner(accountFrom, signer); err != nil {
+			return err
 		}
 
 		var lostRequest accounts.LostRequest
@@ -80,8 +82,8 @@ func (interpreter *LostInterpreter) VerifyTx(ctx stateApp.InterpreterCtx, tx mod
 		}
 
 	case accounts.LostResetOp:
-		if err := checkSigner(accountFrom, signer); err != nil {
-			return stateApp.ErrInvalidSigner
+		if err := accountInterpreter.VerifyControlSigner(accountFrom, signer); err != nil {
+			return err
 		}
 
 	default:
@@ -91,6 +93,11 @@ func (interpreter *LostInterpreter) VerifyTx(ctx stateApp.InterpreterCtx, tx mod
 	return nil
 }
 
+// Gas 每笔交易的gas固定为stateApp.TxGas
+func (interpreter *LostInterpreter) Gas(tx models.StateTransaction) uint64 {
+	return stateApp.TxGas
+}
+
 func (interpreter *LostInterpreter) ApplyTransaction(ctx stateApp.InterpreterCtx, tx models.StateTransaction, usedGas *uint64) (*statetype.Receipt, error) {
 	stateDB := ctx.StateDB()
 
@@ -122,7 +129,7 @@ func (interpreter *LostInterpreter) ApplyTransaction(ctx stateApp.InterpreterCtx
 		return nil, stateApp.ErrInvalidAccountOp
 	}
 
-	*usedGas += uint64(21000)
+	*usedGas += interpreter.Gas(tx)
 
 	account := tx.From()
 	stateDB.SetNonce(account, stateDB.GetNonce(account)+1)
@@ -131,7 +138,7 @@ func (interpreter *LostInterpreter) ApplyTransaction(ctx stateApp.InterpreterCtx
 		Status:            1,
 		CumulativeGasUsed: *usedGas,
 		TransactionHash:   tx.Hash(),
-		GasUsed:           uint64(21000),
+		GasUsed:           interpreter.Gas(tx),
 		Logs:              nil,
 	}
 	return receipt, nil
@@ -148,6 +155,10 @@ func VerifyLostRequest(state *statedb.StateDB, accountFrom string, req *accounts
 	if accountFrom != lostAccount.Partner() {
 		return errUnauthorized
 	}
+	// 找回后的地址加入账户的地址映射，不能为已占用的地址
+	if accountInterpreter.AddressTaken(state, req.RecoverAddr) {
+		return errAddressExists
+	}
 
 	return nil
 }
@@ -188,6 +199,10 @@ func VerifyFoundRequest(state *statedb.StateDB, ctx stateApp.InterpreterCtx, acc
 	if now < lostStore.TimeStamp {
 		return errUnauthorized
 	}
+	// 请求后该地址可能已被注册
+	if accountInterpreter.AddressTaken(state, lostStore.RecoverAddr) {
+		return errAddressExists
+	}
 
 	return nil
 }
@@ -206,6 +221,8 @@ func CommitFoundRequest(state *statedb.StateDB, account string) error {
 
 	state.SetAddress(account, lostStore.RecoverAddr)
 	state.SetLost(account, nil)
+	// 丢失的密钥添加的会话密钥随之失效
+	accountInterpreter.ClearSessionKeys(state, account)
 
 	return nil
 }
@@ -215,12 +232,3 @@ func CommitLostReset(state *statedb.StateDB, account string) error {
 
 	return nil
 }
-
-func checkSigner(store *accounts.AccountStore, signer types.Address) error {
-
-	if !store.ContainAddress(signer) {
-		return stateApp.ErrInvalidSigner
-	}
-
-	return nil
-}
@@ -0,0 +1,134 @@
+// Package lostInterpreter
+//
+// @author: xwc1125
+package lostInterpreter
+
+import (
+	"crypto/ecdsa"
+	"github.com/chain5j/chain5j-pkg/crypto/signature"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/internal/testutil"
+	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
+	"math/big"
+	"testing"
+)
+
+func TestVerifyTx_ControlSigner(t *testing.T) {
+	state := testutil.NewState(t)
+	ownerKey, _ := testutil.NewKey(t)
+	sessionKey, _ := testutil.NewKey(t)
+	strangerKey, _ := testutil.NewKey(t)
+
+	carol := accounts.NewAccountStore("carol", "bank")
+	carol.SetAddress(signature.PubkeyToAddress(&ownerKey.PublicKey), nil)
+	state.CreateAccount(carol)
+	alice := accounts.NewAccountStore("alice", "bank")
+	alice.SetAddress(types.Address{1}, nil)
+	state.CreateAccount(alice)
+	state.SetPartner("alice@bank", accounts.PartnerData{CN: "carol", Domain: "bank"})
+
+	addKey := testutil.Encode(t, &accountInterpreter.AddSessionKeyData{
+		Address:      signature.PubkeyToAddress(&sessionKey.PublicKey),
+		ExpiryHeight: 1000,
+	})
+	accountInterpreter.AddSessionKey(state, "carol@bank", addKey, 1)
+
+	ctx := testutil.NewCtx(t, state, 10, nil)
+	request := testutil.Encode(t, &accounts.LostRequest{CN: "alice", Domain: "bank", RecoverAddr: types.Address{2}})
+
+	tests := []struct {
+		name string
+		op   accounts.AccountOp
+		data []byte
+	}{
+		{"lost request", accounts.LostRequestOp, request},
+		{"lost reset", accounts.LostResetOp, nil},
+	}
+	signers := []struct {
+		name string
+		key  *ecdsa.PrivateKey
+		err  error
+	}{
+		{"owner", ownerKey, nil},
+		{"session key", sessionKey, stateApp.ErrInvalidSigner},
+		{"stranger", strangerKey, stateApp.ErrInvalidSigner},
+	}
+	interpreter := NewInterpreter()
+	for _, tt := range tests {
+		input := testutil.Encode(t, &accounts.AccountOpData{Operation: tt.op, Data: tt.data})
+		for _, s := range signers {
+			tx := stateApp.NewTransaction("carol@bank", "carol@bank", stateApp.LostInterpreter, 0, 0, stateApp.TxGas, big.NewInt(0), input, 0, nil)
+			if _, err := tx.Sign(s.key); err != nil {
+				t.Fatal(err)
+			}
+			if err := interpreter.VerifyTx(ctx, tx); err != s.err {
+				t.Fatalf("%s by %s: %v, want %v", tt.name, s.name, err, s.err)
+			}
+		}
+	}
+}
+
+// 找回的地址不能为已被账户或会话密钥占用的地址
+func TestVerifyLostRequest_RecoverAddr(t *testing.T) {
+	state := testutil.NewState(t)
+	carol := accounts.NewAccountStore("carol", "bank")
+	carol.SetAddress(types.Address{1}, nil)
+	state.CreateAccount(carol)
+	alice := accounts.NewAccountStore("alice", "bank")
+	alice.SetAddress(types.Address{2}, nil)
+	state.CreateAccount(alice)
+	state.SetPartner("alice@bank", accounts.PartnerData{CN: "carol", Domain: "bank"})
+	addKey := testutil.Encode(t, &accountInterpreter.AddSessionKeyData{Address: types.Address{3}, ExpiryHeight: 1000})
+	accountInterpreter.AddSessionKey(state, "carol@bank", addKey, 1)
+
+	tests := []struct {
+		name string
+		addr types.Address
+		err  error
+	}{
+		{"free", types.Address{4}, nil},
+		{"account address", types.Address{1}, errAddressExists},
+		{"own address", types.Address{2}, errAddressExists},
+		{"session key", types.Address{3}, errAddressExists},
+	}
+	for _, tt := range tests {
+		req := &accounts.LostRequest{CN: "alice", Domain: "bank", RecoverAddr: tt.addr}
+		if err := VerifyLostRequest(state, "carol@bank", req); err != tt.err {
+			t.Fatalf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+// 找回后丢失的密钥添加的会话密钥失效
+func TestCommitFoundRequest_ClearsSessionKeys(t *testing.T) {
+	state := testutil.NewState(t)
+	alice := accounts.NewAccountStore("alice", "bank")
+	alice.SetAddress(types.Address{1}, nil)
+	state.CreateAccount(alice)
+	addKey := testutil.Encode(t, &accountInterpreter.AddSessionKeyData{Address: types.Address{3}, ExpiryHeight: 1000})
+	accountInterpreter.AddSessionKey(state, "alice@bank", addKey, 1)
+
+	ctx := testutil.NewCtx(t, state, 10, nil)
+	CommitLostRequest(ctx, state, &accounts.LostRequest{CN: "alice", Domain: "bank", RecoverAddr: types.Address{2}})
+	if err := CommitFoundRequest(state, "alice@bank"); err != nil {
+		t.Fatal(err)
+	}
+
+	store := state.GetAccount("alice@bank")
+	if !store.ContainAddress(types.Address{2}) {
+		t.Fatal("recover address not set")
+	}
+	if keys := accountInterpreter.SessionKeys(state, "alice@bank"); len(keys) != 0 {
+		t.Fatalf("session keys %+v", keys)
+	}
+	tx := stateApp.NewTransaction("alice@bank", "bob@bank", stateApp.BaseInterpreter, 0, 0, stateApp.TxGas, big.NewInt(1), nil, 0, nil)
+	if err := accountInterpreter.VerifySigner(state, store, types.Address{3}, stateApp.BaseInterpreter, tx, 10); err != stateApp.ErrInvalidSigner {
+		t.Fatalf("session key signer: %v, want %v", err, stateApp.ErrInvalidSigner)
+	}
+	// 已移除的会话密钥地址仍被占用
+	if !accountInterpreter.AddressTaken(state, types.Address{3}) {
+		t.Fatal("session key address released")
+	}
+}
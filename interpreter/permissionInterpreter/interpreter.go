@@ -11,6 +11,7 @@ import (
 	"github.com/chain5j/chain5j-protocol/models/statetype"
 	"github.com/chain5j/chain5j-protocol/protocol"
 	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
 	"github.com/chain5j/logger"
 )
 
@@ -36,16 +37,20 @@ func NewInterpreter(nodeKey protocol.NodeKey) *Interpreter {
 }
 
 func (interpreter *Interpreter) VerifyTx(ctx stateApp.InterpreterCtx, t models.StateTransaction) error {
-	tx := t.(*stateApp.Transaction)
+	// 校验节点角色需要签名公钥，模拟执行的未签名交易不满足
+	tx, ok := t.(*stateApp.Transaction)
+	if !ok {
+		return stateApp.ErrInvalidSigner
+	}
 	stateDB := ctx.StateDB()
 
-	accountFrom := stateDB.GetAccount(tx.From())
+	accountFrom := accountInterpreter.GetAccount(stateDB, tx.From())
 	// 账户未找到
 	if accountFrom == nil {
 		return stateApp.ErrFromAccountNotFound
 	}
-	if accountFrom.IsFrozen {
-		return stateApp.ErrFrozenAccount
+	if err := accountInterpreter.VerifyAccountStatus(stateDB, accountFrom, ctx.Header().Height); err != nil {
+		return err
 	}
 
 	// 检查签名
@@ -53,6 +58,9 @@ func (interpreter *Interpreter) VerifyTx(ctx stateApp.InterpreterCtx, t models.S
 	if err != nil {
 		return stateApp.ErrInvalidSigner
 	}
+	if err := accountInterpreter.VerifySigner(stateDB, accountFrom, signer, stateApp.PermissionInterpreter, tx, ctx.Header().Height); err != nil {
+		return err
+	}
 
 	var txData permission.DataPermissionOpData
 	if err := rlp.DecodeBytes(tx.Input(), &txData); err != nil {
@@ -133,8 +141,16 @@ func (interpreter *Interpreter) VerifyTx(ctx stateApp.InterpreterCtx, t models.S
 	return nil
 }
 
+// Gas 每笔交易的gas固定为stateApp.TxGas
+func (interpreter *Interpreter) Gas(tx models.StateTransaction) uint64 {
+	return stateApp.TxGas
+}
+
 func (interpreter *Interpreter) ApplyTransaction(ctx stateApp.InterpreterCtx, t models.StateTransaction, usedGas *uint64) (*statetype.Receipt, error) {
-	tx := t.(*stateApp.Transaction)
+	tx, ok := t.(*stateApp.Transaction)
+	if !ok {
+		return nil, stateApp.ErrInvalidSigner
+	}
 	stateDB := ctx.StateDB()
 
 	if err := interpreter.VerifyTx(ctx, tx); err != nil {
@@ -223,7 +239,7 @@ func (interpreter *Interpreter) ApplyTransaction(ctx stateApp.InterpreterCtx, t
 		return nil, err
 	}
 
-	gasUsed := uint64(21000)
+	gasUsed := interpreter.Gas(t)
 	usedGas = &gasUsed
 
 	account := tx.From()
@@ -0,0 +1,53 @@
+// Package permissionInterpreter
+//
+// @author: xwc1125
+package permissionInterpreter
+
+import (
+	"crypto/ecdsa"
+	"github.com/chain5j/chain5j-pkg/crypto/signature"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/internal/testutil"
+	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
+	"math/big"
+	"testing"
+)
+
+func TestVerifyTx_Signer(t *testing.T) {
+	state := testutil.NewState(t)
+	ownerKey, _ := testutil.NewKey(t)
+	scopedKey, _ := testutil.NewKey(t)
+	strangerKey, _ := testutil.NewKey(t)
+
+	store := accounts.NewAccountStore("admin", "bank")
+	store.SetAddress(signature.PubkeyToAddress(&ownerKey.PublicKey), nil)
+	state.CreateAccount(store)
+	addKey := testutil.Encode(t, &accountInterpreter.AddSessionKeyData{
+		Address:      signature.PubkeyToAddress(&scopedKey.PublicKey),
+		ExpiryHeight: 1000,
+		Interpreters: []string{stateApp.BaseInterpreter},
+	})
+	accountInterpreter.AddSessionKey(state, "admin@bank", addKey, 1)
+	ctx := testutil.NewCtx(t, state, 10, nil)
+
+	// 签名者须为账户的完全控制地址或范围内的会话密钥，校验先于节点权限
+	tests := []struct {
+		name string
+		key  *ecdsa.PrivateKey
+		err  string
+	}{
+		{"stranger", strangerKey, stateApp.ErrInvalidSigner.Error()},
+		{"session key out of scope", scopedKey, "transaction out of session key scope"},
+	}
+	interpreter := NewInterpreter(nil)
+	for _, tt := range tests {
+		tx := stateApp.NewTransaction("admin@bank", "admin@bank", stateApp.PermissionInterpreter, 0, 0, stateApp.TxGas, big.NewInt(0), nil, 0, nil)
+		if _, err := tx.Sign(tt.key); err != nil {
+			t.Fatal(err)
+		}
+		if err := interpreter.VerifyTx(ctx, tx); err == nil || err.Error() != tt.err {
+			t.Fatalf("%s: %v, want %s", tt.name, err, tt.err)
+		}
+	}
+}
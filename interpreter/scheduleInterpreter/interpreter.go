@@ -0,0 +1,208 @@
+// Package scheduleInterpreter
+//
+// @author: xwc1125
+package scheduleInterpreter
+
+import (
+	"fmt"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
+	"github.com/chain5j/logger"
+)
+
+// Lookup 根据名称获取解析器，用于执行内层交易
+type Lookup func(name string) (stateApp.Interpreter, bool)
+
+type Interpreter struct {
+	log    logger.Logger
+	lookup Lookup
+}
+
+func NewInterpreter(lookup Lookup) *Interpreter {
+	return &Interpreter{
+		log:    logger.New("schedule_interpreter"),
+		lookup: lookup,
+	}
+}
+
+func (i *Interpreter) VerifyTx(ctx stateApp.InterpreterCtx, tx models.StateTransaction) error {
+	stateDB := ctx.StateDB()
+	height := ctx.Header().Height
+
+	signer, err := tx.Signer()
+	if err != nil {
+		return stateApp.ErrInvalidSigner
+	}
+	accountFrom := accountInterpreter.GetAccount(stateDB, tx.From())
+	// 账户未找到
+	if accountFrom == nil {
+		return stateApp.ErrFromAccountNotFound
+	}
+	if err := accountInterpreter.VerifyAccountStatus(stateDB, accountFrom, height); err != nil {
+		return err
+	}
+	if err := accountInterpreter.VerifySigner(stateDB, accountFrom, signer, stateApp.ScheduleInterpreter, tx, height); err != nil {
+		return err
+	}
+	if tx.Value() != nil && tx.Value().Sign() != 0 {
+		return errInvalidSchedule
+	}
+
+	op, err := decodeOp(tx.Input())
+	if err != nil {
+		return err
+	}
+
+	from := accountFrom.AccountName()
+	switch op.Operation {
+	case ScheduleTxOp:
+		return verifySchedule(stateDB, from, tx.Nonce(), op.Data, height)
+
+	case CancelOp:
+		return verifyCancel(stateDB, from, op.Data)
+
+	default:
+		return errInvalidScheduleOp
+	}
+}
+
+// Gas 每笔交易的gas固定为stateApp.TxGas
+func (i *Interpreter) Gas(tx models.StateTransaction) uint64 {
+	return stateApp.TxGas
+}
+
+func (i *Interpreter) ApplyTransaction(ctx stateApp.InterpreterCtx, tx models.StateTransaction, usedGas *uint64) (*statetype.Receipt, error) {
+	stateDB := ctx.StateDB()
+
+	if err := i.VerifyTx(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	account := tx.From()
+	currentNonce := stateDB.GetNonce(account)
+	if currentNonce != tx.Nonce() {
+		return nil, fmt.Errorf("stateDB nonce and tx nonce is diff,stateDB nonce = %d, txNonce = %d", currentNonce, tx.Nonce())
+	}
+	stateDB.SetNonce(account, currentNonce+1)
+
+	op, _ := decodeOp(tx.Input())
+	switch op.Operation {
+	case ScheduleTxOp:
+		schedule(stateDB, account, op.Data)
+
+	case CancelOp:
+		cancel(stateDB, op.Data)
+	}
+
+	*usedGas += i.Gas(tx)
+
+	receipt := &statetype.Receipt{
+		Status:            statetype.ReceiptStatusSuccessful,
+		CumulativeGasUsed: *usedGas,
+		TransactionHash:   tx.Hash(),
+		GasUsed:           i.Gas(tx),
+	}
+	receipt.LogsBloom = statetype.CreateBloom(statetype.Receipts{receipt})
+
+	return receipt, nil
+}
+
+// BeginBlock 在区块开始时执行当前高度到期的定时交易，返回各定时交易的收据
+// 内层交易按提交顺序执行，执行时临时将账户nonce置为内层交易的nonce，执行后恢复，失败的交易回滚并记录失败收据
+func (i *Interpreter) BeginBlock(ctx stateApp.InterpreterCtx, header *models.Header) ([]*statetype.Receipt, error) {
+	stateDB := ctx.StateDB()
+	if stateDB == nil {
+		return nil, nil
+	}
+
+	ids := ScheduledAt(stateDB, header.Height)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	receipts := make([]*statetype.Receipt, 0, len(ids))
+	for _, id := range ids {
+		s := GetSchedule(stateDB, id)
+		if s == nil || s.Status != StatusPending {
+			continue
+		}
+
+		receipt, err := i.run(ctx, s, len(receipts))
+		if err != nil {
+			i.log.Debug("scheduled transaction failed", "id", id, "err", err)
+			s.Status = StatusFailed
+			s.Error = err.Error()
+			receipt = &statetype.Receipt{
+				Status:          statetype.ReceiptStatusFailed,
+				TransactionHash: id,
+			}
+			receipt.LogsBloom = statetype.CreateBloom(statetype.Receipts{receipt})
+		} else {
+			s.Status = StatusExecuted
+		}
+		setSchedule(stateDB, s)
+		receipts = append(receipts, receipt)
+	}
+	setScheduledAt(stateDB, header.Height, nil)
+
+	return receipts, nil
+}
+
+// HookTransaction BeginBlock收据对应的内层交易，收据的TransactionHash即内层交易的哈希
+func (i *Interpreter) HookTransaction(ctx stateApp.InterpreterCtx, receipt *statetype.Receipt) *stateApp.Transaction {
+	stateDB := ctx.StateDB()
+	if stateDB == nil {
+		return nil
+	}
+	s := GetSchedule(stateDB, receipt.TransactionHash)
+	if s == nil {
+		return nil
+	}
+	tx, err := DecodeTx(s.Tx)
+	if err != nil {
+		return nil
+	}
+	return tx
+}
+
+func (i *Interpreter) EndBlock(ctx stateApp.InterpreterCtx, header *models.Header) ([]*statetype.Receipt, error) {
+	return nil, nil
+}
+
+func (i *Interpreter) run(ctx stateApp.InterpreterCtx, s *Schedule, tcount int) (*statetype.Receipt, error) {
+	stateDB := ctx.StateDB()
+
+	tx, err := DecodeTx(s.Tx)
+	if err != nil {
+		return nil, err
+	}
+	interpreter, ok := i.lookup(tx.Interpreter())
+	if !ok {
+		return nil, errInvalidInnerTx
+	}
+
+	nonce := stateDB.GetNonce(s.Owner)
+
+	ctx.Prepare(tx.Hash(), types.Hash{}, tcount)
+	snap := ctx.Snapshot()
+	stateDB.SetNonce(s.Owner, tx.Nonce())
+
+	var (
+		receipt *statetype.Receipt
+		usedGas uint64
+	)
+	if err = interpreter.VerifyTx(ctx, tx); err == nil {
+		receipt, err = interpreter.ApplyTransaction(ctx, tx, &usedGas)
+	}
+	if err != nil {
+		ctx.RevertToSnapshot(snap)
+	} else {
+		receipt.GasUsed = usedGas
+	}
+	stateDB.SetNonce(s.Owner, nonce)
+
+	return receipt, err
+}
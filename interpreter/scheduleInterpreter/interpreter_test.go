@@ -0,0 +1,142 @@
+// Package scheduleInterpreter
+//
+// @author: xwc1125
+package scheduleInterpreter
+
+import (
+	"crypto/ecdsa"
+	"github.com/chain5j/chain5j-pkg/codec/rlp"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/internal/testutil"
+	"github.com/chain5j/chain5j-stateApp/interpreter/baseInterpreter"
+	"math/big"
+	"testing"
+)
+
+func newTestAccount(t *testing.T, state *statedb.StateDB, cn string, balance int64) *ecdsa.PrivateKey {
+	key, _ := testutil.NewAccount(t, state, cn, "bank", balance)
+	return key
+}
+
+func signTx(t *testing.T, key *ecdsa.PrivateKey, from, to, interpreter string, nonce uint64, value int64, input []byte) *stateApp.Transaction {
+	tx := stateApp.NewTransaction(from, to, interpreter, nonce, 0, stateApp.TxGas, big.NewInt(value), input, 0, nil)
+	if _, err := tx.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+// newScheduleTx 构造定时交易，inner为内层交易
+func newScheduleTx(t *testing.T, key *ecdsa.PrivateKey, from string, nonce, height uint64, inner *stateApp.Transaction) *stateApp.Transaction {
+	enc, err := rlp.EncodeToBytes(inner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := testutil.Encode(t, &ScheduleOpData{Operation: ScheduleTxOp, Data: testutil.Encode(t, &ScheduleTxData{Height: height, Tx: enc})})
+	return signTx(t, key, from, from, stateApp.ScheduleInterpreter, nonce, 0, input)
+}
+
+func newCancelTx(t *testing.T, key *ecdsa.PrivateKey, from string, nonce uint64, id types.Hash) *stateApp.Transaction {
+	input := testutil.Encode(t, &ScheduleOpData{Operation: CancelOp, Data: testutil.Encode(t, &CancelData{ID: id})})
+	return signTx(t, key, from, from, stateApp.ScheduleInterpreter, nonce, 0, input)
+}
+
+func TestSchedule(t *testing.T) {
+	state := testutil.NewState(t)
+	aliceKey := newTestAccount(t, state, "alice", 1000)
+	bobKey := newTestAccount(t, state, "bob", 0)
+
+	base := baseInterpreter.NewInterpreter()
+	interpreter := NewInterpreter(func(name string) (stateApp.Interpreter, bool) {
+		return base, name == stateApp.BaseInterpreter
+	})
+
+	transfer := signTx(t, aliceKey, "alice@bank", "bob@bank", stateApp.BaseInterpreter, 0, 100, nil)
+	overdraft := signTx(t, aliceKey, "alice@bank", "bob@bank", stateApp.BaseInterpreter, 1, 5000, nil)
+	cancelled := signTx(t, aliceKey, "alice@bank", "bob@bank", stateApp.BaseInterpreter, 2, 1, nil)
+	tests := []struct {
+		name string
+		tx   *stateApp.Transaction
+		err  error
+	}{
+		{"past height", newScheduleTx(t, aliceKey, "alice@bank", 0, 1, transfer), errInvalidTargetHeight},
+		{"too far", newScheduleTx(t, aliceKey, "alice@bank", 0, 1+MaxScheduleBlocks+1, transfer), errInvalidTargetHeight},
+		{"other owner", newScheduleTx(t, bobKey, "bob@bank", 0, 5, transfer), errUnauthorized},
+		{"future nonce", newScheduleTx(t, aliceKey, "alice@bank", 0, 5, overdraft), errInvalidInnerTx},
+		{"nested", newScheduleTx(t, aliceKey, "alice@bank", 0, 5, newScheduleTx(t, aliceKey, "alice@bank", 0, 6, transfer)), errInvalidInnerTx},
+		{"transfer", newScheduleTx(t, aliceKey, "alice@bank", 0, 5, transfer), nil},
+		{"duplicate", newScheduleTx(t, aliceKey, "alice@bank", 1, 5, transfer), errScheduleExists},
+		{"overdraft", newScheduleTx(t, aliceKey, "alice@bank", 1, 5, overdraft), nil},
+		{"to cancel", newScheduleTx(t, aliceKey, "alice@bank", 2, 5, cancelled), nil},
+		{"cancel by other", newCancelTx(t, bobKey, "bob@bank", 0, cancelled.Hash()), errUnauthorized},
+		{"cancel missing", newCancelTx(t, aliceKey, "alice@bank", 3, types.Hash{1}), errScheduleNonExists},
+		{"cancel", newCancelTx(t, aliceKey, "alice@bank", 3, cancelled.Hash()), nil},
+		{"cancel again", newCancelTx(t, aliceKey, "alice@bank", 4, cancelled.Hash()), errScheduleNotPending},
+	}
+	ctx := testutil.NewCtx(t, state, 1, nil)
+	for _, tt := range tests {
+		if err := interpreter.VerifyTx(ctx, tt.tx); err != tt.err {
+			t.Fatalf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+		if tt.err != nil {
+			continue
+		}
+		var usedGas uint64
+		if _, err := interpreter.ApplyTransaction(ctx, tt.tx, &usedGas); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+	}
+	if ids := ScheduledAt(state, 5); len(ids) != 2 || ids[0] != transfer.Hash() || ids[1] != overdraft.Hash() {
+		t.Fatalf("scheduled at 5: %v", ids)
+	}
+
+	// 未到期的高度不执行
+	if receipts, _ := interpreter.BeginBlock(testutil.NewCtx(t, state, 4, nil), &models.Header{Height: 4}); len(receipts) != 0 {
+		t.Fatalf("receipts at 4: %d", len(receipts))
+	}
+
+	ctx = testutil.NewCtx(t, state, 5, nil)
+	receipts, err := interpreter.BeginBlock(ctx, &models.Header{Height: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(receipts) != 2 {
+		t.Fatalf("receipts: %d, want 2", len(receipts))
+	}
+	want := []struct {
+		tx       *stateApp.Transaction
+		receipt  uint64
+		schedule ScheduleStatus
+	}{
+		{transfer, statetype.ReceiptStatusSuccessful, StatusExecuted},
+		{overdraft, statetype.ReceiptStatusFailed, StatusFailed},
+	}
+	for i, w := range want {
+		if receipts[i].TransactionHash != w.tx.Hash() || receipts[i].Status != w.receipt {
+			t.Fatalf("receipt %d: %s status %d", i, receipts[i].TransactionHash.Hex(), receipts[i].Status)
+		}
+		if s := GetSchedule(state, w.tx.Hash()); s.Status != w.schedule {
+			t.Fatalf("schedule %d status %d, want %d", i, s.Status, w.schedule)
+		}
+		if tx := interpreter.HookTransaction(ctx, receipts[i]); tx == nil || tx.Hash() != w.tx.Hash() {
+			t.Fatalf("hook transaction %d: %v", i, tx)
+		}
+	}
+	if s := GetSchedule(state, cancelled.Hash()); s.Status != StatusCancelled {
+		t.Fatalf("cancelled status %d", s.Status)
+	}
+	if len(ScheduledAt(state, 5)) != 0 {
+		t.Fatal("schedules at 5 not cleared")
+	}
+	if balance := state.GetBalance("bob@bank"); balance.Int64() != 100 {
+		t.Fatalf("bob balance %v, want 100", balance)
+	}
+	// 执行内层交易后恢复账户的nonce
+	if nonce := state.GetNonce("alice@bank"); nonce != 4 {
+		t.Fatalf("alice nonce %d, want 4", nonce)
+	}
+}
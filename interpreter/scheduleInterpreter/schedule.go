@@ -0,0 +1,229 @@
+// Package scheduleInterpreter
+//
+// @author: xwc1125
+package scheduleInterpreter
+
+import (
+	"errors"
+	"github.com/chain5j/chain5j-pkg/codec"
+	"github.com/chain5j/chain5j-pkg/codec/rlp"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp"
+	"github.com/chain5j/chain5j-stateApp/interpreter/accountInterpreter"
+	"strconv"
+)
+
+var (
+	errInvalidSchedule     = errors.New("invalid scheduled transaction")
+	errInvalidInnerTx      = errors.New("invalid inner transaction")
+	errInvalidTargetHeight = errors.New("invalid schedule target height")
+	errScheduleExists      = errors.New("scheduled transaction already exists")
+	errScheduleNonExists   = errors.New("scheduled transaction not exists")
+	errScheduleNotPending  = errors.New("scheduled transaction is not pending")
+	errTooManySchedules    = errors.New("too many scheduled transactions at target height")
+	errUnauthorized        = errors.New("unauthorized")
+	errInvalidScheduleOp   = errors.New("invalid schedule operation")
+	errInvalidScheduleData = errors.New("invalid schedule operation input")
+)
+
+const (
+	MaxSchedulesPerHeight = 256
+	MaxScheduleBlocks     = 366 * accountInterpreter.BlocksPerDay // 最远可定时的区块数，约一年
+)
+
+// ScheduleOp 定时交易操作
+type ScheduleOp uint8
+
+const (
+	ScheduleTxOp ScheduleOp = iota // 提交定时交易
+	CancelOp                       // 取消定时交易
+)
+
+// ScheduleStatus 定时交易状态
+type ScheduleStatus uint8
+
+const (
+	StatusPending   ScheduleStatus = iota // 等待执行
+	StatusExecuted                        // 已执行成功
+	StatusFailed                          // 已执行失败
+	StatusCancelled                       // 已取消
+)
+
+// ScheduleOpData 定时交易的input
+type ScheduleOpData struct {
+	Operation ScheduleOp
+	Data      []byte
+}
+
+// ScheduleTxData 提交定时交易的数据对象
+// Tx为内层交易的rlp编码，必须由同一账户预先签名，其nonce不能大于外层交易的nonce，避免内层交易被直接提交执行
+type ScheduleTxData struct {
+	Height uint64
+	Tx     []byte
+}
+
+// CancelData 取消定时交易的数据对象，ID为内层交易的哈希
+type CancelData struct {
+	ID types.Hash
+}
+
+// Schedule 定时交易记录
+type Schedule struct {
+	ID     types.Hash     `json:"id"` // 内层交易哈希
+	Owner  string         `json:"owner"`
+	Height uint64         `json:"height"` // 执行高度
+	Tx     []byte         `json:"tx"`
+	Status ScheduleStatus `json:"status"`
+	Error  string         `json:"error,omitempty"` // 执行失败的原因
+}
+
+// DecodeTx 解析内层交易
+func DecodeTx(data []byte) (*stateApp.Transaction, error) {
+	tx := new(stateApp.Transaction)
+	if err := rlp.DecodeBytes(data, tx); err != nil {
+		return nil, errInvalidInnerTx
+	}
+	return tx, nil
+}
+
+func store(state *statedb.StateDB) *stateApp.SystemStore {
+	return stateApp.NewSystemStore(state, stateApp.ScheduleAddress)
+}
+
+func scheduleKey(id types.Hash) []byte {
+	return stateApp.SystemKey("schedule", id.Hex())
+}
+
+func heightKey(height uint64) []byte {
+	return stateApp.SystemKey("schedule_height", strconv.FormatUint(height, 10))
+}
+
+// GetSchedule 获取定时交易记录，不存在时返回nil
+func GetSchedule(state *statedb.StateDB, id types.Hash) *Schedule {
+	var schedule Schedule
+	ok, err := store(state).Get(scheduleKey(id), &schedule)
+	if !ok || err != nil {
+		return nil
+	}
+	return &schedule
+}
+
+func setSchedule(state *statedb.StateDB, schedule *Schedule) {
+	store(state).Set(scheduleKey(schedule.ID), schedule)
+}
+
+// ScheduledAt 指定高度的定时交易，按提交顺序排列
+func ScheduledAt(state *statedb.StateDB, height uint64) []types.Hash {
+	var ids []types.Hash
+	ok, err := store(state).Get(heightKey(height), &ids)
+	if !ok || err != nil {
+		return nil
+	}
+	return ids
+}
+
+func setScheduledAt(state *statedb.StateDB, height uint64, ids []types.Hash) {
+	if len(ids) == 0 {
+		store(state).Delete(heightKey(height))
+		return
+	}
+	store(state).Set(heightKey(height), ids)
+}
+
+func decodeOp(input []byte) (*ScheduleOpData, error) {
+	var op ScheduleOpData
+	if err := codec.Coder().Decode(input, &op); err != nil {
+		return nil, errInvalidScheduleData
+	}
+	return &op, nil
+}
+
+func verifySchedule(state *statedb.StateDB, owner string, nonce uint64, input []byte, height uint64) error {
+	var data ScheduleTxData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidScheduleData
+	}
+
+	if data.Height <= height || data.Height > height+MaxScheduleBlocks {
+		return errInvalidTargetHeight
+	}
+
+	inner, err := DecodeTx(data.Tx)
+	if err != nil {
+		return err
+	}
+	if inner.From() != owner {
+		return errUnauthorized
+	}
+	if inner.Interpreter() == stateApp.ScheduleInterpreter || inner.Interpreter() == stateApp.EthereumInterpreter {
+		return errInvalidInnerTx
+	}
+	// 内层交易的nonce在外层交易执行后即失效，无法再通过交易池执行
+	if inner.Nonce() > nonce {
+		return errInvalidInnerTx
+	}
+	if _, err := inner.Signer(); err != nil {
+		return stateApp.ErrInvalidSigner
+	}
+
+	if GetSchedule(state, inner.Hash()) != nil {
+		return errScheduleExists
+	}
+	if len(ScheduledAt(state, data.Height)) >= MaxSchedulesPerHeight {
+		return errTooManySchedules
+	}
+	return nil
+}
+
+func schedule(state *statedb.StateDB, owner string, input []byte) {
+	var data ScheduleTxData
+	codec.Coder().Decode(input, &data)
+	inner, _ := DecodeTx(data.Tx)
+
+	setSchedule(state, &Schedule{
+		ID:     inner.Hash(),
+		Owner:  owner,
+		Height: data.Height,
+		Tx:     data.Tx,
+		Status: StatusPending,
+	})
+	setScheduledAt(state, data.Height, append(ScheduledAt(state, data.Height), inner.Hash()))
+}
+
+func verifyCancel(state *statedb.StateDB, owner string, input []byte) error {
+	var data CancelData
+	if err := codec.Coder().Decode(input, &data); err != nil {
+		return errInvalidScheduleData
+	}
+
+	s := GetSchedule(state, data.ID)
+	if s == nil {
+		return errScheduleNonExists
+	}
+	if s.Owner != owner {
+		return errUnauthorized
+	}
+	if s.Status != StatusPending {
+		return errScheduleNotPending
+	}
+	return nil
+}
+
+func cancel(state *statedb.StateDB, input []byte) {
+	var data CancelData
+	codec.Coder().Decode(input, &data)
+
+	s := GetSchedule(state, data.ID)
+	s.Status = StatusCancelled
+	setSchedule(state, s)
+
+	ids := ScheduledAt(state, s.Height)
+	remain := make([]types.Hash, 0, len(ids))
+	for _, id := range ids {
+		if id != data.ID {
+			remain = append(remain, id)
+		}
+	}
+	setScheduledAt(state, s.Height, remain)
+}
@@ -0,0 +1,344 @@
+// Package statediff
+//
+// @author: xwc1125
+package statediff
+
+import (
+	"bytes"
+	"errors"
+	"github.com/chain5j/chain5j-pkg/codec/rlp"
+	"github.com/chain5j/chain5j-pkg/collection/trees/tree"
+	"github.com/chain5j/chain5j-pkg/database/kvstore"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-pkg/util/hexutil"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-protocol/pkg/database/basedb"
+	"github.com/chain5j/chain5j-protocol/pkg/database/ethStatedb/model"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+var (
+	errNotFound = errors.New("not found")
+
+	// 与statedb中状态根及域记录的存储格式一致
+	rootPrefix   = []byte("state-root-")
+	domainPrefix = "domains-"
+)
+
+// StateDiff 两个状态之间的差异
+type StateDiff struct {
+	Accounts []*AccountDiff `json:"accounts"`
+	Domains  []*DomainDiff  `json:"domains"`
+}
+
+// AccountDiff 账户的变更，账户新建时Pre为空，删除时Post为空
+type AccountDiff struct {
+	Account string         `json:"account"` // 账户名称，以太坊模式下为地址
+	Created bool           `json:"created,omitempty"`
+	Deleted bool           `json:"deleted,omitempty"`
+	Balance *BalanceDiff   `json:"balance,omitempty"`
+	Nonce   *NonceDiff     `json:"nonce,omitempty"`
+	Code    bool           `json:"code_changed,omitempty"`
+	Storage []*StorageDiff `json:"storage,omitempty"`
+	Pre     *AccountState  `json:"pre,omitempty"`  // 账户体系下变更前的账户信息
+	Post    *AccountState  `json:"post,omitempty"` // 账户体系下变更后的账户信息
+}
+
+// AccountState 账户信息，与accounts.AccountStore一致，使用默认的json编码
+type AccountState accounts.AccountStore
+
+type BalanceDiff struct {
+	From *hexutil.Big `json:"from"`
+	To   *hexutil.Big `json:"to"`
+}
+
+type NonceDiff struct {
+	From hexutil.Uint64 `json:"from"`
+	To   hexutil.Uint64 `json:"to"`
+}
+
+// StorageDiff 合约存储槽位的变更，槽位的原始key不可得时为其hash
+type StorageDiff struct {
+	Key  types.Hash `json:"key"`
+	From types.Hash `json:"from"`
+	To   types.Hash `json:"to"`
+}
+
+// DomainDiff 域记录的变更，域新建时Pre为空
+type DomainDiff struct {
+	Domain string                `json:"domain"`
+	Pre    *accounts.DomainStore `json:"pre,omitempty"`
+	Post   *accounts.DomainStore `json:"post,omitempty"`
+}
+
+// Diff 账户体系下两个状态之间的差异，db需包含两个状态已提交的全部数据
+func Diff(db kvstore.Database, preRoot, postRoot types.Hash) (*StateDiff, error) {
+	pre, err := ReadRoots(db, preRoot)
+	if err != nil {
+		return nil, err
+	}
+	post, err := ReadRoots(db, postRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	treeDB := basedb.NewDatabase(db)
+	diff := &StateDiff{
+		Accounts: make([]*AccountDiff, 0),
+		Domains:  make([]*DomainDiff, 0),
+	}
+
+	changes, err := diffLeaves(treeDB, pre.AccountRoot, post.AccountRoot)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		var preStore, postStore *accounts.AccountStore
+		if change.pre != nil {
+			if preStore, err = decodeAccount(change.pre); err != nil {
+				return nil, err
+			}
+		}
+		if change.post != nil {
+			if postStore, err = decodeAccount(change.post); err != nil {
+				return nil, err
+			}
+		}
+
+		account := &AccountDiff{
+			Account: string(change.key),
+			Created: preStore == nil,
+			Deleted: postStore == nil,
+			Pre:     (*AccountState)(preStore),
+			Post:    (*AccountState)(postStore),
+		}
+		var (
+			preBalance, postBalance   *big.Int
+			preNonce, postNonce       uint64
+			preCode, postCode         []byte
+			preStorage, postStorage   = types.EmptyRootHash, types.EmptyRootHash
+			preContract, postContract bool
+		)
+		if preStore != nil {
+			preBalance, preNonce = preStore.Balance, preStore.Nonce
+			if preContract = preStore.IsContract(); preContract {
+				preCode, preStorage = preStore.CodeHash(), preStore.StorageRoot()
+			}
+		}
+		if postStore != nil {
+			postBalance, postNonce = postStore.Balance, postStore.Nonce
+			if postContract = postStore.IsContract(); postContract {
+				postCode, postStorage = postStore.CodeHash(), postStore.StorageRoot()
+			}
+		}
+		account.Balance = balanceDiff(preBalance, postBalance)
+		account.Nonce = nonceDiff(preNonce, postNonce)
+		account.Code = !bytes.Equal(preCode, postCode)
+		if preContract || postContract {
+			if account.Storage, err = diffStorage(treeDB, preStorage, postStorage); err != nil {
+				return nil, err
+			}
+		}
+		diff.Accounts = append(diff.Accounts, account)
+	}
+
+	changes, err = diffLeaves(treeDB, pre.XRoot, post.XRoot)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		key := string(change.key)
+		if !strings.HasPrefix(key, domainPrefix) {
+			continue
+		}
+		domain := &DomainDiff{Domain: strings.TrimPrefix(key, domainPrefix)}
+		if change.pre != nil {
+			domain.Pre = new(accounts.DomainStore)
+			if err := rlp.DecodeBytes(change.pre, domain.Pre); err != nil {
+				return nil, err
+			}
+		}
+		if change.post != nil {
+			domain.Post = new(accounts.DomainStore)
+			if err := rlp.DecodeBytes(change.post, domain.Post); err != nil {
+				return nil, err
+			}
+		}
+		diff.Domains = append(diff.Domains, domain)
+	}
+	return diff, nil
+}
+
+// EthDiff 以太坊模式下两个状态之间的差异，db需包含两个状态已提交的全部数据
+func EthDiff(db basedb.Database, preRoot, postRoot types.Hash) (*StateDiff, error) {
+	diff := &StateDiff{
+		Accounts: make([]*AccountDiff, 0),
+		Domains:  make([]*DomainDiff, 0),
+	}
+	changes, err := diffLeaves(db, preRoot, postRoot)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		var preAccount, postAccount *model.Account
+		if change.pre != nil {
+			preAccount = new(model.Account)
+			if err := rlp.DecodeBytes(change.pre, preAccount); err != nil {
+				return nil, err
+			}
+		}
+		if change.post != nil {
+			postAccount = new(model.Account)
+			if err := rlp.DecodeBytes(change.post, postAccount); err != nil {
+				return nil, err
+			}
+		}
+
+		account := &AccountDiff{
+			Account: types.BytesToAddress(change.key).Hex(),
+			Created: preAccount == nil,
+			Deleted: postAccount == nil,
+		}
+		var (
+			preBalance, postBalance *big.Int
+			preNonce, postNonce     uint64
+			preCode, postCode       []byte
+			preStorage, postStorage = types.EmptyRootHash, types.EmptyRootHash
+		)
+		if preAccount != nil {
+			preBalance, preNonce, preCode, preStorage = preAccount.Balance, preAccount.Nonce, preAccount.CodeHash, preAccount.Root
+		}
+		if postAccount != nil {
+			postBalance, postNonce, postCode, postStorage = postAccount.Balance, postAccount.Nonce, postAccount.CodeHash, postAccount.Root
+		}
+		account.Balance = balanceDiff(preBalance, postBalance)
+		account.Nonce = nonceDiff(preNonce, postNonce)
+		account.Code = !bytes.Equal(preCode, postCode)
+		if account.Storage, err = diffStorage(db, preStorage, postStorage); err != nil {
+			return nil, err
+		}
+		diff.Accounts = append(diff.Accounts, account)
+	}
+	return diff, nil
+}
+
+func decodeAccount(enc []byte) (*accounts.AccountStore, error) {
+	store := new(accounts.AccountStore)
+	if err := rlp.DecodeBytes(enc, store); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func balanceDiff(pre, post *big.Int) *BalanceDiff {
+	if pre == nil {
+		pre = new(big.Int)
+	}
+	if post == nil {
+		post = new(big.Int)
+	}
+	if pre.Cmp(post) == 0 {
+		return nil
+	}
+	return &BalanceDiff{From: (*hexutil.Big)(pre), To: (*hexutil.Big)(post)}
+}
+
+func nonceDiff(pre, post uint64) *NonceDiff {
+	if pre == post {
+		return nil
+	}
+	return &NonceDiff{From: hexutil.Uint64(pre), To: hexutil.Uint64(post)}
+}
+
+func diffStorage(db basedb.Database, preRoot, postRoot types.Hash) ([]*StorageDiff, error) {
+	changes, err := diffLeaves(db, preRoot, postRoot)
+	if err != nil {
+		return nil, err
+	}
+	storage := make([]*StorageDiff, 0, len(changes))
+	for _, change := range changes {
+		slot := &StorageDiff{Key: types.BytesToHash(change.key)}
+		if slot.From, err = decodeSlot(change.pre); err != nil {
+			return nil, err
+		}
+		if slot.To, err = decodeSlot(change.post); err != nil {
+			return nil, err
+		}
+		storage = append(storage, slot)
+	}
+	return storage, nil
+}
+
+func decodeSlot(enc []byte) (types.Hash, error) {
+	if len(enc) == 0 {
+		return types.Hash{}, nil
+	}
+	_, content, _, err := rlp.Split(enc)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	return types.BytesToHash(content), nil
+}
+
+// leafChange 树中发生变更的叶子，新增时pre为空，删除时post为空
+type leafChange struct {
+	key       []byte
+	pre, post []byte
+}
+
+// diffLeaves 比较两棵树的叶子，按key排序返回变更，key的原像不可得时为其hash
+func diffLeaves(db basedb.Database, preRoot, postRoot types.Hash) ([]*leafChange, error) {
+	if preRoot == postRoot {
+		return nil, nil
+	}
+	preTree, err := db.OpenTree(preRoot)
+	if err != nil {
+		return nil, err
+	}
+	postTree, err := db.OpenTree(postRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]*leafChange)
+	collect := func(from, to basedb.Tree, post bool) error {
+		diffIt, _ := tree.NewDifferenceIterator(from.NodeIterator(nil), to.NodeIterator(nil))
+		it := tree.NewIterator(diffIt)
+		for it.Next() {
+			change, ok := changes[string(it.Key)]
+			if !ok {
+				key := to.GetKey(it.Key)
+				if len(key) == 0 {
+					key = it.Key
+				}
+				change = &leafChange{key: key}
+				changes[string(it.Key)] = change
+			}
+			value := append([]byte{}, it.Value...)
+			if post {
+				change.post = value
+			} else {
+				change.pre = value
+			}
+		}
+		return it.Err
+	}
+	if err := collect(preTree, postTree, true); err != nil {
+		return nil, err
+	}
+	if err := collect(postTree, preTree, false); err != nil {
+		return nil, err
+	}
+
+	// 修改的叶子在两个方向上均会出现，仅出现在一侧的为新增或删除
+	result := make([]*leafChange, 0, len(changes))
+	for _, change := range changes {
+		result = append(result, change)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return bytes.Compare(result[i].key, result[j].key) < 0
+	})
+	return result, nil
+}
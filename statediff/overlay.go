@@ -0,0 +1,210 @@
+// Package statediff
+//
+// @author: xwc1125
+package statediff
+
+import (
+	"bytes"
+	"github.com/chain5j/chain5j-pkg/database/kvstore"
+	"github.com/chain5j/chain5j-pkg/database/kvstore/memorydb"
+	"sync"
+)
+
+var _ kvstore.Database = (*OverlayDB)(nil)
+
+// OverlayDB 覆盖在底层数据库之上的临时数据库
+// 写入及删除仅保存在内存中，读取时优先读取内存，用于在不落盘的情况下提交临时状态
+// 底层数据库只读，迭代器合并内存中的写入及删除，Close及Compact不作用于底层数据库
+type OverlayDB struct {
+	base kvstore.Database
+
+	mu      sync.RWMutex
+	mem     *memorydb.Database
+	deleted map[string]struct{}
+}
+
+func NewOverlayDB(db kvstore.Database) *OverlayDB {
+	return &OverlayDB{
+		base:    db,
+		mem:     memorydb.New(),
+		deleted: make(map[string]struct{}),
+	}
+}
+
+func (db *OverlayDB) Has(key []byte) (bool, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if _, ok := db.deleted[string(key)]; ok {
+		return false, nil
+	}
+	if ok, _ := db.mem.Has(key); ok {
+		return true, nil
+	}
+	return db.base.Has(key)
+}
+
+func (db *OverlayDB) Get(key []byte) ([]byte, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if _, ok := db.deleted[string(key)]; ok {
+		return nil, errNotFound
+	}
+	if value, err := db.mem.Get(key); err == nil {
+		return value, nil
+	}
+	return db.base.Get(key)
+}
+
+func (db *OverlayDB) Put(key []byte, value []byte) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	delete(db.deleted, string(key))
+	return db.mem.Put(key, value)
+}
+
+func (db *OverlayDB) Delete(key []byte) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.deleted[string(key)] = struct{}{}
+	return db.mem.Delete(key)
+}
+
+// NewBatch 批量写入同样仅写入内存
+func (db *OverlayDB) NewBatch() kvstore.Batch {
+	return &overlayBatch{
+		Batch: memorydb.New().NewBatch(),
+		db:    db,
+	}
+}
+
+func (db *OverlayDB) NewIterator() kvstore.Iterator {
+	return db.NewIteratorWithStart(nil)
+}
+
+func (db *OverlayDB) NewIteratorWithStart(start []byte) kvstore.Iterator {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return newOverlayIterator(db.base.NewIteratorWithStart(start), db.mem.NewIteratorWithStart(start), db.deletedKeys())
+}
+
+func (db *OverlayDB) NewIteratorWithPrefix(prefix []byte) kvstore.Iterator {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return newOverlayIterator(db.base.NewIteratorWithPrefix(prefix), db.mem.NewIteratorWithPrefix(prefix), db.deletedKeys())
+}
+
+// deletedKeys 创建迭代器时已删除的key，调用方需持有读锁
+func (db *OverlayDB) deletedKeys() map[string]struct{} {
+	deleted := make(map[string]struct{}, len(db.deleted))
+	for key := range db.deleted {
+		deleted[key] = struct{}{}
+	}
+	return deleted
+}
+
+func (db *OverlayDB) Stat(property string) (string, error) {
+	return db.mem.Stat(property)
+}
+
+// Compact 内存中的数据无需压缩，也不压缩底层数据库
+func (db *OverlayDB) Compact(start []byte, limit []byte) error {
+	return nil
+}
+
+// Close 不关闭底层数据库
+func (db *OverlayDB) Close() error {
+	return nil
+}
+
+type overlayBatch struct {
+	kvstore.Batch
+	db *OverlayDB
+}
+
+func (b *overlayBatch) Write() error {
+	return b.Batch.Replay(b.db)
+}
+
+// overlayIterator 按key顺序合并底层数据库及内存的迭代器
+// 同一key以内存中的值为准，底层数据库中已删除的key被跳过
+type overlayIterator struct {
+	base, mem kvstore.Iterator
+	deleted   map[string]struct{}
+
+	baseOk, memOk bool
+	key, value    []byte
+}
+
+func newOverlayIterator(base, mem kvstore.Iterator, deleted map[string]struct{}) *overlayIterator {
+	it := &overlayIterator{base: base, mem: mem, deleted: deleted}
+	it.baseOk = it.nextBase()
+	it.memOk = mem.Next()
+	return it
+}
+
+// nextBase 移动到底层数据库中下一个未删除的key
+func (it *overlayIterator) nextBase() bool {
+	for it.base.Next() {
+		if _, ok := it.deleted[string(it.base.Key())]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (it *overlayIterator) Next() bool {
+	switch {
+	case !it.baseOk && !it.memOk:
+		it.key, it.value = nil, nil
+		return false
+	case !it.memOk:
+		it.key, it.value = copyBytes(it.base.Key()), copyBytes(it.base.Value())
+		it.baseOk = it.nextBase()
+	case !it.baseOk:
+		it.key, it.value = copyBytes(it.mem.Key()), copyBytes(it.mem.Value())
+		it.memOk = it.mem.Next()
+	default:
+		switch bytes.Compare(it.base.Key(), it.mem.Key()) {
+		case -1:
+			it.key, it.value = copyBytes(it.base.Key()), copyBytes(it.base.Value())
+			it.baseOk = it.nextBase()
+		case 0:
+			it.key, it.value = copyBytes(it.mem.Key()), copyBytes(it.mem.Value())
+			it.baseOk = it.nextBase()
+			it.memOk = it.mem.Next()
+		default:
+			it.key, it.value = copyBytes(it.mem.Key()), copyBytes(it.mem.Value())
+			it.memOk = it.mem.Next()
+		}
+	}
+	return true
+}
+
+func (it *overlayIterator) Error() error {
+	if err := it.base.Error(); err != nil {
+		return err
+	}
+	return it.mem.Error()
+}
+
+func (it *overlayIterator) Key() []byte {
+	return it.key
+}
+
+func (it *overlayIterator) Value() []byte {
+	return it.value
+}
+
+func (it *overlayIterator) Release() {
+	it.base.Release()
+	it.mem.Release()
+}
+
+// copyBytes 迭代器移动后原key及value的内容可能变化，需复制
+func copyBytes(b []byte) []byte {
+	return append([]byte(nil), b...)
+}
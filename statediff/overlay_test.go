@@ -0,0 +1,91 @@
+// Package statediff
+//
+// @author: xwc1125
+package statediff
+
+import (
+	"github.com/chain5j/chain5j-pkg/database/kvstore"
+	"github.com/chain5j/chain5j-pkg/database/kvstore/memorydb"
+	"testing"
+)
+
+// collect 遍历迭代器，并检查key按顺序返回
+func collect(t *testing.T, it kvstore.Iterator) map[string]string {
+	defer it.Release()
+	kvs := make(map[string]string)
+	var last string
+	for it.Next() {
+		key := string(it.Key())
+		if last != "" && key <= last {
+			t.Fatalf("iterator out of order: %s before %s", last, key)
+		}
+		last = key
+		kvs[key] = string(it.Value())
+	}
+	return kvs
+}
+
+func TestOverlayDB(t *testing.T) {
+	base := memorydb.New()
+	for _, key := range []string{"a1", "a2", "a3", "b1"} {
+		if err := base.Put([]byte(key), []byte("base-"+key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db := NewOverlayDB(base)
+	db.Put([]byte("a2"), []byte("mem-a2"))
+	db.Put([]byte("a4"), []byte("mem-a4"))
+	db.Put([]byte("a0"), []byte("mem-a0"))
+	db.Delete([]byte("a3"))
+	batch := db.NewBatch()
+	batch.Put([]byte("c1"), []byte("mem-c1"))
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		it   kvstore.Iterator
+		want map[string]string
+	}{
+		{"all", db.NewIterator(), map[string]string{"a0": "mem-a0", "a1": "base-a1", "a2": "mem-a2", "a4": "mem-a4", "b1": "base-b1", "c1": "mem-c1"}},
+		{"prefix", db.NewIteratorWithPrefix([]byte("a")), map[string]string{"a0": "mem-a0", "a1": "base-a1", "a2": "mem-a2", "a4": "mem-a4"}},
+		{"start", db.NewIteratorWithStart([]byte("a3")), map[string]string{"a4": "mem-a4", "b1": "base-b1", "c1": "mem-c1"}},
+	}
+	for _, tt := range tests {
+		got := collect(t, tt.it)
+		if len(got) != len(tt.want) {
+			t.Fatalf("%s: %v, want %v", tt.name, got, tt.want)
+		}
+		for k, v := range tt.want {
+			if got[k] != v {
+				t.Fatalf("%s: %s=%q, want %q", tt.name, k, got[k], v)
+			}
+		}
+	}
+
+	if _, err := db.Get([]byte("a3")); err == nil {
+		t.Fatal("deleted key readable")
+	}
+	if ok, _ := db.Has([]byte("a4")); !ok {
+		t.Fatal("pending key not found")
+	}
+
+	// 底层数据库不受写入、压缩及关闭的影响
+	if err := db.Compact(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"a1": "base-a1", "a2": "base-a2", "a3": "base-a3", "b1": "base-b1"}
+	got := collect(t, base.NewIterator())
+	if len(got) != len(want) {
+		t.Fatalf("base: %v", got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("base: %s=%q, want %q", k, got[k], v)
+		}
+	}
+}
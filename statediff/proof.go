@@ -0,0 +1,50 @@
+// Package statediff
+//
+// @author: xwc1125
+package statediff
+
+import (
+	"github.com/chain5j/chain5j-pkg/crypto/hashalg/sha3"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/pkg/database/basedb"
+)
+
+// Hash 账户体系的状态根，与statedb中的计算一致
+func (roots *StateRoots) Hash() types.Hash {
+	var enc []byte
+	enc = append(enc, roots.AccountRoot.Bytes()...)
+	enc = append(enc, roots.MapRoot.Bytes()...)
+	enc = append(enc, roots.KVSRoot.Bytes()...)
+	enc = append(enc, roots.XRoot.Bytes()...)
+	return types.BytesToHash(sha3.Keccak256(enc))
+}
+
+// DomainKey 域记录在扩展存储树中的key
+func DomainKey(domain string) []byte {
+	return []byte(domainPrefix + domain)
+}
+
+// Prove 生成树中key的默克尔证明，树以key的hash为路径，key不存在时为不存在的证明
+func Prove(db basedb.Database, root types.Hash, key []byte) ([][]byte, error) {
+	t, err := db.OpenTree(root)
+	if err != nil {
+		return nil, err
+	}
+	var proof proofList
+	if err := t.Prove(sha3.Keccak256(key), 0, &proof); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// proofList 按路径顺序收集证明的节点
+type proofList [][]byte
+
+func (n *proofList) Put(key []byte, value []byte) error {
+	*n = append(*n, value)
+	return nil
+}
+
+func (n *proofList) Delete(key []byte) error {
+	panic("not supported")
+}
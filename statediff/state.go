@@ -0,0 +1,92 @@
+// Package statediff
+//
+// @author: xwc1125
+package statediff
+
+import (
+	"github.com/chain5j/chain5j-pkg/codec/rlp"
+	"github.com/chain5j/chain5j-pkg/collection/trees/tree"
+	"github.com/chain5j/chain5j-pkg/database/kvstore"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-protocol/pkg/database/basedb"
+	"strings"
+)
+
+// StateRoots 账户体系的状态根，由账户树、地址映射树、kv树及扩展存储树的根组成
+type StateRoots struct {
+	AccountRoot types.Hash
+	MapRoot     types.Hash
+	KVSRoot     types.Hash
+	XRoot       types.Hash
+}
+
+// ReadRoots 读取账户体系状态根对应的各树的根，空状态时各树的根均为空
+func ReadRoots(db kvstore.Database, root types.Hash) (*StateRoots, error) {
+	roots := new(StateRoots)
+	if root == (types.Hash{}) || root == types.EmptyRootHash {
+		return roots, nil
+	}
+	enc, err := db.Get(append(append([]byte{}, rootPrefix...), root.Bytes()...))
+	if err != nil {
+		return nil, err
+	}
+	if err := rlp.DecodeBytes(enc, roots); err != nil {
+		return nil, err
+	}
+	return roots, nil
+}
+
+// ForEachLeaf 按key的hash顺序遍历树的叶子，key的原像不可得时为其hash，fn返回false时停止
+func ForEachLeaf(db basedb.Database, root types.Hash, fn func(key, value []byte) bool) error {
+	t, err := db.OpenTree(root)
+	if err != nil {
+		return err
+	}
+	it := tree.NewIterator(t.NodeIterator(nil))
+	for it.Next() {
+		key := t.GetKey(it.Key)
+		if len(key) == 0 {
+			key = it.Key
+		}
+		if !fn(key, it.Value) {
+			return nil
+		}
+	}
+	return it.Err
+}
+
+// ForEachDomain 遍历扩展存储树中的域记录，fn返回false时停止
+func ForEachDomain(db basedb.Database, xRoot types.Hash, fn func(domain string, store *accounts.DomainStore) bool) error {
+	var decodeErr error
+	err := ForEachLeaf(db, xRoot, func(key, value []byte) bool {
+		if !strings.HasPrefix(string(key), domainPrefix) {
+			return true
+		}
+		store := new(accounts.DomainStore)
+		if decodeErr = rlp.DecodeBytes(value, store); decodeErr != nil {
+			return false
+		}
+		return fn(strings.TrimPrefix(string(key), domainPrefix), store)
+	})
+	if decodeErr != nil {
+		return decodeErr
+	}
+	return err
+}
+
+// ForEachAccount 遍历账户树中的账户，fn返回false时停止
+func ForEachAccount(db basedb.Database, accountRoot types.Hash, fn func(store *accounts.AccountStore) bool) error {
+	var decodeErr error
+	err := ForEachLeaf(db, accountRoot, func(key, value []byte) bool {
+		var store *accounts.AccountStore
+		if store, decodeErr = decodeAccount(value); decodeErr != nil {
+			return false
+		}
+		return fn(store)
+	})
+	if decodeErr != nil {
+		return decodeErr
+	}
+	return err
+}
@@ -0,0 +1,206 @@
+// Package stateproof
+//
+// @author: xwc1125
+package stateproof
+
+import (
+	"fmt"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-pkg/util/hexutil"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"math/big"
+	"reflect"
+)
+
+// StorageProof 合约存储槽位的证明
+type StorageProof struct {
+	Key   types.Hash      `json:"key"`
+	Value types.Hash      `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// DomainProof 域记录的证明，Record为空时证明域不存在
+// Admin、ExpiryHeight及Frozen为生效的值，由RegistryProof中的DomainMeta及域冻结记录校验
+type DomainProof struct {
+	Domain       string                `json:"domain"`
+	Proof        []hexutil.Bytes       `json:"proof"`
+	Record       *accounts.DomainStore `json:"record,omitempty"`
+	Admin        string                `json:"admin"`
+	ExpiryHeight hexutil.Uint64        `json:"expiry_height"`
+	Frozen       bool                  `json:"frozen"`
+}
+
+// AccountProof apps_getProof的返回，Roots为空时为以太坊模式的证明
+// IsFrozen为账户树中的原始标记，Frozen为block_number时生效的冻结状态
+type AccountProof struct {
+	Account      string          `json:"account"`
+	BlockNumber  hexutil.Uint64  `json:"block_number"`
+	StateRoot    types.Hash      `json:"state_root"`
+	Roots        *Roots          `json:"roots,omitempty"`
+	AccountProof []hexutil.Bytes `json:"account_proof"`
+	Balance      *hexutil.Big    `json:"balance"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	IsFrozen     bool            `json:"is_frozen"`
+	Frozen       bool            `json:"frozen"`
+	CodeHash     types.Hash      `json:"code_hash"`
+	StorageHash  types.Hash      `json:"storage_hash"`
+	StorageProof []*StorageProof `json:"storage_proof"`
+	Domain       *DomainProof    `json:"domain,omitempty"`
+	Registry     *RegistryProof  `json:"registry,omitempty"`
+}
+
+// StateDomainProof apps_getDomainProof的返回
+type StateDomainProof struct {
+	BlockNumber hexutil.Uint64 `json:"block_number"`
+	StateRoot   types.Hash     `json:"state_root"`
+	Roots       *Roots         `json:"roots"`
+	*DomainProof
+	Registry *RegistryProof `json:"registry"`
+}
+
+// Verify 以可信的状态根及区块高度校验证明及其中声明的各字段，返回证明的账户，账户不存在时为nil
+// 状态根及高度需由调用方从可信的区块头中取得，不能使用证明中的state_root及block_number
+func (p *AccountProof) Verify(stateRoot types.Hash, height uint64) (*accounts.AccountStore, error) {
+	if uint64(p.BlockNumber) != height {
+		return nil, fmt.Errorf("%w: block_number", ErrValueMismatch)
+	}
+	if p.Roots == nil {
+		return nil, p.verifyEth(stateRoot)
+	}
+
+	store, err := VerifyAccount(stateRoot, p.Roots, p.Account, toBytes(p.AccountProof))
+	if err != nil {
+		return nil, err
+	}
+	var (
+		balance     = new(big.Int)
+		nonce       uint64
+		frozen      bool
+		codeHash    types.Hash
+		storageHash = types.EmptyRootHash
+	)
+	if store != nil {
+		balance, nonce, frozen = store.Balance, store.Nonce, store.IsFrozen
+		if store.IsContract() {
+			codeHash, storageHash = types.BytesToHash(store.CodeHash()), store.StorageRoot()
+		}
+	}
+	if err := p.checkFields(balance, nonce, codeHash, storageHash); err != nil {
+		return nil, err
+	}
+	if p.IsFrozen != frozen {
+		return nil, fmt.Errorf("%w: is_frozen", ErrValueMismatch)
+	}
+	if err := p.verifyStorage(); err != nil {
+		return nil, err
+	}
+
+	records, err := p.Registry.verify(stateRoot, p.Roots)
+	if err != nil {
+		return nil, err
+	}
+	if frozen, err = records.accountFrozen(store, height); err != nil {
+		return nil, err
+	}
+	if p.Frozen != frozen {
+		return nil, fmt.Errorf("%w: frozen", ErrValueMismatch)
+	}
+	if p.Domain != nil {
+		if err := verifyDomainProof(stateRoot, p.Roots, p.Domain, records, height); err != nil {
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+func (p *AccountProof) verifyEth(stateRoot types.Hash) error {
+	if !types.IsHexAddress(p.Account) {
+		return fmt.Errorf("%w: account %s", ErrValueMismatch, p.Account)
+	}
+	account, err := VerifyEthAccount(stateRoot, types.HexToAddress(p.Account), toBytes(p.AccountProof))
+	if err != nil {
+		return err
+	}
+	var (
+		balance     = new(big.Int)
+		nonce       uint64
+		codeHash    types.Hash
+		storageHash = types.EmptyRootHash
+	)
+	if account != nil {
+		balance, nonce = account.Balance, account.Nonce
+		codeHash, storageHash = types.BytesToHash(account.CodeHash), account.Root
+	}
+	if err := p.checkFields(balance, nonce, codeHash, storageHash); err != nil {
+		return err
+	}
+	return p.verifyStorage()
+}
+
+func (p *AccountProof) checkFields(balance *big.Int, nonce uint64, codeHash, storageHash types.Hash) error {
+	if balance == nil {
+		balance = new(big.Int)
+	}
+	switch {
+	case p.Balance == nil || (*big.Int)(p.Balance).Cmp(balance) != 0:
+		return fmt.Errorf("%w: balance", ErrValueMismatch)
+	case uint64(p.Nonce) != nonce:
+		return fmt.Errorf("%w: nonce", ErrValueMismatch)
+	case p.CodeHash != codeHash:
+		return fmt.Errorf("%w: code_hash", ErrValueMismatch)
+	case p.StorageHash != storageHash:
+		return fmt.Errorf("%w: storage_hash", ErrValueMismatch)
+	}
+	return nil
+}
+
+func (p *AccountProof) verifyStorage() error {
+	for _, slot := range p.StorageProof {
+		value, err := VerifyStorage(p.StorageHash, slot.Key, toBytes(slot.Proof))
+		if err != nil {
+			return err
+		}
+		if value != slot.Value {
+			return fmt.Errorf("%w: storage %s", ErrValueMismatch, slot.Key.Hex())
+		}
+	}
+	return nil
+}
+
+// Verify 以可信的状态根及区块高度校验域记录证明，返回证明的域记录，域不存在时为nil
+func (p *StateDomainProof) Verify(stateRoot types.Hash, height uint64) (*accounts.DomainStore, error) {
+	if uint64(p.BlockNumber) != height {
+		return nil, fmt.Errorf("%w: block_number", ErrValueMismatch)
+	}
+	if p.DomainProof == nil {
+		return nil, fmt.Errorf("%w: domain", ErrValueMismatch)
+	}
+	records, err := p.Registry.verify(stateRoot, p.Roots)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyDomainProof(stateRoot, p.Roots, p.DomainProof, records, height); err != nil {
+		return nil, err
+	}
+	return p.Record, nil
+}
+
+// verifyDomainProof 校验域记录及其生效的管理员、过期高度及冻结状态
+func verifyDomainProof(stateRoot types.Hash, roots *Roots, p *DomainProof, records registry, height uint64) error {
+	record, err := VerifyDomain(stateRoot, roots, p.Domain, toBytes(p.Proof))
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(record, p.Record) {
+		return fmt.Errorf("%w: domain %s", ErrValueMismatch, p.Domain)
+	}
+	return records.verifyDomain(p, height)
+}
+
+func toBytes(proof []hexutil.Bytes) [][]byte {
+	b := make([][]byte, len(proof))
+	for i := range proof {
+		b[i] = proof[i]
+	}
+	return b
+}
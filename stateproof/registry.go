@@ -0,0 +1,225 @@
+// Package stateproof
+//
+// @author: xwc1125
+package stateproof
+
+import (
+	"fmt"
+	"github.com/chain5j/chain5j-pkg/codec/rlp"
+	"github.com/chain5j/chain5j-pkg/crypto/hashalg/sha3"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-pkg/util/hexutil"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"math/big"
+	"strings"
+)
+
+// RegistryAccount 域注册系统账户，DomainMeta及冻结记录存储在该账户的storage中
+const RegistryAccount = "0x000000000000000000000000000000000000c501" + accounts.DomainLinkFlag + accounts.ContractDomain
+
+// DomainMeta 域的扩展信息，与节点中的编码一致
+type DomainMeta struct {
+	Admin        string
+	Permissions  *accounts.Permissions `rlp:"nil"`
+	PendingAdmin string
+	FormerAdmins []string
+	ExpiryHeight uint64 // 过期高度，为0表示不过期
+}
+
+// FreezeRecord 账户或域的冻结记录，与节点中的编码一致
+type FreezeRecord struct {
+	Reason         uint32
+	Reference      string
+	Operator       string
+	Height         uint64
+	UnfreezeHeight uint64
+}
+
+// Active 冻结在指定高度是否仍然生效
+func (r *FreezeRecord) Active(height uint64) bool {
+	return r.UnfreezeHeight == 0 || height < r.UnfreezeHeight
+}
+
+// SystemRecordProof 系统账户中一条记录的证明，Slots依次为长度槽位及各数据槽位
+type SystemRecordProof struct {
+	Key   string          `json:"key"`
+	Slots []*StorageProof `json:"slots"`
+}
+
+// RegistryProof 域注册系统账户及其中记录的证明
+type RegistryProof struct {
+	Account      string               `json:"account"`
+	AccountProof []hexutil.Bytes      `json:"account_proof"`
+	StorageHash  types.Hash           `json:"storage_hash"`
+	Records      []*SystemRecordProof `json:"records"`
+}
+
+// registry 校验后的系统账户记录，值为rlp编码的数据，记录不存在时为nil
+type registry map[string][]byte
+
+// verify 以可信的状态根校验系统账户及其中的各条记录
+func (p *RegistryProof) verify(stateRoot types.Hash, roots *Roots) (registry, error) {
+	if p == nil {
+		return nil, fmt.Errorf("%w: registry", ErrMissingRecord)
+	}
+	if p.Account != RegistryAccount {
+		return nil, fmt.Errorf("%w: registry account %s", ErrValueMismatch, p.Account)
+	}
+	store, err := VerifyAccount(stateRoot, roots, RegistryAccount, toBytes(p.AccountProof))
+	if err != nil {
+		return nil, err
+	}
+	storageHash := types.EmptyRootHash
+	if store != nil {
+		storageHash = store.StorageRoot()
+	}
+	if p.StorageHash != storageHash {
+		return nil, fmt.Errorf("%w: registry storage_hash", ErrValueMismatch)
+	}
+	records := make(registry, len(p.Records))
+	for _, record := range p.Records {
+		data, err := VerifyRecord(storageHash, []byte(record.Key), record.Slots)
+		if err != nil {
+			return nil, err
+		}
+		records[record.Key] = data
+	}
+	return records, nil
+}
+
+// get 解码key对应的记录，记录不存在时返回false，未提供证明时返回ErrMissingRecord
+func (r registry) get(key string, val interface{}) (bool, error) {
+	data, ok := r[key]
+	if !ok {
+		return false, fmt.Errorf("%w: %s", ErrMissingRecord, key)
+	}
+	if len(data) == 0 {
+		return false, nil
+	}
+	return true, rlp.DecodeBytes(data, val)
+}
+
+func (r registry) domainMeta(domain string) (*DomainMeta, error) {
+	var meta DomainMeta
+	if ok, err := r.get(systemKey("domain", domain), &meta); !ok || err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+func (r registry) freezeRecord(key string) (*FreezeRecord, error) {
+	var record FreezeRecord
+	if ok, err := r.get(key, &record); !ok || err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// accountFrozen 账户在指定高度生效的冻结状态，已到达解冻高度的冻结记录视为已解冻
+func (r registry) accountFrozen(store *accounts.AccountStore, height uint64) (bool, error) {
+	if store == nil || !store.IsFrozen {
+		return false, nil
+	}
+	record, err := r.freezeRecord(systemKey("freeze", store.AccountName()))
+	if err != nil || record == nil {
+		return true, err
+	}
+	return record.Active(height), nil
+}
+
+// verifyDomain 校验域证明中声明的生效值：当前管理员、顶级域的过期高度及域链上的冻结
+func (r registry) verifyDomain(p *DomainProof, height uint64) error {
+	meta, err := r.domainMeta(p.Domain)
+	if err != nil {
+		return err
+	}
+	admin := ""
+	if p.Record != nil {
+		admin = p.Record.Admin
+	}
+	if meta != nil && meta.Admin != "" {
+		admin = meta.Admin
+	}
+
+	top := p.Domain
+	if i := strings.LastIndex(top, "."); i >= 0 {
+		top = top[i+1:]
+	}
+	var expiry uint64
+	if top != p.Domain {
+		meta, err = r.domainMeta(top)
+		if err != nil {
+			return err
+		}
+	}
+	if meta != nil {
+		expiry = meta.ExpiryHeight
+	}
+
+	frozen := false
+	labels := strings.Split(p.Domain, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		record, err := r.freezeRecord(systemKey("domain_freeze", strings.Join(labels[i:], ".")))
+		if err != nil {
+			return err
+		}
+		if record != nil && record.Active(height) {
+			frozen = true
+		}
+	}
+
+	switch {
+	case p.Admin != admin:
+		return fmt.Errorf("%w: domain %s admin", ErrValueMismatch, p.Domain)
+	case uint64(p.ExpiryHeight) != expiry:
+		return fmt.Errorf("%w: domain %s expiry_height", ErrValueMismatch, p.Domain)
+	case p.Frozen != frozen:
+		return fmt.Errorf("%w: domain %s frozen", ErrValueMismatch, p.Domain)
+	}
+	return nil
+}
+
+// VerifyRecord 校验系统账户中一条记录的证明，storageRoot为系统账户的存储根，返回rlp编码的数据，记录不存在时为nil
+func VerifyRecord(storageRoot types.Hash, key []byte, slots []*StorageProof) ([]byte, error) {
+	head := types.BytesToHash(sha3.Keccak256(key))
+	if len(slots) == 0 || slots[0].Key != head {
+		return nil, fmt.Errorf("%w: record %s head slot", ErrValueMismatch, key)
+	}
+	for i, slot := range slots {
+		if i > 0 && slot.Key != dataSlot(head, uint64(i-1)) {
+			return nil, fmt.Errorf("%w: record %s slot %d", ErrValueMismatch, key, i)
+		}
+		value, err := VerifyStorage(storageRoot, slot.Key, toBytes(slot.Proof))
+		if err != nil {
+			return nil, err
+		}
+		if value != slot.Value {
+			return nil, fmt.Errorf("%w: storage %s", ErrValueMismatch, slot.Key.Hex())
+		}
+	}
+
+	// 数据槽位的数量需与长度一致
+	size, chunks := slots[0].Value.Big(), uint64(len(slots)-1)
+	if !size.IsUint64() || size.Uint64() > chunks*types.HashLength || (chunks > 0 && size.Uint64() <= (chunks-1)*types.HashLength) {
+		return nil, fmt.Errorf("%w: record %s length", ErrValueMismatch, key)
+	}
+	if chunks == 0 {
+		return nil, nil
+	}
+	data := make([]byte, 0, chunks*types.HashLength)
+	for _, slot := range slots[1:] {
+		data = append(data, slot.Value[:]...)
+	}
+	return data[:size.Uint64()], nil
+}
+
+// dataSlot 第i段数据所在槽位
+func dataSlot(head types.Hash, i uint64) types.Hash {
+	base := new(big.Int).SetBytes(sha3.Keccak256(head[:]))
+	return types.BigToHash(base.Add(base, new(big.Int).SetUint64(i)))
+}
+
+// systemKey 系统账户中记录的key
+func systemKey(prefix, field string) string {
+	return prefix + "/" + field
+}
@@ -0,0 +1,144 @@
+// Package stateproof 离线校验apps_getProof及apps_getDomainProof返回的默克尔证明
+// 仅依赖树及编码的基础库，不依赖节点及状态数据库
+//
+// @author: xwc1125
+package stateproof
+
+import (
+	"errors"
+	"fmt"
+	"github.com/chain5j/chain5j-pkg/codec"
+	"github.com/chain5j/chain5j-pkg/codec/rlp"
+	"github.com/chain5j/chain5j-pkg/collection/trees/tree"
+	"github.com/chain5j/chain5j-pkg/crypto/hashalg/sha3"
+	"github.com/chain5j/chain5j-pkg/database/kvstore/memorydb"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"math/big"
+)
+
+var (
+	ErrRootMismatch  = errors.New("roots do not match the state root")
+	ErrValueMismatch = errors.New("proven value does not match")
+	ErrNoStateRoot   = errors.New("state roots have no STATE entry")
+	ErrMissingRecord = errors.New("registry record is not proven")
+)
+
+// domainPrefix 域记录在扩展存储树中的key前缀
+const domainPrefix = "domains-"
+
+// Roots 账户体系下组成状态根的各树的根
+type Roots struct {
+	AccountRoot types.Hash `json:"account_root"`
+	MapRoot     types.Hash `json:"map_root"`
+	KVSRoot     types.Hash `json:"kvs_root"`
+	XRoot       types.Hash `json:"x_root"`
+}
+
+// Hash 状态根，为四个根拼接后的keccak256
+func (r *Roots) Hash() types.Hash {
+	var enc []byte
+	enc = append(enc, r.AccountRoot.Bytes()...)
+	enc = append(enc, r.MapRoot.Bytes()...)
+	enc = append(enc, r.KVSRoot.Bytes()...)
+	enc = append(enc, r.XRoot.Bytes()...)
+	return types.BytesToHash(sha3.Keccak256(enc))
+}
+
+// StateRoot 从区块头的StateRoots中取出STATE的值
+func StateRoot(stateRoots []byte) (types.Hash, error) {
+	roots := statetype.NewRoots()
+	if err := codec.Coder().Decode(stateRoots, roots); err != nil {
+		return types.Hash{}, err
+	}
+	root := roots.GetObj("STATE")
+	if root == (types.Hash{}) {
+		return types.Hash{}, ErrNoStateRoot
+	}
+	return root, nil
+}
+
+// VerifyAccount 校验账户体系下的账户证明，账户不存在时返回nil
+func VerifyAccount(stateRoot types.Hash, roots *Roots, account string, proof [][]byte) (*accounts.AccountStore, error) {
+	if roots == nil || roots.Hash() != stateRoot {
+		return nil, ErrRootMismatch
+	}
+	value, err := verify(roots.AccountRoot, []byte(account), proof)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	store := new(accounts.AccountStore)
+	if err := rlp.DecodeBytes(value, store); err != nil {
+		return nil, err
+	}
+	if store.AccountName() != account {
+		return nil, fmt.Errorf("%w: account %s", ErrValueMismatch, store.AccountName())
+	}
+	return store, nil
+}
+
+// VerifyDomain 校验账户体系下的域记录证明，域不存在时返回nil
+func VerifyDomain(stateRoot types.Hash, roots *Roots, domain string, proof [][]byte) (*accounts.DomainStore, error) {
+	if roots == nil || roots.Hash() != stateRoot {
+		return nil, ErrRootMismatch
+	}
+	value, err := verify(roots.XRoot, []byte(domainPrefix+domain), proof)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	store := new(accounts.DomainStore)
+	if err := rlp.DecodeBytes(value, store); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// VerifyStorage 校验合约存储槽位的证明，storageRoot为账户证明中的存储根，槽位不存在时返回零值
+func VerifyStorage(storageRoot, key types.Hash, proof [][]byte) (types.Hash, error) {
+	value, err := verify(storageRoot, key.Bytes(), proof)
+	if err != nil || value == nil {
+		return types.Hash{}, err
+	}
+	_, content, _, err := rlp.Split(value)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	return types.BytesToHash(content), nil
+}
+
+// EthAccount 以太坊模式下状态树中的账户
+type EthAccount struct {
+	Nonce    uint64
+	Balance  *big.Int
+	Root     types.Hash
+	CodeHash []byte
+}
+
+// VerifyEthAccount 校验以太坊模式下的账户证明，账户证明直接以状态根为根，账户不存在时返回nil
+func VerifyEthAccount(stateRoot types.Hash, addr types.Address, proof [][]byte) (*EthAccount, error) {
+	value, err := verify(stateRoot, addr.Bytes(), proof)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	account := new(EthAccount)
+	if err := rlp.DecodeBytes(value, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// verify 校验以key的hash为路径的证明，返回证明的值，key不存在时为nil
+func verify(root types.Hash, key []byte, proof [][]byte) ([]byte, error) {
+	if len(proof) == 0 && (root == types.EmptyRootHash || root == types.Hash{}) {
+		return nil, nil
+	}
+	db := memorydb.New()
+	for _, node := range proof {
+		if err := db.Put(sha3.Keccak256(node), node); err != nil {
+			return nil, err
+		}
+	}
+	value, _, err := tree.VerifyProof(root, sha3.Keccak256(key), db)
+	return value, err
+}
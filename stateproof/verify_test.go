@@ -0,0 +1,275 @@
+// Package stateproof
+//
+// @author: xwc1125
+package stateproof
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-pkg/util/hexutil"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+)
+
+// testdata下的向量由apps_getProof及apps_getDomainProof生成，state_roots及height为区块头中的StateRoots及高度
+type vector struct {
+	StateRoots hexutil.Bytes   `json:"state_roots"`
+	Height     hexutil.Uint64  `json:"height"`
+	Proof      json.RawMessage `json:"proof"`
+}
+
+func loadVector(t *testing.T, name string, proof interface{}) (types.Hash, uint64) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v vector
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(v.Proof, proof); err != nil {
+		t.Fatal(err)
+	}
+	root, err := StateRoot(v.StateRoots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root, uint64(v.Height)
+}
+
+func TestAccountProof_Verify(t *testing.T) {
+	tests := []struct {
+		name    string
+		exists  bool
+		balance int64
+		frozen  bool
+	}{
+		{"account", true, 250, false},
+		{"account_frozen", true, 40, true},
+		{"account_absent", false, 0, false},
+		{"contract_storage", true, 0, false},
+	}
+	for _, tt := range tests {
+		var proof AccountProof
+		root, height := loadVector(t, tt.name, &proof)
+		store, err := proof.Verify(root, height)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if (store != nil) != tt.exists {
+			t.Fatalf("%s: exists %v, want %v", tt.name, store != nil, tt.exists)
+		}
+		if store == nil {
+			continue
+		}
+		if store.AccountName() != proof.Account || store.Balance.Int64() != tt.balance || store.IsFrozen != tt.frozen {
+			t.Fatalf("%s: decoded %s balance %v frozen %v", tt.name, store.AccountName(), store.Balance, store.IsFrozen)
+		}
+	}
+}
+
+func TestAccountProof_Storage(t *testing.T) {
+	var proof AccountProof
+	root, height := loadVector(t, "contract_storage", &proof)
+	if len(proof.StorageProof) != 3 {
+		t.Fatalf("storage proofs %d, want 3", len(proof.StorageProof))
+	}
+	want := []types.Hash{{31: 0x2a}, {31: 0x07}, {}}
+	for i, slot := range proof.StorageProof {
+		value, err := VerifyStorage(proof.StorageHash, slot.Key, toBytes(slot.Proof))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != want[i] {
+			t.Fatalf("slot %s: %s, want %s", slot.Key.Hex(), value.Hex(), want[i].Hex())
+		}
+	}
+
+	proof.StorageProof[0].Value = types.Hash{31: 0x2b}
+	if _, err := proof.Verify(root, height); !errors.Is(err, ErrValueMismatch) {
+		t.Fatalf("tampered storage: %v", err)
+	}
+}
+
+func TestAccountProof_Tampered(t *testing.T) {
+	var proof AccountProof
+	root, height := loadVector(t, "account", &proof)
+
+	proof.Balance = (*hexutil.Big)(big.NewInt(251))
+	if _, err := proof.Verify(root, height); !errors.Is(err, ErrValueMismatch) {
+		t.Fatalf("tampered balance: %v", err)
+	}
+
+	loadVector(t, "account", &proof)
+	proof.Roots.AccountRoot[0] ^= 1
+	if _, err := proof.Verify(root, height); !errors.Is(err, ErrRootMismatch) {
+		t.Fatalf("tampered roots: %v", err)
+	}
+
+	loadVector(t, "account", &proof)
+	proof.Domain.Record.Number++
+	if _, err := proof.Verify(root, height); !errors.Is(err, ErrValueMismatch) {
+		t.Fatalf("tampered domain: %v", err)
+	}
+
+	loadVector(t, "account", &proof)
+	if _, err := proof.Verify(types.Hash{1}, height); !errors.Is(err, ErrRootMismatch) {
+		t.Fatalf("wrong state root: %v", err)
+	}
+
+	// 用其他账户的证明冒充
+	var other AccountProof
+	loadVector(t, "account_frozen", &other)
+	other.Account = proof.Account
+	if _, err := other.Verify(root, height); err == nil {
+		t.Fatal("proof of bob@bank accepted for alice@bank")
+	}
+}
+
+func TestStateDomainProof_Verify(t *testing.T) {
+	var proof StateDomainProof
+	root, height := loadVector(t, "domain", &proof)
+	record, err := proof.Verify(root, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record == nil || record.Admin != "admin" {
+		t.Fatalf("domain record %+v", record)
+	}
+
+	var absent StateDomainProof
+	root, height = loadVector(t, "domain_absent", &absent)
+	if record, err := absent.Verify(root, height); err != nil || record != nil {
+		t.Fatalf("absent domain: %+v %v", record, err)
+	}
+
+	// 声明存在的记录无法通过不存在的证明
+	absent.Record = proof.Record
+	if _, err := absent.Verify(root, height); !errors.Is(err, ErrValueMismatch) {
+		t.Fatalf("forged domain: %v", err)
+	}
+}
+
+func TestAccountProof_VerifyEth(t *testing.T) {
+	var proof AccountProof
+	root, height := loadVector(t, "eth_account", &proof)
+	if _, err := proof.Verify(root, height); err != nil {
+		t.Fatal(err)
+	}
+	account, err := VerifyEthAccount(root, types.HexToAddress(proof.Account), toBytes(proof.AccountProof))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account == nil || account.Balance.Int64() != 5000 || account.Nonce != 2 {
+		t.Fatalf("eth account %+v", account)
+	}
+	proof.Nonce++
+	if _, err := proof.Verify(root, height); !errors.Is(err, ErrValueMismatch) {
+		t.Fatalf("tampered nonce: %v", err)
+	}
+
+	var contract AccountProof
+	root, height = loadVector(t, "eth_contract_storage", &contract)
+	if _, err := contract.Verify(root, height); err != nil {
+		t.Fatal(err)
+	}
+	if contract.StorageProof[0].Value != (types.Hash{31: 0x63}) {
+		t.Fatalf("eth storage %s", contract.StorageProof[0].Value.Hex())
+	}
+
+	var absent AccountProof
+	root, height = loadVector(t, "eth_account_absent", &absent)
+	if _, err := absent.Verify(root, height); err != nil {
+		t.Fatal(err)
+	}
+	absent.Balance = (*hexutil.Big)(big.NewInt(1))
+	if _, err := absent.Verify(root, height); !errors.Is(err, ErrValueMismatch) {
+		t.Fatalf("tampered absent balance: %v", err)
+	}
+}
+
+func TestRegistryProof_Verify(t *testing.T) {
+	tests := []struct {
+		name     string
+		isFrozen bool
+		frozen   bool
+	}{
+		{"account_registry", true, true},
+		{"account_unfrozen", true, false},
+	}
+	for _, tt := range tests {
+		var proof AccountProof
+		root, height := loadVector(t, tt.name, &proof)
+		store, err := proof.Verify(root, height)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if store.IsFrozen != tt.isFrozen || proof.Frozen != tt.frozen {
+			t.Fatalf("%s: is_frozen %v frozen %v", tt.name, store.IsFrozen, proof.Frozen)
+		}
+		if proof.Domain.Record.Admin != "admin" || proof.Domain.Admin != "alice" || proof.Domain.ExpiryHeight != 100 {
+			t.Fatalf("%s: domain %+v", tt.name, proof.Domain)
+		}
+
+		proof.Frozen = !proof.Frozen
+		if _, err := proof.Verify(root, height); !errors.Is(err, ErrValueMismatch) {
+			t.Fatalf("%s: tampered frozen: %v", tt.name, err)
+		}
+		proof.Frozen = tt.frozen
+		// 生效状态与高度相关，只能以证明对应区块头中的高度校验
+		if _, err := proof.Verify(root, height-6); !errors.Is(err, ErrValueMismatch) {
+			t.Fatalf("%s: wrong height: %v", tt.name, err)
+		}
+	}
+
+	var proof AccountProof
+	root, height := loadVector(t, "account_registry", &proof)
+	proof.Domain.Admin = proof.Domain.Record.Admin
+	if _, err := proof.Verify(root, height); !errors.Is(err, ErrValueMismatch) {
+		t.Fatalf("stale admin: %v", err)
+	}
+
+	loadVector(t, "account_registry", &proof)
+	proof.Registry = nil
+	if _, err := proof.Verify(root, height); !errors.Is(err, ErrMissingRecord) {
+		t.Fatalf("missing registry: %v", err)
+	}
+
+	// 篡改记录的数据槽位
+	loadVector(t, "account_registry", &proof)
+	proof.Registry.Records[0].Slots[1].Value[0] ^= 1
+	if _, err := proof.Verify(root, height); !errors.Is(err, ErrValueMismatch) {
+		t.Fatalf("tampered record: %v", err)
+	}
+
+	// 以空记录的证明隐藏冻结记录
+	loadVector(t, "account_registry", &proof)
+	proof.Registry.Records[0].Slots = proof.Registry.Records[0].Slots[:1]
+	if _, err := proof.Verify(root, height); !errors.Is(err, ErrValueMismatch) {
+		t.Fatalf("truncated record: %v", err)
+	}
+}
+
+func TestStateDomainProof_Frozen(t *testing.T) {
+	var proof StateDomainProof
+	root, height := loadVector(t, "domain_frozen", &proof)
+	record, err := proof.Verify(root, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Admin != "ops" || proof.Admin != "ops" || proof.ExpiryHeight != 100 || !proof.Frozen {
+		t.Fatalf("domain %+v", proof.DomainProof)
+	}
+	proof.Frozen = false
+	if _, err := proof.Verify(root, height); !errors.Is(err, ErrValueMismatch) {
+		t.Fatalf("tampered frozen: %v", err)
+	}
+	proof.Frozen = true
+	proof.ExpiryHeight = 0
+	if _, err := proof.Verify(root, height); !errors.Is(err, ErrValueMismatch) {
+		t.Fatalf("tampered expiry: %v", err)
+	}
+}
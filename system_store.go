@@ -0,0 +1,159 @@
+// Package stateApp
+//
+// @author: xwc1125
+package stateApp
+
+import (
+	"github.com/chain5j/chain5j-pkg/codec/rlp"
+	"github.com/chain5j/chain5j-pkg/crypto/hashalg/sha3"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models/accounts"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"math/big"
+	"strings"
+)
+
+// 系统账户地址，扩展状态存储在这些保留合约账户的storage中
+var (
+	DomainRegistryAddress = types.HexToAddress("0x000000000000000000000000000000000000c501")
+	EscrowAddress         = types.HexToAddress("0x000000000000000000000000000000000000c502")
+	ScheduleAddress       = types.HexToAddress("0x000000000000000000000000000000000000c503")
+	DeployerAddress       = types.HexToAddress("0x000000000000000000000000000000000000c504") // 以太坊模式下的合约部署白名单
+)
+
+// SystemAccountName 保留地址对应的系统账户名称
+func SystemAccountName(addr types.Address) string {
+	return strings.ToLower(addr.Hex()) + accounts.DomainLinkFlag + accounts.ContractDomain
+}
+
+// SystemStore 系统账户存储
+// 数据以rlp编码后按槽位写入保留合约账户的storage，随stateDB的快照回滚，并计入stateRoot
+type SystemStore struct {
+	db      *statedb.StateDB
+	addr    types.Address
+	account string
+}
+
+func NewSystemStore(db *statedb.StateDB, addr types.Address) *SystemStore {
+	return &SystemStore{
+		db:      db,
+		addr:    addr,
+		account: SystemAccountName(addr),
+	}
+}
+
+// Get 读取key对应的数据，不存在时返回false
+func (s *SystemStore) Get(key []byte, val interface{}) (bool, error) {
+	data := s.GetBytes(key)
+	if len(data) == 0 {
+		return false, nil
+	}
+	if err := rlp.DecodeBytes(data, val); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Set 写入key对应的数据
+func (s *SystemStore) Set(key []byte, val interface{}) error {
+	data, err := rlp.EncodeToBytes(val)
+	if err != nil {
+		return err
+	}
+	s.SetBytes(key, data)
+	return nil
+}
+
+// Has key是否存在
+func (s *SystemStore) Has(key []byte) bool {
+	return s.length(headSlot(key)) > 0
+}
+
+// Delete 删除key对应的数据
+func (s *SystemStore) Delete(key []byte) {
+	s.SetBytes(key, nil)
+}
+
+// GetBytes 读取key对应的原始数据
+func (s *SystemStore) GetBytes(key []byte) []byte {
+	head := headSlot(key)
+	size := s.length(head)
+	if size == 0 {
+		return nil
+	}
+
+	data := make([]byte, 0, size)
+	for i := uint64(0); uint64(len(data)) < size; i++ {
+		chunk := s.db.GetState(s.account, dataSlot(head, i))
+		data = append(data, chunk[:]...)
+	}
+	return data[:size]
+}
+
+// SetBytes 写入key对应的原始数据，value为空时删除
+func (s *SystemStore) SetBytes(key []byte, value []byte) {
+	s.ensureAccount()
+
+	head := headSlot(key)
+	prevSize := s.length(head)
+
+	var i uint64
+	for ; i*types.HashLength < uint64(len(value)); i++ {
+		var chunk types.Hash
+		copy(chunk[:], value[i*types.HashLength:])
+		s.db.SetState(s.account, dataSlot(head, i), chunk)
+	}
+	// 清理旧数据多余的槽位
+	for ; i*types.HashLength < prevSize; i++ {
+		s.db.SetState(s.account, dataSlot(head, i), types.Hash{})
+	}
+
+	s.db.SetState(s.account, head, types.BigToHash(new(big.Int).SetUint64(uint64(len(value)))))
+}
+
+// Slots key对应数据所在的槽位，依次为长度槽位及各数据槽位，用于状态证明
+func (s *SystemStore) Slots(key []byte) []types.Hash {
+	head := headSlot(key)
+	size := s.length(head)
+	slots := []types.Hash{head}
+	for i := uint64(0); i*types.HashLength < size; i++ {
+		slots = append(slots, dataSlot(head, i))
+	}
+	return slots
+}
+
+func (s *SystemStore) length(head types.Hash) uint64 {
+	return s.db.GetState(s.account, head).Big().Uint64()
+}
+
+// ensureAccount 系统账户不存在时创建
+func (s *SystemStore) ensureAccount() {
+	if s.db.Exist(s.account) {
+		return
+	}
+
+	store := accounts.NewAccountStore(s.addr.Hex(), accounts.ContractDomain)
+	store.SetAddress(s.addr, &accounts.AddressStore{})
+	s.db.CreateAccount(store)
+}
+
+// headSlot 数据长度所在槽位
+func headSlot(key []byte) types.Hash {
+	return types.BytesToHash(sha3.Keccak256(key))
+}
+
+// dataSlot 第i段数据所在槽位
+func dataSlot(head types.Hash, i uint64) types.Hash {
+	base := new(big.Int).SetBytes(sha3.Keccak256(head[:]))
+	return types.BigToHash(base.Add(base, new(big.Int).SetUint64(i)))
+}
+
+// SystemKey 以前缀及字段拼接存储key
+func SystemKey(prefix string, fields ...string) []byte {
+	key := []byte(prefix)
+	for _, f := range fields {
+		key = append(key, '/')
+		key = append(key, f...)
+	}
+	return key
+}
@@ -0,0 +1,66 @@
+// Package stateApp
+//
+// @author: xwc1125
+package stateApp_test
+
+import (
+	"bytes"
+	"github.com/chain5j/chain5j-pkg/database/kvstore/memorydb"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/pkg/database/statedb"
+	"github.com/chain5j/chain5j-stateApp"
+	_ "github.com/chain5j/chain5j-stateApp/internal/testutil" // 注册root logger
+	"testing"
+)
+
+func TestSystemStore(t *testing.T) {
+	db := memorydb.New()
+	state, err := statedb.New(types.Hash{}, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress)
+	key := stateApp.SystemKey("test", "a")
+	long := bytes.Repeat([]byte{0xab}, 70)
+	store.SetBytes(key, long)
+	if got := store.GetBytes(key); !bytes.Equal(got, long) {
+		t.Fatalf("unexpected value: %x", got)
+	}
+
+	snap := state.Snapshot()
+	store.SetBytes(key, []byte{1, 2, 3})
+	if got := store.GetBytes(key); !bytes.Equal(got, []byte{1, 2, 3}) {
+		t.Fatalf("unexpected value: %x", got)
+	}
+	state.RevertToSnapshot(snap)
+	if got := store.GetBytes(key); !bytes.Equal(got, long) {
+		t.Fatalf("revert failed: %x", got)
+	}
+
+	root, err := state.Commit(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err = statedb.New(root, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store = stateApp.NewSystemStore(state, stateApp.DomainRegistryAddress)
+	if got := store.GetBytes(key); !bytes.Equal(got, long) {
+		t.Fatalf("unexpected committed value: %x", got)
+	}
+	// 70字节的数据占用长度槽位及3个数据槽位
+	if slots := store.Slots(key); len(slots) != 4 || slots[0] != stateApp.HeadSlot(key) || slots[3] != stateApp.DataSlot(stateApp.HeadSlot(key), 2) {
+		t.Fatalf("unexpected slots: %v", slots)
+	}
+
+	store.Delete(key)
+	if store.Has(key) {
+		t.Fatal("key should be deleted")
+	}
+	if slots := store.Slots(key); len(slots) != 1 {
+		t.Fatalf("deleted key has %d slots", len(slots))
+	}
+}
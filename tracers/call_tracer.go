@@ -0,0 +1,212 @@
+// Package tracers
+//
+// @author: xwc1125
+package tracers
+
+import (
+	"errors"
+	evm "github.com/chain5j/chain5j-evm"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-pkg/util/hexutil"
+	"math/big"
+	"time"
+)
+
+var (
+	errExecutionReverted = errors.New("execution reverted")
+	errInternalFailure   = errors.New("internal failure")
+)
+
+// CallFrame 调用树中的一次调用
+type CallFrame struct {
+	Type    string         `json:"type"`
+	From    types.Address  `json:"from"`
+	To      types.Address  `json:"to"`
+	Value   *hexutil.Big   `json:"value,omitempty"`
+	Gas     hexutil.Uint64 `json:"gas"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	Input   hexutil.Bytes  `json:"input"`
+	Output  hexutil.Bytes  `json:"output,omitempty"`
+	Error   string         `json:"error,omitempty"`
+	Calls   []*CallFrame   `json:"calls,omitempty"`
+
+	gasIn   uint64 // 发起调用时的剩余gas
+	gasCost uint64 // 调用指令的gas消耗，包含转入子调用的gas
+	entered bool   // 是否进入了子调用的执行
+	outOff  int64
+	outLen  int64
+}
+
+// CallTracer 记录交易的调用树，包括内部调用及合约创建
+// 子调用在evm中执行指令时入栈，回到上一层深度时出栈
+type CallTracer struct {
+	callstack []*CallFrame
+	descended bool // 上一条指令发起了子调用
+}
+
+func NewCallTracer() *CallTracer {
+	return &CallTracer{}
+}
+
+func (t *CallTracer) CaptureTxStart(db evm.StateDB, from types.Address, to *types.Address) {}
+
+func (t *CallTracer) CaptureStart(from types.Address, to types.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	typ := evm.CALL.String()
+	if create {
+		typ = evm.CREATE.String()
+	}
+	t.callstack = []*CallFrame{{
+		Type:  typ,
+		From:  from,
+		To:    to,
+		Value: (*hexutil.Big)(new(big.Int).Set(value)),
+		Gas:   hexutil.Uint64(gas),
+		Input: append([]byte(nil), input...),
+	}}
+	return nil
+}
+
+func (t *CallTracer) CaptureState(env *evm.EVM, pc uint64, op evm.OpCode, gas, cost uint64, memory *evm.Memory, stack *evm.Stack, contract *evm.Contract, depth int, err error) error {
+	if err != nil {
+		return t.CaptureFault(env, pc, op, gas, cost, memory, stack, contract, depth, err)
+	}
+	if len(t.callstack) == 0 {
+		return nil
+	}
+
+	// 上一条指令发起的子调用已开始执行，记录转入的gas
+	if t.descended {
+		if depth >= len(t.callstack) {
+			frame := t.callstack[len(t.callstack)-1]
+			frame.Gas = hexutil.Uint64(gas)
+			frame.entered = true
+		}
+		t.descended = false
+	}
+
+	switch op {
+	case evm.CREATE, evm.CREATE2:
+		offset, size := stack.Back(1).Int64(), stack.Back(2).Int64()
+		t.callstack = append(t.callstack, &CallFrame{
+			Type:    op.String(),
+			From:    contract.Address(),
+			Value:   (*hexutil.Big)(new(big.Int).Set(stack.Back(0))),
+			Input:   memory.GetCopy(offset, size),
+			gasIn:   gas,
+			gasCost: cost,
+		})
+		t.descended = true
+		return nil
+
+	case evm.SELFDESTRUCT:
+		t.appendCall(&CallFrame{
+			Type:    op.String(),
+			From:    contract.Address(),
+			To:      types.BigToAddress(stack.Back(0)),
+			Value:   (*hexutil.Big)(env.StateDB.GetBalance(contract.Address())),
+			Gas:     hexutil.Uint64(gas),
+			GasUsed: hexutil.Uint64(cost),
+		})
+		return nil
+
+	case evm.CALL, evm.CALLCODE, evm.DELEGATECALL, evm.STATICCALL:
+		// CALL及CALLCODE的第三个参数为转账金额
+		args := 2
+		value := new(big.Int)
+		if op == evm.CALL || op == evm.CALLCODE {
+			value.Set(stack.Back(2))
+			args = 3
+		}
+		inOff, inLen := stack.Back(args).Int64(), stack.Back(args+1).Int64()
+		t.callstack = append(t.callstack, &CallFrame{
+			Type:    op.String(),
+			From:    contract.Address(),
+			To:      types.BigToAddress(stack.Back(1)),
+			Value:   (*hexutil.Big)(value),
+			Input:   memory.GetCopy(inOff, inLen),
+			gasIn:   gas,
+			gasCost: cost,
+			outOff:  stack.Back(args + 2).Int64(),
+			outLen:  stack.Back(args + 3).Int64(),
+		})
+		t.descended = true
+		return nil
+
+	case evm.REVERT:
+		t.callstack[len(t.callstack)-1].Error = errExecutionReverted.Error()
+		return nil
+	}
+
+	// 回到发起调用的深度，子调用结束
+	if depth == len(t.callstack)-1 {
+		frame := t.callstack[len(t.callstack)-1]
+		t.callstack = t.callstack[:len(t.callstack)-1]
+
+		ret := stack.Back(0)
+		if frame.Type == evm.CREATE.String() || frame.Type == evm.CREATE2.String() {
+			frame.GasUsed = hexutil.Uint64(frame.gasIn - frame.gasCost - gas)
+			if ret.Sign() != 0 {
+				frame.To = types.BigToAddress(ret)
+				frame.Output = env.StateDB.GetCode(frame.To)
+			} else if frame.Error == "" {
+				frame.Error = errInternalFailure.Error()
+			}
+		} else {
+			if frame.entered {
+				frame.GasUsed = hexutil.Uint64(frame.gasIn - frame.gasCost + uint64(frame.Gas) - gas)
+			}
+			if ret.Sign() != 0 {
+				frame.Output = memory.GetCopy(frame.outOff, frame.outLen)
+			} else if frame.Error == "" {
+				frame.Error = errInternalFailure.Error()
+			}
+		}
+		t.appendCall(frame)
+	}
+	return nil
+}
+
+func (t *CallTracer) CaptureFault(env *evm.EVM, pc uint64, op evm.OpCode, gas, cost uint64, memory *evm.Memory, stack *evm.Stack, contract *evm.Contract, depth int, err error) error {
+	if len(t.callstack) == 0 || t.callstack[len(t.callstack)-1].Error != "" {
+		return nil
+	}
+	// 出错的调用消耗全部gas
+	frame := t.callstack[len(t.callstack)-1]
+	frame.Error = err.Error()
+	if frame.entered {
+		frame.GasUsed = frame.Gas
+	}
+	if len(t.callstack) > 1 {
+		t.callstack = t.callstack[:len(t.callstack)-1]
+		t.appendCall(frame)
+	}
+	return nil
+}
+
+func (t *CallTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	if len(t.callstack) == 0 {
+		return nil
+	}
+	root := t.callstack[0]
+	root.GasUsed = hexutil.Uint64(gasUsed)
+	root.Output = append([]byte(nil), output...)
+	if err != nil {
+		root.Error = err.Error()
+	}
+	return nil
+}
+
+// Result 调用树的根调用，gasUsed为交易的总gas消耗
+func (t *CallTracer) Result(gasUsed uint64, failed bool) (interface{}, error) {
+	if len(t.callstack) == 0 {
+		return nil, nil
+	}
+	root := t.callstack[0]
+	root.GasUsed = hexutil.Uint64(gasUsed)
+	return root, nil
+}
+
+func (t *CallTracer) appendCall(frame *CallFrame) {
+	parent := t.callstack[len(t.callstack)-1]
+	parent.Calls = append(parent.Calls, frame)
+}
@@ -0,0 +1,93 @@
+// Package tracers
+//
+// @author: xwc1125
+package tracers
+
+import (
+	evm "github.com/chain5j/chain5j-evm"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-pkg/util/hexutil"
+	"math/big"
+	"time"
+)
+
+// PrestateAccount 交易执行前的账户状态
+type PrestateAccount struct {
+	Balance *hexutil.Big              `json:"balance"`
+	Nonce   uint64                    `json:"nonce"`
+	Code    hexutil.Bytes             `json:"code,omitempty"`
+	Storage map[types.Hash]types.Hash `json:"storage,omitempty"`
+}
+
+// PrestateTracer 记录交易涉及的账户在执行前的状态
+// 账户在首次被访问时记录，存储取交易开始时的已提交值
+type PrestateTracer struct {
+	prestate map[types.Address]*PrestateAccount
+}
+
+func NewPrestateTracer() *PrestateTracer {
+	return &PrestateTracer{
+		prestate: make(map[types.Address]*PrestateAccount),
+	}
+}
+
+func (t *PrestateTracer) CaptureTxStart(db evm.StateDB, from types.Address, to *types.Address) {
+	t.lookupAccount(db, from)
+	if to != nil {
+		t.lookupAccount(db, *to)
+	}
+}
+
+func (t *PrestateTracer) CaptureStart(from types.Address, to types.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	return nil
+}
+
+func (t *PrestateTracer) CaptureState(env *evm.EVM, pc uint64, op evm.OpCode, gas, cost uint64, memory *evm.Memory, stack *evm.Stack, contract *evm.Contract, depth int, err error) error {
+	if err != nil {
+		return nil
+	}
+	db := env.StateDB
+	switch op {
+	case evm.SLOAD, evm.SSTORE:
+		t.lookupStorage(db, contract.Address(), types.BigToHash(stack.Back(0)))
+	case evm.BALANCE, evm.EXTCODESIZE, evm.EXTCODECOPY, evm.EXTCODEHASH, evm.SELFDESTRUCT:
+		t.lookupAccount(db, types.BigToAddress(stack.Back(0)))
+	case evm.CALL, evm.CALLCODE, evm.DELEGATECALL, evm.STATICCALL:
+		t.lookupAccount(db, types.BigToAddress(stack.Back(1)))
+	}
+	return nil
+}
+
+func (t *PrestateTracer) CaptureFault(env *evm.EVM, pc uint64, op evm.OpCode, gas, cost uint64, memory *evm.Memory, stack *evm.Stack, contract *evm.Contract, depth int, err error) error {
+	return nil
+}
+
+func (t *PrestateTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	return nil
+}
+
+func (t *PrestateTracer) Result(gasUsed uint64, failed bool) (interface{}, error) {
+	return t.prestate, nil
+}
+
+func (t *PrestateTracer) lookupAccount(db evm.StateDB, addr types.Address) *PrestateAccount {
+	if account, ok := t.prestate[addr]; ok {
+		return account
+	}
+	account := &PrestateAccount{
+		Balance: (*hexutil.Big)(new(big.Int).Set(db.GetBalance(addr))),
+		Nonce:   db.GetNonce(addr),
+		Code:    db.GetCode(addr),
+		Storage: make(map[types.Hash]types.Hash),
+	}
+	t.prestate[addr] = account
+	return account
+}
+
+func (t *PrestateTracer) lookupStorage(db evm.StateDB, addr types.Address, key types.Hash) {
+	account := t.lookupAccount(db, addr)
+	if _, ok := account.Storage[key]; ok {
+		return
+	}
+	account.Storage[key] = db.GetCommittedState(addr, key)
+}
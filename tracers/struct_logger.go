@@ -0,0 +1,88 @@
+// Package tracers
+//
+// @author: xwc1125
+package tracers
+
+import (
+	"fmt"
+	evm "github.com/chain5j/chain5j-evm"
+	"github.com/chain5j/chain5j-pkg/types"
+)
+
+// ExecutionResult 逐条指令跟踪的结果
+type ExecutionResult struct {
+	Gas         uint64         `json:"gas"`
+	Failed      bool           `json:"failed"`
+	ReturnValue string         `json:"returnValue"`
+	StructLogs  []StructLogRes `json:"structLogs"`
+}
+
+// StructLogRes 单条指令的执行状态
+type StructLogRes struct {
+	Pc      uint64             `json:"pc"`
+	Op      string             `json:"op"`
+	Gas     uint64             `json:"gas"`
+	GasCost uint64             `json:"gasCost"`
+	Depth   int                `json:"depth"`
+	Error   string             `json:"error,omitempty"`
+	Stack   *[]string          `json:"stack,omitempty"`
+	Memory  *[]string          `json:"memory,omitempty"`
+	Storage *map[string]string `json:"storage,omitempty"`
+}
+
+// StructLogger 对evm.StructLogger的封装
+type StructLogger struct {
+	*evm.StructLogger
+}
+
+func NewStructLogger(cfg *evm.LogConfig) *StructLogger {
+	return &StructLogger{evm.NewStructLogger(cfg)}
+}
+
+func (l *StructLogger) CaptureTxStart(db evm.StateDB, from types.Address, to *types.Address) {}
+
+func (l *StructLogger) Result(gasUsed uint64, failed bool) (interface{}, error) {
+	return &ExecutionResult{
+		Gas:         gasUsed,
+		Failed:      failed,
+		ReturnValue: fmt.Sprintf("%x", l.Output()),
+		StructLogs:  FormatLogs(l.StructLogs()),
+	}, nil
+}
+
+// FormatLogs 将指令日志转换为便于展示的格式
+func FormatLogs(logs []evm.StructLog) []StructLogRes {
+	formatted := make([]StructLogRes, len(logs))
+	for index, trace := range logs {
+		formatted[index] = StructLogRes{
+			Pc:      trace.Pc,
+			Op:      trace.Op.String(),
+			Gas:     trace.Gas,
+			GasCost: trace.GasCost,
+			Depth:   trace.Depth,
+			Error:   trace.ErrorString(),
+		}
+		if trace.Stack != nil {
+			stack := make([]string, len(trace.Stack))
+			for i, value := range trace.Stack {
+				stack[i] = fmt.Sprintf("%x", types.BigToHash(value))
+			}
+			formatted[index].Stack = &stack
+		}
+		if trace.Memory != nil {
+			memory := make([]string, 0, (len(trace.Memory)+31)/32)
+			for i := 0; i+32 <= len(trace.Memory); i += 32 {
+				memory = append(memory, fmt.Sprintf("%x", trace.Memory[i:i+32]))
+			}
+			formatted[index].Memory = &memory
+		}
+		if trace.Storage != nil {
+			storage := make(map[string]string)
+			for i, storageValue := range trace.Storage {
+				storage[fmt.Sprintf("%x", i)] = fmt.Sprintf("%x", storageValue)
+			}
+			formatted[index].Storage = &storage
+		}
+	}
+	return formatted
+}
@@ -0,0 +1,50 @@
+// Package tracers
+//
+// @author: xwc1125
+package tracers
+
+import (
+	"errors"
+	evm "github.com/chain5j/chain5j-evm"
+	"github.com/chain5j/chain5j-pkg/types"
+)
+
+var (
+	errUnknownTracer = errors.New("unknown tracer")
+)
+
+// 内置的跟踪器名称
+const (
+	StructLoggerName   = ""               // 逐条指令的跟踪
+	CallTracerName     = "callTracer"     // 调用树
+	PrestateTracerName = "prestateTracer" // 执行前涉及的账户状态
+)
+
+// Tracer 交易执行的跟踪器
+type Tracer interface {
+	evm.Tracer
+	// CaptureTxStart 在扣除gas及转账之前调用
+	CaptureTxStart(db evm.StateDB, from types.Address, to *types.Address)
+	// Result 执行结束后的跟踪结果，gasUsed及failed为交易的执行结果
+	Result(gasUsed uint64, failed bool) (interface{}, error)
+}
+
+// New 根据名称创建跟踪器，cfg仅用于逐条指令的跟踪
+func New(name string, cfg *evm.LogConfig) (Tracer, error) {
+	switch name {
+	case StructLoggerName:
+		return NewStructLogger(cfg), nil
+	case CallTracerName:
+		return NewCallTracer(), nil
+	case PrestateTracerName:
+		return NewPrestateTracer(), nil
+	}
+	return nil, errUnknownTracer
+}
+
+// CaptureTxStart 跟踪器不为空时通知交易开始执行
+func CaptureTxStart(tracer evm.Tracer, db evm.StateDB, from types.Address, to *types.Address) {
+	if t, ok := tracer.(Tracer); ok {
+		t.CaptureTxStart(db, from, to)
+	}
+}
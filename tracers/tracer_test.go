@@ -0,0 +1,154 @@
+// Package tracers
+//
+// @author: xwc1125
+package tracers
+
+import (
+	"bytes"
+	evm "github.com/chain5j/chain5j-evm"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-pkg/util/hexutil"
+	"github.com/chain5j/chain5j-protocol/models"
+	"github.com/chain5j/chain5j-stateApp/internal/testutil"
+	"math/big"
+	"testing"
+)
+
+var (
+	callerAddr = types.HexToAddress("0x00000000000000000000000000000000000000ff")
+	entryAddr  = types.HexToAddress("0x00000000000000000000000000000000000000aa")
+	storeAddr  = types.HexToAddress("0x00000000000000000000000000000000000000bb")
+	revertAddr = types.HexToAddress("0x00000000000000000000000000000000000000cc")
+	storeCode  = hexutil.MustDecode("0x6001600055602a60005260206000f3") // sstore(0, 1); return 42
+	revertCode = hexutil.MustDecode("0x60006000fd")                     // revert(0, 0)
+)
+
+// callCode 依次以call(0xffff, addr, 0, 0, 0, 0, 32)调用各地址，忽略调用结果
+func callCode(addrs ...types.Address) []byte {
+	var code []byte
+	for _, addr := range addrs {
+		code = append(code, hexutil.MustDecode("0x6020600060006000600060")...)
+		code = append(code, addr[len(addr)-1])
+		code = append(code, hexutil.MustDecode("0x61fffff150")...)
+	}
+	return append(code, byte(evm.STOP))
+}
+
+// runTrace 由callerAddr调用entryAddr，返回跟踪结果
+func runTrace(t *testing.T, tracer Tracer) interface{} {
+	state := testutil.NewEthState(t)
+	state.AddBalance(callerAddr, big.NewInt(1000))
+	state.SetCode(entryAddr, callCode(storeAddr, revertAddr))
+	state.SetCode(storeAddr, storeCode)
+	state.SetCode(revertAddr, revertCode)
+	state.Finalise(true)
+
+	context := evm.Context{
+		CanTransfer: func(db evm.StateDB, addr types.Address, amount *big.Int) bool {
+			return db.GetBalance(addr).Cmp(amount) >= 0
+		},
+		Transfer: func(db evm.StateDB, sender, recipient types.Address, amount *big.Int) {
+			db.SubBalance(sender, amount)
+			db.AddBalance(recipient, amount)
+		},
+		GetHash:     func(uint64) types.Hash { return types.Hash{} },
+		Origin:      callerAddr,
+		GasPrice:    new(big.Int),
+		BlockNumber: big.NewInt(1),
+		Time:        big.NewInt(1),
+		Difficulty:  new(big.Int),
+		GasLimit:    1 << 32,
+	}
+	to := entryAddr
+	CaptureTxStart(tracer, state, callerAddr, &to)
+	vmenv := evm.NewEVM(context, state, &models.ChainConfig{}, evm.Config{Debug: true, Tracer: tracer})
+	_, left, err := vmenv.Call(models.AccountRef(callerAddr), entryAddr, nil, 1000000, big.NewInt(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := tracer.Result(1000000-left, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{StructLoggerName, nil},
+		{CallTracerName, nil},
+		{PrestateTracerName, nil},
+		{"jsTracer", errUnknownTracer},
+	}
+	for _, tt := range tests {
+		if _, err := New(tt.name, &evm.LogConfig{}); err != tt.err {
+			t.Fatalf("%q: %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestCallTracer(t *testing.T) {
+	root := runTrace(t, NewCallTracer()).(*CallFrame)
+	if root.Type != "CALL" || root.From != callerAddr || root.To != entryAddr || root.Value.ToInt().Int64() != 10 || root.Error != "" {
+		t.Fatalf("root: %+v", root)
+	}
+	want := []struct {
+		to     types.Address
+		output []byte
+		err    string
+	}{
+		{storeAddr, types.BigToHash(big.NewInt(42)).Bytes(), ""},
+		{revertAddr, nil, errExecutionReverted.Error()},
+	}
+	if len(root.Calls) != len(want) {
+		t.Fatalf("calls: %d, want %d", len(root.Calls), len(want))
+	}
+	for i, w := range want {
+		call := root.Calls[i]
+		if call.Type != "CALL" || call.From != entryAddr || call.To != w.to || call.Error != w.err || !bytes.Equal(call.Output, w.output) {
+			t.Fatalf("call %d: %+v", i, call)
+		}
+		if call.GasUsed == 0 || call.GasUsed > call.Gas {
+			t.Fatalf("call %d: gas used %d of %d", i, call.GasUsed, call.Gas)
+		}
+	}
+}
+
+func TestPrestateTracer(t *testing.T) {
+	prestate := runTrace(t, NewPrestateTracer()).(map[types.Address]*PrestateAccount)
+	for _, addr := range []types.Address{callerAddr, entryAddr, storeAddr, revertAddr} {
+		if _, ok := prestate[addr]; !ok {
+			t.Fatalf("missing %s", addr.Hex())
+		}
+	}
+	if balance := prestate[callerAddr].Balance.ToInt(); balance.Int64() != 1000 {
+		t.Fatalf("caller balance %v, want 1000", balance)
+	}
+	if !bytes.Equal(prestate[storeAddr].Code, storeCode) {
+		t.Fatalf("code %x", prestate[storeAddr].Code)
+	}
+	// 存储记录执行前的值
+	if value, ok := prestate[storeAddr].Storage[types.Hash{}]; !ok || value != (types.Hash{}) {
+		t.Fatalf("storage %v", prestate[storeAddr].Storage)
+	}
+}
+
+func TestStructLogger(t *testing.T) {
+	result := runTrace(t, NewStructLogger(&evm.LogConfig{})).(*ExecutionResult)
+	if result.Failed || result.Gas == 0 || len(result.StructLogs) == 0 {
+		t.Fatalf("result: %+v", result)
+	}
+	ops := make(map[string]int)
+	for _, log := range result.StructLogs {
+		ops[log.Op]++
+		if log.Stack == nil {
+			t.Fatalf("pc %d: stack not captured", log.Pc)
+		}
+	}
+	if ops["CALL"] != 2 || ops["SSTORE"] != 1 || ops["REVERT"] != 1 {
+		t.Fatalf("ops: %v", ops)
+	}
+}
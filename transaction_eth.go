@@ -0,0 +1,188 @@
+// Package stateApp
+//
+// @author: xwc1125
+package stateApp
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"github.com/chain5j/chain5j-pkg/codec/rlp"
+	"github.com/chain5j/chain5j-pkg/crypto/hashalg"
+	"github.com/chain5j/chain5j-pkg/crypto/signature"
+	"github.com/chain5j/chain5j-pkg/crypto/signature/secp256k1"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/pkg/crypto"
+	"math/big"
+)
+
+// EthSignatureName 以太坊签名的算法名称
+// 签名数据为r||s||v，v遵循EIP-155，签名原文为以太坊的交易结构
+const EthSignatureName = "eth_secp256k1"
+
+var (
+	ErrInvalidEthTx     = errors.New("invalid ethereum transaction")
+	ErrInvalidEthSigner = errors.New("invalid ethereum transaction v, r, s values")
+)
+
+// ethTxData 以太坊的legacy交易
+type ethTxData struct {
+	Nonce    uint64
+	GasPrice *big.Int
+	Gas      uint64
+	To       []byte // 创建合约时为空
+	Value    *big.Int
+	Data     []byte
+	V, R, S  *big.Int
+}
+
+// DecodeEthTransaction 解析以太坊签名的原始交易，转换为以太坊解析器的交易
+func DecodeEthTransaction(raw []byte) (*Transaction, error) {
+	var d ethTxData
+	if err := rlp.DecodeBytes(raw, &d); err != nil {
+		return nil, err
+	}
+	if !d.GasPrice.IsUint64() || (len(d.To) != 0 && len(d.To) != types.AddressLength) {
+		return nil, ErrInvalidEthTx
+	}
+	var to string
+	if len(d.To) != 0 {
+		to = types.BytesToAddress(d.To).Hex()
+	}
+	// r, s须在曲线阶内且s不大于阶的一半，避免同一交易存在两个有效签名
+	chainId, recovery := ethChainIdAndRecovery(d.V)
+	if chainId.Sign() < 0 || !signature.ValidateSignatureValues(recovery, d.R, d.S, true) {
+		return nil, ErrInvalidEthSigner
+	}
+
+	sig := make([]byte, 64, 64+len(d.V.Bytes()))
+	d.R.FillBytes(sig[:32])
+	d.S.FillBytes(sig[32:])
+	tx := NewTransaction("", to, EthereumInterpreter, d.Nonce, d.GasPrice.Uint64(), d.Gas, d.Value, d.Data, 0, nil)
+	tx.data.Signature = &signature.SignResult{
+		Name:      EthSignatureName,
+		Signature: append(sig, d.V.Bytes()...),
+	}
+
+	from, err := tx.Signer()
+	if err != nil {
+		return nil, err
+	}
+	tx.data.From = from.Hex()
+	return tx, nil
+}
+
+// EthChainId 以太坊签名的交易所属的链ID，未采用EIP-155签名时为0
+func (tx *Transaction) EthChainId() *big.Int {
+	v, _, _, err := tx.EthSignatureValues()
+	if err != nil {
+		return nil
+	}
+	chainId, _ := ethChainIdAndRecovery(v)
+	return chainId
+}
+
+func (tx *Transaction) isEthSigned() bool {
+	return tx.data.Signature != nil && tx.data.Signature.Name == EthSignatureName
+}
+
+// EthSignatureValues 以太坊签名的v, r, s
+func (tx *Transaction) EthSignatureValues() (v, r, s *big.Int, err error) {
+	if !tx.isEthSigned() {
+		return nil, nil, nil, ErrInvalidEthSigner
+	}
+	sig := tx.data.Signature.Signature
+	if len(sig) <= 64 {
+		return nil, nil, nil, ErrInvalidEthSigner
+	}
+	return new(big.Int).SetBytes(sig[64:]), new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64]), nil
+}
+
+// ethChainIdAndRecovery 由v计算链ID及恢复ID
+func ethChainIdAndRecovery(v *big.Int) (*big.Int, byte) {
+	if v.BitLen() <= 8 && (v.Uint64() == 27 || v.Uint64() == 28) {
+		return new(big.Int), byte(v.Uint64() - 27)
+	}
+	// v = chainId*2 + 35 + recovery
+	x := new(big.Int).Sub(v, big.NewInt(35))
+	return new(big.Int).Rsh(x, 1), byte(x.Bit(0))
+}
+
+func (tx *Transaction) ethTo() []byte {
+	if tx.data.To == "" {
+		return nil
+	}
+	return types.HexToAddress(tx.data.To).Bytes()
+}
+
+// ethSigningHash 以太坊交易的签名原文
+func (tx *Transaction) ethSigningHash(chainId *big.Int) (types.Hash, error) {
+	fields := []interface{}{
+		tx.data.Nonce,
+		new(big.Int).SetUint64(tx.data.GasPrice),
+		tx.data.GasLimit,
+		tx.ethTo(),
+		tx.data.Value,
+		tx.data.Input,
+	}
+	if chainId.Sign() != 0 {
+		fields = append(fields, chainId, uint(0), uint(0))
+	}
+	return hashalg.RlpHash(fields)
+}
+
+// ethHash 以太坊交易的hash，与钱包计算的结果一致
+func (tx *Transaction) ethHash() (types.Hash, error) {
+	v, r, s, err := tx.EthSignatureValues()
+	if err != nil {
+		return types.Hash{}, err
+	}
+	return hashalg.RlpHash(&ethTxData{
+		Nonce:    tx.data.Nonce,
+		GasPrice: new(big.Int).SetUint64(tx.data.GasPrice),
+		Gas:      tx.data.GasLimit,
+		To:       tx.ethTo(),
+		Value:    tx.data.Value,
+		Data:     tx.data.Input,
+		V:        v,
+		R:        r,
+		S:        s,
+	})
+}
+
+func (tx *Transaction) recoverEthPubKey() (*ecdsa.PublicKey, error) {
+	v, _, _, err := tx.EthSignatureValues()
+	if err != nil {
+		return nil, err
+	}
+	chainId, recovery := ethChainIdAndRecovery(v)
+	if chainId.Sign() < 0 {
+		return nil, ErrInvalidEthSigner
+	}
+	hash, err := tx.ethSigningHash(chainId)
+	if err != nil {
+		return nil, err
+	}
+
+	sig := make([]byte, 65)
+	copy(sig, tx.data.Signature.Signature[:64])
+	sig[64] = recovery
+	pubKey, err := secp256k1.SigToPub(hash[:], sig)
+	if err != nil {
+		return nil, ErrInvalidEthSigner
+	}
+	return pubKey, nil
+}
+
+func (tx *Transaction) ethSigner() (types.Address, error) {
+	pubKey, err := tx.recoverEthPubKey()
+	if err != nil {
+		return types.EmptyAddress, err
+	}
+	address, err := crypto.PubkeyToAddress(pubKey)
+	if err != nil {
+		return types.EmptyAddress, err
+	}
+	tx.signer.Store(address)
+	tx.fromPub.Store(pubKey)
+	return address, nil
+}
@@ -0,0 +1,89 @@
+// Package stateApp
+//
+// @author: xwc1125
+package stateApp
+
+import (
+	"github.com/chain5j/chain5j-pkg/codec"
+	"github.com/chain5j/chain5j-pkg/codec/rlp"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-pkg/util/hexutil"
+	"math/big"
+	"testing"
+)
+
+// EIP-155中的示例交易
+func TestDecodeEthTransaction(t *testing.T) {
+	raw := hexutil.MustDecode("0xf86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83")
+	hash := types.HexToHash("0x33469b22e9f636356c4160a87eb19df52b7412e8eac32a4a55ffe88ea8350788")
+	from := types.HexToAddress("0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F")
+
+	tx, err := DecodeEthTransaction(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Interpreter() != EthereumInterpreter {
+		t.Fatalf("interpreter mismatch: %s", tx.Interpreter())
+	}
+	if types.HexToAddress(tx.From()) != from {
+		t.Fatalf("from mismatch: %s", tx.From())
+	}
+	if tx.To() != "0x3535353535353535353535353535353535353535" || tx.Nonce() != 9 || tx.GasLimit() != 21000 {
+		t.Fatalf("tx fields mismatch: %s %d %d", tx.To(), tx.Nonce(), tx.GasLimit())
+	}
+	if tx.EthChainId().Cmp(big.NewInt(1)) != 0 {
+		t.Fatalf("chain id mismatch: %v", tx.EthChainId())
+	}
+	if tx.Hash() != hash {
+		t.Fatalf("hash mismatch: %s", tx.Hash().Hex())
+	}
+
+	// 转发到其他节点后，签名者及hash保持一致
+	enc, err := codec.Coder().Encode(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := new(Transaction)
+	if err := codec.Coder().Decode(enc, dec); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := dec.Signer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer != from || dec.Hash() != hash {
+		t.Fatalf("decoded tx mismatch: signer=%s hash=%s", signer.Hex(), dec.Hash().Hex())
+	}
+}
+
+// 签名值越界或s大于曲线阶的一半时拒绝
+func TestDecodeEthTransaction_SignatureValues(t *testing.T) {
+	raw := hexutil.MustDecode("0xf86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83")
+	curveN, _ := new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+	tests := []struct {
+		name   string
+		modify func(d *ethTxData)
+		err    error
+	}{
+		{"valid", func(d *ethTxData) {}, nil},
+		{"r too long", func(d *ethTxData) { d.R = new(big.Int).Lsh(d.R, 8) }, ErrInvalidEthSigner},
+		{"s too long", func(d *ethTxData) { d.S = new(big.Int).Lsh(d.S, 8) }, ErrInvalidEthSigner},
+		{"zero r", func(d *ethTxData) { d.R = new(big.Int) }, ErrInvalidEthSigner},
+		{"high s", func(d *ethTxData) { d.S = new(big.Int).Sub(curveN, d.S) }, ErrInvalidEthSigner},
+		{"negative chain id", func(d *ethTxData) { d.V = big.NewInt(30) }, ErrInvalidEthSigner},
+	}
+	for _, tt := range tests {
+		var d ethTxData
+		if err := rlp.DecodeBytes(raw, &d); err != nil {
+			t.Fatal(err)
+		}
+		tt.modify(&d)
+		enc, err := rlp.EncodeToBytes(&d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := DecodeEthTransaction(enc); err != tt.err {
+			t.Fatalf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
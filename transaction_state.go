@@ -129,15 +129,23 @@ func (tx *Transaction) Deserialize(d []byte) error {
 	}
 	tx.data = txJson.Data
 
-	rawHash, err := tx.getRawHash()
-	if err != nil {
-		return err
-	}
-	pubKey, err := crypto.RecoverPubKey(rawHash.Bytes(), tx.data.Signature)
-	if err != nil {
-		return err
+	if tx.isEthSigned() {
+		pubKey, err := tx.recoverEthPubKey()
+		if err != nil {
+			return err
+		}
+		tx.fromPub.Store(pubKey)
+	} else {
+		rawHash, err := tx.getRawHash()
+		if err != nil {
+			return err
+		}
+		pubKey, err := crypto.RecoverPubKey(rawHash.Bytes(), tx.data.Signature)
+		if err != nil {
+			return err
+		}
+		tx.fromPub.Store(pubKey)
 	}
-	tx.fromPub.Store(pubKey)
 	tx.signer.Store(txJson.Signer)
 	tx.txHash.Store(txJson.TransactionHash)
 	return nil
@@ -149,7 +157,14 @@ func (tx *Transaction) MarshalJSON() ([]byte, error) {
 }
 
 func (tx *Transaction) UnmarshalJSON(input []byte) error {
-	return json.Unmarshal(input, &tx.data)
+	if err := json.Unmarshal(input, &tx.data); err != nil {
+		return err
+	}
+	// 未填写value时视为0，与rlp编码一致
+	if tx.data.Value == nil {
+		tx.data.Value = new(big.Int)
+	}
+	return nil
 }
 
 func (tx *Transaction) EncodeRLP(w io.Writer) error {
@@ -225,6 +240,13 @@ func (tx *Transaction) Signer() (types.Address, error) {
 		return address.(types.Address), nil
 	}
 
+	// 未签名的交易
+	if tx.data.Signature == nil {
+		return types.EmptyAddress, ErrInvalidSigner
+	}
+	if tx.isEthSigned() {
+		return tx.ethSigner()
+	}
 	rlpHash, err := tx.getRawHash()
 	if err != nil {
 		return types.EmptyAddress, err
@@ -244,12 +266,22 @@ func (tx *Transaction) Signer() (types.Address, error) {
 	return address, nil
 }
 
+// Signed 交易是否已签名
+func (tx *Transaction) Signed() bool {
+	return tx.data.Signature != nil
+}
+
 func (tx *Transaction) Hash() types.Hash {
 	if hash := tx.txHash.Load(); hash != nil {
 		return hash.(types.Hash)
 	}
 	// TODO 计算hash应该与sign中的rlpHash一致？
-	hash, _ := tx.getSignedHash()
+	var hash types.Hash
+	if tx.isEthSigned() {
+		hash, _ = tx.ethHash()
+	} else {
+		hash, _ = tx.getSignedHash()
+	}
 	tx.txHash.Store(hash)
 	return hash
 }
@@ -0,0 +1,62 @@
+// Package stateApp
+//
+// @author: xwc1125
+package stateApp
+
+import (
+	"github.com/chain5j/chain5j-pkg/codec/rlp"
+	"github.com/chain5j/chain5j-pkg/crypto/hashalg/sha3"
+	"github.com/chain5j/chain5j-pkg/types"
+	"github.com/chain5j/chain5j-protocol/models/statetype"
+	"math/big"
+)
+
+// TransferLogAddress 转账日志的地址，不对应账户
+var TransferLogAddress = types.HexToAddress("0x000000000000000000000000000000000000c505")
+
+// EventTransfer 转账日志的topic
+var EventTransfer = types.BytesToHash(sha3.Keccak256([]byte("Transfer")))
+
+// transferLogHeight 合约内部调用记录转账日志的启用高度
+var transferLogHeight uint64
+
+// TransferLog 接收方不是交易接收方的转账，如托管的释放及退回、归属计划的发放及合约内部调用的转账
+// 账户体系的原生解析器中为账户名，合约内部调用中为地址的hex
+type TransferLog struct {
+	From   string
+	To     string
+	Amount *big.Int
+}
+
+// NewTransferLog 转账日志，由解析器写入交易的日志中，用于建立接收方的交易历史
+func NewTransferLog(from, to string, amount *big.Int, height uint64) *statetype.Log {
+	data, _ := rlp.EncodeToBytes(&TransferLog{From: from, To: to, Amount: amount})
+	return &statetype.Log{
+		Address:     TransferLogAddress,
+		Topics:      []types.Hash{EventTransfer},
+		Data:        data,
+		BlockHeight: height,
+	}
+}
+
+// DecodeTransferLog 解析转账日志，不是转账日志时返回false
+func DecodeTransferLog(log *statetype.Log) (*TransferLog, bool) {
+	if log.Address != TransferLogAddress || len(log.Topics) != 1 || log.Topics[0] != EventTransfer {
+		return nil, false
+	}
+	var transfer TransferLog
+	if err := rlp.DecodeBytes(log.Data, &transfer); err != nil {
+		return nil, false
+	}
+	return &transfer, true
+}
+
+// SetTransferLogHeight 设置合约内部调用记录转账日志的启用高度，已有的链设为升级高度，使之前区块的收据不变
+func SetTransferLogHeight(height uint64) {
+	transferLogHeight = height
+}
+
+// TransferLogEnabled height高度的合约内部调用是否记录转账日志
+func TransferLogEnabled(height uint64) bool {
+	return height >= transferLogHeight
+}
@@ -0,0 +1,135 @@
+// Package txindex
+//
+// @author: xwc1125
+package txindex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/chain5j/chain5j-pkg/codec/rlp"
+	"github.com/chain5j/chain5j-pkg/types"
+)
+
+const (
+	DirectionOut  = "out"  // 账户为发送方
+	DirectionIn   = "in"   // 账户为接收方
+	DirectionSelf = "self" // 发送方与接收方为同一账户
+)
+
+var (
+	historyPrefix = []byte("stateApp-tx-h") // historyPrefix + account + 0 + ^height + ^txIndex -> 历史记录
+
+	historyScanLimit = 10000 // 单次查询最多遍历的记录数，按解析器过滤时避免遍历账户的全部历史
+
+	errInvalidCursor = errors.New("invalid cursor")
+)
+
+// History 账户的一条交易历史
+type History struct {
+	Account     string
+	Height      uint64
+	TxIndex     uint64
+	Hash        types.Hash
+	Direction   string
+	Interpreter string
+}
+
+type historyRLP struct {
+	Hash        types.Hash
+	Direction   string
+	Interpreter string
+}
+
+// HistoryFilter 历史查询的条件，FromBlock及ToBlock为闭区间，ToBlock为nil时不限制
+type HistoryFilter struct {
+	Interpreter string
+	FromBlock   uint64
+	ToBlock     *uint64
+}
+
+// AddHistory 写入交易历史，重复写入同一交易时覆盖
+func (idx *Indexer) AddHistory(histories []*History) error {
+	batch := idx.db.NewBatch()
+	for _, h := range histories {
+		enc, err := rlp.EncodeToBytes(&historyRLP{Hash: h.Hash, Direction: h.Direction, Interpreter: h.Interpreter})
+		if err != nil {
+			return err
+		}
+		if err := batch.Put(historyKey(h.Account, h.Height, h.TxIndex), enc); err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
+
+// History 按区块高度倒序查询账户的交易历史，最多返回limit条
+// cursor为上一页返回的游标，为空时从最新的记录开始；next为空时表示没有更多记录
+// 单次最多遍历historyScanLimit条记录，达到上限时返回的记录可能少于limit，甚至为空，此时以next继续查询
+func (idx *Indexer) History(account string, filter HistoryFilter, cursor []byte, limit int) ([]*History, []byte, error) {
+	prefix := historyKey(account, 0, 0)[:len(historyPrefix)+len(account)+1]
+	start := prefix
+	if filter.ToBlock != nil {
+		start = historyKey(account, *filter.ToBlock, ^uint64(0))
+	}
+	if cursor != nil {
+		if len(cursor) != 16 {
+			return nil, nil, errInvalidCursor
+		}
+		if c := append(append([]byte{}, prefix...), cursor...); bytes.Compare(c, start) > 0 {
+			start = c
+		}
+	}
+
+	it := idx.db.NewIteratorWithStart(start)
+	defer it.Release()
+
+	result := make([]*History, 0)
+	scanned := 0
+	for it.Next() {
+		key := it.Key()
+		if !bytes.HasPrefix(key, prefix) || len(key) != len(prefix)+16 {
+			break
+		}
+		height := ^binary.BigEndian.Uint64(key[len(prefix):])
+		if height < filter.FromBlock {
+			break
+		}
+		if len(result) == limit || scanned == historyScanLimit {
+			return result, append([]byte{}, key[len(prefix):]...), nil
+		}
+		scanned++
+		var dec historyRLP
+		if err := rlp.DecodeBytes(it.Value(), &dec); err != nil {
+			return nil, nil, err
+		}
+		if filter.Interpreter != "" && dec.Interpreter != filter.Interpreter {
+			continue
+		}
+		result = append(result, &History{
+			Account:     account,
+			Height:      height,
+			TxIndex:     ^binary.BigEndian.Uint64(key[len(prefix)+8:]),
+			Hash:        dec.Hash,
+			Direction:   dec.Direction,
+			Interpreter: dec.Interpreter,
+		})
+	}
+	return result, nil, it.Error()
+}
+
+// historyKey 高度及位置取反，按key升序遍历即为倒序
+func historyKey(account string, height, txIndex uint64) []byte {
+	key := make([]byte, 0, len(historyPrefix)+len(account)+17)
+	key = append(key, historyPrefix...)
+	key = append(key, account...)
+	key = append(key, 0)
+	key = append(key, encodeUint64(^height)...)
+	return append(key, encodeUint64(^txIndex)...)
+}
+
+func encodeUint64(n uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, n)
+	return enc
+}
@@ -0,0 +1,93 @@
+// Package txindex
+//
+// @author: xwc1125
+package txindex
+
+import (
+	"fmt"
+	"github.com/chain5j/chain5j-pkg/database/kvstore/memorydb"
+	"github.com/chain5j/chain5j-pkg/types"
+	"strings"
+	"testing"
+)
+
+// pageAll 按游标查询全部页，返回"高度/位置"形式的记录及页数
+func pageAll(t *testing.T, idx *Indexer, filter HistoryFilter, limit int) ([]string, int) {
+	var (
+		got    []string
+		cursor []byte
+		pages  int
+	)
+	for {
+		histories, next, err := idx.History("alice@bank", filter, cursor, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages++
+		for _, h := range histories {
+			got = append(got, fmt.Sprintf("%d/%d", h.Height, h.TxIndex))
+		}
+		if next == nil {
+			return got, pages
+		}
+		cursor = next
+	}
+}
+
+func TestIndexer_History(t *testing.T) {
+	idx := NewIndexer(memorydb.New())
+	histories := []*History{
+		{Account: "alice@bank", Height: 0, TxIndex: 0, Direction: DirectionIn},
+		{Account: "alice@bank", Height: 1, TxIndex: 0, Direction: DirectionOut, Interpreter: "escrow"},
+		{Account: "alice@bank", Height: 2, TxIndex: 0, Direction: DirectionOut},
+		{Account: "alice@bank", Height: 2, TxIndex: 1, Direction: DirectionSelf},
+		{Account: "alice@bank", Height: 3, TxIndex: 0, Direction: DirectionIn},
+		{Account: "alice@bank2", Height: 4, TxIndex: 0, Direction: DirectionIn},
+		{Account: "bob@bank", Height: 1, TxIndex: 0, Direction: DirectionIn, Interpreter: "escrow"},
+	}
+	for i, h := range histories {
+		h.Hash = types.Hash{byte(i + 1)}
+	}
+	if err := idx.AddHistory(histories); err != nil {
+		t.Fatal(err)
+	}
+
+	block := func(n uint64) *uint64 { return &n }
+	tests := []struct {
+		name      string
+		filter    HistoryFilter
+		limit     int
+		scanLimit int
+		want      string
+		pages     int
+	}{
+		{"all", HistoryFilter{}, 10, 10000, "3/0,2/1,2/0,1/0,0/0", 1},
+		{"paged", HistoryFilter{}, 2, 10000, "3/0,2/1,2/0,1/0,0/0", 3},
+		{"from block", HistoryFilter{FromBlock: 2}, 10, 10000, "3/0,2/1,2/0", 1},
+		{"to block", HistoryFilter{ToBlock: block(2)}, 10, 10000, "2/1,2/0,1/0,0/0", 1},
+		{"block 0", HistoryFilter{ToBlock: block(0)}, 10, 10000, "0/0", 1},
+		{"range", HistoryFilter{FromBlock: 1, ToBlock: block(2)}, 10, 10000, "2/1,2/0,1/0", 1},
+		{"interpreter", HistoryFilter{Interpreter: "escrow"}, 10, 10000, "1/0", 1},
+		// 达到遍历上限时返回游标，后续页继续遍历
+		{"scan limit", HistoryFilter{Interpreter: "escrow"}, 10, 2, "1/0", 3},
+	}
+	for _, tt := range tests {
+		historyScanLimit = tt.scanLimit
+		got, pages := pageAll(t, idx, tt.filter, tt.limit)
+		if strings.Join(got, ",") != tt.want || pages != tt.pages {
+			t.Fatalf("%s: %v in %d pages, want %s in %d", tt.name, got, pages, tt.want, tt.pages)
+		}
+	}
+	historyScanLimit = 10000
+
+	histories2, _, err := idx.History("alice@bank", HistoryFilter{}, nil, 1)
+	if err != nil || len(histories2) != 1 {
+		t.Fatal(histories2, err)
+	}
+	if h := histories2[0]; h.Hash != histories[4].Hash || h.Direction != DirectionIn || h.Account != "alice@bank" {
+		t.Fatalf("history %+v", h)
+	}
+	if _, _, err := idx.History("alice@bank", HistoryFilter{}, []byte{1}, 10); err != errInvalidCursor {
+		t.Fatalf("cursor: %v, want %v", err, errInvalidCursor)
+	}
+}
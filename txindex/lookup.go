@@ -0,0 +1,82 @@
+// Package txindex
+//
+// @author: xwc1125
+package txindex
+
+import (
+	"github.com/chain5j/chain5j-pkg/codec/rlp"
+	"github.com/chain5j/chain5j-pkg/database/kvstore"
+	"github.com/chain5j/chain5j-pkg/types"
+)
+
+var (
+	lookupPrefix = []byte("stateApp-tx-l") // lookupPrefix + txHash -> 交易所在区块
+	txPrefix     = []byte("stateApp-tx-t") // txPrefix + txHash -> 不在区块中的交易，如到期执行的定时交易
+)
+
+// Lookup 交易所在的区块及位置，区块级处理执行的交易排在区块中的交易之后
+type Lookup struct {
+	BlockHash types.Hash
+	Height    uint64
+	Index     uint64
+}
+
+// Indexer 交易的索引，区块提交时写入
+type Indexer struct {
+	db kvstore.Database
+}
+
+func NewIndexer(db kvstore.Database) *Indexer {
+	return &Indexer{db: db}
+}
+
+// Add 写入交易到区块的索引，位置为交易在hashes中的顺序
+// txs为不在区块中的交易的编码，按hash写入以便查询
+func (idx *Indexer) Add(blockHash types.Hash, height uint64, hashes []types.Hash, txs map[types.Hash][]byte) error {
+	batch := idx.db.NewBatch()
+	for i, hash := range hashes {
+		enc, err := rlp.EncodeToBytes(&Lookup{BlockHash: blockHash, Height: height, Index: uint64(i)})
+		if err != nil {
+			return err
+		}
+		if err := batch.Put(lookupKey(hash), enc); err != nil {
+			return err
+		}
+	}
+	for hash, enc := range txs {
+		if err := batch.Put(txKey(hash), enc); err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
+
+// Tx 读取不在区块中的交易的编码
+func (idx *Indexer) Tx(hash types.Hash) ([]byte, bool) {
+	data, err := idx.db.Get(txKey(hash))
+	if err != nil || len(data) == 0 {
+		return nil, false
+	}
+	return data, true
+}
+
+// Lookup 查询交易所在的区块，索引启用前的交易返回false
+func (idx *Indexer) Lookup(hash types.Hash) (*Lookup, bool) {
+	data, err := idx.db.Get(lookupKey(hash))
+	if err != nil || len(data) == 0 {
+		return nil, false
+	}
+	lookup := new(Lookup)
+	if err := rlp.DecodeBytes(data, lookup); err != nil {
+		return nil, false
+	}
+	return lookup, true
+}
+
+func lookupKey(hash types.Hash) []byte {
+	return append(append([]byte{}, lookupPrefix...), hash.Bytes()...)
+}
+
+func txKey(hash types.Hash) []byte {
+	return append(append([]byte{}, txPrefix...), hash.Bytes()...)
+}
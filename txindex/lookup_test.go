@@ -0,0 +1,52 @@
+// Package txindex
+//
+// @author: xwc1125
+package txindex
+
+import (
+	"bytes"
+	"github.com/chain5j/chain5j-pkg/database/kvstore/memorydb"
+	"github.com/chain5j/chain5j-pkg/types"
+	"testing"
+)
+
+func TestIndexer_Lookup(t *testing.T) {
+	idx := NewIndexer(memorydb.New())
+	var (
+		block1 = types.Hash{0xb1}
+		block2 = types.Hash{0xb2}
+		tx1    = types.Hash{0x01}
+		tx2    = types.Hash{0x02}
+		hook   = types.Hash{0x03}
+		tx3    = types.Hash{0x04}
+	)
+	if err := idx.Add(block1, 1, []types.Hash{tx1, tx2, hook}, map[types.Hash][]byte{hook: []byte("hook")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Add(block2, 2, []types.Hash{tx3}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		hash   types.Hash
+		lookup *Lookup
+		tx     []byte
+	}{
+		{"first", tx1, &Lookup{BlockHash: block1, Height: 1, Index: 0}, nil},
+		{"second", tx2, &Lookup{BlockHash: block1, Height: 1, Index: 1}, nil},
+		{"hook", hook, &Lookup{BlockHash: block1, Height: 1, Index: 2}, []byte("hook")},
+		{"next block", tx3, &Lookup{BlockHash: block2, Height: 2, Index: 0}, nil},
+		{"unknown", types.Hash{0xff}, nil, nil},
+	}
+	for _, tt := range tests {
+		lookup, ok := idx.Lookup(tt.hash)
+		if ok != (tt.lookup != nil) || (ok && *lookup != *tt.lookup) {
+			t.Fatalf("%s: lookup %+v %v, want %+v", tt.name, lookup, ok, tt.lookup)
+		}
+		tx, ok := idx.Tx(tt.hash)
+		if ok != (tt.tx != nil) || !bytes.Equal(tx, tt.tx) {
+			t.Fatalf("%s: tx %q %v, want %q", tt.name, tx, ok, tt.tx)
+		}
+	}
+}